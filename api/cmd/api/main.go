@@ -11,11 +11,17 @@ import (
 	"syscall"
 	"time"
 
+	"afterzin/api/internal/attendees"
 	"afterzin/api/internal/config"
 	"afterzin/api/internal/db"
 	"afterzin/api/internal/graphql"
+	"afterzin/api/internal/mailer"
 	"afterzin/api/internal/middleware"
 	"afterzin/api/internal/pagarme"
+	"afterzin/api/internal/qrkeys"
+	"afterzin/api/internal/sales"
+	"afterzin/api/internal/tickets"
+	"afterzin/api/internal/wallet"
 
 	"github.com/joho/godotenv"
 )
@@ -40,12 +46,50 @@ func main() {
 		log.Fatalf("migrate: %v", err)
 	}
 
-	graphqlHandler := graphql.NewHandler(sqlite, cfg)
+	mail := mailer.New(cfg)
+	outbox := mailer.NewOutbox(sqlite, mail, cfg.BaseURL)
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	go outbox.Run(workerCtx)
+	go sales.Run(workerCtx, sqlite)
+
+	walletService, err := wallet.New(sqlite, cfg)
+	if err != nil {
+		log.Fatalf("wallet: %v", err)
+	}
+
+	qrKeys, err := qrkeys.New(sqlite, cfg)
+	if err != nil {
+		log.Fatalf("qrkeys: %v", err)
+	}
+
+	graphqlHandler := graphql.NewHandler(sqlite, cfg, outbox, walletService, qrKeys)
 
 	// Build HTTP mux with all routes
 	mux := http.NewServeMux()
 	mux.Handle("/graphql", graphqlHandler)
 
+	// Ticket files: QR images (owner-authenticated) and PDF (signed link from Ticket.pdfUrl)
+	ticketsHandler := tickets.NewHandler(sqlite, cfg, walletService)
+	mux.HandleFunc("/tickets/{id}/qr.png", ticketsHandler.QRPNG)
+	mux.HandleFunc("/tickets/{id}/qr.svg", ticketsHandler.QRSVG)
+	mux.HandleFunc("/tickets/{id}/ticket.pdf", ticketsHandler.PDF)
+	mux.HandleFunc("/tickets/{id}/wallet.pkpass", ticketsHandler.ApplePass)
+
+	// Attendee list export (producer or MANAGER), CPFs masked
+	attendeesHandler := attendees.NewHandler(sqlite)
+	mux.HandleFunc("/event-dates/{id}/attendees.csv", attendeesHandler.CSV)
+	mux.HandleFunc("/event-dates/{id}/attendees.xlsx", attendeesHandler.XLSX)
+
+	// Apple Wallet web service (device registration and pass updates)
+	if walletService.AppleEnabled() {
+		mux.Handle("/wallet/", walletService.WebService())
+		log.Println("Apple Wallet passes enabled")
+	}
+	if walletService.GoogleEnabled() {
+		log.Println("Google Wallet passes enabled")
+	}
+
 	// Pagar.me REST endpoints (only registered when PAGARME_API_KEY is set)
 	if cfg.PagarmeAPIKey != "" {
 		pagarmeClient := pagarme.NewClient(
@@ -55,18 +99,19 @@ func main() {
 			cfg.PagarmeAppFee,
 			cfg.BaseURL,
 		)
-		pagarmeHandler := pagarme.NewHandler(pagarmeClient, sqlite, cfg)
+		pagarmeHandler := pagarme.NewHandler(pagarmeClient, sqlite, cfg, outbox, walletService, qrKeys)
 		mux.HandleFunc("/api/pagarme/recipient/create", pagarmeHandler.CreateRecipient)
 		mux.HandleFunc("/api/pagarme/recipient/status", pagarmeHandler.GetRecipientStatus)
+		mux.HandleFunc("/api/pagarme/seller/recipient/create", pagarmeHandler.CreateSellerRecipient)
 		mux.HandleFunc("/api/pagarme/payment/create", pagarmeHandler.CreatePayment)
 		mux.HandleFunc("/api/pagarme/payment/status", pagarmeHandler.GetPaymentStatus)
 		mux.HandleFunc("/api/pagarme/webhook", pagarmeHandler.HandleWebhook)
-		log.Println("Pagar.me endpoints registered (Recipient + PIX Payment + Webhook)")
+		log.Println("Pagar.me endpoints registered (Recipient + Seller Recipient + PIX Payment + Webhook)")
 	} else {
 		log.Println("PAGARME_API_KEY not set — Pagar.me endpoints disabled")
 	}
 
-	handler := middleware.CORS(cfg.CORSOrigins)(middleware.Auth(cfg.JWTSecret)(mux))
+	handler := middleware.CORS(cfg.CORSOrigins)(middleware.Auth(cfg.JWTSecret, sqlite)(mux))
 
 	addr := fmt.Sprintf("0.0.0.0:%d", cfg.Port)
 	httpServer := &http.Server{
@@ -88,6 +133,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("shutting down...")
+	stopWorkers()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	if err := httpServer.Shutdown(ctx); err != nil {
@@ -0,0 +1,99 @@
+// Command qrkeys manages the keys that sign ticket QR codes:
+//
+//	qrkeys list          keys, newest first, with the number of tickets each one signed
+//	qrkeys rotate        creates a new key, which signs new QR codes from then on
+//	qrkeys retire <kid>  signs the tickets of the key again with the newest other key and
+//	                     stops accepting it
+//	qrkeys reseal        encrypts the stored keys again with QR_KEYS_SECRET, opening them with
+//	                     QR_KEYS_SECRET_PREVIOUS; run it before changing the secret of the servers
+//
+// Running servers pick up the changes within a minute.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"afterzin/api/internal/config"
+	"afterzin/api/internal/db"
+	"afterzin/api/internal/qrkeys"
+	"afterzin/api/internal/repository"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: qrkeys list | rotate | retire <kid> | reseal")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cfg := config.Load()
+
+	if err := os.MkdirAll(filepath.Dir(cfg.DBPath), 0755); err != nil {
+		log.Fatalf("create data dir: %v", err)
+	}
+
+	sqlite, err := db.OpenSQLite(cfg.DBPath)
+	if err != nil {
+		log.Fatalf("open db: %v", err)
+	}
+	defer sqlite.Close()
+
+	if err := db.Migrate(sqlite); err != nil {
+		log.Fatalf("migrate: %v", err)
+	}
+
+	switch os.Args[1] {
+	case "list":
+		keys, err := repository.QRSigningKeys(sqlite)
+		if err != nil {
+			log.Fatalf("list keys: %v", err)
+		}
+		for _, k := range keys {
+			tickets, err := repository.TicketsByQRKey(sqlite, k.ID)
+			if err != nil {
+				log.Fatalf("count tickets: %v", err)
+			}
+			status := "active"
+			if k.RetiredAt.Valid {
+				status = "retired " + k.RetiredAt.String
+			}
+			fmt.Printf("%s\tcreated %s\t%s\t%d tickets\n", k.ID, k.CreatedAt, status, len(tickets))
+		}
+	case "rotate":
+		id, err := qrkeys.Rotate(sqlite, cfg)
+		if err != nil {
+			log.Fatalf("rotate: %v", err)
+		}
+		log.Printf("New signing key %s", id)
+	case "retire":
+		if len(os.Args) != 3 {
+			usage()
+		}
+		keys, err := qrkeys.New(sqlite, cfg)
+		if err != nil {
+			log.Fatalf("load keys: %v", err)
+		}
+		resigned, err := keys.Retire(os.Args[2])
+		if err != nil {
+			log.Fatalf("retire: %v", err)
+		}
+		log.Printf("Key %s retired; %d tickets signed again", os.Args[2], resigned)
+	case "reseal":
+		previous := os.Getenv("QR_KEYS_SECRET_PREVIOUS")
+		if previous == "" {
+			log.Fatal("reseal: QR_KEYS_SECRET_PREVIOUS is required")
+		}
+		n, err := qrkeys.Reseal(sqlite, previous, cfg)
+		if err != nil {
+			log.Fatalf("reseal: %v", err)
+		}
+		log.Printf("%d keys sealed with the new QR_KEYS_SECRET", n)
+	default:
+		usage()
+	}
+}
@@ -0,0 +1,79 @@
+// Package attendees exports the attendee list of an event date for producers, with CPFs masked
+// (LGPD): only the middle digits go out of the platform.
+package attendees
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"time"
+
+	"afterzin/api/internal/repository"
+	"afterzin/api/internal/taxid"
+)
+
+// header are the columns of the exported list.
+var header = []string{"Código", "Tipo de ingresso", "Participante", "CPF", "Comprador", "Data da compra", "Check-in", "Entrada em"}
+
+// record is one line of the exported list; dates in the event's time zone.
+func record(a *repository.AttendeeRow) []string {
+	cpf := ""
+	if a.CPF != "" {
+		cpf = taxid.MaskCPF(a.CPF)
+	}
+	buyer := "Cortesia"
+	if a.BuyerName.Valid {
+		buyer = a.BuyerName.String
+	}
+	status, usedAt := "Não entrou", ""
+	if a.Used == 1 {
+		status = "Entrou"
+		if a.CheckinState == repository.CheckinOut {
+			status = "Saiu"
+		}
+		if a.UsedAt.Valid {
+			usedAt = localTime(a.UsedAt.String)
+		}
+	}
+	return []string{a.Code, a.TicketTypeName, a.Name, cpf, buyer, localTime(a.PurchasedAt), status, usedAt}
+}
+
+func localTime(s string) string {
+	t, err := time.Parse("2006-01-02 15:04:05", s)
+	if err != nil {
+		return s
+	}
+	return t.In(repository.EventLocation).Format("02/01/2006 15:04")
+}
+
+// WriteCSV writes the list as CSV the way spreadsheet apps in pt-BR open it: UTF-8 with BOM and
+// ';' between fields.
+func WriteCSV(w io.Writer, list []*repository.AttendeeRow) error {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = ';'
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, a := range list {
+		fields := record(a)
+		for i, f := range fields {
+			fields[i] = csvSafe(f)
+		}
+		if err := cw.Write(fields); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvSafe keeps spreadsheet apps from running names typed as formulas (=, +, -, @).
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,119 @@
+package attendees
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"afterzin/api/internal/middleware"
+	"afterzin/api/internal/repository"
+)
+
+// Handler provides the attendee list export endpoints.
+type Handler struct {
+	db *sql.DB
+}
+
+// NewHandler creates the attendee export HTTP handler.
+func NewHandler(db *sql.DB) *Handler {
+	return &Handler{db: db}
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// list loads the attendees of the event date from the {id} path value, filtered by the query
+// (search, ticketTypeId, checkedIn=true|false), after checking that the authenticated user is
+// the event's producer or an active staff member with the manage scope. Writes the error response and returns false otherwise.
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) (*repository.EventDateRow, []*repository.AttendeeRow, bool) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil, nil, false
+	}
+	userID := middleware.UserID(r.Context())
+	if userID == "" {
+		respondError(w, http.StatusUnauthorized, "não autenticado")
+		return nil, nil, false
+	}
+	ed, _ := repository.EventDateByID(h.db, r.PathValue("id"))
+	if ed == nil {
+		respondError(w, http.StatusNotFound, "data não encontrada")
+		return nil, nil, false
+	}
+	ev, ok, err := repository.EventAccess(h.db, ed.EventID, userID, repository.ScopeManage)
+	if ev == nil {
+		respondError(w, http.StatusNotFound, "evento não encontrado")
+		return nil, nil, false
+	}
+	if err != nil {
+		log.Printf("attendees: access to %s error: %v", ev.ID, err)
+		respondError(w, http.StatusInternalServerError, "erro ao verificar permissão")
+		return nil, nil, false
+	}
+	if !ok {
+		respondError(w, http.StatusForbidden, "sem permissão")
+		return nil, nil, false
+	}
+	q := r.URL.Query()
+	f := repository.AttendeeFilter{Search: q.Get("search"), TicketTypeID: q.Get("ticketTypeId")}
+	if v, err := strconv.ParseBool(q.Get("checkedIn")); err == nil {
+		f.CheckedIn = &v
+	}
+	list, _, err := repository.EventAttendees(h.db, ed.ID, f, 0, 0)
+	if err != nil {
+		log.Printf("attendees: list for %s error: %v", ed.ID, err)
+		respondError(w, http.StatusInternalServerError, "erro ao buscar participantes")
+		return nil, nil, false
+	}
+	return ed, list, true
+}
+
+func filename(ed *repository.EventDateRow, ext string) string {
+	return "participantes-" + ed.Date + "." + ext
+}
+
+// CSV handles GET /event-dates/{id}/attendees.csv (producer or MANAGER).
+func (h *Handler) CSV(w http.ResponseWriter, r *http.Request) {
+	ed, list, ok := h.list(w, r)
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, list); err != nil {
+		log.Printf("attendees: CSV for %s error: %v", ed.ID, err)
+		respondError(w, http.StatusInternalServerError, "erro ao gerar lista")
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename(ed, "csv")+`"`)
+	w.Header().Set("Cache-Control", "private, no-store")
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Printf("attendees: write for %s error: %v", ed.ID, err)
+	}
+}
+
+// XLSX handles GET /event-dates/{id}/attendees.xlsx (producer or MANAGER).
+func (h *Handler) XLSX(w http.ResponseWriter, r *http.Request) {
+	ed, list, ok := h.list(w, r)
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, list); err != nil {
+		log.Printf("attendees: XLSX for %s error: %v", ed.ID, err)
+		respondError(w, http.StatusInternalServerError, "erro ao gerar planilha")
+		return
+	}
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename(ed, "xlsx")+`"`)
+	w.Header().Set("Cache-Control", "private, no-store")
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Printf("attendees: write for %s error: %v", ed.ID, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -16,10 +17,14 @@ func CheckPassword(hash, password string) bool {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
 }
 
-func NewToken(userID, role, secret string, exp time.Duration) (string, error) {
+// NewToken issues a short-lived access token bound to a session (sid claim).
+// The jti makes every token unique; revocation is checked per session by the auth middleware.
+func NewToken(userID, role, sessionID, secret string, exp time.Duration) (string, error) {
 	claims := jwt.MapClaims{
 		"sub":  userID,
 		"role": role,
+		"sid":  sessionID,
+		"jti":  uuid.New().String(),
 		"exp":  time.Now().Add(exp).Unix(),
 		"iat":  time.Now().Unix(),
 	}
@@ -0,0 +1,71 @@
+package checkin
+
+import (
+	"sync"
+	"time"
+)
+
+// Validation is a ticket read at the door (an entry or, for re-entry tickets, an exit), published
+// to the live dashboards.
+type Validation struct {
+	ID           string
+	TicketID     string
+	EventID      string
+	EventDateID  string
+	TicketTypeID string
+	ValidatedBy  string
+	Direction    string // "IN" or "OUT"
+	Gate         string
+	Offline      bool
+	Reverted     bool // the validation was undone by a manager
+	ValidatedAt  time.Time
+}
+
+// subscriberBuffer is how many validations a slow subscriber can lag behind before new ones
+// are dropped for it.
+const subscriberBuffer = 64
+
+// Hub is the in-process pub/sub of validations, by event. Publishing never blocks.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[string]map[chan Validation]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: map[string]map[chan Validation]struct{}{}}
+}
+
+// Subscribe receives the validations of the event until cancel is called.
+func (h *Hub) Subscribe(eventID string) (ch <-chan Validation, cancel func()) {
+	c := make(chan Validation, subscriberBuffer)
+	h.mu.Lock()
+	if h.subs[eventID] == nil {
+		h.subs[eventID] = map[chan Validation]struct{}{}
+	}
+	h.subs[eventID][c] = struct{}{}
+	h.mu.Unlock()
+	var once sync.Once
+	return c, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs[eventID], c)
+			if len(h.subs[eventID]) == 0 {
+				delete(h.subs, eventID)
+			}
+			h.mu.Unlock()
+			close(c)
+		})
+	}
+}
+
+// Publish delivers the validation to the event's subscribers.
+func (h *Hub) Publish(v Validation) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.subs[v.EventID] {
+		select {
+		case c <- v:
+		default:
+		}
+	}
+}
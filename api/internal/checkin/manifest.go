@@ -0,0 +1,276 @@
+// Package checkin builds the offline check-in manifest of an event date for registered scanner
+// devices. The manifest is encrypted (AES-256-GCM) and signed (HMAC-SHA256) with keys derived
+// from the device key, which the device receives once when it is registered.
+package checkin
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"afterzin/api/internal/qrcode"
+)
+
+// ManifestVersion is the envelope format version; devices must reject unknown versions.
+const ManifestVersion = 1
+
+// Manifest is the plaintext content of the offline check-in bundle.
+type Manifest struct {
+	EventID     string `json:"eventId"`
+	EventDateID string `json:"eventDateId"`
+	GeneratedAt string `json:"generatedAt"`
+	// The event only accepts the rotating QR codes of the app (no static fallback).
+	DynamicOnly bool `json:"dynamicOnly,omitempty"`
+	// QRKeys are the Ed25519 public keys (hex, by key ID) that verify V3 payloads offline.
+	QRKeys map[string]string `json:"qrKeys,omitempty"`
+	// Gates are the registered gates of the date, with the areas each one admits.
+	Gates   []Gate  `json:"gates,omitempty"`
+	Tickets []Entry `json:"tickets"`
+}
+
+// Gate is a registered gate of the manifest; without AreaIDs it admits every ticket.
+type Gate struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	AreaIDs []string `json:"areaIds,omitempty"`
+}
+
+// Entry is one ticket of the manifest. QRHash is the hex SHA-256 of the ticket's current QR
+// payload: the device hashes what it scans and looks it up, so the QR signing secret never
+// leaves the server and reissued QR Codes (after transfers) are not accepted. DynamicOfflineSecret
+// (hex), set for events with rotating QR codes, only verifies the offline signature of the
+// ticket's dynamic payloads: it cannot produce payloads validateTicket accepts.
+type Entry struct {
+	TicketID             string `json:"ticketId"`
+	Code                 string `json:"code"`
+	QRHash               string `json:"qrHash"`
+	DynamicOfflineSecret string `json:"dynamicOfflineSecret,omitempty"`
+	TicketTypeID         string `json:"ticketTypeId"`
+	TicketTypeName       string `json:"ticketTypeName"`
+	AreaID               string `json:"areaId,omitempty"`
+	HolderName           string `json:"holderName,omitempty"`
+	// Nominal ticket without a holder: refused at the door.
+	HolderRequired bool `json:"holderRequired,omitempty"`
+	Used           bool `json:"used"`
+	// Door state, for the entry policy of the ticket type (SINGLE, REENTRY, DAILY or MULTI):
+	// MaxEntries is set for MULTI, LastEntryDate (YYYY-MM-DD, São Paulo time) once it entered
+	// and Inside while its last reading was an entry.
+	EntryPolicy   string `json:"entryPolicy"`
+	MaxEntries    int    `json:"maxEntries,omitempty"`
+	EntryCount    int    `json:"entryCount"`
+	LastEntryDate string `json:"lastEntryDate,omitempty"`
+	Inside        bool   `json:"inside,omitempty"`
+}
+
+// Envelope is the manifest as delivered to the device. Nonce and Ciphertext are base64,
+// Signature is hex HMAC-SHA256 over the other fields.
+type Envelope struct {
+	Version     int
+	DeviceID    string
+	EventDateID string
+	IssuedAt    time.Time
+	Nonce       string
+	Ciphertext  string
+	Signature   string
+}
+
+// NewDeviceKey returns a random 256-bit device key, hex encoded.
+func NewDeviceKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// QRHash is the manifest hash of a QR payload.
+func QRHash(payload string) string {
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// deriveKeys splits the device key into the encryption and signing keys.
+func deriveKeys(deviceKey string) (encKey, sigKey []byte, err error) {
+	key, err := hex.DecodeString(deviceKey)
+	if err != nil || len(key) != 32 {
+		return nil, nil, errors.New("checkin: invalid device key")
+	}
+	derive := func(label string) []byte {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(label))
+		return mac.Sum(nil)
+	}
+	return derive("afterzin-manifest-enc"), derive("afterzin-manifest-sig"), nil
+}
+
+func (e *Envelope) signedData() []byte {
+	return []byte(strconv.Itoa(e.Version) + "\n" + e.DeviceID + "\n" + e.EventDateID + "\n" +
+		e.IssuedAt.UTC().Format(time.RFC3339) + "\n" + e.Nonce + "\n" + e.Ciphertext)
+}
+
+func sign(sigKey []byte, e *Envelope) string {
+	mac := hmac.New(sha256.New, sigKey)
+	mac.Write(e.signedData())
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func gcm(encKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seal encrypts and signs the manifest for the device. The device and date IDs are bound as
+// additional data, so an envelope cannot be replayed to another device or date.
+func Seal(deviceKey, deviceID string, m *Manifest) (*Envelope, error) {
+	encKey, sigKey, err := deriveKeys(deviceKey)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := gcm(encKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	e := &Envelope{
+		Version:     ManifestVersion,
+		DeviceID:    deviceID,
+		EventDateID: m.EventDateID,
+		IssuedAt:    time.Now().UTC().Truncate(time.Second),
+		Nonce:       base64.StdEncoding.EncodeToString(nonce),
+	}
+	e.Ciphertext = base64.StdEncoding.EncodeToString(aead.Seal(nil, nonce, plain, []byte(deviceID+"\n"+m.EventDateID)))
+	e.Signature = sign(sigKey, e)
+	return e, nil
+}
+
+// Open verifies and decrypts an envelope; it is the reference for scanner apps.
+func Open(deviceKey string, e *Envelope) (*Manifest, error) {
+	if e.Version != ManifestVersion {
+		return nil, errors.New("checkin: unsupported manifest version")
+	}
+	encKey, sigKey, err := deriveKeys(deviceKey)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(sign(sigKey, e)), []byte(e.Signature)) {
+		return nil, errors.New("checkin: invalid manifest signature")
+	}
+	nonce, err := base64.StdEncoding.DecodeString(e.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(e.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := gcm(encKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, errors.New("checkin: invalid manifest nonce")
+	}
+	plain, err := aead.Open(nil, nonce, sealed, []byte(e.DeviceID+"\n"+e.EventDateID))
+	if err != nil {
+		return nil, errors.New("checkin: manifest decryption failed")
+	}
+	var m Manifest
+	if err := json.Unmarshal(plain, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Lookup validates a scanned QR payload against the manifest the way the device does offline:
+// a static payload must have the V1/V2/V3 shape, V3 signed with one of QRKeys, and its hash must
+// belong to the ticket it names; a dynamic one must carry the ticket's offline signature for a
+// window around now.
+// Returns nil when the ticket is not in the manifest or the payload is not valid.
+func (m *Manifest) Lookup(payload string, now time.Time) *Entry {
+	ticketID, ok := qrcode.PayloadTicketID(payload)
+	if !ok {
+		return nil
+	}
+	dynamic := qrcode.IsDynamicPayload(payload)
+	if !dynamic && m.DynamicOnly {
+		return nil
+	}
+	if _, isV3 := qrcode.ParseV3(payload); isV3 {
+		if _, ok := qrcode.VerifySignedPayloadV3(payload, m.publicKey); !ok {
+			return nil
+		}
+	}
+	hash := QRHash(payload)
+	for i := range m.Tickets {
+		e := &m.Tickets[i]
+		if e.TicketID != ticketID {
+			continue
+		}
+		if !dynamic && e.QRHash == hash {
+			return e
+		}
+		if dynamic && e.DynamicOfflineSecret != "" {
+			secret, err := hex.DecodeString(e.DynamicOfflineSecret)
+			if err == nil && qrcode.VerifyDynamicPayloadOffline(payload, secret, now) == nil {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Manifest) publicKey(id string) ed25519.PublicKey {
+	key, err := hex.DecodeString(m.QRKeys[id])
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil
+	}
+	return key
+}
+
+// Admits tells whether the gate lets the ticket in, like validateTicket's WRONG_AREA check: a
+// gate without areas admits every ticket, otherwise only the tickets of its areas. Unknown gates
+// admit nothing.
+func (m *Manifest) Admits(gateID string, e *Entry) bool {
+	for _, g := range m.Gates {
+		if g.ID == gateID {
+			return len(g.AreaIDs) == 0 || slices.Contains(g.AreaIDs, e.AreaID)
+		}
+	}
+	return false
+}
+
+// CanEnter tells whether the ticket's entry policy allows one more entry on day (YYYY-MM-DD,
+// São Paulo time), like validateTicket and the sync of offline scans: SINGLE only the first
+// entry, REENTRY when outside, DAILY once per day and MULTI up to MaxEntries entries.
+func (e *Entry) CanEnter(day string) bool {
+	switch e.EntryPolicy {
+	case "REENTRY":
+		return !e.Inside
+	case "DAILY":
+		return e.LastEntryDate != day
+	case "MULTI":
+		return e.EntryCount < e.MaxEntries
+	default:
+		return e.EntryCount == 0 && !e.Used
+	}
+}
@@ -0,0 +1,145 @@
+package checkout
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"afterzin/api/internal/repository"
+)
+
+// ChildMaxAge is the oldest age accepted on CHILD tickets (ECA: child until 12 incomplete).
+const ChildMaxAge = 11
+
+// Ticket type audiences (ticket_types.audience).
+const (
+	audienceMale   = "MALE"
+	audienceFemale = "FEMALE"
+	audienceChild  = "CHILD"
+)
+
+// Attendee is the person a ticket is checked against: the nominal holder or the buyer.
+type Attendee struct {
+	Label     string // prefix for error messages ("" for the buyer)
+	BirthDate string // YYYY-MM-DD
+	Gender    string // MALE, FEMALE or ""
+}
+
+func BuyerAttendee(u *repository.UserRow) Attendee {
+	return Attendee{BirthDate: u.BirthDate, Gender: u.Gender.String}
+}
+
+func HolderAttendee(h repository.TicketHolder) Attendee {
+	return Attendee{Label: "titular " + h.Name + ": ", BirthDate: h.BirthDate, Gender: h.Gender}
+}
+
+// TicketAttendees returns who the tickets of a cart item are checked against: each nominal
+// holder given, or the buyer when none was. CHILD tickets are always nominal and are checked
+// against the child, so without holders the check waits for assignTicketHolder.
+func TicketAttendees(buyer *repository.UserRow, tt *repository.TicketTypeRow, holders []repository.TicketHolder) []Attendee {
+	if len(holders) > 0 {
+		out := make([]Attendee, 0, len(holders))
+		for _, h := range holders {
+			out = append(out, HolderAttendee(h))
+		}
+		return out
+	}
+	if tt.Audience == audienceChild {
+		return nil
+	}
+	return []Attendee{BuyerAttendee(buyer)}
+}
+
+// ageOn returns the age in whole years on the given date (YYYY-MM-DD). ok is false when either
+// date cannot be parsed.
+func ageOn(birthDate, date string) (age int, ok bool) {
+	birth, err := time.Parse("2006-01-02", birthDate)
+	if err != nil {
+		return 0, false
+	}
+	on, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return 0, false
+	}
+	age = on.Year() - birth.Year()
+	if on.Month() < birth.Month() || (on.Month() == birth.Month() && on.Day() < birth.Day()) {
+		age--
+	}
+	return age, true
+}
+
+// CheckTicketAudience checks the event's minimum age and the ticket type's audience (gender or
+// child) against the attendee, with the age computed at the event date.
+func CheckTicketAudience(ev *repository.EventRow, d *repository.EventDateRow, tt *repository.TicketTypeRow, a Attendee) error {
+	if ev.MinAge > 0 || tt.Audience == audienceChild {
+		age, ok := ageOn(a.BirthDate, d.Date)
+		if !ok {
+			return errors.New(a.Label + "data de nascimento inválida no cadastro")
+		}
+		if age < ev.MinAge {
+			return fmt.Errorf("%sidade mínima para este evento é %d anos", a.Label, ev.MinAge)
+		}
+		if tt.Audience == audienceChild && age > ChildMaxAge {
+			return fmt.Errorf("%singresso %s é exclusivo para crianças até %d anos", a.Label, tt.Name, ChildMaxAge)
+		}
+	}
+	switch tt.Audience {
+	case audienceMale, audienceFemale:
+		if a.Gender == "" {
+			if a.Label == "" {
+				return fmt.Errorf("ingresso %s é exclusivo por gênero: informe seu gênero no perfil", tt.Name)
+			}
+			return fmt.Errorf("%sinforme o gênero (ingresso %s é exclusivo por gênero)", a.Label, tt.Name)
+		}
+		if a.Gender != tt.Audience {
+			return fmt.Errorf("%singresso %s é %s", a.Label, tt.Name, AudienceRestriction(tt.Audience))
+		}
+	}
+	return nil
+}
+
+// CheckOrderAudience checks a pending order again right before payment: the event's minimum age
+// and the ticket types' audiences may have changed since the preview, and so may the buyer's
+// profile.
+func CheckOrderAudience(db repository.Querier, orderID string, buyer *repository.UserRow) error {
+	items, err := repository.OrderItemsByOrderID(db, orderID)
+	if err != nil {
+		return err
+	}
+	for _, it := range items {
+		ev, tt, err := TicketTypeEvent(db, it.TicketTypeID)
+		if err != nil {
+			return err
+		}
+		d, err := repository.EventDateByID(db, it.EventDateID)
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			return errors.New("data não encontrada")
+		}
+		holders, err := repository.OrderItemHolders(db, it.ID)
+		if err != nil {
+			return err
+		}
+		for _, a := range TicketAttendees(buyer, tt, holders) {
+			if err := CheckTicketAudience(ev, d, tt, a); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// AudienceRestriction describes the audience of a ticket type ("" for GENERAL).
+func AudienceRestriction(audience string) string {
+	switch audience {
+	case audienceMale:
+		return "exclusivo para o público masculino"
+	case audienceFemale:
+		return "exclusivo para o público feminino"
+	case audienceChild:
+		return fmt.Sprintf("exclusivo para crianças até %d anos", ChildMaxAge)
+	}
+	return ""
+}
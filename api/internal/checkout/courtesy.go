@@ -0,0 +1,97 @@
+package checkout
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"afterzin/api/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// CourtesyIssue is one courtesy ticket to issue: its recipient and the account that owns it.
+type CourtesyIssue struct {
+	Courtesy repository.CourtesyTicketRow
+	OwnerID  string
+}
+
+// CourtesySignFunc signs the QR payload of a new courtesy ticket of its zero-value order.
+type CourtesySignFunc func(ticketID, orderID string) (string, error)
+
+// IssueCourtesyTickets issues the courtesy tickets of the ticket type (of eventDateID) in one
+// transaction, after checking the event's courtesy quota and the remaining capacity of the
+// ticket type and its lot, so concurrent issues cannot exceed them: each ticket gets a
+// zero-value order and takes capacity like a sold one. Every QR payload is signed before the
+// transaction. Returns the new ticket IDs, in the order of issues; the error message is meant
+// for the producer.
+func IssueCourtesyTickets(db *sql.DB, ev *repository.EventRow, tt *repository.TicketTypeRow, eventDateID string, issues []CourtesyIssue, sign CourtesySignFunc) ([]string, error) {
+	type ticket struct {
+		id, orderID, qrCode string
+	}
+	tickets := make([]ticket, 0, len(issues))
+	for range issues {
+		t := ticket{id: uuid.New().String(), orderID: uuid.New().String()}
+		qrCode, err := sign(t.id, t.orderID)
+		if err != nil {
+			return nil, fmt.Errorf("não foi possível emitir o QR Code da cortesia: %w", err)
+		}
+		t.qrCode = qrCode
+		tickets = append(tickets, t)
+	}
+
+	// The pool has a single connection: the transaction also serializes concurrent issues.
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	issued, err := repository.CountCourtesyTickets(tx, ev.ID)
+	if err != nil {
+		return nil, err
+	}
+	if issued+len(issues) > ev.CourtesyQuota {
+		return nil, fmt.Errorf("cota de cortesias do evento insuficiente: restam %d de %d", max(0, ev.CourtesyQuota-issued), ev.CourtesyQuota)
+	}
+	current, err := repository.TicketTypeByID(tx, tt.ID)
+	if err != nil {
+		return nil, err
+	}
+	lot, err := repository.LotByID(tx, tt.LotID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil || lot == nil {
+		return nil, errors.New("tipo de ingresso não encontrado")
+	}
+	if current.SoldQuantity+len(issues) > current.MaxQuantity || lot.AvailableQuantity < len(issues) {
+		return nil, errors.New("quantidade indisponível")
+	}
+	ids := make([]string, 0, len(issues))
+	for i, in := range issues {
+		t := tickets[i]
+		if err := repository.CreateCourtesyOrder(tx, t.orderID, in.OwnerID); err != nil {
+			return nil, err
+		}
+		itemID, err := repository.CreateOrderItem(tx, t.orderID, eventDateID, tt.ID, 1, 0)
+		if err != nil {
+			return nil, err
+		}
+		if err := repository.CreateTicketWithID(tx, t.id, repository.GenerateTicketCode(), t.qrCode, t.orderID, itemID, in.OwnerID, ev.ID, eventDateID, tt.ID); err != nil {
+			return nil, err
+		}
+		c := in.Courtesy
+		c.TicketID, c.EventID = t.id, ev.ID
+		if err := repository.CreateCourtesyTicket(tx, c); err != nil {
+			return nil, err
+		}
+		ids = append(ids, t.id)
+	}
+	if err := repository.IncrementTicketTypeSold(tx, tt.ID, len(issues)); err != nil {
+		return nil, err
+	}
+	if err := repository.DecrementLotAvailable(tx, tt.LotID, len(issues)); err != nil {
+		return nil, err
+	}
+	return ids, tx.Commit()
+}
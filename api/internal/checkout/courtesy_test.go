@@ -0,0 +1,68 @@
+package checkout
+
+import (
+	"strings"
+	"testing"
+
+	"afterzin/api/internal/repository"
+)
+
+// issueCourtesies issues n courtesy tickets of the fixture's ticket type, owned by the issuer.
+func (f *fixture) issueCourtesies(t *testing.T, issuer *repository.UserRow, n int) ([]string, error) {
+	t.Helper()
+	ev, err := repository.EventByID(f.DB, f.EventID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt, err := repository.TicketTypeByID(f.DB, f.TicketTypeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues := make([]CourtesyIssue, n)
+	for i := range issues {
+		issues[i] = CourtesyIssue{Courtesy: repository.CourtesyTicketRow{IssuedBy: issuer.ID, RecipientName: "Convidado"}, OwnerID: issuer.ID}
+	}
+	return IssueCourtesyTickets(f.DB, ev, tt, f.DateID, issues, func(ticketID, orderID string) (string, error) {
+		return "qr:" + ticketID, nil
+	})
+}
+
+func TestIssueCourtesyTicketsQuota(t *testing.T) {
+	f := newFixture(t)
+	if err := repository.UpdateEventCourtesyQuota(f.DB, f.EventID, 3); err != nil {
+		t.Fatal(err)
+	}
+	issuer := f.user(t)
+	ids, err := f.issueCourtesies(t, issuer, 2)
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("2 of 3: %d tickets, %v", len(ids), err)
+	}
+	if _, err := f.issueCourtesies(t, issuer, 2); err == nil || !strings.Contains(err.Error(), "restam 1 de 3") {
+		t.Errorf("2 more: got %v, want quota error", err)
+	}
+	if n, _ := repository.CountCourtesyTickets(f.DB, f.EventID); n != 2 {
+		t.Errorf("%d courtesies after the refused issue, want 2", n)
+	}
+
+	// Courtesies take capacity like sold tickets but are not purchases.
+	tt, _ := repository.TicketTypeByID(f.DB, f.TicketTypeID)
+	if tt.SoldQuantity != 2 {
+		t.Errorf("sold quantity = %d, want 2", tt.SoldQuantity)
+	}
+	if n, err := repository.CountPurchasedTicketsByUser(f.DB, issuer.ID, f.EventID, ""); err != nil || n != 0 {
+		t.Errorf("purchased tickets = %d, %v; want 0", n, err)
+	}
+}
+
+func TestIssueCourtesyTicketsCapacity(t *testing.T) {
+	f := newFixture(t)
+	if err := repository.UpdateEventCourtesyQuota(f.DB, f.EventID, 10); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.DB.Exec(`UPDATE ticket_types SET max_quantity = 1 WHERE id = ?`, f.TicketTypeID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.issueCourtesies(t, f.user(t), 2); err == nil || err.Error() != "quantidade indisponível" {
+		t.Errorf("got %v, want capacity error", err)
+	}
+}
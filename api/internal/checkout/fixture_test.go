@@ -0,0 +1,46 @@
+package checkout
+
+import (
+	"database/sql"
+	"testing"
+
+	"afterzin/api/internal/repository"
+	"afterzin/api/internal/repository/repotest"
+)
+
+// fixture is the shared test fixture, with users as rows and the order flow of checkout.
+type fixture struct {
+	*repotest.Fixture
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	return &fixture{repotest.NewFixture(t)}
+}
+
+// user creates a user with a unique e-mail and CPF (000.000.000-NN).
+func (f *fixture) user(t *testing.T) *repository.UserRow {
+	t.Helper()
+	u, err := repository.UserByID(f.DB, f.User(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+// order creates the buyer's pending order of quantity tickets of the fixture's ticket type.
+func (f *fixture) order(t *testing.T, buyer *repository.UserRow, quantity int) string {
+	t.Helper()
+	orderID, _ := f.PendingOrder(t, buyer.ID, quantity)
+	return orderID
+}
+
+// fulfill pays the order as the payment confirmation does.
+func (f *fixture) fulfill(t *testing.T, orderID string) ([]string, error) {
+	t.Helper()
+	return FulfillOrder(f.DB, orderID, func(ticketID, eventID string) (string, error) {
+		return "qr:" + ticketID, nil
+	})
+}
+
+func limit(n int64) sql.NullInt64 { return sql.NullInt64{Int64: n, Valid: true} }
@@ -0,0 +1,140 @@
+package checkout
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"afterzin/api/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// ErrOrderNotPending is returned by FulfillOrder when the order was already paid, refunded or
+// cancelled (e.g. a repeated payment webhook).
+var ErrOrderNotPending = errors.New("pedido já processado")
+
+// UnfulfillableError is why a pending order cannot be fulfilled when it is paid; once charged,
+// the payment must be returned. The message is meant for the buyer.
+type UnfulfillableError struct {
+	Reason error
+}
+
+func (e *UnfulfillableError) Error() string { return e.Reason.Error() }
+
+func (e *UnfulfillableError) Unwrap() error { return e.Reason }
+
+// SignFunc signs the QR payload of a new ticket of the event.
+type SignFunc func(ticketID, eventID string) (string, error)
+
+// newTicket is a ticket of the order, with its QR payload signed before the confirmation starts.
+type newTicket struct {
+	id       string
+	qrCode   string
+	eventID  string
+	item     repository.OrderItemRow
+	position int
+}
+
+// FulfillOrder issues the tickets of a pending order and marks it PAID in one transaction, after
+// checking again that its events are on sale and the rules that only count paid orders (purchase limits per account and CPF,
+// half-price quota, promo code caps), so concurrent confirmations cannot exceed them. Every QR payload is signed before the transaction: if any
+// cannot be signed, no ticket is issued. Returns the new ticket IDs; rule violations and
+// signing failures are *UnfulfillableError, an order no longer pending is ErrOrderNotPending.
+func FulfillOrder(db *sql.DB, orderID string, sign SignFunc) ([]string, error) {
+	items, err := repository.OrderItemsByOrderID(db, orderID)
+	if err != nil {
+		return nil, err
+	}
+	var tickets []newTicket
+	for _, it := range items {
+		ed, err := repository.EventDateByID(db, it.EventDateID)
+		if err != nil {
+			return nil, err
+		}
+		if ed == nil {
+			return nil, &UnfulfillableError{errors.New("data do evento não encontrada")}
+		}
+		for i := 0; i < it.Quantity; i++ {
+			id := uuid.New().String()
+			qrCode, err := sign(id, ed.EventID)
+			if err != nil {
+				return nil, &UnfulfillableError{fmt.Errorf("não foi possível emitir o QR Code do ingresso: %w", err)}
+			}
+			tickets = append(tickets, newTicket{id: id, qrCode: qrCode, eventID: ed.EventID, item: it, position: i})
+		}
+	}
+
+	// The pool has a single connection: the transaction also serializes concurrent confirmations.
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	userID, status, _, err := repository.OrderByID(tx, orderID)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("pedido não encontrado")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != "PENDING" {
+		return nil, ErrOrderNotPending
+	}
+	buyer, err := repository.UserByID(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if buyer == nil {
+		return nil, &UnfulfillableError{errors.New("comprador não encontrado")}
+	}
+	cart, err := OrderItems(tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckOrderOnSale(tx, orderID); err != nil {
+		return nil, &UnfulfillableError{err}
+	}
+	if err := CheckPurchaseLimits(tx, buyer, cart); err != nil {
+		return nil, &UnfulfillableError{err}
+	}
+	if err := CheckOrderHalfPriceQuota(tx, orderID); err != nil {
+		return nil, &UnfulfillableError{err}
+	}
+	redeemed, err := repository.RedeemOrderPromoCode(tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if !redeemed {
+		return nil, &UnfulfillableError{errors.New("cupom esgotado antes da confirmação do pagamento")}
+	}
+	ticketIDs := make([]string, 0, len(tickets))
+	for _, t := range tickets {
+		if err := repository.CreateTicketWithID(tx, t.id, repository.GenerateTicketCode(), t.qrCode, orderID, t.item.ID, userID, t.eventID, t.item.EventDateID, t.item.TicketTypeID); err != nil {
+			return nil, err
+		}
+		if err := repository.ApplyOrderItemHolder(tx, t.id, t.item.ID, t.position); err != nil {
+			return nil, err
+		}
+		if err := repository.ApplyOrderItemHalfPriceDocument(tx, t.id, t.item.ID, t.position); err != nil {
+			return nil, err
+		}
+		if err := repository.IncrementTicketTypeSold(tx, t.item.TicketTypeID, 1); err != nil {
+			return nil, err
+		}
+		tt, err := repository.TicketTypeByID(tx, t.item.TicketTypeID)
+		if err != nil {
+			return nil, err
+		}
+		if tt != nil {
+			if err := repository.DecrementLotAvailable(tx, tt.LotID, 1); err != nil {
+				return nil, err
+			}
+		}
+		ticketIDs = append(ticketIDs, t.id)
+	}
+	if err := repository.ConfirmOrder(tx, orderID); err != nil {
+		return nil, err
+	}
+	return ticketIDs, tx.Commit()
+}
@@ -0,0 +1,65 @@
+package checkout
+
+import (
+	"errors"
+	"fmt"
+
+	"afterzin/api/internal/repository"
+)
+
+// HalfPriceQuotaPercent is the share of each date's capacity reserved for meia-entrada
+// (Lei 12.933/2013); half-price sales stop once it is reached.
+const HalfPriceQuotaPercent = 40
+
+// HalfPriceQuota returns the quota and the half-price tickets already sold for the date.
+func HalfPriceQuota(db repository.Querier, eventDateID string) (quota, sold int, err error) {
+	capacity, sold, err := repository.HalfPriceUsage(db, eventDateID)
+	if err != nil {
+		return 0, 0, err
+	}
+	return capacity * HalfPriceQuotaPercent / 100, sold, nil
+}
+
+// CheckHalfPriceQuota fails when the half-price tickets requested per date would exceed the quota.
+func CheckHalfPriceQuota(db repository.Querier, requested map[string]int) error {
+	for dateID, n := range requested {
+		quota, sold, err := HalfPriceQuota(db, dateID)
+		if err != nil {
+			return err
+		}
+		if sold+n > quota {
+			if left := quota - sold; left > 0 {
+				return fmt.Errorf("cota de meia-entrada quase esgotada para esta data: restam %d", left)
+			}
+			return errors.New("cota de meia-entrada esgotada para esta data")
+		}
+	}
+	return nil
+}
+
+// CheckOrderHalfPriceQuota checks the quota again for the half-price tickets of the order, right
+// before payment: sales only count once paid, so other orders may have used it up since checkout.
+func CheckOrderHalfPriceQuota(db repository.Querier, orderID string) error {
+	items, err := repository.OrderItemsByOrderID(db, orderID)
+	if err != nil {
+		return err
+	}
+	requested := map[string]int{}
+	for _, it := range items {
+		tt, err := repository.TicketTypeByID(db, it.TicketTypeID)
+		if err != nil {
+			return err
+		}
+		if tt == nil || !tt.HalfPriceEntitlement.Valid {
+			continue
+		}
+		lot, err := repository.LotByID(db, tt.LotID)
+		if err != nil {
+			return err
+		}
+		if lot != nil {
+			requested[lot.EventDateID] += it.Quantity
+		}
+	}
+	return CheckHalfPriceQuota(db, requested)
+}
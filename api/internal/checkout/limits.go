@@ -0,0 +1,165 @@
+// Package checkout holds purchase rules shared by the GraphQL checkout and the payment handlers.
+package checkout
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"afterzin/api/internal/repository"
+	"afterzin/api/internal/taxid"
+)
+
+// Item is one line of a cart or order checked against the purchase limits.
+type Item struct {
+	EventDateID  string
+	TicketTypeID string
+	Quantity     int
+	// HolderCPFs are the nominal holders' CPFs by ticket position; tickets without a holder
+	// count for the buyer's CPF.
+	HolderCPFs []string
+}
+
+// OrderItems loads the items of a pending order (with checkout holders) for a new limit check
+// right before payment.
+func OrderItems(db repository.Querier, orderID string) ([]Item, error) {
+	rows, err := repository.OrderItemsByOrderID(db, orderID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]Item, 0, len(rows))
+	for _, it := range rows {
+		cpfs, err := repository.OrderItemHolderCPFs(db, it.ID)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, Item{EventDateID: it.EventDateID, TicketTypeID: it.TicketTypeID, Quantity: it.Quantity, HolderCPFs: cpfs})
+	}
+	return items, nil
+}
+
+// scope accumulates the cart tickets counted against one event's or one ticket type's limits.
+type scope struct {
+	name         string // shown in errors, e.g. "do evento Show X" or "Pista"
+	eventID      string
+	ticketTypeID string // empty for the event scope
+	limits       repository.PurchaseLimits
+	quantity     int
+	perCPF       map[string]int
+}
+
+// CheckPurchaseLimits enforces the per-order, per-account and per-CPF limits of each event and
+// ticket type in the cart, counting the buyer's paid tickets from earlier orders. The error
+// message is meant for the buyer.
+func CheckPurchaseLimits(db repository.Querier, buyer *repository.UserRow, items []Item) error {
+	buyerCPF := taxid.Digits(buyer.CPF)
+	var order []*scope
+	scopes := map[string]*scope{}
+	add := func(key string, s *scope, it Item) {
+		if existing, ok := scopes[key]; ok {
+			s = existing
+		} else {
+			s.perCPF = map[string]int{}
+			scopes[key] = s
+			order = append(order, s)
+		}
+		s.quantity += it.Quantity
+		for i := 0; i < it.Quantity; i++ {
+			cpf := buyerCPF
+			if i < len(it.HolderCPFs) {
+				cpf = it.HolderCPFs[i]
+			}
+			s.perCPF[cpf]++
+		}
+	}
+	for _, it := range items {
+		// The event comes from the ticket type, never from the date sent with the item.
+		ev, tt, err := TicketTypeEvent(db, it.TicketTypeID)
+		if err != nil {
+			return err
+		}
+		add("event:"+ev.ID, &scope{name: "do evento " + ev.Title, eventID: ev.ID, limits: ev.Limits}, it)
+		add("type:"+tt.ID, &scope{name: tt.Name, eventID: ev.ID, ticketTypeID: tt.ID, limits: tt.Limits}, it)
+	}
+	for _, s := range order {
+		if err := s.check(db, buyer.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TicketTypeEvent loads the ticket type and its event (ticket type → lot → date → event).
+func TicketTypeEvent(db repository.Querier, ticketTypeID string) (*repository.EventRow, *repository.TicketTypeRow, error) {
+	tt, _ := repository.TicketTypeByID(db, ticketTypeID)
+	if tt == nil {
+		return nil, nil, errors.New("tipo de ingresso não encontrado")
+	}
+	lot, _ := repository.LotByID(db, tt.LotID)
+	if lot == nil {
+		return nil, nil, errors.New("tipo de ingresso não encontrado")
+	}
+	d, _ := repository.EventDateByID(db, lot.EventDateID)
+	if d == nil {
+		return nil, nil, errors.New("data não encontrada")
+	}
+	ev, _ := repository.EventByID(db, d.EventID)
+	if ev == nil {
+		return nil, nil, errors.New("evento não encontrado")
+	}
+	return ev, tt, nil
+}
+
+// CheckHolderCPFLimit enforces the per-CPF limits of the ticket's event and ticket type when a
+// purchased ticket is given to a new holder CPF (digits only), so tickets bought for different
+// CPFs cannot be moved onto one afterwards.
+func CheckHolderCPFLimit(db *sql.DB, t *repository.TicketRow, cpf string) error {
+	current, purchased, err := repository.TicketPurchaseCPF(db, t.ID)
+	if err != nil {
+		return err
+	}
+	if !purchased || current == cpf {
+		return nil
+	}
+	ev, tt, err := TicketTypeEvent(db, t.TicketTypeID)
+	if err != nil {
+		return err
+	}
+	for _, s := range []*scope{
+		{name: "do evento " + ev.Title, eventID: ev.ID, limits: repository.PurchaseLimits{MaxPerCPF: ev.Limits.MaxPerCPF}},
+		{name: tt.Name, eventID: ev.ID, ticketTypeID: tt.ID, limits: repository.PurchaseLimits{MaxPerCPF: tt.Limits.MaxPerCPF}},
+	} {
+		s.quantity, s.perCPF = 1, map[string]int{cpf: 1}
+		if err := s.check(db, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *scope) check(db repository.Querier, buyerID string) error {
+	if max := s.limits.MaxPerOrder; max.Valid && int64(s.quantity) > max.Int64 {
+		return fmt.Errorf("limite de %d ingresso(s) %s por pedido", max.Int64, s.name)
+	}
+	if max := s.limits.MaxPerAccount; max.Valid {
+		owned, err := repository.CountPurchasedTicketsByUser(db, buyerID, s.eventID, s.ticketTypeID)
+		if err != nil {
+			return err
+		}
+		if int64(owned+s.quantity) > max.Int64 {
+			return fmt.Errorf("limite de %d ingresso(s) %s por conta (você já tem %d)", max.Int64, s.name, owned)
+		}
+	}
+	if max := s.limits.MaxPerCPF; max.Valid {
+		for cpf, n := range s.perCPF {
+			owned, err := repository.CountPurchasedTicketsByCPF(db, cpf, s.eventID, s.ticketTypeID)
+			if err != nil {
+				return err
+			}
+			if int64(owned+n) > max.Int64 {
+				return fmt.Errorf("limite de %d ingresso(s) %s por CPF atingido para o CPF %s", max.Int64, s.name, taxid.MaskCPF(cpf))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package checkout
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"afterzin/api/internal/repository"
+	"afterzin/api/internal/taxid"
+)
+
+func TestCheckPurchaseLimitsPerOrder(t *testing.T) {
+	f := newFixture(t)
+	if err := repository.UpdateTicketTypePurchaseLimits(f.DB, f.TicketTypeID, repository.PurchaseLimits{MaxPerOrder: limit(2)}); err != nil {
+		t.Fatal(err)
+	}
+	buyer := f.user(t)
+	if err := CheckPurchaseLimits(f.DB, buyer, []Item{{EventDateID: f.DateID, TicketTypeID: f.TicketTypeID, Quantity: 2}}); err != nil {
+		t.Errorf("2 tickets: %v", err)
+	}
+	// Items of the same ticket type add up.
+	err := CheckPurchaseLimits(f.DB, buyer, []Item{
+		{EventDateID: f.DateID, TicketTypeID: f.TicketTypeID, Quantity: 2},
+		{EventDateID: f.DateID, TicketTypeID: f.TicketTypeID, Quantity: 1},
+	})
+	if err == nil || !strings.Contains(err.Error(), "por pedido") {
+		t.Errorf("3 tickets: got %v, want per-order limit", err)
+	}
+}
+
+func TestCheckPurchaseLimitsPerAccount(t *testing.T) {
+	f := newFixture(t)
+	if err := repository.UpdateEventPurchaseLimits(f.DB, f.EventID, repository.PurchaseLimits{MaxPerAccount: limit(3)}); err != nil {
+		t.Fatal(err)
+	}
+	buyer := f.user(t)
+	if _, err := f.fulfill(t, f.order(t, buyer, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckPurchaseLimits(f.DB, buyer, []Item{{EventDateID: f.DateID, TicketTypeID: f.TicketTypeID, Quantity: 1}}); err != nil {
+		t.Errorf("third ticket: %v", err)
+	}
+	err := CheckPurchaseLimits(f.DB, buyer, []Item{{EventDateID: f.DateID, TicketTypeID: f.TicketTypeID, Quantity: 2}})
+	if err == nil || !strings.Contains(err.Error(), "por conta") {
+		t.Errorf("fourth ticket: got %v, want per-account limit", err)
+	}
+	// Another account is not affected.
+	if err := CheckPurchaseLimits(f.DB, f.user(t), []Item{{EventDateID: f.DateID, TicketTypeID: f.TicketTypeID, Quantity: 3}}); err != nil {
+		t.Errorf("other account: %v", err)
+	}
+}
+
+func TestCheckPurchaseLimitsPerCPF(t *testing.T) {
+	f := newFixture(t)
+	if err := repository.UpdateTicketTypePurchaseLimits(f.DB, f.TicketTypeID, repository.PurchaseLimits{MaxPerCPF: limit(1)}); err != nil {
+		t.Fatal(err)
+	}
+	buyer := f.user(t)
+	// One ticket for the buyer and one for another holder fit the limit; two for the buyer do not.
+	if err := CheckPurchaseLimits(f.DB, buyer, []Item{{EventDateID: f.DateID, TicketTypeID: f.TicketTypeID, Quantity: 2, HolderCPFs: []string{"12345678909"}}}); err != nil {
+		t.Errorf("buyer and holder: %v", err)
+	}
+	err := CheckPurchaseLimits(f.DB, buyer, []Item{{EventDateID: f.DateID, TicketTypeID: f.TicketTypeID, Quantity: 2}})
+	if err == nil || !strings.Contains(err.Error(), "por CPF") {
+		t.Fatalf("two for the buyer: got %v, want per-CPF limit", err)
+	}
+	if strings.Contains(err.Error(), taxid.Digits(buyer.CPF)) {
+		t.Errorf("error shows the full CPF: %v", err)
+	}
+}
+
+func TestFulfillOrderRechecksPurchaseLimits(t *testing.T) {
+	f := newFixture(t)
+	if err := repository.UpdateEventPurchaseLimits(f.DB, f.EventID, repository.PurchaseLimits{MaxPerAccount: limit(2)}); err != nil {
+		t.Fatal(err)
+	}
+	buyer := f.user(t)
+	// Both orders passed the checkout check; only the first one paid fits the limit.
+	first, second := f.order(t, buyer, 2), f.order(t, buyer, 1)
+	ids, err := f.fulfill(t, first)
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("first order: %d tickets, %v", len(ids), err)
+	}
+	_, err = f.fulfill(t, second)
+	var unfulfillable *UnfulfillableError
+	if !errors.As(err, &unfulfillable) {
+		t.Fatalf("second order: got %v, want UnfulfillableError", err)
+	}
+	if _, status, _, _ := repository.OrderByID(f.DB, second); status != "PENDING" {
+		t.Errorf("second order status = %s, want PENDING", status)
+	}
+	if _, err := f.fulfill(t, first); err != ErrOrderNotPending {
+		t.Errorf("repeated confirmation: got %v, want ErrOrderNotPending", err)
+	}
+}
@@ -0,0 +1,37 @@
+package checkout
+
+import (
+	"errors"
+
+	"afterzin/api/internal/repository"
+)
+
+// eventPublished is the only event status (events.status) open for sale.
+const eventPublished = "PUBLISHED"
+
+// CheckEventOnSale refuses events not published: paused (e.g. the producer was rejected),
+// ended, removed or still drafts.
+func CheckEventOnSale(ev *repository.EventRow) error {
+	if ev.Status != eventPublished {
+		return errors.New("evento " + ev.Title + " não está à venda")
+	}
+	return nil
+}
+
+// CheckOrderOnSale checks that every event of a pending order is still on sale.
+func CheckOrderOnSale(db repository.Querier, orderID string) error {
+	items, err := repository.OrderItemsByOrderID(db, orderID)
+	if err != nil {
+		return err
+	}
+	for _, it := range items {
+		ev, _, err := TicketTypeEvent(db, it.TicketTypeID)
+		if err != nil {
+			return err
+		}
+		if err := CheckEventOnSale(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
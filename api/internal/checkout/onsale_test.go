@@ -0,0 +1,32 @@
+package checkout
+
+import (
+	"errors"
+	"testing"
+
+	"afterzin/api/internal/repository"
+)
+
+func TestFulfillOrderEventNotOnSale(t *testing.T) {
+	f := newFixture(t)
+	buyer := f.user(t)
+	orderID := f.order(t, buyer, 1)
+	if err := CheckOrderOnSale(f.DB, orderID); err != nil {
+		t.Fatalf("published event: %v", err)
+	}
+	// The event was paused (e.g. its producer was rejected) after the checkout.
+	if err := repository.UpdateEventStatus(f.DB, f.EventID, "PAUSED"); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckOrderOnSale(f.DB, orderID); err == nil {
+		t.Error("CheckOrderOnSale accepted a paused event")
+	}
+	_, err := f.fulfill(t, orderID)
+	var unfulfillable *UnfulfillableError
+	if !errors.As(err, &unfulfillable) {
+		t.Fatalf("got %v, want UnfulfillableError", err)
+	}
+	if tickets, _ := repository.TicketsByOrderID(f.DB, orderID); len(tickets) != 0 {
+		t.Errorf("%d tickets issued for a paused event", len(tickets))
+	}
+}
@@ -0,0 +1,145 @@
+package checkout
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"afterzin/api/internal/repository"
+)
+
+// PromoTimeLayout is the format of the promo code validity window columns (UTC).
+const PromoTimeLayout = "2006-01-02 15:04:05"
+
+// NormalizePromoCode trims and uppercases a promo code; codes are matched case-insensitively.
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// promoUnitDiscount is the discount of the promo code on one ticket of the given original
+// price, rounded to centavos and never above the price.
+func promoUnitDiscount(p *repository.PromoCodeRow, price float64) float64 {
+	d := p.DiscountValue
+	if p.DiscountType == repository.PromoPercent {
+		d = price * p.DiscountValue / 100
+	}
+	d = math.Round(d*100) / 100
+	if d > price {
+		d = price
+	}
+	return d
+}
+
+// checkPromoCode enforces the promo code's state, validity window and usage caps for the
+// buyer, ignoring the order being checked in the counts.
+func checkPromoCode(db *sql.DB, p *repository.PromoCodeRow, userID, orderID string) error {
+	if !p.Active {
+		return errors.New("cupom inativo")
+	}
+	now := time.Now().UTC()
+	if p.ValidFrom.Valid {
+		if t, err := time.Parse(PromoTimeLayout, p.ValidFrom.String); err == nil && now.Before(t) {
+			return errors.New("cupom ainda não está válido")
+		}
+	}
+	if p.ValidUntil.Valid {
+		if t, err := time.Parse(PromoTimeLayout, p.ValidUntil.String); err == nil && now.After(t) {
+			return errors.New("cupom expirado")
+		}
+	}
+	if p.MaxUses.Valid {
+		n, err := repository.CountPromoCodeRedemptions(db, p.ID, "", orderID)
+		if err != nil {
+			return err
+		}
+		if int64(n) >= p.MaxUses.Int64 {
+			return errors.New("cupom esgotado")
+		}
+	}
+	if p.MaxUsesPerUser.Valid {
+		n, err := repository.CountPromoCodeRedemptions(db, p.ID, userID, orderID)
+		if err != nil {
+			return err
+		}
+		if int64(n) >= p.MaxUsesPerUser.Int64 {
+			return errors.New("você já usou este cupom o número máximo de vezes")
+		}
+	}
+	return nil
+}
+
+// ApplyPromoCode applies the promo code to the buyer's pending order, replacing the one applied
+// before; an empty code removes the discount. The discount lowers the unit price of the items
+// of the code's event (or ticket type) and the order total. The error message is meant for
+// the buyer.
+func ApplyPromoCode(db *sql.DB, orderID, userID, code string) error {
+	items, err := repository.OrderItemsByOrderID(db, orderID)
+	if err != nil {
+		return err
+	}
+	current, err := repository.OrderItemDiscounts(db, orderID)
+	if err != nil {
+		return err
+	}
+	var promoCodeID string
+	discounts := map[string]float64{}
+	if code = NormalizePromoCode(code); code != "" {
+		eventByDate := map[string]string{}
+		var promo *repository.PromoCodeRow
+		for _, it := range items {
+			ed, _ := repository.EventDateByID(db, it.EventDateID)
+			if ed == nil {
+				continue
+			}
+			eventByDate[it.EventDateID] = ed.EventID
+			if promo == nil {
+				promo, _ = repository.PromoCodeByEventAndCode(db, ed.EventID, code)
+			}
+		}
+		if promo == nil {
+			return errors.New("cupom inválido")
+		}
+		if err := checkPromoCode(db, promo, userID, orderID); err != nil {
+			return err
+		}
+		for _, it := range items {
+			if eventByDate[it.EventDateID] != promo.EventID || (promo.TicketTypeID.Valid && promo.TicketTypeID.String != it.TicketTypeID) {
+				continue
+			}
+			if d := promoUnitDiscount(promo, it.UnitPrice+current[it.ID]); d > 0 {
+				discounts[it.ID] = d
+			}
+		}
+		if len(discounts) == 0 {
+			return errors.New("cupom não se aplica aos ingressos do pedido")
+		}
+		promoCodeID = promo.ID
+	}
+	ok, err := repository.ApplyOrderDiscount(db, orderID, promoCodeID, discounts)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("pedido já processado; não é possível alterar o cupom")
+	}
+	return nil
+}
+
+// CheckOrderPromoCode checks again, right before payment, that the promo code applied to the
+// order can still be used by the buyer.
+func CheckOrderPromoCode(db *sql.DB, orderID, userID string) error {
+	id, err := repository.OrderPromoCodeID(db, orderID)
+	if err != nil || id == "" {
+		return err
+	}
+	p, err := repository.PromoCodeByID(db, id)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return errors.New("cupom não está mais disponível")
+	}
+	return checkPromoCode(db, p, userID, orderID)
+}
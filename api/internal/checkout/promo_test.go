@@ -0,0 +1,105 @@
+package checkout
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"afterzin/api/internal/repository"
+)
+
+func (f *fixture) promoCode(t *testing.T, p repository.PromoCodeRow) {
+	t.Helper()
+	p.EventID, p.Active = f.EventID, true
+	if _, err := repository.CreatePromoCode(f.DB, &p); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func orderTotal(t *testing.T, f *fixture, orderID string) float64 {
+	t.Helper()
+	_, _, total, err := repository.OrderByID(f.DB, orderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return total
+}
+
+func TestApplyPromoCode(t *testing.T) {
+	f := newFixture(t)
+	f.promoCode(t, repository.PromoCodeRow{Code: "DEZ", DiscountType: repository.PromoPercent, DiscountValue: 10})
+	f.promoCode(t, repository.PromoCodeRow{Code: "MAIOR", DiscountType: repository.PromoFixed, DiscountValue: 500})
+	buyer := f.user(t)
+	orderID := f.order(t, buyer, 2)
+
+	if err := ApplyPromoCode(f.DB, orderID, buyer.ID, " dez "); err != nil {
+		t.Fatal(err)
+	}
+	if got := orderTotal(t, f, orderID); got != 180 {
+		t.Errorf("10%% off: total = %v, want 180", got)
+	}
+	// Replacing the code starts from the original price; a discount never exceeds it.
+	if err := ApplyPromoCode(f.DB, orderID, buyer.ID, "MAIOR"); err != nil {
+		t.Fatal(err)
+	}
+	if got := orderTotal(t, f, orderID); got != 0 {
+		t.Errorf("fixed discount above the price: total = %v, want 0", got)
+	}
+	if err := ApplyPromoCode(f.DB, orderID, buyer.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := orderTotal(t, f, orderID); got != 200 {
+		t.Errorf("code removed: total = %v, want 200", got)
+	}
+	if err := ApplyPromoCode(f.DB, orderID, buyer.ID, "NAOEXISTE"); err == nil {
+		t.Error("unknown code accepted")
+	}
+}
+
+func TestPromoCodeMaxUses(t *testing.T) {
+	f := newFixture(t)
+	f.promoCode(t, repository.PromoCodeRow{Code: "UNICO", DiscountType: repository.PromoFixed, DiscountValue: 10, MaxUses: sql.NullInt64{Int64: 1, Valid: true}})
+	first, second := f.user(t), f.user(t)
+	firstOrder, secondOrder := f.order(t, first, 1), f.order(t, second, 1)
+	// Redemptions only count once paid: both pending orders can apply the code.
+	for _, o := range []struct {
+		buyer   *repository.UserRow
+		orderID string
+	}{{first, firstOrder}, {second, secondOrder}} {
+		if err := ApplyPromoCode(f.DB, o.orderID, o.buyer.ID, "UNICO"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := f.fulfill(t, firstOrder); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckOrderPromoCode(f.DB, secondOrder, second.ID); err == nil {
+		t.Error("CheckOrderPromoCode accepted a code already used up")
+	}
+	// The cap is enforced again when the payment is confirmed.
+	_, err := f.fulfill(t, secondOrder)
+	var unfulfillable *UnfulfillableError
+	if !errors.As(err, &unfulfillable) {
+		t.Errorf("second confirmation: got %v, want UnfulfillableError", err)
+	}
+}
+
+func TestPromoCodeMaxUsesPerUser(t *testing.T) {
+	f := newFixture(t)
+	f.promoCode(t, repository.PromoCodeRow{Code: "UMPORCONTA", DiscountType: repository.PromoFixed, DiscountValue: 10, MaxUsesPerUser: sql.NullInt64{Int64: 1, Valid: true}})
+	buyer := f.user(t)
+	orderID := f.order(t, buyer, 1)
+	if err := ApplyPromoCode(f.DB, orderID, buyer.ID, "UMPORCONTA"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.fulfill(t, orderID); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyPromoCode(f.DB, f.order(t, buyer, 1), buyer.ID, "UMPORCONTA"); err == nil {
+		t.Error("second use by the same buyer accepted")
+	}
+	other := f.user(t)
+	if err := ApplyPromoCode(f.DB, f.order(t, other, 1), other.ID, "UMPORCONTA"); err != nil {
+		t.Errorf("another buyer: %v", err)
+	}
+}
@@ -1,15 +1,23 @@
 package config
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
 	Port                 int
 	DBPath               string
 	JWTSecret            string
+	QRLegacySecret       string        // verifies V1/V2 QR payloads (before the QR keyring); keep it when rotating JWT_SECRET
+	QRKeysSecret         string        // encrypts the QR signing keys stored in the database; required outside dev, never change it without re-sealing the keys (qrkeys reseal)
+	AccessTokenTTL       time.Duration // lifetime of JWT access tokens
+	RefreshTokenTTL      time.Duration // sliding lifetime of refresh tokens / sessions
 	Playground           bool
 	CORSOrigins          []string
 	PagarmeAPIKey        string
@@ -17,6 +25,41 @@ type Config struct {
 	PagarmeRecipientID   string // Platform's own recipient ID for split
 	PagarmeAppFee        int64  // centavos per ticket (default 500 = R$5.00)
 	BaseURL              string // frontend URL for redirects
+	APIBaseURL           string // public URL of this API, used in signed download links
+	TicketLinkTTL        time.Duration
+	TicketLinkSecret     string // signs the ticket file links (PDF, Apple Wallet)
+	MailDriver           string // transactional email backend: "log" (default), "file" or "smtp"
+	MailFrom             string // sender address, e.g. "Afterzin <no-reply@afterzin.com.br>"
+	MailDir              string // output directory of the "file" driver (.eml files)
+	SMTPHost             string
+	SMTPPort             int
+	SMTPUsername         string // empty disables SMTP AUTH (local catchers)
+	SMTPPassword         string
+	// Apple Wallet: Pass Type ID certificate/key (PEM) and Apple WWDR intermediate certificate.
+	WalletApplePassTypeID string
+	WalletAppleTeamID     string
+	WalletAppleCertFile   string
+	WalletAppleKeyFile    string
+	WalletAppleWWDRFile   string
+	// Google Wallet: issuer ID and service account JSON key file.
+	WalletGoogleIssuerID           string
+	WalletGoogleServiceAccountFile string
+	// RequireVerifiedEmail blocks checkout until the buyer confirms their email address.
+	RequireVerifiedEmail bool
+	// EventModerationFirstEvents sends the first N events of each producer to the admin
+	// moderation queue instead of publishing them directly (0 disables moderation).
+	EventModerationFirstEvents int
+}
+
+// devSecret is the default of the secrets in development, when JWT_SECRET is not set.
+const devSecret = "dev-secret-change-in-production"
+
+// deriveSecret derives a secret for one purpose from a shared one (HMAC-SHA256 with the purpose
+// as label), so a signature made for that purpose is never valid for another, e.g. a JWT.
+func deriveSecret(secret, purpose string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("afterzin/" + purpose))
+	return hex.EncodeToString(mac.Sum(nil))
 }
 
 func Load() *Config {
@@ -31,8 +74,28 @@ func Load() *Config {
 		dbPath = "./data/afterzin.db"
 	}
 	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "dev-secret-change-in-production"
+	dev := jwtSecret == ""
+	if dev {
+		jwtSecret = devSecret
+	}
+	qrLegacySecret := os.Getenv("QR_LEGACY_SECRET")
+	if qrLegacySecret == "" {
+		qrLegacySecret = jwtSecret
+	}
+	// QR_KEYS_SECRET is independent of JWT_SECRET, so rotating the JWT secret never locks the
+	// keyring. Outside dev an empty value stops the API at startup (qrkeys.New). Changing it
+	// requires re-sealing the stored keys with the previous value first (qrkeys reseal).
+	qrKeysSecret := os.Getenv("QR_KEYS_SECRET")
+	if qrKeysSecret == "" && dev {
+		qrKeysSecret = devSecret
+	}
+	accessTokenTTL := 15 * time.Minute
+	if v, err := strconv.Atoi(os.Getenv("ACCESS_TOKEN_TTL_MINUTES")); err == nil && v > 0 {
+		accessTokenTTL = time.Duration(v) * time.Minute
+	}
+	refreshTokenTTL := 30 * 24 * time.Hour
+	if v, err := strconv.Atoi(os.Getenv("REFRESH_TOKEN_TTL_DAYS")); err == nil && v > 0 {
+		refreshTokenTTL = time.Duration(v) * 24 * time.Hour
 	}
 	playground := os.Getenv("PLAYGROUND") == "true" || os.Getenv("PLAYGROUND") == "1"
 	corsOrigins := []string{
@@ -70,11 +133,54 @@ func Load() *Config {
 	if baseURL == "" {
 		baseURL = "http://localhost:4040"
 	}
+	apiBaseURL := strings.TrimRight(os.Getenv("API_BASE_URL"), "/")
+	if apiBaseURL == "" {
+		apiBaseURL = "http://localhost:" + strconv.Itoa(port)
+	}
+	ticketLinkSecret := os.Getenv("TICKET_LINK_SECRET")
+	if ticketLinkSecret == "" {
+		ticketLinkSecret = deriveSecret(jwtSecret, "ticket-file-links")
+	}
+	ticketLinkTTL := time.Hour
+	if v, err := strconv.Atoi(os.Getenv("TICKET_LINK_TTL_MINUTES")); err == nil && v > 0 {
+		ticketLinkTTL = time.Duration(v) * time.Minute
+	}
+	mailDriver := os.Getenv("MAIL_DRIVER")
+	if mailDriver == "" {
+		mailDriver = "log"
+	}
+	mailFrom := os.Getenv("MAIL_FROM")
+	if mailFrom == "" {
+		mailFrom = "Afterzin <no-reply@afterzin.com.br>"
+	}
+	mailDir := os.Getenv("MAIL_DIR")
+	if mailDir == "" {
+		mailDir = "./data/mail"
+	}
+	smtpHost := os.Getenv("SMTP_HOST")
+	if smtpHost == "" {
+		smtpHost = "localhost"
+	}
+	smtpPort := 587
+	if v, err := strconv.Atoi(os.Getenv("SMTP_PORT")); err == nil && v > 0 {
+		smtpPort = v
+	}
+	requireVerifiedEmail := os.Getenv("REQUIRE_VERIFIED_EMAIL") == "true" || os.Getenv("REQUIRE_VERIFIED_EMAIL") == "1"
+	moderationFirstEvents := 0
+	if m := os.Getenv("EVENT_MODERATION_FIRST_EVENTS"); m != "" {
+		if v, err := strconv.Atoi(m); err == nil && v > 0 {
+			moderationFirstEvents = v
+		}
+	}
 
 	return &Config{
 		Port:                 port,
 		DBPath:               dbPath,
 		JWTSecret:            jwtSecret,
+		QRLegacySecret:       qrLegacySecret,
+		QRKeysSecret:         qrKeysSecret,
+		AccessTokenTTL:       accessTokenTTL,
+		RefreshTokenTTL:      refreshTokenTTL,
 		Playground:           playground,
 		CORSOrigins:          corsOrigins,
 		PagarmeAPIKey:        stripeSecretKey,
@@ -82,5 +188,26 @@ func Load() *Config {
 		PagarmeRecipientID:   pagarmeRecipientID,
 		PagarmeAppFee:        stripeAppFee,
 		BaseURL:              baseURL,
+		APIBaseURL:           apiBaseURL,
+		TicketLinkTTL:        ticketLinkTTL,
+		TicketLinkSecret:     ticketLinkSecret,
+		MailDriver:           mailDriver,
+		MailFrom:             mailFrom,
+		MailDir:              mailDir,
+		SMTPHost:             smtpHost,
+		SMTPPort:             smtpPort,
+		SMTPUsername:         os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:         os.Getenv("SMTP_PASSWORD"),
+		RequireVerifiedEmail: requireVerifiedEmail,
+
+		WalletApplePassTypeID:          os.Getenv("WALLET_APPLE_PASS_TYPE_ID"),
+		WalletAppleTeamID:              os.Getenv("WALLET_APPLE_TEAM_ID"),
+		WalletAppleCertFile:            os.Getenv("WALLET_APPLE_CERT"),
+		WalletAppleKeyFile:             os.Getenv("WALLET_APPLE_KEY"),
+		WalletAppleWWDRFile:            os.Getenv("WALLET_APPLE_WWDR"),
+		WalletGoogleIssuerID:           os.Getenv("WALLET_GOOGLE_ISSUER_ID"),
+		WalletGoogleServiceAccountFile: os.Getenv("WALLET_GOOGLE_SERVICE_ACCOUNT"),
+
+		EventModerationFirstEvents: moderationFirstEvents,
 	}
 }
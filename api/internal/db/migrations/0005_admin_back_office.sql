@@ -0,0 +1,27 @@
+-- Back office administrativo
+-- Bloqueio de contas, remoção de eventos e trilha de auditoria das ações de ADMIN
+
+-- users: bloqueio de conta
+ALTER TABLE users ADD COLUMN blocked_at TEXT;
+ALTER TABLE users ADD COLUMN blocked_reason TEXT;
+
+-- events: motivo da remoção (status REMOVED)
+ALTER TABLE events ADD COLUMN removed_reason TEXT;
+
+-- producers: motivo da reprovação
+ALTER TABLE producers ADD COLUMN rejection_reason TEXT;
+
+-- auditoria das ações administrativas
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+  id TEXT PRIMARY KEY,
+  admin_user_id TEXT NOT NULL REFERENCES users(id),
+  action TEXT NOT NULL,
+  target_type TEXT NOT NULL,
+  target_id TEXT,
+  details TEXT,
+  created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_audit_admin ON admin_audit_log(admin_user_id);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_target ON admin_audit_log(target_type, target_id);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_created ON admin_audit_log(created_at);
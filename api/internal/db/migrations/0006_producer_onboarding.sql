@@ -0,0 +1,31 @@
+-- Onboarding de produtores
+-- Solicitação com dados da empresa, estados de análise e fila de moderação de eventos
+
+-- producers: estado da solicitação (PENDING_REVIEW, APPROVED, REJECTED) e dados cadastrais
+ALTER TABLE producers ADD COLUMN status TEXT NOT NULL DEFAULT 'PENDING_REVIEW';
+ALTER TABLE producers ADD COLUMN cnpj TEXT;
+ALTER TABLE producers ADD COLUMN contact_name TEXT;
+ALTER TABLE producers ADD COLUMN contact_email TEXT;
+ALTER TABLE producers ADD COLUMN contact_phone TEXT;
+ALTER TABLE producers ADD COLUMN submitted_at TEXT;
+ALTER TABLE producers ADD COLUMN reviewed_at TEXT;
+ALTER TABLE producers ADD COLUMN reviewed_by TEXT REFERENCES users(id);
+
+-- produtores já aprovados antes do fluxo de análise continuam aprovados
+UPDATE producers SET status = 'APPROVED' WHERE approved = 1;
+UPDATE producers SET status = 'REJECTED' WHERE approved = 0 AND rejection_reason IS NOT NULL;
+
+-- documentos enviados na solicitação (contrato social, documento do responsável etc.)
+CREATE TABLE IF NOT EXISTS producer_documents (
+  id TEXT PRIMARY KEY,
+  producer_id TEXT NOT NULL REFERENCES producers(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL,
+  url TEXT NOT NULL,
+  created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+-- events: observação da moderação (motivo da reprovação na fila IN_REVIEW)
+ALTER TABLE events ADD COLUMN moderation_note TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_producers_status ON producers(status);
+CREATE INDEX IF NOT EXISTS idx_producer_documents_producer ON producer_documents(producer_id);
@@ -0,0 +1,19 @@
+-- Sessões de autenticação
+-- Refresh tokens rotativos (armazenados apenas como hash SHA-256) e revogação de sessões
+
+CREATE TABLE IF NOT EXISTS sessions (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  refresh_token_hash TEXT NOT NULL UNIQUE,
+  -- hash anterior, mantido para detectar reutilização de refresh token já rotacionado
+  previous_refresh_token_hash TEXT,
+  user_agent TEXT,
+  ip_address TEXT,
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  last_used_at TEXT NOT NULL DEFAULT (datetime('now')),
+  expires_at TEXT NOT NULL,
+  revoked_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_previous_hash ON sessions(previous_refresh_token_hash);
@@ -0,0 +1,18 @@
+-- Recuperação de senha e verificação de e-mail
+
+-- users: confirmação do e-mail
+ALTER TABLE users ADD COLUMN email_verified_at TEXT;
+
+-- tokens de uso único (armazenados apenas como hash SHA-256)
+-- purpose: PASSWORD_RESET | EMAIL_VERIFICATION
+CREATE TABLE IF NOT EXISTS user_tokens (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  purpose TEXT NOT NULL,
+  token_hash TEXT NOT NULL UNIQUE,
+  expires_at TEXT NOT NULL,
+  used_at TEXT,
+  created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user ON user_tokens(user_id, purpose);
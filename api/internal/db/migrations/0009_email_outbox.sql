@@ -0,0 +1,25 @@
+-- Outbox de e-mails transacionais
+-- Mensagens já renderizadas são gravadas aqui e enviadas por um worker com novas tentativas,
+-- para que o envio nunca bloqueie o webhook de pagamento.
+
+-- status: PENDING | SENT | FAILED (esgotou as tentativas)
+CREATE TABLE IF NOT EXISTS email_outbox (
+  id TEXT PRIMARY KEY,
+  kind TEXT NOT NULL,
+  -- chave de deduplicação (ex.: order_confirmation:<order_id>); evita enviar o mesmo e-mail duas vezes
+  dedupe_key TEXT UNIQUE,
+  to_address TEXT NOT NULL,
+  subject TEXT NOT NULL,
+  text_body TEXT NOT NULL,
+  html_body TEXT,
+  -- anexos em JSON (conteúdo em base64)
+  attachments TEXT,
+  status TEXT NOT NULL DEFAULT 'PENDING',
+  attempts INTEGER NOT NULL DEFAULT 0,
+  last_error TEXT,
+  next_attempt_at TEXT NOT NULL DEFAULT (datetime('now')),
+  sent_at TEXT,
+  created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_outbox_due ON email_outbox(status, next_attempt_at);
@@ -0,0 +1,23 @@
+-- Passes de carteira digital (Apple Wallet / Google Wallet)
+
+-- um registro por ingresso que já gerou um .pkpass; o token autentica o web service da Apple
+-- e updated_at indica aos dispositivos que o passe mudou (data do evento, uso, reembolso)
+CREATE TABLE IF NOT EXISTS wallet_passes (
+  ticket_id TEXT PRIMARY KEY REFERENCES tickets(id) ON DELETE CASCADE,
+  authentication_token TEXT NOT NULL,
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+-- dispositivos registrados pelo Apple Wallet para receber push de atualização
+CREATE TABLE IF NOT EXISTS wallet_device_registrations (
+  id TEXT PRIMARY KEY,
+  device_library_id TEXT NOT NULL,
+  push_token TEXT NOT NULL,
+  pass_type_id TEXT NOT NULL,
+  ticket_id TEXT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  UNIQUE (device_library_id, pass_type_id, ticket_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_registrations_ticket ON wallet_device_registrations(ticket_id);
@@ -0,0 +1,29 @@
+-- Transferência de ingressos entre usuários
+
+-- events: política de transferência definida pelo produtor
+-- transfer_cutoff_hours: transferências bloqueadas nas N horas antes do início da data (0 = até o início)
+ALTER TABLE events ADD COLUMN transfers_enabled INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE events ADD COLUMN transfer_cutoff_hours INTEGER NOT NULL DEFAULT 0;
+
+-- tickets: preenchido quando o QR Code é reemitido numa transferência; a partir daí só o
+-- qr_code atual é aceito na validação (prints do dono anterior deixam de valer)
+ALTER TABLE tickets ADD COLUMN qr_reissued_at TEXT;
+
+-- histórico de titularidade: cada oferta de transferência e sua resposta
+-- status: PENDING, ACCEPTED, DECLINED, CANCELLED
+CREATE TABLE IF NOT EXISTS ticket_transfers (
+  id TEXT PRIMARY KEY,
+  ticket_id TEXT NOT NULL REFERENCES tickets(id),
+  from_user_id TEXT NOT NULL REFERENCES users(id),
+  to_user_id TEXT REFERENCES users(id),
+  recipient_email TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'PENDING',
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  responded_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_transfers_ticket ON ticket_transfers(ticket_id);
+CREATE INDEX IF NOT EXISTS idx_ticket_transfers_from ON ticket_transfers(from_user_id);
+CREATE INDEX IF NOT EXISTS idx_ticket_transfers_email ON ticket_transfers(recipient_email);
+-- no máximo uma oferta pendente por ingresso
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ticket_transfers_pending ON ticket_transfers(ticket_id) WHERE status = 'PENDING';
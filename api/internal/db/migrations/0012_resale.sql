@@ -0,0 +1,37 @@
+-- Revenda oficial de ingressos (mercado secundário)
+
+-- events: regras de revenda definidas pelo produtor
+-- resale_max_markup_percent: teto do preço de revenda acima do valor de face (10 = até +10%)
+-- resale_royalty_percent: percentual do preço de revenda repassado ao produtor
+ALTER TABLE events ADD COLUMN resale_enabled INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE events ADD COLUMN resale_max_markup_percent REAL NOT NULL DEFAULT 10;
+ALTER TABLE events ADD COLUMN resale_royalty_percent REAL NOT NULL DEFAULT 0;
+
+-- users: recebedor Pagar.me do vendedor (repasse da revenda via split)
+ALTER TABLE users ADD COLUMN pagarme_recipient_id TEXT;
+
+-- anúncios de revenda; valores do split calculados na criação do anúncio
+-- status: ACTIVE, SOLD, CANCELLED
+-- order_id: pedido do comprador que reservou (enquanto ACTIVE e reserved_until no futuro) ou comprou (SOLD)
+CREATE TABLE IF NOT EXISTS resale_listings (
+  id TEXT PRIMARY KEY,
+  ticket_id TEXT NOT NULL REFERENCES tickets(id),
+  seller_id TEXT NOT NULL REFERENCES users(id),
+  event_id TEXT NOT NULL REFERENCES events(id),
+  price REAL NOT NULL,
+  face_value REAL NOT NULL,
+  seller_amount REAL NOT NULL,
+  royalty_amount REAL NOT NULL DEFAULT 0,
+  platform_fee REAL NOT NULL DEFAULT 0,
+  status TEXT NOT NULL DEFAULT 'ACTIVE',
+  order_id TEXT REFERENCES orders(id),
+  reserved_until TEXT,
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  sold_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_resale_listings_event ON resale_listings(event_id, status);
+CREATE INDEX IF NOT EXISTS idx_resale_listings_seller ON resale_listings(seller_id);
+CREATE INDEX IF NOT EXISTS idx_resale_listings_order ON resale_listings(order_id);
+-- no máximo um anúncio ativo por ingresso
+CREATE UNIQUE INDEX IF NOT EXISTS idx_resale_listings_active ON resale_listings(ticket_id) WHERE status = 'ACTIVE';
@@ -0,0 +1,25 @@
+-- Ingressos nominais: titular (nome, CPF e nascimento) conferido com documento na portaria
+
+-- ticket_types: nominal = 1 exige titular identificado em cada ingresso
+ALTER TABLE ticket_types ADD COLUMN nominal INTEGER NOT NULL DEFAULT 0;
+
+-- tickets: titular do ingresso (holder_birthdate em YYYY-MM-DD, holder_cpf só dígitos)
+-- limpo quando o ingresso muda de dono (transferência ou revenda); o novo dono informa outro
+ALTER TABLE tickets ADD COLUMN holder_name TEXT;
+ALTER TABLE tickets ADD COLUMN holder_cpf TEXT;
+ALTER TABLE tickets ADD COLUMN holder_birthdate TEXT;
+ALTER TABLE tickets ADD COLUMN holder_assigned_at TEXT;
+
+-- events: titulares podem ser informados ou trocados até N horas antes do início da data (0 = até o início)
+ALTER TABLE events ADD COLUMN holder_cutoff_hours INTEGER NOT NULL DEFAULT 0;
+
+-- titulares informados no checkout, copiados para os ingressos na emissão
+-- position: ordem do ingresso dentro do item do pedido (0 .. quantity-1)
+CREATE TABLE IF NOT EXISTS order_item_holders (
+  order_item_id TEXT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+  position INTEGER NOT NULL,
+  name TEXT NOT NULL,
+  cpf TEXT NOT NULL,
+  birthdate TEXT NOT NULL,
+  PRIMARY KEY (order_item_id, position)
+);
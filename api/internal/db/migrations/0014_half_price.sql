@@ -0,0 +1,16 @@
+-- Meia-entrada (Lei 12.933/2013): benefício por categoria e cota de 40% dos ingressos de cada data
+
+-- ticket_types: categoria do benefício (STUDENT, SENIOR, PCD, YOUTH_LOW_INCOME); NULL = inteira
+ALTER TABLE ticket_types ADD COLUMN half_price_entitlement TEXT;
+
+-- tickets: número do documento comprobatório informado na compra (carteira estudantil, ID Jovem etc.)
+ALTER TABLE tickets ADD COLUMN half_price_document TEXT;
+
+-- documentos informados no checkout, copiados para os ingressos na emissão
+-- position: ordem do ingresso dentro do item do pedido (0 .. quantity-1)
+CREATE TABLE IF NOT EXISTS order_item_half_price_documents (
+  order_item_id TEXT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+  position INTEGER NOT NULL,
+  document TEXT NOT NULL,
+  PRIMARY KEY (order_item_id, position)
+);
@@ -0,0 +1,12 @@
+-- Restrições de público: idade mínima do evento e público do tipo de ingresso (MALE, FEMALE, CHILD)
+-- verificados no checkout contra o perfil do comprador ou o titular do ingresso nominal
+
+-- events: idade mínima para entrar (0 = livre), calculada na data do evento
+ALTER TABLE events ADD COLUMN min_age INTEGER NOT NULL DEFAULT 0;
+
+-- users: gênero informado no perfil (MALE, FEMALE; NULL = não informado), exigido só para ingressos por gênero
+ALTER TABLE users ADD COLUMN gender TEXT;
+
+-- titulares de ingressos nominais: gênero (opcional)
+ALTER TABLE tickets ADD COLUMN holder_gender TEXT;
+ALTER TABLE order_item_holders ADD COLUMN gender TEXT;
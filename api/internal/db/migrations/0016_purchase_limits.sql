@@ -0,0 +1,13 @@
+-- Limites de compra definidos pelo produtor (NULL = sem limite), por evento e por tipo de ingresso
+-- max_per_order: ingressos num mesmo pedido
+-- max_per_account: ingressos pagos de uma mesma conta (somando pedidos)
+-- max_per_cpf: ingressos por CPF (titular do ingresso nominal ou, sem titular, CPF do dono)
+ALTER TABLE events ADD COLUMN max_per_order INTEGER;
+ALTER TABLE events ADD COLUMN max_per_account INTEGER;
+ALTER TABLE events ADD COLUMN max_per_cpf INTEGER;
+
+ALTER TABLE ticket_types ADD COLUMN max_per_order INTEGER;
+ALTER TABLE ticket_types ADD COLUMN max_per_account INTEGER;
+ALTER TABLE ticket_types ADD COLUMN max_per_cpf INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_tickets_holder_cpf ON tickets(holder_cpf);
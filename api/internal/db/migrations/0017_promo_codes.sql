@@ -0,0 +1,42 @@
+-- Cupons de desconto gerenciados pelo produtor
+
+-- discount_type: PERCENT (value = percentual) ou FIXED (value = reais de desconto por ingresso)
+-- ticket_type_id: NULL vale para todos os tipos de ingresso do evento
+-- max_uses / max_uses_per_user: limites de pedidos pagos com o cupom (NULL = sem limite)
+-- valid_from / valid_until: janela de validade em UTC (NULL = sem limite)
+CREATE TABLE IF NOT EXISTS promo_codes (
+  id TEXT PRIMARY KEY,
+  event_id TEXT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+  ticket_type_id TEXT REFERENCES ticket_types(id) ON DELETE CASCADE,
+  code TEXT NOT NULL,
+  discount_type TEXT NOT NULL,
+  discount_value REAL NOT NULL,
+  max_uses INTEGER,
+  max_uses_per_user INTEGER,
+  valid_from TEXT,
+  valid_until TEXT,
+  active INTEGER NOT NULL DEFAULT 1,
+  created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+-- código único por evento (gravado em maiúsculas)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_promo_codes_event_code ON promo_codes(event_id, code);
+
+-- orders: cupom aplicado ao pedido
+ALTER TABLE orders ADD COLUMN promo_code_id TEXT REFERENCES promo_codes(id);
+
+-- order_items: desconto por ingresso já abatido de unit_price (preço original = unit_price + discount)
+ALTER TABLE order_items ADD COLUMN discount REAL NOT NULL DEFAULT 0;
+
+-- uso do cupom por pedido, para limites e relatórios (conta só quando o pedido é pago)
+CREATE TABLE IF NOT EXISTS promo_code_redemptions (
+  id TEXT PRIMARY KEY,
+  promo_code_id TEXT NOT NULL REFERENCES promo_codes(id) ON DELETE CASCADE,
+  order_id TEXT NOT NULL UNIQUE REFERENCES orders(id),
+  user_id TEXT NOT NULL REFERENCES users(id),
+  discount_total REAL NOT NULL,
+  created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_promo_code_redemptions_code ON promo_code_redemptions(promo_code_id);
+CREATE INDEX IF NOT EXISTS idx_promo_code_redemptions_user ON promo_code_redemptions(user_id);
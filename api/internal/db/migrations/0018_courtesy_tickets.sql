@@ -0,0 +1,24 @@
+-- Cortesias e lista de convidados emitidas pelo produtor
+
+-- events: quantidade máxima de cortesias do evento (0 = produtor não emite cortesias)
+ALTER TABLE events ADD COLUMN courtesy_quota INTEGER NOT NULL DEFAULT 0;
+
+-- orders: SALE (venda) ou COURTESY (cortesia, valor zero; não conta como receita nem nos limites de compra)
+ALTER TABLE orders ADD COLUMN kind TEXT NOT NULL DEFAULT 'SALE';
+
+-- ingressos emitidos como cortesia, com o convidado
+-- issued_by: usuário do produtor que emitiu (dono do ingresso quando o convidado não tem conta)
+-- guest_list: 1 = entrada pela busca do nome na lista de convidados em vez do QR Code
+CREATE TABLE IF NOT EXISTS courtesy_tickets (
+  ticket_id TEXT PRIMARY KEY REFERENCES tickets(id) ON DELETE CASCADE,
+  event_id TEXT NOT NULL REFERENCES events(id),
+  issued_by TEXT NOT NULL REFERENCES users(id),
+  recipient_name TEXT NOT NULL,
+  recipient_email TEXT,
+  recipient_cpf TEXT,
+  category TEXT,
+  guest_list INTEGER NOT NULL DEFAULT 0,
+  created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_courtesy_tickets_event ON courtesy_tickets(event_id, guest_list);
@@ -0,0 +1,20 @@
+-- Equipe do evento: usuários convidados pelo produtor com papel por evento
+
+-- role: SCANNER (portaria), MANAGER (portaria, cortesias e cupons) ou FINANCE (cupons e relatórios, sem portaria)
+-- revoked_at: preenchido quando o produtor remove o membro; o acesso também termina no dia seguinte à última data do evento
+CREATE TABLE IF NOT EXISTS event_staff (
+  id TEXT PRIMARY KEY,
+  event_id TEXT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+  user_id TEXT NOT NULL REFERENCES users(id),
+  role TEXT NOT NULL,
+  invited_by TEXT NOT NULL REFERENCES users(id),
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  revoked_at TEXT
+);
+
+-- no máximo um vínculo ativo por usuário e evento
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_staff_active ON event_staff(event_id, user_id) WHERE revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_event_staff_user ON event_staff(user_id);
+
+-- ticket_validations: usuário que validou (produtor ou membro da equipe)
+ALTER TABLE ticket_validations ADD COLUMN validated_by TEXT REFERENCES users(id);
@@ -0,0 +1,36 @@
+-- Check-in offline: aparelhos de portaria registrados e leituras sincronizadas depois
+
+-- device_key: chave do aparelho (hex), usada para cifrar e assinar a manifest; entregue uma única vez no registro
+-- user_id: membro da equipe (ou produtor) que registrou o aparelho; só ele baixa a manifest e sincroniza
+CREATE TABLE IF NOT EXISTS scanner_devices (
+  id TEXT PRIMARY KEY,
+  event_id TEXT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+  user_id TEXT NOT NULL REFERENCES users(id),
+  name TEXT NOT NULL,
+  device_key TEXT NOT NULL,
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  last_sync_at TEXT,
+  revoked_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_scanner_devices_event ON scanner_devices(event_id);
+
+-- leituras feitas sem conexão; scan_id é gerado pelo aparelho e torna o reenvio idempotente
+-- status: ACCEPTED (entrada válida), DUPLICATE (ingresso já aceito em outra leitura) ou REJECTED (error_code)
+CREATE TABLE IF NOT EXISTS offline_checkins (
+  id TEXT PRIMARY KEY,
+  device_id TEXT NOT NULL REFERENCES scanner_devices(id),
+  scan_id TEXT NOT NULL,
+  ticket_id TEXT NOT NULL,
+  scanned_at TEXT NOT NULL,
+  gate TEXT,
+  status TEXT NOT NULL,
+  error_code TEXT,
+  synced_at TEXT NOT NULL DEFAULT (datetime('now')),
+  UNIQUE (device_id, scan_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_offline_checkins_ticket ON offline_checkins(ticket_id);
+
+-- ticket_validations: leitura offline que validou o ingresso (NULL = validação online)
+ALTER TABLE ticket_validations ADD COLUMN offline_checkin_id TEXT REFERENCES offline_checkins(id);
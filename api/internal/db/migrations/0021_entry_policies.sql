@@ -0,0 +1,19 @@
+-- Reentrada e ingressos com várias entradas
+
+-- ticket_types: política de entrada
+-- SINGLE (uma entrada), REENTRY (entra e sai à vontade, saída registrada na portaria),
+-- DAILY (uma entrada por dia, passaporte de vários dias) ou MULTI (max_entries entradas)
+ALTER TABLE ticket_types ADD COLUMN entry_policy TEXT NOT NULL DEFAULT 'SINGLE';
+ALTER TABLE ticket_types ADD COLUMN max_entries INTEGER;
+
+-- tickets: situação na portaria (IN = dentro, OUT = fora), entradas feitas e dia da última entrada (horário de São Paulo)
+ALTER TABLE tickets ADD COLUMN checkin_state TEXT NOT NULL DEFAULT 'OUT';
+ALTER TABLE tickets ADD COLUMN entry_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE tickets ADD COLUMN last_entry_date TEXT;
+UPDATE tickets SET checkin_state = 'IN', entry_count = 1, last_entry_date = date(used_at, '-3 hours') WHERE used = 1;
+
+-- ticket_validations: sentido da leitura (IN = entrada, OUT = saída) e portão
+ALTER TABLE ticket_validations ADD COLUMN direction TEXT NOT NULL DEFAULT 'IN';
+ALTER TABLE ticket_validations ADD COLUMN gate TEXT;
+UPDATE ticket_validations SET gate = (SELECT gate FROM offline_checkins WHERE id = ticket_validations.offline_checkin_id)
+  WHERE offline_checkin_id IS NOT NULL;
@@ -0,0 +1,8 @@
+-- Correções na portaria: entrada manual e leitura desfeita
+
+-- manual_reason: preenchido na entrada manual (manualCheckin, sem leitura do QR Code), com o motivo informado pelo gerente
+-- reverted_*: leitura desfeita por um gerente (revertTicketValidation); a linha é mantida para auditoria
+ALTER TABLE ticket_validations ADD COLUMN manual_reason TEXT;
+ALTER TABLE ticket_validations ADD COLUMN reverted_at TEXT;
+ALTER TABLE ticket_validations ADD COLUMN reverted_by TEXT REFERENCES users(id);
+ALTER TABLE ticket_validations ADD COLUMN revert_reason TEXT;
@@ -0,0 +1,6 @@
+-- QR Code dinâmico (rotativo) contra prints repassados
+
+-- dynamic_qr: o app mostra um QR Code que muda a cada 30 segundos, assinado com um segredo do ingresso
+-- static_qr_fallback: com o QR dinâmico ligado, se o QR Code fixo (PDF impresso, e-mail, carteiras) ainda é aceito
+ALTER TABLE events ADD COLUMN dynamic_qr INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE events ADD COLUMN static_qr_fallback INTEGER NOT NULL DEFAULT 1;
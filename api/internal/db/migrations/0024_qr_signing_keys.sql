@@ -0,0 +1,13 @@
+-- Chaveiro de assinatura dos QR Codes (payload V3, Ed25519), separado do segredo do JWT
+
+-- id: identificador da chave, incluído no payload V3
+-- public_key / private_key: chave Ed25519 em hex (a privada é a semente de 32 bytes)
+-- a chave ativa mais recente assina os novos QR Codes; todas as ativas são aceitas na validação
+-- retired_at: chave aposentada (cmd/qrkeys retire), deixa de ser aceita
+CREATE TABLE IF NOT EXISTS qr_signing_keys (
+  id TEXT PRIMARY KEY,
+  public_key TEXT NOT NULL,
+  private_key TEXT NOT NULL,
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  retired_at TEXT
+);
@@ -0,0 +1,35 @@
+-- Setores (áreas) do evento e portões de cada data
+
+-- event_areas: setores do evento (pista, camarote, VIP...)
+-- capacity: lotação máxima de segurança (NULL = sem limite), acompanhada pela ocupação ao vivo na portaria
+CREATE TABLE IF NOT EXISTS event_areas (
+  id TEXT PRIMARY KEY,
+  event_id TEXT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  capacity INTEGER,
+  created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_areas_name ON event_areas(event_id, name COLLATE NOCASE);
+
+-- ticket_types: setor a que o ingresso dá acesso (NULL = sem setor)
+ALTER TABLE ticket_types ADD COLUMN area_id TEXT REFERENCES event_areas(id);
+
+-- event_gates: portões de uma data; um portão sem setores em event_gate_areas aceita qualquer ingresso
+CREATE TABLE IF NOT EXISTS event_gates (
+  id TEXT PRIMARY KEY,
+  event_date_id TEXT NOT NULL REFERENCES event_dates(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_gates_name ON event_gates(event_date_id, name COLLATE NOCASE);
+
+CREATE TABLE IF NOT EXISTS event_gate_areas (
+  gate_id TEXT NOT NULL REFERENCES event_gates(id) ON DELETE CASCADE,
+  area_id TEXT NOT NULL REFERENCES event_areas(id) ON DELETE CASCADE,
+  PRIMARY KEY (gate_id, area_id)
+);
+
+-- ticket_validations: portão cadastrado da leitura (gate guarda o nome, também para portões livres)
+ALTER TABLE ticket_validations ADD COLUMN gate_id TEXT;
@@ -0,0 +1,46 @@
+-- Painel de vendas do produtor: rollup diário de pedidos e ingressos vendidos
+
+-- orders: quando o pedido foi pago e estornado (dia da venda no painel)
+ALTER TABLE orders ADD COLUMN paid_at TEXT;
+ALTER TABLE orders ADD COLUMN refunded_at TEXT;
+
+-- pedidos pagos antes desta migration: horário de emissão dos ingressos (ou da criação do pedido)
+UPDATE orders SET paid_at = COALESCE((SELECT MIN(created_at) FROM tickets WHERE order_id = orders.id), created_at)
+  WHERE status IN ('PAID', 'REFUNDED');
+
+CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders(created_at);
+CREATE INDEX IF NOT EXISTS idx_orders_paid_at ON orders(paid_at);
+CREATE INDEX IF NOT EXISTS idx_orders_refunded_at ON orders(refunded_at);
+CREATE INDEX IF NOT EXISTS idx_order_items_order ON order_items(order_id);
+
+-- sales_daily: ingressos vendidos e receita por dia (horário de Brasília) e tipo de ingresso
+-- conta pedidos de venda pagos no dia do pagamento, sem cortesias nem revendas; estornos saem da conta
+CREATE TABLE IF NOT EXISTS sales_daily (
+  event_id TEXT NOT NULL,
+  day TEXT NOT NULL,
+  ticket_type_id TEXT NOT NULL,
+  event_date_id TEXT NOT NULL,
+  lot_id TEXT NOT NULL,
+  tickets INTEGER NOT NULL,
+  revenue REAL NOT NULL,
+  PRIMARY KEY (event_id, day, ticket_type_id)
+);
+
+-- sales_daily_orders: pedidos de venda por dia e evento
+-- previews: pedidos criados no checkout no dia; converted: quantos deles foram pagos; paid_orders: pagos no dia
+CREATE TABLE IF NOT EXISTS sales_daily_orders (
+  event_id TEXT NOT NULL,
+  day TEXT NOT NULL,
+  previews INTEGER NOT NULL,
+  converted INTEGER NOT NULL,
+  paid_orders INTEGER NOT NULL,
+  PRIMARY KEY (event_id, day)
+);
+
+-- sales_rollup_state: até quando os pedidos já entraram no rollup (NULL = reconstruir tudo)
+CREATE TABLE IF NOT EXISTS sales_rollup_state (
+  id INTEGER PRIMARY KEY CHECK (id = 1),
+  refreshed_at TEXT
+);
+
+INSERT OR IGNORE INTO sales_rollup_state (id, refreshed_at) VALUES (1, NULL);
@@ -0,0 +1,4 @@
+-- E-mails de redefinição de senha e verificação de e-mail levam o token de uso único em texto puro
+-- no link; a tabela user_tokens guarda só o hash. Os já enviados (ou abandonados) perdem o corpo.
+UPDATE email_outbox SET text_body = '[conteúdo removido após o envio: link de uso único]', html_body = NULL
+  WHERE kind IN ('password_reset', 'email_verification') AND status != 'PENDING';
@@ -0,0 +1,3 @@
+-- offline_checkins: portão cadastrado da leitura offline, conferido na sincronização como em validateTicket
+-- (evento com setores exige portão; o portão precisa atender o setor do ingresso)
+ALTER TABLE offline_checkins ADD COLUMN gate_id TEXT;
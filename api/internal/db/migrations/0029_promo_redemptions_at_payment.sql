@@ -0,0 +1,3 @@
+-- promo_code_redemptions: o uso do cupom passa a ser gravado na confirmação do pagamento (o insert
+-- confere max_uses e max_uses_per_user); usos de pedidos ainda não pagos saem da tabela
+DELETE FROM promo_code_redemptions WHERE order_id IN (SELECT id FROM orders WHERE status NOT IN ('PAID', 'REFUNDED'));
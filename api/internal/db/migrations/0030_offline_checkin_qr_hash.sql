@@ -0,0 +1,3 @@
+-- offline_checkins: SHA-256 (hex) do QR Code lido, conferido na sincronização (a leitura de um QR Code que não
+-- pertence ao ingresso é recusada com INVALID_QR)
+ALTER TABLE offline_checkins ADD COLUMN qr_hash TEXT;
@@ -0,0 +1,3 @@
+-- ticket_types: ingressos infantis (CHILD) passam a ser nominais, com a idade conferida na data de
+-- nascimento do titular em vez do perfil do comprador
+UPDATE ticket_types SET nominal = 1 WHERE audience = 'CHILD';
@@ -0,0 +1,5 @@
+-- events: produtores já rejeitados deixam de vender, como passa a acontecer na reprovação
+-- (publicados ficam PAUSED, os em análise voltam para DRAFT)
+UPDATE events SET status = CASE status WHEN 'PUBLISHED' THEN 'PAUSED' ELSE 'DRAFT' END, updated_at = datetime('now')
+WHERE status IN ('PUBLISHED', 'IN_REVIEW')
+  AND producer_id IN (SELECT id FROM producers WHERE status = 'REJECTED');
@@ -22,7 +22,8 @@ func Run(db *sql.DB) error {
 
 func clear(db *sql.DB) error {
 	tables := []string{
-		"tickets", "order_items", "orders",
+		"admin_audit_log", "ticket_validations", "offline_checkins", "scanner_devices", "sessions", "user_tokens", "email_outbox",
+		"wallet_device_registrations", "wallet_passes", "resale_listings", "ticket_transfers", "courtesy_tickets", "tickets", "order_item_holders", "order_item_half_price_documents", "order_items", "promo_code_redemptions", "orders", "promo_codes", "event_staff",
 		"ticket_types", "lots", "event_dates", "events",
 		"producers", "users",
 	}
@@ -56,17 +57,24 @@ func insert(db *sql.DB) error {
 		{"seed-user-1", "João Silva", "joao@email.com", passwordHash, "123.456.789-00", "1990-05-15", "USER"},
 		{"seed-user-2", "Maria Santos", "maria@email.com", passwordHash, "987.654.321-00", "1988-11-20", "USER"},
 		{"seed-producer-user", "Produtor Eventos", "produtor@email.com", passwordHash, "111.222.333-44", "1985-03-10", "USER"},
+		{"seed-admin-user", "Admin Afterzin", "admin@email.com", passwordHash, "555.666.777-88", "1980-01-01", "ADMIN"},
 	}
 	for _, u := range users {
-		_, err := db.Exec(`INSERT INTO users (id, name, email, password_hash, cpf, birth_date, role, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-			u.id, u.name, u.email, u.passwordHash, u.cpf, u.birthDate, u.role, now)
+		_, err := db.Exec(`INSERT INTO users (id, name, email, password_hash, cpf, birth_date, role, created_at, email_verified_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			u.id, u.name, u.email, u.passwordHash, u.cpf, u.birthDate, u.role, now, now)
 		if err != nil {
 			return fmt.Errorf("insert user %s: %w", u.id, err)
 		}
 	}
+	// Buyers have a gender in the profile so gender-restricted tickets can be bought
+	for id, gender := range map[string]string{"seed-user-1": "MALE", "seed-user-2": "FEMALE"} {
+		if _, err := db.Exec(`UPDATE users SET gender = ? WHERE id = ?`, gender, id); err != nil {
+			return fmt.Errorf("set gender of %s: %w", id, err)
+		}
+	}
 
 	// Producers (produtor user becomes producer)
-	_, err = db.Exec(`INSERT INTO producers (id, user_id, approved, created_at) VALUES (?, ?, 1, ?)`,
+	_, err = db.Exec(`INSERT INTO producers (id, user_id, approved, status, created_at) VALUES (?, ?, 1, 'APPROVED', ?)`,
 		"seed-producer-1", "seed-producer-user", now)
 	if err != nil {
 		return fmt.Errorf("insert producer: %w", err)
@@ -153,6 +161,10 @@ func insert(db *sql.DB) error {
 			return fmt.Errorf("insert event %s: %w", e.id, err)
 		}
 	}
+	// The baile is 18+
+	if _, err := db.Exec(`UPDATE events SET min_age = 18 WHERE id = 'seed-event-4'`); err != nil {
+		return fmt.Errorf("set min_age: %w", err)
+	}
 
 	// Event dates
 	type eventDate struct {
@@ -246,6 +258,21 @@ func insert(db *sql.DB) error {
 			return fmt.Errorf("insert ticket_type %s: %w", tt.id, err)
 		}
 	}
+	// Camarote is nominal: each ticket needs a holder checked against an ID at the door; CHILD
+	// tickets too, checked against the child's birth date
+	if _, err := db.Exec(`UPDATE ticket_types SET nominal = 1 WHERE id = 'seed-tt-1a-c' OR audience = 'CHILD'`); err != nil {
+		return fmt.Errorf("set nominal ticket_type: %w", err)
+	}
+	// Half-price (meia-entrada) for students, counted against the 40% quota of the date
+	if _, err := db.Exec(`INSERT INTO ticket_types (id, lot_id, name, description, price, audience, max_quantity, sold_quantity, half_price_entitlement, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, 0, ?, ?)`,
+		"seed-tt-1a-pm", "seed-lot-1a", "Pista - Meia Estudante", "Meia-entrada com carteira estudantil", 140, "GENERAL", 600, "STUDENT", now); err != nil {
+		return fmt.Errorf("insert ticket_type seed-tt-1a-pm: %w", err)
+	}
+	// Promo code: 10% off any ticket type of the festival
+	if _, err := db.Exec(`INSERT INTO promo_codes (id, event_id, code, discount_type, discount_value, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		"seed-promo-1", "seed-event-1", "VERAO10", "PERCENT", 10, now); err != nil {
+		return fmt.Errorf("insert promo_code: %w", err)
+	}
 
 	return nil
 }
@@ -0,0 +1,74 @@
+package graphql
+
+import (
+	"errors"
+	"log"
+	"net/url"
+	"time"
+
+	"afterzin/api/internal/auth"
+	"afterzin/api/internal/mailer"
+	"afterzin/api/internal/repository"
+)
+
+const (
+	passwordResetTTL     = time.Hour
+	emailVerificationTTL = 24 * time.Hour
+)
+
+// frontendLink builds a link to a frontend route carrying a single-use token.
+func (r *Resolver) frontendLink(path, token string) string {
+	return r.Config.BaseURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// sendPasswordReset issues a reset token and queues the email with the link to the user.
+func (r *Resolver) sendPasswordReset(user *repository.UserRow) error {
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		return err
+	}
+	if err := repository.CreateUserToken(r.DB, user.ID, repository.TokenPasswordReset, auth.HashToken(token), passwordResetTTL); err != nil {
+		return err
+	}
+	msg, err := mailer.PasswordResetMessage(user.Email, user.Name, r.frontendLink("/redefinir-senha", token))
+	if err != nil {
+		return err
+	}
+	return r.Outbox.Enqueue(mailer.KindPasswordReset, "", msg)
+}
+
+// sendEmailVerification issues a verification token and queues the email with the
+// confirmation link.
+func (r *Resolver) sendEmailVerification(user *repository.UserRow) error {
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		return err
+	}
+	if err := repository.CreateUserToken(r.DB, user.ID, repository.TokenEmailVerification, auth.HashToken(token), emailVerificationTTL); err != nil {
+		return err
+	}
+	msg, err := mailer.EmailVerificationMessage(user.Email, user.Name, r.frontendLink("/verificar-email", token))
+	if err != nil {
+		return err
+	}
+	return r.Outbox.Enqueue(mailer.KindEmailVerification, "", msg)
+}
+
+// requireVerifiedEmail enforces REQUIRE_VERIFIED_EMAIL before checkout.
+func (r *Resolver) requireVerifiedEmail(userID string) error {
+	if !r.Config.RequireVerifiedEmail {
+		return nil
+	}
+	user, _ := repository.UserByID(r.DB, userID)
+	if user == nil || !user.EmailVerifiedAt.Valid {
+		return errors.New("confirme seu e-mail antes de comprar ingressos")
+	}
+	return nil
+}
+
+// logMailError logs failures to queue best-effort emails.
+func logMailError(kind, userID string, err error) {
+	if err != nil {
+		log.Printf("mailer: %s for user %s error: %v", kind, userID, err)
+	}
+}
@@ -0,0 +1,61 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"afterzin/api/internal/graphql/model"
+	"afterzin/api/internal/middleware"
+	"afterzin/api/internal/repository"
+)
+
+const (
+	adminDefaultLimit = 50
+	adminMaxLimit     = 200
+)
+
+// requireAdmin returns the authenticated user ID if the user has the ADMIN role.
+// The role is read from the database (not the token) so demotions take effect immediately.
+func (r *Resolver) requireAdmin(ctx context.Context) (string, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return "", errors.New("não autenticado")
+	}
+	user, _ := repository.UserByID(r.DB, userID)
+	if user == nil || user.Role != string(model.UserRoleAdmin) {
+		return "", errors.New("sem permissão")
+	}
+	return userID, nil
+}
+
+// auditAdmin records an admin action. Failures are logged but never block the action itself.
+func (r *Resolver) auditAdmin(adminID, action, targetType, targetID, details string) {
+	if err := repository.InsertAdminAuditLog(r.DB, adminID, action, targetType, targetID, details); err != nil {
+		log.Printf("admin: audit log %s on %s %s error: %v", action, targetType, targetID, err)
+	}
+}
+
+// adminFilter unpacks the optional search input with sane paging defaults.
+func adminFilter(f *model.AdminSearchInput) (search, status string, limit, offset int) {
+	limit = adminDefaultLimit
+	if f == nil {
+		return
+	}
+	if f.Search != nil {
+		search = *f.Search
+	}
+	if f.Status != nil {
+		status = *f.Status
+	}
+	if f.Limit != nil && *f.Limit > 0 {
+		limit = *f.Limit
+	}
+	if limit > adminMaxLimit {
+		limit = adminMaxLimit
+	}
+	if f.Offset != nil && *f.Offset > 0 {
+		offset = *f.Offset
+	}
+	return
+}
@@ -0,0 +1,151 @@
+package graphql
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+
+	"afterzin/api/internal/graphql/model"
+	"afterzin/api/internal/repository"
+)
+
+func eventAreaRowToModel(a *repository.EventAreaRow) *model.EventArea {
+	if a == nil {
+		return nil
+	}
+	out := &model.EventArea{ID: a.ID, Name: a.Name}
+	if a.Capacity.Valid {
+		capacity := int(a.Capacity.Int64)
+		out.Capacity = &capacity
+	}
+	return out
+}
+
+func eventGateRowToModel(db *sql.DB, g *repository.EventGateRow) *model.EventGate {
+	out := &model.EventGate{ID: g.ID, EventDateID: g.EventDateID, Name: g.Name, Areas: []*model.EventArea{}}
+	for _, id := range g.AreaIDs {
+		if a, _ := repository.EventAreaByID(db, id); a != nil {
+			out.Areas = append(out.Areas, eventAreaRowToModel(a))
+		}
+	}
+	return out
+}
+
+// eventAreaFromInput validates the area's name and safety capacity.
+func eventAreaFromInput(in model.EventAreaInput) (string, sql.NullInt64, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return "", sql.NullInt64{}, errors.New("informe o nome do setor")
+	}
+	var capacity sql.NullInt64
+	if in.Capacity != nil {
+		if *in.Capacity <= 0 {
+			return "", sql.NullInt64{}, errors.New("lotação do setor deve ser maior que zero")
+		}
+		capacity = sql.NullInt64{Int64: int64(*in.Capacity), Valid: true}
+	}
+	return name, capacity, nil
+}
+
+// eventGateFromInput validates the gate's name and that the areas it admits are the event's.
+func eventGateFromInput(db *sql.DB, eventID string, in model.EventGateInput) (string, []string, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return "", nil, errors.New("informe o nome do portão")
+	}
+	for _, id := range in.AreaIds {
+		if a, _ := repository.EventAreaByID(db, id); a == nil || a.EventID != eventID {
+			return "", nil, errors.New("setor não encontrado neste evento")
+		}
+	}
+	return name, in.AreaIds, nil
+}
+
+// eventDateGate loads the gate and checks that the user is the event's producer or a MANAGER.
+func (r *Resolver) eventDateGate(userID, gateID string) (*repository.EventGateRow, *repository.EventRow, error) {
+	g, _ := repository.EventGateByID(r.DB, gateID)
+	if g == nil {
+		return nil, nil, errors.New("portão não encontrado")
+	}
+	ed, _ := repository.EventDateByID(r.DB, g.EventDateID)
+	if ed == nil {
+		return nil, nil, errors.New("data não encontrada")
+	}
+	ev, err := r.eventAccess(userID, ed.EventID, repository.ScopeManage)
+	if err != nil {
+		return nil, nil, err
+	}
+	return g, ev, nil
+}
+
+// checkGate resolves the registered gate of a reading into v (its name and ID) and, on entries,
+// checks that it serves the ticket's area. On events with areas an entry must name a gate, or
+// omitting it would skip the area check. Returns nil when the ticket may go through, or the
+// failed result for the scanner. Exits are allowed at any gate of the date.
+func checkGate(db *sql.DB, t *repository.TicketRow, tt *repository.TicketTypeRow, gateID *string, v *repository.TicketValidationRow) *model.ValidateTicketResult {
+	if gateID == nil || *gateID == "" {
+		if v.Direction != repository.CheckinIn {
+			return nil
+		}
+		hasAreas, err := repository.EventHasAreas(db, t.EventID)
+		if err != nil {
+			return &model.ValidateTicketResult{Success: false, Message: strPtr("erro ao validar")}
+		}
+		if hasAreas {
+			return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("GATE_REQUIRED"), Message: strPtr("evento com setores: escolha um portão cadastrado")}
+		}
+		return nil
+	}
+	g, _ := repository.EventGateByID(db, *gateID)
+	if g == nil || g.EventDateID != t.EventDateID {
+		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("WRONG_GATE"), Message: strPtr("portão não pertence à data deste ingresso")}
+	}
+	v.Gate, v.GateID = g.Name, g.ID
+	if v.Direction != repository.CheckinIn || len(g.AreaIDs) == 0 {
+		return nil
+	}
+	if tt == nil || tt.Area == nil || !slices.Contains(g.AreaIDs, tt.Area.ID) {
+		msg := "ingresso sem setor; este portão atende apenas setores específicos"
+		if tt != nil && tt.Area != nil {
+			msg = "ingresso do setor " + tt.Area.Name + "; este portão não atende esse setor"
+		}
+		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("WRONG_AREA"), Message: &msg}
+	}
+	return nil
+}
+
+// areaOccupancy is the current occupancy of every area of the event date, including the areas
+// without tickets sold and, last, the tickets without area.
+func areaOccupancy(db *sql.DB, eventID, eventDateID string) ([]*model.AreaOccupancy, error) {
+	counts, err := repository.CheckinsByArea(db, eventDateID)
+	if err != nil {
+		return nil, err
+	}
+	byArea := map[string]*repository.AreaCheckins{}
+	for _, c := range counts {
+		byArea[c.AreaID] = c
+	}
+	areas, err := repository.EventAreasByEvent(db, eventID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.AreaOccupancy, 0, len(areas)+1)
+	for _, a := range areas {
+		item := &model.AreaOccupancy{Area: eventAreaRowToModel(a)}
+		if c := byArea[a.ID]; c != nil {
+			item.Inside, item.Total = c.Inside, c.Total
+		}
+		if a.Capacity.Valid {
+			capacity := int(a.Capacity.Int64)
+			percent := float64(item.Inside) * 100 / float64(capacity)
+			item.Capacity, item.OccupancyPercent = &capacity, &percent
+			item.AtCapacity = item.Inside >= capacity
+		}
+		out = append(out, item)
+	}
+	if c := byArea[""]; c != nil {
+		out = append(out, &model.AreaOccupancy{Inside: c.Inside, Total: c.Total})
+	}
+	return out, nil
+}
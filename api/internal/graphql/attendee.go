@@ -0,0 +1,74 @@
+package graphql
+
+import (
+	"database/sql"
+
+	"afterzin/api/internal/graphql/model"
+	"afterzin/api/internal/repository"
+	"afterzin/api/internal/taxid"
+)
+
+// attendeeFilter unpacks the optional filter with the same paging defaults as the admin lists.
+func attendeeFilter(f *model.AttendeeFilter) (filter repository.AttendeeFilter, limit, offset int) {
+	limit = adminDefaultLimit
+	if f == nil {
+		return
+	}
+	if f.Search != nil {
+		filter.Search = *f.Search
+	}
+	if f.TicketTypeID != nil {
+		filter.TicketTypeID = *f.TicketTypeID
+	}
+	filter.CheckedIn = f.CheckedIn
+	if f.Limit != nil && *f.Limit > 0 {
+		limit = *f.Limit
+	}
+	if limit > adminMaxLimit {
+		limit = adminMaxLimit
+	}
+	if f.Offset != nil && *f.Offset > 0 {
+		offset = *f.Offset
+	}
+	return
+}
+
+// attendeeRowsToModel converts a page of attendees, loading each ticket type once.
+func attendeeRowsToModel(db *sql.DB, list []*repository.AttendeeRow) []*model.Attendee {
+	types := map[string]*model.TicketType{}
+	out := make([]*model.Attendee, 0, len(list))
+	for _, a := range list {
+		tt, ok := types[a.TicketTypeID]
+		if !ok {
+			row, _ := repository.TicketTypeByID(db, a.TicketTypeID)
+			tt = ticketTypeRowToModel(row)
+			types[a.TicketTypeID] = tt
+		}
+		item := &model.Attendee{
+			TicketID:     a.TicketID,
+			Code:         a.Code,
+			TicketType:   tt,
+			Name:         a.Name,
+			Courtesy:     a.Courtesy,
+			PurchasedAt:  parseDateTimeToRFC3339(a.PurchasedAt),
+			CheckedIn:    a.Used == 1,
+			CheckinState: model.CheckinDirection(a.CheckinState),
+		}
+		if a.Holder.Valid {
+			item.HolderName = &a.Holder.String
+		}
+		if a.BuyerName.Valid {
+			item.BuyerName = &a.BuyerName.String
+		}
+		if a.CPF != "" {
+			cpf := taxid.MaskCPF(a.CPF)
+			item.Cpf = &cpf
+		}
+		if a.UsedAt.Valid {
+			at := parseDateTimeToRFC3339(a.UsedAt.String)
+			item.CheckedInAt = &at
+		}
+		out = append(out, item)
+	}
+	return out
+}
@@ -0,0 +1,23 @@
+package graphql
+
+import (
+	"database/sql"
+
+	"afterzin/api/internal/checkout"
+	"afterzin/api/internal/repository"
+)
+
+// maxEventMinAge bounds the minimum age a producer can require.
+const maxEventMinAge = 21
+
+// checkTicketOwnerAudience checks a ticket changing owner (transfer or resale) against the new
+// owner's profile. Nominal tickets are checked when the new owner assigns the holder instead.
+func checkTicketOwnerAudience(db *sql.DB, t *repository.TicketRow, u *repository.UserRow) error {
+	tt, _ := repository.TicketTypeByID(db, t.TicketTypeID)
+	ev, _ := repository.EventByID(db, t.EventID)
+	d, _ := repository.EventDateByID(db, t.EventDateID)
+	if tt == nil || ev == nil || d == nil || u == nil || tt.Nominal == 1 {
+		return nil
+	}
+	return checkout.CheckTicketAudience(ev, d, tt, checkout.BuyerAttendee(u))
+}
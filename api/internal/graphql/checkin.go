@@ -0,0 +1,232 @@
+package graphql
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"afterzin/api/internal/checkin"
+	"afterzin/api/internal/graphql/model"
+	"afterzin/api/internal/middleware"
+	"afterzin/api/internal/repository"
+)
+
+// entryRefusals are the validateTicket errors of an entry refused by each entry policy.
+var entryRefusals = map[string][2]string{
+	repository.EntrySingle:  {"ALREADY_USED", "ingresso já utilizado"},
+	repository.EntryReentry: {"ALREADY_INSIDE", "titular já está dentro do evento; registre a saída antes de uma nova entrada"},
+	repository.EntryDaily:   {"ALREADY_USED_TODAY", "ingresso já utilizado hoje"},
+	repository.EntryMulti:   {"NO_ENTRIES_LEFT", "ingresso sem entradas restantes"},
+}
+
+// admitTicket records an entry (IN) or exit (OUT) of a ticket of a paid order as allowed by its
+// type's entry policy, together with the validation v (its ID is set) by v.ValidatedBy (the
+// producer's user or a staff member), atomically so two concurrent scans cannot both succeed,
+// and publishes it to the check-in subscriptions once stored. Returns nil on success, or the
+// failed result for the scanner.
+func (r *Resolver) admitTicket(t *repository.TicketRow, tt *repository.TicketTypeRow, v *repository.TicketValidationRow) *model.ValidateTicketResult {
+	policy, maxEntries := repository.EntrySingle, sql.NullInt64{}
+	if tt != nil && tt.EntryPolicy != "" {
+		policy, maxEntries = tt.EntryPolicy, tt.MaxEntries
+	}
+	dir := model.CheckinDirection(v.Direction)
+	// Only tickets of paid orders get in, as in the offline manifest: refunded ones are void.
+	_, status, _, err := repository.OrderByID(r.DB, t.OrderID)
+	switch {
+	case err != nil && err != sql.ErrNoRows:
+		return &model.ValidateTicketResult{Success: false, Direction: &dir, Message: strPtr("erro ao validar")}
+	case status == "REFUNDED":
+		return &model.ValidateTicketResult{Success: false, Direction: &dir, ErrorCode: strPtr("REFUNDED"), Message: strPtr("ingresso de pedido reembolsado")}
+	case status != "PAID":
+		return &model.ValidateTicketResult{Success: false, Direction: &dir, ErrorCode: strPtr("INVALID"), Message: strPtr("ingresso sem pagamento confirmado")}
+	}
+	v.TicketID = t.ID
+	ok, err := repository.AdmitTicket(r.DB, v, policy, maxEntries, repository.EntryDate(time.Now()))
+	if err != nil {
+		return &model.ValidateTicketResult{Success: false, Direction: &dir, Message: strPtr("erro ao validar")}
+	}
+	if !ok {
+		if v.Direction == repository.CheckinOut {
+			return &model.ValidateTicketResult{Success: false, Direction: &dir, ErrorCode: strPtr("NOT_INSIDE"), Message: strPtr("titular não está dentro do evento")}
+		}
+		refusal := entryRefusals[policy]
+		return &model.ValidateTicketResult{Success: false, Direction: &dir, ErrorCode: strPtr(refusal[0]), Message: strPtr(refusal[1])}
+	}
+	// Keep the loaded row in step with the door state just written, for the result.
+	t.CheckinState = v.Direction
+	if v.Direction == repository.CheckinIn {
+		t.EntryCount++
+	}
+	if t.Used == 0 {
+		_ = repository.CancelResaleListingsByTicket(r.DB, t.ID)
+		r.Wallet.TicketsChanged(t.ID)
+		t.Used = 1
+	} else if policy == repository.EntryMulti && v.Direction == repository.CheckinIn {
+		// The wallet pass is voided on the last entry.
+		r.Wallet.TicketsChanged(t.ID)
+	}
+	r.publishValidation(t, v, false)
+	return nil
+}
+
+// publishValidation sends a validation of the ticket, or its revert, to the check-in subscriptions.
+func (r *Resolver) publishValidation(t *repository.TicketRow, v *repository.TicketValidationRow, reverted bool) {
+	r.Checkins.Publish(checkin.Validation{
+		ID:           v.ID,
+		TicketID:     t.ID,
+		EventID:      v.EventID,
+		EventDateID:  t.EventDateID,
+		TicketTypeID: t.TicketTypeID,
+		ValidatedBy:  v.ValidatedBy,
+		Direction:    v.Direction,
+		Gate:         v.Gate,
+		Reverted:     reverted,
+		ValidatedAt:  time.Now().UTC(),
+	})
+}
+
+// admittedResult is the successful validateTicket (and manualCheckin) result: the ticket, its
+// holder, the entry counts and, on entries, what staff must check for half-price tickets.
+func admittedResult(db *sql.DB, t *repository.TicketRow, tt *repository.TicketTypeRow, holder *repository.TicketHolder, v *repository.TicketValidationRow) *model.ValidateTicketResult {
+	ticket, _ := staffTicketRowToModel(db, t)
+	result := &model.ValidateTicketResult{Success: true, Ticket: ticket, Holder: staffTicketHolderToModel(holder), ValidationID: &v.ID}
+	entryCounts(db, result, tt, t.ID, v.Direction)
+	// Half-price tickets: staff must ask for the proof document before letting the attendee in.
+	if v.Direction == repository.CheckinIn {
+		result.HalfPriceEntitlement = halfPriceEntitlementToModel(tt)
+	}
+	if result.HalfPriceEntitlement != nil {
+		result.HalfPriceDocument = ticket.HalfPriceDocument
+		result.Message = strPtr("meia-entrada: confira o documento comprobatório")
+	}
+	return result
+}
+
+// entryCounts fills the direction and entry counts of a successful validateTicket result.
+func entryCounts(db *sql.DB, result *model.ValidateTicketResult, tt *repository.TicketTypeRow, ticketID, direction string) {
+	dir := model.CheckinDirection(direction)
+	result.Direction = &dir
+	c, _ := repository.TicketCheckinByID(db, ticketID)
+	if c == nil {
+		return
+	}
+	result.EntryCount = &c.EntryCount
+	switch {
+	case tt == nil || tt.EntryPolicy == "" || tt.EntryPolicy == repository.EntrySingle:
+		remaining := max(0, 1-c.EntryCount)
+		result.EntriesRemaining = &remaining
+	case tt.EntryPolicy == repository.EntryMulti && tt.MaxEntries.Valid:
+		remaining := max(0, int(tt.MaxEntries.Int64)-c.EntryCount)
+		result.EntriesRemaining = &remaining
+	}
+}
+
+// statsInterval is the minimum time between two checkinStats updates of a subscription.
+const statsInterval = time.Second
+
+// subscriptionRecheckInterval is how often running subscriptions re-check the session and the
+// access to the event.
+const subscriptionRecheckInterval = 30 * time.Second
+
+// subscriptionAllowed tells whether a running subscription may go on: the session is still
+// active (not revoked or expired, account not blocked) and the user still has the scope on the
+// event (e.g. not removed from the team).
+func (r *Resolver) subscriptionAllowed(ctx context.Context, eventID string, scope repository.StaffScope) bool {
+	userID := middleware.UserID(ctx)
+	if active, _ := repository.SessionActive(r.DB, middleware.SessionID(ctx), userID); !active {
+		return false
+	}
+	_, err := r.eventAccess(userID, eventID, scope)
+	return err == nil
+}
+
+// checkinStats computes the door numbers of the event date.
+func checkinStats(db *sql.DB, eventDateID string) (*model.CheckinStats, error) {
+	now := time.Now().UTC()
+	out := &model.CheckinStats{
+		EventDateID:  eventDateID,
+		ByTicketType: []*model.TicketTypeCheckinStats{},
+		ByGate:       []*model.GateCheckinStats{},
+		ByArea:       []*model.AreaOccupancy{},
+		PerMinute:    []*model.MinuteCheckinStats{},
+		UpdatedAt:    now.Format(time.RFC3339),
+	}
+	types, err := repository.CheckinsByTicketType(db, eventDateID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range types {
+		tt, _ := repository.TicketTypeByID(db, c.TicketTypeID)
+		out.ByTicketType = append(out.ByTicketType, &model.TicketTypeCheckinStats{
+			TicketType: ticketTypeRowToModel(tt),
+			Validated:  c.Validated,
+			Total:      c.Total,
+		})
+		out.Validated += c.Validated
+		out.Total += c.Total
+	}
+	gates, err := repository.CheckinsByGate(db, eventDateID)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(gates))
+	for g := range gates {
+		names = append(names, g)
+	}
+	sort.Strings(names)
+	for _, g := range names {
+		item := &model.GateCheckinStats{Validated: gates[g]}
+		if g != "" {
+			item.Gate = &g
+		}
+		out.ByGate = append(out.ByGate, item)
+	}
+	if ed, _ := repository.EventDateByID(db, eventDateID); ed != nil {
+		if out.ByArea, err = areaOccupancy(db, ed.EventID, eventDateID); err != nil {
+			return nil, err
+		}
+	}
+	minutes, err := repository.CheckinsPerMinute(db, eventDateID, now.Add(-time.Hour).Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range minutes {
+		out.PerMinute = append(out.PerMinute, &model.MinuteCheckinStats{
+			Minute:    parseDateTimeToRFC3339(m.Minute + ":00"),
+			Validated: m.Validated,
+		})
+	}
+	return out, nil
+}
+
+func ticketValidationRowToModel(db *sql.DB, v *repository.TicketValidationRow) *model.TicketValidation {
+	out := &model.TicketValidation{
+		ID:          v.ID,
+		Direction:   model.CheckinDirection(v.Direction),
+		Offline:     v.OfflineCheckinID.Valid,
+		Manual:      v.ManualReason != "",
+		ValidatedAt: parseDateTimeToRFC3339(v.ValidatedAt),
+	}
+	if v.Gate != "" {
+		out.Gate = &v.Gate
+	}
+	if v.ManualReason != "" {
+		out.Reason = &v.ManualReason
+	}
+	if u, _ := repository.UserByID(db, v.ValidatedBy); u != nil {
+		out.ValidatedBy = &u.Name
+	}
+	if v.RevertedAt.Valid {
+		revertedAt := parseDateTimeToRFC3339(v.RevertedAt.String)
+		out.RevertedAt = &revertedAt
+		if u, _ := repository.UserByID(db, v.RevertedBy.String); u != nil {
+			out.RevertedBy = &u.Name
+		}
+		out.RevertReason = &v.RevertReason.String
+	}
+	if t, _ := repository.TicketByID(db, v.TicketID); t != nil {
+		out.Ticket, _ = staffTicketRowToModel(db, t)
+	}
+	return out
+}
@@ -1,9 +1,14 @@
 package graphql
 
 import (
+	"afterzin/api/internal/checkout"
 	"afterzin/api/internal/graphql/model"
+	"afterzin/api/internal/qrcode"
 	"afterzin/api/internal/repository"
+	"afterzin/api/internal/tickets"
 	"database/sql"
+	"encoding/hex"
+	"strings"
 	"time"
 )
 
@@ -15,16 +20,49 @@ func userRowToModel(u *repository.UserRow) *model.User {
 	if u.PhotoURL.Valid {
 		photoURL = &u.PhotoURL.String
 	}
-	return &model.User{
-		ID:        u.ID,
-		Name:      u.Name,
-		Email:     u.Email,
-		Cpf:       u.CPF,
-		BirthDate: u.BirthDate,
-		PhotoURL:  photoURL,
-		Role:      model.UserRole(u.Role),
-		CreatedAt: u.CreatedAt.UTC().Format("2006-01-02T15:04:05Z07:00"),
+	user := &model.User{
+		ID:            u.ID,
+		Name:          u.Name,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerifiedAt.Valid,
+		Cpf:           u.CPF,
+		BirthDate:     u.BirthDate,
+		PhotoURL:      photoURL,
+		Role:          model.UserRole(u.Role),
+		CreatedAt:     u.CreatedAt.UTC().Format("2006-01-02T15:04:05Z07:00"),
 	}
+	if u.BlockedAt.Valid && u.BlockedAt.String != "" {
+		blockedAt := parseDateTimeToRFC3339(u.BlockedAt.String)
+		user.BlockedAt = &blockedAt
+	}
+	if u.BlockedReason.Valid {
+		user.BlockedReason = &u.BlockedReason.String
+	}
+	if u.Gender.Valid {
+		g := model.Gender(u.Gender.String)
+		user.Gender = &g
+	}
+	return user
+}
+
+func producerRowToModel(db *sql.DB, p *repository.ProducerRow) *model.Producer {
+	if p == nil {
+		return nil
+	}
+	owner, _ := repository.UserByID(db, p.UserID)
+	producer := &model.Producer{
+		ID:       p.ID,
+		User:     userRowToModel(owner),
+		Approved: p.Approved == 1,
+		Status:   model.ProducerStatus(p.Status),
+	}
+	if p.CompanyName.Valid {
+		producer.CompanyName = &p.CompanyName.String
+	}
+	if p.RejectionReason.Valid {
+		producer.RejectionReason = &p.RejectionReason.String
+	}
+	return producer
 }
 
 func eventRowToModel(e *repository.EventRow, db *sql.DB) (*model.Event, error) {
@@ -37,17 +75,40 @@ func eventRowToModel(e *repository.EventRow, db *sql.DB) (*model.Event, error) {
 	}
 	feat := e.Featured == 1
 	ev := &model.Event{
-		ID:          e.ID,
-		Title:       e.Title,
-		Description: e.Description,
-		Category:    e.Category,
-		CoverImage:  e.CoverImage,
-		Location:    e.Location,
-		Address:     addr,
-		Status:      model.EventStatus(e.Status),
-		Featured:    &feat,
-		Dates:       nil,
-		Producer:    nil,
+		ID:                     e.ID,
+		Title:                  e.Title,
+		Description:            e.Description,
+		Category:               e.Category,
+		CoverImage:             e.CoverImage,
+		Location:               e.Location,
+		Address:                addr,
+		Status:                 model.EventStatus(e.Status),
+		Featured:               &feat,
+		Dates:                  nil,
+		Producer:               nil,
+		TransfersEnabled:       e.TransfersEnabled == 1,
+		TransferCutoffHours:    e.TransferCutoffHours,
+		HolderCutoffHours:      e.HolderCutoffHours,
+		MinAge:                 e.MinAge,
+		PurchaseLimits:         purchaseLimitsToModel(e.Limits),
+		CourtesyQuota:          e.CourtesyQuota,
+		ResaleEnabled:          e.ResaleEnabled == 1,
+		ResaleMaxMarkupPercent: e.ResaleMaxMarkupPercent,
+		ResaleRoyaltyPercent:   e.ResaleRoyaltyPercent,
+		DynamicQREnabled:       e.DynamicQR == 1,
+		StaticQRFallback:       e.StaticQRFallback == 1,
+	}
+	if e.RemovedReason.Valid {
+		ev.RemovedReason = &e.RemovedReason.String
+	}
+	if e.ModerationNote.Valid {
+		ev.ModerationNote = &e.ModerationNote.String
+	}
+	ev.CourtesyIssued, _ = repository.CountCourtesyTickets(db, e.ID)
+	areas, _ := repository.EventAreasByEvent(db, e.ID)
+	ev.Areas = make([]*model.EventArea, 0, len(areas))
+	for _, a := range areas {
+		ev.Areas = append(ev.Areas, eventAreaRowToModel(a))
 	}
 	dateIDs, err := repository.EventDateIDsByEvent(db, e.ID)
 	if err != nil {
@@ -68,18 +129,7 @@ func eventRowToModel(e *repository.EventRow, db *sql.DB) (*model.Event, error) {
 	if err != nil {
 		return nil, err
 	}
-	if prod != nil {
-		owner, _ := repository.UserByID(db, prod.UserID)
-		ev.Producer = &model.Producer{
-			ID:          prod.ID,
-			User:        userRowToModel(owner),
-			CompanyName: nil,
-			Approved:    prod.Approved == 1,
-		}
-		if prod.CompanyName.Valid {
-			ev.Producer.CompanyName = &prod.CompanyName.String
-		}
-	}
+	ev.Producer = producerRowToModel(db, prod)
 	return ev, nil
 }
 
@@ -107,6 +157,12 @@ func eventDateToModel(db *sql.DB, dateID string) (*model.EventDate, error) {
 	if err != nil {
 		return nil, err
 	}
+	ed.HalfPriceQuota, ed.HalfPriceSold, _ = checkout.HalfPriceQuota(db, d.ID)
+	gates, _ := repository.EventGatesByEventDate(db, d.ID)
+	ed.Gates = make([]*model.EventGate, 0, len(gates))
+	for _, g := range gates {
+		ed.Gates = append(ed.Gates, eventGateRowToModel(db, g))
+	}
 	ed.Lots = make([]*model.Lot, 0, len(lotIDs))
 	for _, lid := range lotIDs {
 		lot, err := lotToModel(db, lid)
@@ -145,23 +201,48 @@ func lotToModel(db *sql.DB, lotID string) (*model.Lot, error) {
 		if err != nil || tt == nil {
 			continue
 		}
-		var desc *string
-		if tt.Description.Valid {
-			desc = &tt.Description.String
-		}
-		lot.TicketTypes = append(lot.TicketTypes, &model.TicketType{
-			ID:           tt.ID,
-			Name:         tt.Name,
-			Description:  desc,
-			Price:        tt.Price,
-			Audience:     model.AudienceType(tt.Audience),
-			MaxQuantity:  tt.MaxQuantity,
-			SoldQuantity: tt.SoldQuantity,
-		})
+		lot.TicketTypes = append(lot.TicketTypes, ticketTypeRowToModel(tt))
 	}
 	return lot, nil
 }
 
+func ticketTypeRowToModel(tt *repository.TicketTypeRow) *model.TicketType {
+	if tt == nil {
+		return nil
+	}
+	var desc *string
+	if tt.Description.Valid {
+		desc = &tt.Description.String
+	}
+	out := &model.TicketType{
+		ID:                   tt.ID,
+		Name:                 tt.Name,
+		Description:          desc,
+		Price:                tt.Price,
+		Audience:             model.AudienceType(tt.Audience),
+		MaxQuantity:          tt.MaxQuantity,
+		SoldQuantity:         tt.SoldQuantity,
+		Nominal:              tt.Nominal == 1,
+		HalfPriceEntitlement: halfPriceEntitlementToModel(tt),
+		PurchaseLimits:       purchaseLimitsToModel(tt.Limits),
+		EntryPolicy:          model.EntryPolicy(tt.EntryPolicy),
+		Area:                 eventAreaRowToModel(tt.Area),
+	}
+	if tt.MaxEntries.Valid {
+		maxEntries := int(tt.MaxEntries.Int64)
+		out.MaxEntries = &maxEntries
+	}
+	if tt.Audience == string(model.AudienceTypeChild) {
+		maxAge := checkout.ChildMaxAge
+		out.MaxAge = &maxAge
+	}
+	if restriction := checkout.AudienceRestriction(tt.Audience); restriction != "" {
+		restriction = strings.ToUpper(restriction[:1]) + restriction[1:]
+		out.Restriction = &restriction
+	}
+	return out
+}
+
 func ticketRowToModel(db *sql.DB, t *repository.TicketRow) (*model.Ticket, error) {
 	if t == nil {
 		return nil, nil
@@ -170,38 +251,31 @@ func ticketRowToModel(db *sql.DB, t *repository.TicketRow) (*model.Ticket, error
 	ev, _ := eventRowToModel(evRow, db)
 	ed, _ := eventDateToModel(db, t.EventDateID)
 	tt, _ := repository.TicketTypeByID(db, t.TicketTypeID)
-	var ttModel *model.TicketType
-	if tt != nil {
-		var desc *string
-		if tt.Description.Valid {
-			desc = &tt.Description.String
-		}
-		ttModel = &model.TicketType{
-			ID:           tt.ID,
-			Name:         tt.Name,
-			Description:  desc,
-			Price:        tt.Price,
-			Audience:     model.AudienceType(tt.Audience),
-			MaxQuantity:  tt.MaxQuantity,
-			SoldQuantity: tt.SoldQuantity,
-		}
-	}
+	ttModel := ticketTypeRowToModel(tt)
 	owner, _ := repository.UserByID(db, t.UserID)
 	ticket := &model.Ticket{
-		ID:         t.ID,
-		Code:       t.Code,
-		QRCode:     t.QRCode,
-		Event:      ev,
-		EventDate:  ed,
-		TicketType: ttModel,
-		Owner:      userRowToModel(owner),
-		Used:       t.Used == 1,
-		CreatedAt:  t.CreatedAt.UTC().Format("2006-01-02T15:04:05Z07:00"),
+		ID:           t.ID,
+		Code:         t.Code,
+		QRCode:       t.QRCode,
+		Event:        ev,
+		EventDate:    ed,
+		TicketType:   ttModel,
+		Owner:        userRowToModel(owner),
+		Used:         t.Used == 1,
+		CheckinState: model.CheckinDirection(t.CheckinState),
+		EntryCount:   t.EntryCount,
+		CreatedAt:    t.CreatedAt.UTC().Format("2006-01-02T15:04:05Z07:00"),
 	}
 	if t.UsedAt.Valid && t.UsedAt.String != "" {
 		usedAt := parseDateTimeToRFC3339(t.UsedAt.String)
 		ticket.UsedAt = &usedAt
 	}
+	holder, _ := repository.TicketHolderByTicketID(db, t.ID)
+	ticket.Holder = ticketHolderToModel(holder)
+	ticket.HolderRequired = tt != nil && tt.Nominal == 1 && holder == nil
+	if doc, _ := repository.TicketHalfPriceDocument(db, t.ID); doc != "" {
+		ticket.HalfPriceDocument = &doc
+	}
 	return ticket, nil
 }
 
@@ -212,3 +286,93 @@ func parseDateTimeToRFC3339(s string) string {
 	}
 	return t.UTC().Format(time.RFC3339)
 }
+
+func orderRowToModel(db *sql.DB, o *repository.OrderRow) *model.Order {
+	if o == nil {
+		return nil
+	}
+	buyer, _ := repository.UserByID(db, o.UserID)
+	order := &model.Order{
+		ID:        o.ID,
+		User:      userRowToModel(buyer),
+		Status:    o.Status,
+		Total:     o.Total,
+		CreatedAt: parseDateTimeToRFC3339(o.CreatedAt),
+	}
+	if o.PagarmeOrderID.Valid {
+		order.PagarmeOrderID = &o.PagarmeOrderID.String
+	}
+	if o.PagarmeChargeID.Valid {
+		order.PagarmeChargeID = &o.PagarmeChargeID.String
+	}
+	return order
+}
+
+func webhookEventRowToModel(w *repository.WebhookEventRow) *model.WebhookEventLog {
+	ev := &model.WebhookEventLog{
+		ID:         w.ID,
+		Provider:   w.Provider,
+		ExternalID: w.ExternalID,
+		EventType:  w.EventType,
+		Processed:  w.Processed == 1,
+		CreatedAt:  parseDateTimeToRFC3339(w.CreatedAt),
+	}
+	if w.ErrorMessage.Valid {
+		ev.ErrorMessage = &w.ErrorMessage.String
+	}
+	return ev
+}
+
+func adminAuditLogRowToModel(db *sql.DB, a *repository.AdminAuditLogRow) *model.AdminAuditLogEntry {
+	admin, _ := repository.UserByID(db, a.AdminUserID)
+	entry := &model.AdminAuditLogEntry{
+		ID:         a.ID,
+		Admin:      userRowToModel(admin),
+		Action:     a.Action,
+		TargetType: a.TargetType,
+		CreatedAt:  parseDateTimeToRFC3339(a.CreatedAt),
+	}
+	if a.TargetID.Valid {
+		entry.TargetID = &a.TargetID.String
+	}
+	if a.Details.Valid {
+		entry.Details = &a.Details.String
+	}
+	return entry
+}
+
+// withOwnerLinks fills the links only the ticket owner receives: the signed PDF link and,
+// when configured, the Apple Wallet download and Google Wallet save links; and, for events with
+// rotating QR codes, the dynamic QR code.
+func (r *Resolver) withOwnerLinks(ticket *model.Ticket, t *repository.TicketRow) {
+	secret := []byte(r.Config.TicketLinkSecret)
+	pdf := tickets.SignedFileURL(r.Config.APIBaseURL, t, tickets.FilePDF, secret, r.Config.TicketLinkTTL)
+	ticket.PDFURL = &pdf
+	if r.Wallet.AppleEnabled() {
+		apple := tickets.SignedFileURL(r.Config.APIBaseURL, t, tickets.FileApplePass, secret, r.Config.TicketLinkTTL)
+		ticket.ApplePassURL = &apple
+	}
+	if r.Wallet.GoogleEnabled() {
+		if google, err := r.Wallet.GoogleSaveURL(t); err == nil {
+			ticket.GoogleWalletURL = &google
+		}
+	}
+	if ev, _ := repository.EventByID(r.DB, t.EventID); ev != nil && ev.DynamicQR == 1 {
+		if ticketSecret, err := r.QRKeys.DynamicSecret(t.QRCode); err == nil {
+			ticket.DynamicQR = dynamicQRCode(t, ticketSecret, time.Now())
+		}
+	}
+}
+
+// dynamicQRCode is the ticket's dynamic QR secrets and its payload for the window of now.
+func dynamicQRCode(t *repository.TicketRow, ticketSecret []byte, now time.Time) *model.DynamicQRCode {
+	period := int64(qrcode.DynamicPeriod / time.Second)
+	expiresAt := time.Unix((qrcode.DynamicWindow(now)+1)*period, 0).UTC()
+	return &model.DynamicQRCode{
+		Secret:        hex.EncodeToString(ticketSecret),
+		OfflineSecret: hex.EncodeToString(qrcode.DynamicOfflineSecret(ticketSecret)),
+		Period:        int(period),
+		Payload:       qrcode.GenerateDynamicPayload(t.ID, ticketSecret, now),
+		ExpiresAt:     expiresAt.Format(time.RFC3339),
+	}
+}
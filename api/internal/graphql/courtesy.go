@@ -0,0 +1,85 @@
+package graphql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"afterzin/api/internal/graphql/model"
+	"afterzin/api/internal/repository"
+	"afterzin/api/internal/taxid"
+)
+
+// maxCourtesyBatch bounds the recipients of one issueCourtesyTickets call.
+const maxCourtesyBatch = 200
+
+// validateCourtesyRecipients normalizes the recipients; outside the guest list each one needs
+// an email to receive the ticket.
+func validateCourtesyRecipients(in []*model.CourtesyRecipientInput, guestList bool) ([]repository.CourtesyTicketRow, error) {
+	if len(in) == 0 {
+		return nil, errors.New("informe ao menos um convidado")
+	}
+	if len(in) > maxCourtesyBatch {
+		return nil, fmt.Errorf("máximo de %d convidados por emissão", maxCourtesyBatch)
+	}
+	optional := func(s *string) sql.NullString {
+		if s == nil {
+			return sql.NullString{}
+		}
+		v := strings.TrimSpace(*s)
+		return sql.NullString{String: v, Valid: v != ""}
+	}
+	list := make([]repository.CourtesyTicketRow, 0, len(in))
+	for _, r := range in {
+		c := repository.CourtesyTicketRow{
+			RecipientName: strings.TrimSpace(r.Name),
+			Category:      optional(r.Category),
+			GuestList:     guestList,
+		}
+		if c.RecipientName == "" {
+			return nil, errors.New("nome do convidado é obrigatório")
+		}
+		if email := optional(r.Email); email.Valid {
+			if !strings.Contains(email.String, "@") {
+				return nil, fmt.Errorf("e-mail inválido para %s", c.RecipientName)
+			}
+			c.RecipientEmail = sql.NullString{String: repository.NormalizeEmail(email.String), Valid: true}
+		} else if !guestList {
+			return nil, fmt.Errorf("informe o e-mail de %s para enviar a cortesia", c.RecipientName)
+		}
+		if cpf := optional(r.Cpf); cpf.Valid {
+			if !taxid.ValidCPF(cpf.String) {
+				return nil, fmt.Errorf("CPF inválido para %s", c.RecipientName)
+			}
+			c.RecipientCPF = sql.NullString{String: taxid.Digits(cpf.String), Valid: true}
+		}
+		list = append(list, c)
+	}
+	return list, nil
+}
+
+func courtesyTicketRowToModel(db *sql.DB, c *repository.CourtesyTicketRow) *model.CourtesyTicket {
+	nullStr := func(s sql.NullString) *string {
+		if !s.Valid {
+			return nil
+		}
+		return &s.String
+	}
+	out := &model.CourtesyTicket{
+		RecipientName:  c.RecipientName,
+		RecipientEmail: nullStr(c.RecipientEmail),
+		Category:       nullStr(c.Category),
+		GuestList:      c.GuestList,
+		CreatedAt:      parseDateTimeToRFC3339(c.CreatedAt),
+	}
+	// The guest list is shown at the door: CPFs go out masked, like the holders'.
+	if c.RecipientCPF.Valid {
+		cpf := taxid.MaskCPF(c.RecipientCPF.String)
+		out.RecipientCpf = &cpf
+	}
+	if t, _ := repository.TicketByID(db, c.TicketID); t != nil {
+		out.Ticket, _ = staffTicketRowToModel(db, t)
+	}
+	return out
+}
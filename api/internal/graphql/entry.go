@@ -0,0 +1,28 @@
+package graphql
+
+import (
+	"database/sql"
+	"errors"
+
+	"afterzin/api/internal/graphql/model"
+	"afterzin/api/internal/repository"
+)
+
+// entryPolicyFromInput validates the entry policy of a ticket type (SINGLE when omitted);
+// maxEntries is required by MULTI and not accepted by the other policies.
+func entryPolicyFromInput(policy *model.EntryPolicy, maxEntries *int) (string, sql.NullInt64, error) {
+	p := repository.EntrySingle
+	if policy != nil {
+		p = string(*policy)
+	}
+	if p != repository.EntryMulti {
+		if maxEntries != nil {
+			return "", sql.NullInt64{}, errors.New("maxEntries só pode ser informado na política MULTI")
+		}
+		return p, sql.NullInt64{}, nil
+	}
+	if maxEntries == nil || *maxEntries < 2 {
+		return "", sql.NullInt64{}, errors.New("a política MULTI exige maxEntries de pelo menos 2 entradas")
+	}
+	return p, sql.NullInt64{Int64: int64(*maxEntries), Valid: true}, nil
+}
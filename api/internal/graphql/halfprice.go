@@ -0,0 +1,43 @@
+package graphql
+
+import (
+	"errors"
+	"strings"
+
+	"afterzin/api/internal/graphql/model"
+	"afterzin/api/internal/repository"
+)
+
+// maxHalfPriceDocumentLength bounds the proof document number typed at checkout.
+const maxHalfPriceDocumentLength = 40
+
+// validateHalfPriceDocuments checks the proof documents given at checkout for one item: one per
+// ticket for half-price types, none otherwise.
+func validateHalfPriceDocuments(tt *repository.TicketTypeRow, quantity int, in []string) ([]string, error) {
+	if !tt.HalfPriceEntitlement.Valid {
+		if len(in) > 0 {
+			return nil, errors.New("documentos de meia-entrada só podem ser informados para ingressos de meia")
+		}
+		return nil, nil
+	}
+	if len(in) != quantity {
+		return nil, errors.New("informe o número do documento comprobatório de cada ingresso de meia-entrada")
+	}
+	docs := make([]string, 0, len(in))
+	for _, d := range in {
+		d = strings.TrimSpace(d)
+		if d == "" || len(d) > maxHalfPriceDocumentLength {
+			return nil, errors.New("documento comprobatório de meia-entrada inválido")
+		}
+		docs = append(docs, d)
+	}
+	return docs, nil
+}
+
+func halfPriceEntitlementToModel(tt *repository.TicketTypeRow) *model.HalfPriceEntitlement {
+	if tt == nil || !tt.HalfPriceEntitlement.Valid {
+		return nil
+	}
+	e := model.HalfPriceEntitlement(tt.HalfPriceEntitlement.String)
+	return &e
+}
@@ -0,0 +1,104 @@
+package graphql
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"afterzin/api/internal/graphql/model"
+	"afterzin/api/internal/repository"
+	"afterzin/api/internal/taxid"
+)
+
+// validateTicketHolder normalizes and validates holder data (name, CPF and birth date).
+func validateTicketHolder(in *model.TicketHolderInput) (repository.TicketHolder, error) {
+	h := repository.TicketHolder{
+		Name:      strings.Join(strings.Fields(in.Name), " "),
+		CPF:       taxid.Digits(in.Cpf),
+		BirthDate: strings.TrimSpace(in.BirthDate),
+	}
+	if in.Gender != nil {
+		h.Gender = string(*in.Gender)
+	}
+	if len(h.Name) < 3 || len(h.Name) > 120 {
+		return h, errors.New("nome do titular inválido")
+	}
+	if !taxid.ValidCPF(h.CPF) {
+		return h, errors.New("CPF do titular inválido")
+	}
+	birth, err := time.Parse("2006-01-02", h.BirthDate)
+	if err != nil || birth.After(time.Now()) || birth.Year() < 1900 {
+		return h, errors.New("data de nascimento do titular inválida (use AAAA-MM-DD)")
+	}
+	return h, nil
+}
+
+// validateTicketHolders validates the holders given at checkout for one item: either none or
+// exactly one per ticket, and only for nominal ticket types.
+func validateTicketHolders(tt *repository.TicketTypeRow, quantity int, in []*model.TicketHolderInput) ([]repository.TicketHolder, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	if tt.Nominal == 0 {
+		return nil, errors.New("titulares só podem ser informados para ingressos nominais")
+	}
+	if len(in) != quantity {
+		return nil, errors.New("informe um titular para cada ingresso (ou nenhum e defina depois)")
+	}
+	holders := make([]repository.TicketHolder, 0, len(in))
+	for _, h := range in {
+		holder, err := validateTicketHolder(h)
+		if err != nil {
+			return nil, err
+		}
+		holders = append(holders, holder)
+	}
+	return holders, nil
+}
+
+// ticketHolderAssignable checks that the holder of the ticket can still be set or changed.
+func ticketHolderAssignable(db *sql.DB, t *repository.TicketRow) error {
+	if t.Used == 1 {
+		return errors.New("ingresso já utilizado não pode ter o titular alterado")
+	}
+	if _, status, _, _ := repository.OrderByID(db, t.OrderID); status != "PAID" {
+		return errors.New("ingresso de pedido não pago ou reembolsado não pode ter o titular alterado")
+	}
+	ev, _ := repository.EventByID(db, t.EventID)
+	if ev == nil {
+		return errors.New("evento não encontrado")
+	}
+	return checkDateCutoff(db, t.EventDateID, ev.HolderCutoffHours, "alterações de titular")
+}
+
+func ticketHolderToModel(h *repository.TicketHolder) *model.TicketHolder {
+	if h == nil {
+		return nil
+	}
+	out := &model.TicketHolder{Name: h.Name, Cpf: h.CPF, BirthDate: h.BirthDate}
+	if h.Gender != "" {
+		g := model.Gender(h.Gender)
+		out.Gender = &g
+	}
+	return out
+}
+
+// staffTicketHolderToModel is the holder as the event team sees it: the CPF masked (LGPD), enough
+// to match the middle digits against the document at the door.
+func staffTicketHolderToModel(h *repository.TicketHolder) *model.TicketHolder {
+	out := ticketHolderToModel(h)
+	if out != nil {
+		out.Cpf = taxid.MaskCPF(out.Cpf)
+	}
+	return out
+}
+
+// staffTicketRowToModel is ticketRowToModel for the event team, with the holder's CPF masked.
+func staffTicketRowToModel(db *sql.DB, t *repository.TicketRow) (*model.Ticket, error) {
+	ticket, err := ticketRowToModel(db, t)
+	if ticket != nil && ticket.Holder != nil {
+		ticket.Holder.Cpf = taxid.MaskCPF(ticket.Holder.Cpf)
+	}
+	return ticket, err
+}
@@ -0,0 +1,49 @@
+package graphql
+
+import (
+	"database/sql"
+	"errors"
+
+	"afterzin/api/internal/graphql/model"
+	"afterzin/api/internal/repository"
+)
+
+// purchaseLimitsFromInput converts the producer's limits; each set limit must be at least 1.
+func purchaseLimitsFromInput(in *model.PurchaseLimitsInput) (repository.PurchaseLimits, error) {
+	var l repository.PurchaseLimits
+	if in == nil {
+		return l, nil
+	}
+	for _, f := range []struct {
+		in  *int
+		out *sql.NullInt64
+	}{
+		{in.MaxPerOrder, &l.MaxPerOrder},
+		{in.MaxPerAccount, &l.MaxPerAccount},
+		{in.MaxPerCpf, &l.MaxPerCPF},
+	} {
+		if f.in == nil {
+			continue
+		}
+		if *f.in < 1 {
+			return l, errors.New("limites de compra devem ser de pelo menos 1 ingresso")
+		}
+		*f.out = sql.NullInt64{Int64: int64(*f.in), Valid: true}
+	}
+	return l, nil
+}
+
+func purchaseLimitsToModel(l repository.PurchaseLimits) *model.PurchaseLimits {
+	toInt := func(v sql.NullInt64) *int {
+		if !v.Valid {
+			return nil
+		}
+		n := int(v.Int64)
+		return &n
+	}
+	return &model.PurchaseLimits{
+		MaxPerOrder:   toInt(l.MaxPerOrder),
+		MaxPerAccount: toInt(l.MaxPerAccount),
+		MaxPerCpf:     toInt(l.MaxPerCPF),
+	}
+}
@@ -8,9 +8,96 @@ import (
 	"strconv"
 )
 
+// Registro da trilha de auditoria das ações administrativas.
+type AdminAuditLogEntry struct {
+	ID         string  `json:"id"`
+	Admin      *User   `json:"admin"`
+	Action     string  `json:"action"`
+	TargetType string  `json:"targetType"`
+	TargetID   *string `json:"targetId,omitempty"`
+	Details    *string `json:"details,omitempty"`
+	CreatedAt  string  `json:"createdAt"`
+}
+
+type AdminSearchInput struct {
+	Search *string `json:"search,omitempty"`
+	Status *string `json:"status,omitempty"`
+	Limit  *int    `json:"limit,omitempty"`
+	Offset *int    `json:"offset,omitempty"`
+}
+
+type AreaOccupancy struct {
+	// null para tipos de ingresso sem setor.
+	Area *EventArea `json:"area,omitempty"`
+	// Titulares dentro do setor agora (última leitura foi uma entrada).
+	Inside   int  `json:"inside"`
+	Total    int  `json:"total"`
+	Capacity *int `json:"capacity,omitempty"`
+	// inside / capacity em %, null sem limite.
+	OccupancyPercent *float64 `json:"occupancyPercent,omitempty"`
+	// Lotação atingida ou ultrapassada.
+	AtCapacity bool `json:"atCapacity"`
+}
+
+// Ingresso pago de uma data na lista de participantes (produtor e equipe MANAGER).
+type Attendee struct {
+	TicketID   string      `json:"ticketId"`
+	Code       string      `json:"code"`
+	TicketType *TicketType `json:"ticketType"`
+	// Quem entra com o ingresso: titular nominal, convidado da cortesia ou dono do ingresso.
+	Name string `json:"name"`
+	// Titular informado no ingresso nominal.
+	HolderName *string `json:"holderName,omitempty"`
+	// Quem pagou o pedido; null em cortesias.
+	BuyerName *string `json:"buyerName,omitempty"`
+	// CPF mascarado (LGPD): apenas os dígitos do meio, ex. ***.456.789-**.
+	Cpf         *string `json:"cpf,omitempty"`
+	Courtesy    bool    `json:"courtesy"`
+	PurchasedAt string  `json:"purchasedAt"`
+	// Entrou ao menos uma vez.
+	CheckedIn bool `json:"checkedIn"`
+	// Primeira entrada.
+	CheckedInAt  *string          `json:"checkedInAt,omitempty"`
+	CheckinState CheckinDirection `json:"checkinState"`
+}
+
+// Filtro da lista de participantes; search busca por nome, e-mail, código do ingresso ou CPF.
+type AttendeeFilter struct {
+	Search       *string `json:"search,omitempty"`
+	TicketTypeID *string `json:"ticketTypeId,omitempty"`
+	CheckedIn    *bool   `json:"checkedIn,omitempty"`
+	Limit        *int    `json:"limit,omitempty"`
+	Offset       *int    `json:"offset,omitempty"`
+}
+
+type AttendeePage struct {
+	Items []*Attendee `json:"items"`
+	// Total de participantes com o filtro, para a paginação.
+	Total int `json:"total"`
+}
+
 type AuthPayload struct {
+	// Access token JWT de curta duração (header Authorization: Bearer).
 	Token string `json:"token"`
-	User  *User  `json:"user"`
+	// Expiração do access token.
+	ExpiresAt string `json:"expiresAt"`
+	// Refresh token opaco e rotativo: cada uso em refreshToken devolve um novo.
+	RefreshToken string `json:"refreshToken"`
+	User         *User  `json:"user"`
+}
+
+// Números da portaria de uma data, atualizados a cada validação.
+type CheckinStats struct {
+	EventDateID  string                    `json:"eventDateId"`
+	Validated    int                       `json:"validated"`
+	Total        int                       `json:"total"`
+	ByTicketType []*TicketTypeCheckinStats `json:"byTicketType"`
+	ByGate       []*GateCheckinStats       `json:"byGate"`
+	// Ocupação atual de cada setor, para os limites de segurança.
+	ByArea []*AreaOccupancy `json:"byArea"`
+	// Validações por minuto na última hora (minutos sem validação são omitidos).
+	PerMinute []*MinuteCheckinStats `json:"perMinute"`
+	UpdatedAt string                `json:"updatedAt"`
 }
 
 type CheckoutInput struct {
@@ -21,6 +108,10 @@ type CheckoutItemInput struct {
 	EventDateID  string `json:"eventDateId"`
 	TicketTypeID string `json:"ticketTypeId"`
 	Quantity     int    `json:"quantity"`
+	// Titulares dos ingressos nominais, um por ingresso (opcional: podem ser informados depois com assignTicketHolder).
+	Holders []*TicketHolderInput `json:"holders,omitempty"`
+	// Número do documento comprobatório de cada ingresso de meia-entrada (obrigatório, um por ingresso).
+	HalfPriceDocuments []string `json:"halfPriceDocuments,omitempty"`
 }
 
 type CheckoutPayInput struct {
@@ -36,18 +127,46 @@ type CheckoutPayResult struct {
 }
 
 type CheckoutPreviewItem struct {
-	EventTitle     string  `json:"eventTitle"`
-	EventDate      string  `json:"eventDate"`
-	TicketTypeName string  `json:"ticketTypeName"`
-	Quantity       int     `json:"quantity"`
-	UnitPrice      float64 `json:"unitPrice"`
-	Subtotal       float64 `json:"subtotal"`
+	EventTitle     string `json:"eventTitle"`
+	EventDate      string `json:"eventDate"`
+	TicketTypeName string `json:"ticketTypeName"`
+	Quantity       int    `json:"quantity"`
+	// Preço por ingresso já com o desconto do cupom.
+	UnitPrice float64 `json:"unitPrice"`
+	Subtotal  float64 `json:"subtotal"`
+	Discount  float64 `json:"discount"`
 }
 
 type CheckoutPreviewResult struct {
-	CheckoutID string                 `json:"checkoutId"`
-	Total      float64                `json:"total"`
-	Items      []*CheckoutPreviewItem `json:"items"`
+	CheckoutID string  `json:"checkoutId"`
+	Total      float64 `json:"total"`
+	// Desconto do cupom já abatido do total.
+	Discount  float64                `json:"discount"`
+	PromoCode *string                `json:"promoCode,omitempty"`
+	Items     []*CheckoutPreviewItem `json:"items"`
+}
+
+type CourtesyRecipientInput struct {
+	Name string `json:"name"`
+	// Obrigatório fora da lista de convidados: a cortesia é enviada por e-mail e fica na conta com este e-mail, se houver.
+	Email *string `json:"email,omitempty"`
+	// CPF do convidado, para conferência na entrada.
+	Cpf *string `json:"cpf,omitempty"`
+	// Categoria livre: imprensa, artista, promoter...
+	Category *string `json:"category,omitempty"`
+}
+
+// Ingresso de cortesia emitido pelo produtor (pedido de valor zero), com o convidado.
+type CourtesyTicket struct {
+	Ticket         *Ticket `json:"ticket"`
+	RecipientName  string  `json:"recipientName"`
+	RecipientEmail *string `json:"recipientEmail,omitempty"`
+	// CPF do convidado, mascarado (***.456.789-**).
+	RecipientCpf *string `json:"recipientCpf,omitempty"`
+	Category     *string `json:"category,omitempty"`
+	// Entrada pela busca do nome na lista de convidados (checkInGuest) em vez do QR Code.
+	GuestList bool   `json:"guestList"`
+	CreatedAt string `json:"createdAt"`
 }
 
 type CreateEventInput struct {
@@ -57,20 +176,82 @@ type CreateEventInput struct {
 	CoverImage  string  `json:"coverImage"`
 	Location    string  `json:"location"`
 	Address     *string `json:"address,omitempty"`
+	MinAge      *int    `json:"minAge,omitempty"`
+}
+
+// Período em dias do horário de Brasília, inclusivo; sem from ou to, o período fica aberto.
+type DateRangeInput struct {
+	From *string `json:"from,omitempty"`
+	To   *string `json:"to,omitempty"`
+}
+
+// QR Code dinâmico do ingresso. O app mostra payload até expiresAt e depois gera o próximo sem conexão: a cada janela
+// de period segundos, "D2:" + ticketId + ":" + janela + "." + HMAC-SHA256 em hex de ticketId + ":" + janela com o secret
+// + "." + o mesmo HMAC com o offlineSecret, sendo janela o Unix time dividido por period. A portaria online confere a
+// primeira assinatura; os aparelhos offline só recebem o offlineSecret e conferem a segunda.
+type DynamicQRCode struct {
+	// Segredo do ingresso (hex); muda quando o QR Code é reemitido numa transferência ou revenda.
+	Secret string `json:"secret"`
+	// Segredo da assinatura offline (hex), derivado do secret.
+	OfflineSecret string `json:"offlineSecret"`
+	Period        int    `json:"period"`
+	Payload       string `json:"payload"`
+	ExpiresAt     string `json:"expiresAt"`
 }
 
 type Event struct {
-	ID          string       `json:"id"`
-	Title       string       `json:"title"`
-	Description string       `json:"description"`
-	Category    string       `json:"category"`
-	CoverImage  string       `json:"coverImage"`
-	Location    string       `json:"location"`
-	Address     *string      `json:"address,omitempty"`
-	Status      EventStatus  `json:"status"`
-	Dates       []*EventDate `json:"dates"`
-	Producer    *Producer    `json:"producer"`
-	Featured    *bool        `json:"featured,omitempty"`
+	ID             string       `json:"id"`
+	Title          string       `json:"title"`
+	Description    string       `json:"description"`
+	Category       string       `json:"category"`
+	CoverImage     string       `json:"coverImage"`
+	Location       string       `json:"location"`
+	Address        *string      `json:"address,omitempty"`
+	Status         EventStatus  `json:"status"`
+	Dates          []*EventDate `json:"dates"`
+	Producer       *Producer    `json:"producer"`
+	Featured       *bool        `json:"featured,omitempty"`
+	RemovedReason  *string      `json:"removedReason,omitempty"`
+	ModerationNote *string      `json:"moderationNote,omitempty"`
+	// Idade mínima na data do evento (0 = livre), verificada no checkout. Acima de 11 anos só sem ingressos infantis.
+	MinAge int `json:"minAge"`
+	// Limites de compra somando todos os tipos de ingresso do evento.
+	PurchaseLimits *PurchaseLimits `json:"purchaseLimits"`
+	// Se os compradores podem transferir ingressos deste evento para outras pessoas.
+	TransfersEnabled bool `json:"transfersEnabled"`
+	// Transferências ficam bloqueadas nas N horas antes do início de cada data (0 = até o início).
+	TransferCutoffHours int `json:"transferCutoffHours"`
+	// Se a revenda oficial está liberada para este evento.
+	ResaleEnabled bool `json:"resaleEnabled"`
+	// Teto do preço de revenda acima do valor de face, em % (10 = até +10%).
+	ResaleMaxMarkupPercent float64 `json:"resaleMaxMarkupPercent"`
+	// Percentual do preço de revenda repassado ao produtor.
+	ResaleRoyaltyPercent float64 `json:"resaleRoyaltyPercent"`
+	// Titulares de ingressos nominais podem ser informados ou trocados até N horas antes do início de cada data.
+	HolderCutoffHours int `json:"holderCutoffHours"`
+	// Quantidade máxima de cortesias do evento (0 = sem cortesias).
+	CourtesyQuota  int `json:"courtesyQuota"`
+	CourtesyIssued int `json:"courtesyIssued"`
+	// O app mostra um QR Code dinâmico, que muda a cada 30 segundos (Ticket.dynamicQr), para impedir o repasse de prints.
+	DynamicQREnabled bool `json:"dynamicQrEnabled"`
+	// Com o QR dinâmico ligado, se o QR Code fixo (PDF impresso, e-mail, carteiras) ainda é aceito na portaria.
+	StaticQRFallback bool `json:"staticQrFallback"`
+	// Setores do evento (pista, camarote, VIP...).
+	Areas []*EventArea `json:"areas"`
+}
+
+// Setor do evento; cada tipo de ingresso dá acesso a um setor.
+type EventArea struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Lotação máxima de segurança (null = sem limite).
+	Capacity *int `json:"capacity,omitempty"`
+}
+
+type EventAreaInput struct {
+	Name string `json:"name"`
+	// Lotação máxima de segurança; null ou omitido = sem limite.
+	Capacity *int `json:"capacity,omitempty"`
 }
 
 type EventDate struct {
@@ -80,6 +261,10 @@ type EventDate struct {
 	StartTime *string `json:"startTime,omitempty"`
 	EndTime   *string `json:"endTime,omitempty"`
 	Lots      []*Lot  `json:"lots"`
+	// Cota legal de meia-entrada da data (40% da capacidade somada dos lotes).
+	HalfPriceQuota int          `json:"halfPriceQuota"`
+	HalfPriceSold  int          `json:"halfPriceSold"`
+	Gates          []*EventGate `json:"gates"`
 }
 
 type EventDateInput struct {
@@ -94,6 +279,47 @@ type EventFilter struct {
 	City     *string `json:"city,omitempty"`
 }
 
+// Portão de uma data. Sem setores, aceita qualquer ingresso; com setores, só os ingressos desses setores.
+type EventGate struct {
+	ID          string       `json:"id"`
+	EventDateID string       `json:"eventDateId"`
+	Name        string       `json:"name"`
+	Areas       []*EventArea `json:"areas"`
+}
+
+type EventGateInput struct {
+	Name string `json:"name"`
+	// Setores admitidos; vazio = todos os ingressos.
+	AreaIds []string `json:"areaIds,omitempty"`
+}
+
+type EventSalesSummary struct {
+	Event   *Event        `json:"event"`
+	Summary *SalesSummary `json:"summary"`
+}
+
+// Membro da equipe do evento, convidado pelo produtor.
+type EventStaff struct {
+	ID     string    `json:"id"`
+	Event  *Event    `json:"event"`
+	UserID string    `json:"userId"`
+	Name   string    `json:"name"`
+	Email  string    `json:"email"`
+	Role   StaffRole `json:"role"`
+	// false depois de revogado ou do dia seguinte à última data do evento.
+	Active bool `json:"active"`
+	// Ingressos do evento validados por este usuário.
+	ValidationsCount int     `json:"validationsCount"`
+	CreatedAt        string  `json:"createdAt"`
+	RevokedAt        *string `json:"revokedAt,omitempty"`
+}
+
+type GateCheckinStats struct {
+	// null para validações sem portão informado.
+	Gate      *string `json:"gate,omitempty"`
+	Validated int     `json:"validated"`
+}
+
 type LoginInput struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
@@ -117,14 +343,120 @@ type LotInput struct {
 	TotalQuantity int    `json:"totalQuantity"`
 }
 
+type LotSales struct {
+	Lot         *Lot    `json:"lot"`
+	TicketsSold int     `json:"ticketsSold"`
+	Revenue     float64 `json:"revenue"`
+	// total_quantity do lote.
+	Capacity    int      `json:"capacity"`
+	SellThrough *float64 `json:"sellThrough,omitempty"`
+}
+
+type MinuteCheckinStats struct {
+	Minute    string `json:"minute"`
+	Validated int    `json:"validated"`
+}
+
 type Mutation struct {
 }
 
+type OfflineCheckinResult struct {
+	ScanID   string               `json:"scanId"`
+	TicketID string               `json:"ticketId"`
+	Status   OfflineCheckinStatus `json:"status"`
+	// Em REJECTED: NOT_FOUND, WRONG_EVENT, INVALID_QR (qrCode não é do ingresso), QR_EXPIRED, QR_REISSUED,
+	// STATIC_QR_NOT_ALLOWED, DYNAMIC_QR_NOT_ALLOWED, GATE_REQUIRED, WRONG_GATE, WRONG_AREA ou REVERTED (entrada desfeita
+	// depois em revertTicketValidation). Em DUPLICATE, a recusa da política de entrada do
+	// tipo de ingresso no dia da leitura: ALREADY_USED, ALREADY_INSIDE, ALREADY_USED_TODAY ou NO_ENTRIES_LEFT.
+	ErrorCode *string `json:"errorCode,omitempty"`
+	// Em DUPLICATE: horário e portão da leitura que deu entrada.
+	AcceptedAt   *string `json:"acceptedAt,omitempty"`
+	AcceptedGate *string `json:"acceptedGate,omitempty"`
+}
+
+// Manifest de check-in offline de uma data, cifrada (AES-256-GCM) e assinada (HMAC-SHA256) com chaves
+// derivadas da chave do aparelho. Contém, por ingresso, o SHA-256 do QR Code atual, tipo, titular e se já foi usado.
+type OfflineManifest struct {
+	Version     int    `json:"version"`
+	DeviceID    string `json:"deviceId"`
+	EventDateID string `json:"eventDateId"`
+	IssuedAt    string `json:"issuedAt"`
+	// Base64.
+	Nonce string `json:"nonce"`
+	// Base64.
+	Ciphertext string `json:"ciphertext"`
+	// Hex.
+	Signature   string `json:"signature"`
+	TicketCount int    `json:"ticketCount"`
+}
+
+// Leitura feita sem conexão.
+type OfflineScanInput struct {
+	// Gerado pelo aparelho; reenviar a mesma leitura não duplica o registro.
+	ScanID   string `json:"scanId"`
+	TicketID string `json:"ticketId"`
+	// Conteúdo lido do QR Code (fixo ou dinâmico), conferido de novo na sincronização.
+	QRCode    string  `json:"qrCode"`
+	ScannedAt string  `json:"scannedAt"`
+	Gate      *string `json:"gate,omitempty"`
+	// Portão cadastrado da leitura; obrigatório em eventos com setores, como em validateTicket.
+	GateID *string `json:"gateId,omitempty"`
+}
+
+// Pedido (visão administrativa).
+type Order struct {
+	ID              string  `json:"id"`
+	User            *User   `json:"user"`
+	Status          string  `json:"status"`
+	Total           float64 `json:"total"`
+	CreatedAt       string  `json:"createdAt"`
+	PagarmeOrderID  *string `json:"pagarmeOrderId,omitempty"`
+	PagarmeChargeID *string `json:"pagarmeChargeId,omitempty"`
+}
+
 type Producer struct {
-	ID          string  `json:"id"`
-	User        *User   `json:"user"`
-	CompanyName *string `json:"companyName,omitempty"`
-	Approved    bool    `json:"approved"`
+	ID              string         `json:"id"`
+	User            *User          `json:"user"`
+	CompanyName     *string        `json:"companyName,omitempty"`
+	Approved        bool           `json:"approved"`
+	Status          ProducerStatus `json:"status"`
+	RejectionReason *string        `json:"rejectionReason,omitempty"`
+}
+
+// Solicitação de cadastro como produtor (visível ao próprio produtor e à administração).
+type ProducerApplication struct {
+	Producer        *Producer           `json:"producer"`
+	Status          ProducerStatus      `json:"status"`
+	CompanyName     *string             `json:"companyName,omitempty"`
+	Cnpj            *string             `json:"cnpj,omitempty"`
+	ContactName     *string             `json:"contactName,omitempty"`
+	ContactEmail    *string             `json:"contactEmail,omitempty"`
+	ContactPhone    *string             `json:"contactPhone,omitempty"`
+	Documents       []*ProducerDocument `json:"documents"`
+	RejectionReason *string             `json:"rejectionReason,omitempty"`
+	SubmittedAt     *string             `json:"submittedAt,omitempty"`
+	ReviewedAt      *string             `json:"reviewedAt,omitempty"`
+}
+
+type ProducerApplicationInput struct {
+	CompanyName  string                   `json:"companyName"`
+	Cnpj         string                   `json:"cnpj"`
+	ContactName  string                   `json:"contactName"`
+	ContactEmail string                   `json:"contactEmail"`
+	ContactPhone string                   `json:"contactPhone"`
+	Documents    []*ProducerDocumentInput `json:"documents"`
+}
+
+type ProducerDocument struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	URL  string `json:"url"`
+}
+
+type ProducerDocumentInput struct {
+	Kind string `json:"kind"`
+	// URL ou data URI (base64) do arquivo.
+	URL string `json:"url"`
 }
 
 // Perfil público do produtor: dados do produtor + eventos publicados (excl. rascunho).
@@ -133,15 +465,168 @@ type ProducerPublicProfile struct {
 	Events   []*Event  `json:"events"`
 }
 
+// Cupom de desconto do evento, gerenciado pelo produtor.
+type PromoCode struct {
+	ID    string `json:"id"`
+	Event *Event `json:"event"`
+	Code  string `json:"code"`
+	// Tipo de ingresso em que vale; null vale para todos do evento.
+	TicketType     *TicketType       `json:"ticketType,omitempty"`
+	DiscountType   PromoDiscountType `json:"discountType"`
+	DiscountValue  float64           `json:"discountValue"`
+	MaxUses        *int              `json:"maxUses,omitempty"`
+	MaxUsesPerUser *int              `json:"maxUsesPerUser,omitempty"`
+	ValidFrom      *string           `json:"validFrom,omitempty"`
+	ValidUntil     *string           `json:"validUntil,omitempty"`
+	Active         bool              `json:"active"`
+	// Pedidos pagos com o cupom.
+	UsedCount int `json:"usedCount"`
+	// Desconto total concedido nos pedidos pagos.
+	DiscountTotal float64 `json:"discountTotal"`
+	CreatedAt     string  `json:"createdAt"`
+}
+
+type PromoCodeInput struct {
+	Code           string            `json:"code"`
+	TicketTypeID   *string           `json:"ticketTypeId,omitempty"`
+	DiscountType   PromoDiscountType `json:"discountType"`
+	DiscountValue  float64           `json:"discountValue"`
+	MaxUses        *int              `json:"maxUses,omitempty"`
+	MaxUsesPerUser *int              `json:"maxUsesPerUser,omitempty"`
+	ValidFrom      *string           `json:"validFrom,omitempty"`
+	ValidUntil     *string           `json:"validUntil,omitempty"`
+	// Padrão: true.
+	Active *bool `json:"active,omitempty"`
+}
+
+// Uso do cupom em um pedido (conta nos limites e relatórios quando o pedido é pago).
+type PromoCodeRedemption struct {
+	ID          string  `json:"id"`
+	OrderID     string  `json:"orderId"`
+	User        *User   `json:"user"`
+	OrderStatus string  `json:"orderStatus"`
+	Discount    float64 `json:"discount"`
+	CreatedAt   string  `json:"createdAt"`
+}
+
+// Limites de compra definidos pelo produtor (null = sem limite). Por CPF conta o titular do ingresso nominal ou, sem titular, o CPF do comprador.
+type PurchaseLimits struct {
+	MaxPerOrder   *int `json:"maxPerOrder,omitempty"`
+	MaxPerAccount *int `json:"maxPerAccount,omitempty"`
+	MaxPerCpf     *int `json:"maxPerCpf,omitempty"`
+}
+
+// Substitui todos os limites; campo null ou omitido remove o limite.
+type PurchaseLimitsInput struct {
+	MaxPerOrder   *int `json:"maxPerOrder,omitempty"`
+	MaxPerAccount *int `json:"maxPerAccount,omitempty"`
+	MaxPerCpf     *int `json:"maxPerCpf,omitempty"`
+}
+
 type Query struct {
 }
 
 type RegisterInput struct {
-	Name      string `json:"name"`
-	Email     string `json:"email"`
-	Password  string `json:"password"`
-	Cpf       string `json:"cpf"`
-	BirthDate string `json:"birthDate"`
+	Name      string  `json:"name"`
+	Email     string  `json:"email"`
+	Password  string  `json:"password"`
+	Cpf       string  `json:"cpf"`
+	BirthDate string  `json:"birthDate"`
+	Gender    *Gender `json:"gender,omitempty"`
+}
+
+// Anúncio da revenda oficial. Valores do split fixados na criação do anúncio.
+type ResaleListing struct {
+	ID         string      `json:"id"`
+	Event      *Event      `json:"event"`
+	EventDate  *EventDate  `json:"eventDate"`
+	TicketType *TicketType `json:"ticketType"`
+	Price      float64     `json:"price"`
+	// Valor pago pelo ingresso na compra original (base do teto de revenda).
+	FaceValue float64 `json:"faceValue"`
+	// Valor líquido do vendedor (preço - taxa da plataforma - repasse ao produtor).
+	SellerAmount  float64             `json:"sellerAmount"`
+	RoyaltyAmount float64             `json:"royaltyAmount"`
+	PlatformFee   float64             `json:"platformFee"`
+	Status        ResaleListingStatus `json:"status"`
+	// true enquanto um comprador está com o pagamento em andamento.
+	Reserved  bool    `json:"reserved"`
+	CreatedAt string  `json:"createdAt"`
+	SoldAt    *string `json:"soldAt,omitempty"`
+}
+
+// Painel de vendas de um evento. Vendas são pedidos pagos, no dia do pagamento (horário de Brasília), sem
+// cortesias nem revendas; pedidos estornados saem das vendas.
+type SalesAnalytics struct {
+	Event   *Event        `json:"event"`
+	Summary *SalesSummary `json:"summary"`
+	// Dias com vendas ou checkouts no período, do mais antigo ao mais recente.
+	Daily []*SalesDay `json:"daily"`
+	// Todos os tipos de ingresso do evento, com as vendas no período.
+	ByTicketType []*TicketTypeSales `json:"byTicketType"`
+	ByLot        []*LotSales        `json:"byLot"`
+}
+
+type SalesDay struct {
+	Day         string  `json:"day"`
+	Revenue     float64 `json:"revenue"`
+	TicketsSold int     `json:"ticketsSold"`
+	PaidOrders  int     `json:"paidOrders"`
+	Previews    int     `json:"previews"`
+}
+
+type SalesSummary struct {
+	Revenue     float64 `json:"revenue"`
+	TicketsSold int     `json:"ticketsSold"`
+	PaidOrders  int     `json:"paidOrders"`
+	// Pedidos criados no checkout (checkoutPreview) no período.
+	Previews int `json:"previews"`
+	// Fração das prévias do período que foram pagas (0 a 1); null sem prévias.
+	ConversionRate *float64 `json:"conversionRate,omitempty"`
+	// Receita por pedido pago; null sem pedidos.
+	AverageOrderValue *float64 `json:"averageOrderValue,omitempty"`
+	// Soma de total_quantity dos lotes do evento.
+	Capacity int `json:"capacity"`
+	// Ingressos vendidos no período sobre a capacidade (0 a 1); null sem capacidade.
+	SellThrough *float64 `json:"sellThrough,omitempty"`
+	// Ingressos emitidos (vendas, cortesias e revendas) e quantos já entraram; não dependem do período.
+	TicketsIssued int      `json:"ticketsIssued"`
+	CheckedIn     int      `json:"checkedIn"`
+	CheckinRate   *float64 `json:"checkinRate,omitempty"`
+}
+
+// Aparelho de portaria registrado para o check-in offline.
+type ScannerDevice struct {
+	ID    string `json:"id"`
+	Event *Event `json:"event"`
+	Name  string `json:"name"`
+	// Nome de quem registrou; só essa pessoa baixa a manifest e sincroniza pelo aparelho.
+	RegisteredBy string  `json:"registeredBy"`
+	CreatedAt    string  `json:"createdAt"`
+	LastSyncAt   *string `json:"lastSyncAt,omitempty"`
+	RevokedAt    *string `json:"revokedAt,omitempty"`
+}
+
+type ScannerDeviceRegistration struct {
+	Device *ScannerDevice `json:"device"`
+	// Chave do aparelho (hex, 256 bits) para abrir a manifest. Exibida apenas no registro.
+	DeviceKey string `json:"deviceKey"`
+}
+
+// Sessão (dispositivo) autenticada do usuário.
+type Session struct {
+	ID         string  `json:"id"`
+	UserAgent  *string `json:"userAgent,omitempty"`
+	IPAddress  *string `json:"ipAddress,omitempty"`
+	CreatedAt  string  `json:"createdAt"`
+	LastUsedAt string  `json:"lastUsedAt"`
+	ExpiresAt  string  `json:"expiresAt"`
+	Current    bool    `json:"current"`
+}
+
+// Assinaturas via WebSocket em /graphql (protocolos graphql-ws e graphql-transport-ws). O token vai no
+// payload de connection_init: {"Authorization": "Bearer <token>"}.
+type Subscription struct {
 }
 
 type Ticket struct {
@@ -153,8 +638,59 @@ type Ticket struct {
 	TicketType *TicketType `json:"ticketType"`
 	Owner      *User       `json:"owner"`
 	Used       bool        `json:"used"`
-	UsedAt     *string     `json:"usedAt,omitempty"`
-	CreatedAt  string      `json:"createdAt"`
+	// Horário da primeira entrada.
+	UsedAt *string `json:"usedAt,omitempty"`
+	// Onde o titular está agora: IN depois de uma entrada, OUT antes dela ou depois de uma saída.
+	CheckinState CheckinDirection `json:"checkinState"`
+	EntryCount   int              `json:"entryCount"`
+	CreatedAt    string           `json:"createdAt"`
+	// Link assinado e temporário para o PDF do ingresso (apenas para o dono do ingresso).
+	PDFURL *string `json:"pdfUrl,omitempty"`
+	// Link assinado e temporário do passe .pkpass do Apple Wallet (apenas para o dono; null se não configurado).
+	ApplePassURL *string `json:"applePassUrl,omitempty"`
+	// Link "Salvar no Google Wallet" (apenas para o dono; null se não configurado).
+	GoogleWalletURL *string `json:"googleWalletUrl,omitempty"`
+	// QR Code dinâmico (apenas para o dono; null quando o evento não usa QR dinâmico).
+	DynamicQR *DynamicQRCode `json:"dynamicQr,omitempty"`
+	// Titular do ingresso nominal (null enquanto não informado).
+	Holder *TicketHolder `json:"holder,omitempty"`
+	// true quando o ingresso é nominal e ainda não tem titular; sem titular ele é recusado na portaria.
+	HolderRequired bool `json:"holderRequired"`
+	// Número do documento comprobatório da meia-entrada informado na compra.
+	HalfPriceDocument *string `json:"halfPriceDocument,omitempty"`
+}
+
+// Titular de ingresso nominal.
+type TicketHolder struct {
+	Name string `json:"name"`
+	// CPF, apenas dígitos; para a equipe do evento, mascarado (***.456.789-**).
+	Cpf       string  `json:"cpf"`
+	BirthDate string  `json:"birthDate"`
+	Gender    *Gender `json:"gender,omitempty"`
+}
+
+type TicketHolderInput struct {
+	Name      string `json:"name"`
+	Cpf       string `json:"cpf"`
+	BirthDate string `json:"birthDate"`
+	// Obrigatório em ingressos exclusivos de um gênero.
+	Gender *Gender `json:"gender,omitempty"`
+}
+
+// Oferta de transferência de ingresso (histórico de titularidade).
+type TicketTransfer struct {
+	ID             string               `json:"id"`
+	TicketID       string               `json:"ticketId"`
+	Event          *Event               `json:"event"`
+	EventDate      *EventDate           `json:"eventDate"`
+	TicketType     *TicketType          `json:"ticketType"`
+	FromName       string               `json:"fromName"`
+	RecipientEmail string               `json:"recipientEmail"`
+	Status         TicketTransferStatus `json:"status"`
+	// true quando a transferência foi recebida pelo usuário atual (e não enviada).
+	Incoming    bool    `json:"incoming"`
+	CreatedAt   string  `json:"createdAt"`
+	RespondedAt *string `json:"respondedAt,omitempty"`
 }
 
 type TicketType struct {
@@ -165,6 +701,26 @@ type TicketType struct {
 	Audience     AudienceType `json:"audience"`
 	MaxQuantity  int          `json:"maxQuantity"`
 	SoldQuantity int          `json:"soldQuantity"`
+	// Ingresso nominal: cada ingresso precisa de titular (nome, CPF e nascimento) conferido na portaria.
+	Nominal bool `json:"nominal"`
+	// Meia-entrada: categoria do benefício (null = inteira). Exige documento comprobatório na compra e na portaria.
+	HalfPriceEntitlement *HalfPriceEntitlement `json:"halfPriceEntitlement,omitempty"`
+	// Idade máxima na data do evento (ingresso infantil), null sem limite.
+	MaxAge *int `json:"maxAge,omitempty"`
+	// Descrição da restrição de público do tipo (ex.: exclusivo para o público feminino), null quando livre.
+	Restriction    *string         `json:"restriction,omitempty"`
+	PurchaseLimits *PurchaseLimits `json:"purchaseLimits"`
+	EntryPolicy    EntryPolicy     `json:"entryPolicy"`
+	// Número máximo de entradas em MULTI (null nas demais políticas).
+	MaxEntries *int `json:"maxEntries,omitempty"`
+	// Setor a que o ingresso dá acesso (null = sem setor).
+	Area *EventArea `json:"area,omitempty"`
+}
+
+type TicketTypeCheckinStats struct {
+	TicketType *TicketType `json:"ticketType"`
+	Validated  int         `json:"validated"`
+	Total      int         `json:"total"`
 }
 
 type TicketTypeInput struct {
@@ -173,34 +729,112 @@ type TicketTypeInput struct {
 	Price       float64      `json:"price"`
 	Audience    AudienceType `json:"audience"`
 	MaxQuantity int          `json:"maxQuantity"`
+	// Ingressos infantis (CHILD) são sempre nominais.
+	Nominal              *bool                 `json:"nominal,omitempty"`
+	HalfPriceEntitlement *HalfPriceEntitlement `json:"halfPriceEntitlement,omitempty"`
+	PurchaseLimits       *PurchaseLimitsInput  `json:"purchaseLimits,omitempty"`
+	// Padrão SINGLE.
+	EntryPolicy *EntryPolicy `json:"entryPolicy,omitempty"`
+	// Obrigatório em MULTI (mínimo 2).
+	MaxEntries *int `json:"maxEntries,omitempty"`
+	// Setor do evento (createEventArea).
+	AreaID *string `json:"areaId,omitempty"`
+}
+
+type TicketTypeSales struct {
+	TicketType  *TicketType `json:"ticketType"`
+	TicketsSold int         `json:"ticketsSold"`
+	Revenue     float64     `json:"revenue"`
+	// max_quantity do tipo de ingresso.
+	Capacity    int      `json:"capacity"`
+	SellThrough *float64 `json:"sellThrough,omitempty"`
+}
+
+// Leitura registrada na portaria (entrada ou saída).
+type TicketValidation struct {
+	ID     string  `json:"id"`
+	Ticket *Ticket `json:"ticket"`
+	// Nome de quem validou (produtor ou equipe).
+	ValidatedBy *string          `json:"validatedBy,omitempty"`
+	Direction   CheckinDirection `json:"direction"`
+	Gate        *string          `json:"gate,omitempty"`
+	// Leitura feita sem conexão e enviada por syncCheckins.
+	Offline bool `json:"offline"`
+	// Entrada manual (manualCheckin), sem leitura do QR Code.
+	Manual bool `json:"manual"`
+	// Motivo informado na entrada manual.
+	Reason      *string `json:"reason,omitempty"`
+	ValidatedAt string  `json:"validatedAt"`
+	// Preenchidos quando a leitura foi desfeita por revertTicketValidation.
+	RevertedAt   *string `json:"revertedAt,omitempty"`
+	RevertedBy   *string `json:"revertedBy,omitempty"`
+	RevertReason *string `json:"revertReason,omitempty"`
 }
 
 type UpdateEventInput struct {
-	Title       *string `json:"title,omitempty"`
-	Description *string `json:"description,omitempty"`
-	Category    *string `json:"category,omitempty"`
-	CoverImage  *string `json:"coverImage,omitempty"`
-	Location    *string `json:"location,omitempty"`
-	Address     *string `json:"address,omitempty"`
+	Title                  *string              `json:"title,omitempty"`
+	Description            *string              `json:"description,omitempty"`
+	Category               *string              `json:"category,omitempty"`
+	CoverImage             *string              `json:"coverImage,omitempty"`
+	Location               *string              `json:"location,omitempty"`
+	Address                *string              `json:"address,omitempty"`
+	TransfersEnabled       *bool                `json:"transfersEnabled,omitempty"`
+	TransferCutoffHours    *int                 `json:"transferCutoffHours,omitempty"`
+	ResaleEnabled          *bool                `json:"resaleEnabled,omitempty"`
+	ResaleMaxMarkupPercent *float64             `json:"resaleMaxMarkupPercent,omitempty"`
+	ResaleRoyaltyPercent   *float64             `json:"resaleRoyaltyPercent,omitempty"`
+	HolderCutoffHours      *int                 `json:"holderCutoffHours,omitempty"`
+	MinAge                 *int                 `json:"minAge,omitempty"`
+	PurchaseLimits         *PurchaseLimitsInput `json:"purchaseLimits,omitempty"`
+	CourtesyQuota          *int                 `json:"courtesyQuota,omitempty"`
+	DynamicQREnabled       *bool                `json:"dynamicQrEnabled,omitempty"`
+	StaticQRFallback       *bool                `json:"staticQrFallback,omitempty"`
 }
 
 type User struct {
-	ID        string   `json:"id"`
-	Name      string   `json:"name"`
-	Email     string   `json:"email"`
-	Cpf       string   `json:"cpf"`
-	BirthDate string   `json:"birthDate"`
-	PhotoURL  *string  `json:"photoUrl,omitempty"`
-	Role      UserRole `json:"role"`
-	CreatedAt string   `json:"createdAt"`
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Email         string   `json:"email"`
+	EmailVerified bool     `json:"emailVerified"`
+	Cpf           string   `json:"cpf"`
+	BirthDate     string   `json:"birthDate"`
+	Gender        *Gender  `json:"gender,omitempty"`
+	PhotoURL      *string  `json:"photoUrl,omitempty"`
+	Role          UserRole `json:"role"`
+	CreatedAt     string   `json:"createdAt"`
+	BlockedAt     *string  `json:"blockedAt,omitempty"`
+	BlockedReason *string  `json:"blockedReason,omitempty"`
 }
 
 // Resultado da validação de ingresso por QR Code.
 type ValidateTicketResult struct {
-	Success   bool    `json:"success"`
-	Ticket    *Ticket `json:"ticket,omitempty"`
-	ErrorCode *string `json:"errorCode,omitempty"`
-	Message   *string `json:"message,omitempty"`
+	Success bool    `json:"success"`
+	Ticket  *Ticket `json:"ticket,omitempty"`
+	// Titular do ingresso nominal, para conferência com o documento.
+	Holder *TicketHolder `json:"holder,omitempty"`
+	// Preenchidos em ingresso de meia-entrada: a equipe deve pedir o comprovante.
+	HalfPriceEntitlement *HalfPriceEntitlement `json:"halfPriceEntitlement,omitempty"`
+	HalfPriceDocument    *string               `json:"halfPriceDocument,omitempty"`
+	// Leitura registrada, para desfazer com revertTicketValidation.
+	ValidationID *string `json:"validationId,omitempty"`
+	// Sentido da leitura e, em caso de sucesso, total de entradas do ingresso.
+	Direction  *CheckinDirection `json:"direction,omitempty"`
+	EntryCount *int              `json:"entryCount,omitempty"`
+	// Entradas restantes em MULTI e SINGLE; null quando ilimitadas (REENTRY) ou por dia (DAILY).
+	EntriesRemaining *int    `json:"entriesRemaining,omitempty"`
+	ErrorCode        *string `json:"errorCode,omitempty"`
+	Message          *string `json:"message,omitempty"`
+}
+
+// Evento de webhook recebido do provedor de pagamento.
+type WebhookEventLog struct {
+	ID           string  `json:"id"`
+	Provider     string  `json:"provider"`
+	ExternalID   string  `json:"externalId"`
+	EventType    string  `json:"eventType"`
+	Processed    bool    `json:"processed"`
+	ErrorMessage *string `json:"errorMessage,omitempty"`
+	CreatedAt    string  `json:"createdAt"`
 }
 
 type AudienceType string
@@ -248,25 +882,121 @@ func (e AudienceType) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
+// Sentido da leitura na portaria.
+type CheckinDirection string
+
+const (
+	CheckinDirectionIn  CheckinDirection = "IN"
+	CheckinDirectionOut CheckinDirection = "OUT"
+)
+
+var AllCheckinDirection = []CheckinDirection{
+	CheckinDirectionIn,
+	CheckinDirectionOut,
+}
+
+func (e CheckinDirection) IsValid() bool {
+	switch e {
+	case CheckinDirectionIn, CheckinDirectionOut:
+		return true
+	}
+	return false
+}
+
+func (e CheckinDirection) String() string {
+	return string(e)
+}
+
+func (e *CheckinDirection) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CheckinDirection(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CheckinDirection", str)
+	}
+	return nil
+}
+
+func (e CheckinDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// Quantas vezes um ingresso do tipo pode entrar no evento.
+type EntryPolicy string
+
+const (
+	// Uma única entrada (padrão).
+	EntryPolicySingle EntryPolicy = "SINGLE"
+	// Sai e volta quantas vezes quiser, sempre registrando a saída antes de entrar de novo.
+	EntryPolicyReentry EntryPolicy = "REENTRY"
+	// Uma entrada por dia (passaporte de festival).
+	EntryPolicyDaily EntryPolicy = "DAILY"
+	// Até maxEntries entradas.
+	EntryPolicyMulti EntryPolicy = "MULTI"
+)
+
+var AllEntryPolicy = []EntryPolicy{
+	EntryPolicySingle,
+	EntryPolicyReentry,
+	EntryPolicyDaily,
+	EntryPolicyMulti,
+}
+
+func (e EntryPolicy) IsValid() bool {
+	switch e {
+	case EntryPolicySingle, EntryPolicyReentry, EntryPolicyDaily, EntryPolicyMulti:
+		return true
+	}
+	return false
+}
+
+func (e EntryPolicy) String() string {
+	return string(e)
+}
+
+func (e *EntryPolicy) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = EntryPolicy(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid EntryPolicy", str)
+	}
+	return nil
+}
+
+func (e EntryPolicy) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
 type EventStatus string
 
 const (
 	EventStatusDraft     EventStatus = "DRAFT"
+	EventStatusInReview  EventStatus = "IN_REVIEW"
 	EventStatusPublished EventStatus = "PUBLISHED"
 	EventStatusPaused    EventStatus = "PAUSED"
 	EventStatusEnded     EventStatus = "ENDED"
+	EventStatusRemoved   EventStatus = "REMOVED"
 )
 
 var AllEventStatus = []EventStatus{
 	EventStatusDraft,
+	EventStatusInReview,
 	EventStatusPublished,
 	EventStatusPaused,
 	EventStatusEnded,
+	EventStatusRemoved,
 }
 
 func (e EventStatus) IsValid() bool {
 	switch e {
-	case EventStatusDraft, EventStatusPublished, EventStatusPaused, EventStatusEnded:
+	case EventStatusDraft, EventStatusInReview, EventStatusPublished, EventStatusPaused, EventStatusEnded, EventStatusRemoved:
 		return true
 	}
 	return false
@@ -293,6 +1023,359 @@ func (e EventStatus) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
+// Gênero do perfil, usado apenas para ingressos por público (MALE/FEMALE).
+type Gender string
+
+const (
+	GenderMale   Gender = "MALE"
+	GenderFemale Gender = "FEMALE"
+)
+
+var AllGender = []Gender{
+	GenderMale,
+	GenderFemale,
+}
+
+func (e Gender) IsValid() bool {
+	switch e {
+	case GenderMale, GenderFemale:
+		return true
+	}
+	return false
+}
+
+func (e Gender) String() string {
+	return string(e)
+}
+
+func (e *Gender) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Gender(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Gender", str)
+	}
+	return nil
+}
+
+func (e Gender) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// Categoria do benefício de meia-entrada (Lei 12.933/2013 e Estatuto da Pessoa Idosa).
+type HalfPriceEntitlement string
+
+const (
+	HalfPriceEntitlementStudent        HalfPriceEntitlement = "STUDENT"
+	HalfPriceEntitlementSenior         HalfPriceEntitlement = "SENIOR"
+	HalfPriceEntitlementPcd            HalfPriceEntitlement = "PCD"
+	HalfPriceEntitlementYouthLowIncome HalfPriceEntitlement = "YOUTH_LOW_INCOME"
+)
+
+var AllHalfPriceEntitlement = []HalfPriceEntitlement{
+	HalfPriceEntitlementStudent,
+	HalfPriceEntitlementSenior,
+	HalfPriceEntitlementPcd,
+	HalfPriceEntitlementYouthLowIncome,
+}
+
+func (e HalfPriceEntitlement) IsValid() bool {
+	switch e {
+	case HalfPriceEntitlementStudent, HalfPriceEntitlementSenior, HalfPriceEntitlementPcd, HalfPriceEntitlementYouthLowIncome:
+		return true
+	}
+	return false
+}
+
+func (e HalfPriceEntitlement) String() string {
+	return string(e)
+}
+
+func (e *HalfPriceEntitlement) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = HalfPriceEntitlement(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid HalfPriceEntitlement", str)
+	}
+	return nil
+}
+
+func (e HalfPriceEntitlement) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type OfflineCheckinStatus string
+
+const (
+	OfflineCheckinStatusAccepted OfflineCheckinStatus = "ACCEPTED"
+	// O ingresso já foi aceito em outra leitura (online ou offline mais antiga).
+	OfflineCheckinStatusDuplicate OfflineCheckinStatus = "DUPLICATE"
+	OfflineCheckinStatusRejected  OfflineCheckinStatus = "REJECTED"
+)
+
+var AllOfflineCheckinStatus = []OfflineCheckinStatus{
+	OfflineCheckinStatusAccepted,
+	OfflineCheckinStatusDuplicate,
+	OfflineCheckinStatusRejected,
+}
+
+func (e OfflineCheckinStatus) IsValid() bool {
+	switch e {
+	case OfflineCheckinStatusAccepted, OfflineCheckinStatusDuplicate, OfflineCheckinStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (e OfflineCheckinStatus) String() string {
+	return string(e)
+}
+
+func (e *OfflineCheckinStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OfflineCheckinStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OfflineCheckinStatus", str)
+	}
+	return nil
+}
+
+func (e OfflineCheckinStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ProducerStatus string
+
+const (
+	ProducerStatusPendingReview ProducerStatus = "PENDING_REVIEW"
+	ProducerStatusApproved      ProducerStatus = "APPROVED"
+	ProducerStatusRejected      ProducerStatus = "REJECTED"
+)
+
+var AllProducerStatus = []ProducerStatus{
+	ProducerStatusPendingReview,
+	ProducerStatusApproved,
+	ProducerStatusRejected,
+}
+
+func (e ProducerStatus) IsValid() bool {
+	switch e {
+	case ProducerStatusPendingReview, ProducerStatusApproved, ProducerStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (e ProducerStatus) String() string {
+	return string(e)
+}
+
+func (e *ProducerStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ProducerStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ProducerStatus", str)
+	}
+	return nil
+}
+
+func (e ProducerStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type PromoDiscountType string
+
+const (
+	// Percentual sobre o preço do ingresso.
+	PromoDiscountTypePercent PromoDiscountType = "PERCENT"
+	// Valor fixo em reais por ingresso.
+	PromoDiscountTypeFixed PromoDiscountType = "FIXED"
+)
+
+var AllPromoDiscountType = []PromoDiscountType{
+	PromoDiscountTypePercent,
+	PromoDiscountTypeFixed,
+}
+
+func (e PromoDiscountType) IsValid() bool {
+	switch e {
+	case PromoDiscountTypePercent, PromoDiscountTypeFixed:
+		return true
+	}
+	return false
+}
+
+func (e PromoDiscountType) String() string {
+	return string(e)
+}
+
+func (e *PromoDiscountType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PromoDiscountType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PromoDiscountType", str)
+	}
+	return nil
+}
+
+func (e PromoDiscountType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ResaleListingStatus string
+
+const (
+	ResaleListingStatusActive    ResaleListingStatus = "ACTIVE"
+	ResaleListingStatusSold      ResaleListingStatus = "SOLD"
+	ResaleListingStatusCancelled ResaleListingStatus = "CANCELLED"
+)
+
+var AllResaleListingStatus = []ResaleListingStatus{
+	ResaleListingStatusActive,
+	ResaleListingStatusSold,
+	ResaleListingStatusCancelled,
+}
+
+func (e ResaleListingStatus) IsValid() bool {
+	switch e {
+	case ResaleListingStatusActive, ResaleListingStatusSold, ResaleListingStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (e ResaleListingStatus) String() string {
+	return string(e)
+}
+
+func (e *ResaleListingStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ResaleListingStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ResaleListingStatus", str)
+	}
+	return nil
+}
+
+func (e ResaleListingStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// Papel de um membro da equipe do evento.
+type StaffRole string
+
+const (
+	// Portaria: validateTicket, checkInGuest e busca na lista de convidados.
+	StaffRoleScanner StaffRole = "SCANNER"
+	// Portaria, entrada manual, leituras desfeitas, cortesias e cupons.
+	StaffRoleManager StaffRole = "MANAGER"
+	// Cupons e seus usos, sem acesso à portaria.
+	StaffRoleFinance StaffRole = "FINANCE"
+)
+
+var AllStaffRole = []StaffRole{
+	StaffRoleScanner,
+	StaffRoleManager,
+	StaffRoleFinance,
+}
+
+func (e StaffRole) IsValid() bool {
+	switch e {
+	case StaffRoleScanner, StaffRoleManager, StaffRoleFinance:
+		return true
+	}
+	return false
+}
+
+func (e StaffRole) String() string {
+	return string(e)
+}
+
+func (e *StaffRole) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = StaffRole(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid StaffRole", str)
+	}
+	return nil
+}
+
+func (e StaffRole) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type TicketTransferStatus string
+
+const (
+	TicketTransferStatusPending   TicketTransferStatus = "PENDING"
+	TicketTransferStatusAccepted  TicketTransferStatus = "ACCEPTED"
+	TicketTransferStatusDeclined  TicketTransferStatus = "DECLINED"
+	TicketTransferStatusCancelled TicketTransferStatus = "CANCELLED"
+)
+
+var AllTicketTransferStatus = []TicketTransferStatus{
+	TicketTransferStatusPending,
+	TicketTransferStatusAccepted,
+	TicketTransferStatusDeclined,
+	TicketTransferStatusCancelled,
+}
+
+func (e TicketTransferStatus) IsValid() bool {
+	switch e {
+	case TicketTransferStatusPending, TicketTransferStatusAccepted, TicketTransferStatusDeclined, TicketTransferStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (e TicketTransferStatus) String() string {
+	return string(e)
+}
+
+func (e *TicketTransferStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TicketTransferStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TicketTransferStatus", str)
+	}
+	return nil
+}
+
+func (e TicketTransferStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
 type UserRole string
 
 const (
@@ -0,0 +1,216 @@
+package graphql
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"afterzin/api/internal/checkin"
+	"afterzin/api/internal/graphql/model"
+	"afterzin/api/internal/qrcode"
+	"afterzin/api/internal/qrkeys"
+	"afterzin/api/internal/repository"
+)
+
+// maxSyncBatch bounds the scans of one syncCheckins call; devices send larger backlogs in pages.
+const maxSyncBatch = 500
+
+// scannerDevice loads the device for its registering user, checking that it was not revoked and
+// that the user still works the event's door. Returns the device and its event.
+func (r *Resolver) scannerDevice(userID, deviceID string) (*repository.ScannerDeviceRow, *repository.EventRow, error) {
+	d, _ := repository.ScannerDeviceByID(r.DB, deviceID)
+	if d == nil || d.UserID != userID {
+		return nil, nil, errors.New("aparelho não encontrado")
+	}
+	if d.RevokedAt.Valid {
+		return nil, nil, errors.New("aparelho revogado")
+	}
+	ev, err := r.eventAccess(userID, d.EventID, repository.ScopeCheckIn)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d, ev, nil
+}
+
+// offlineManifest builds the plaintext manifest of the event date.
+func offlineManifest(db *sql.DB, ev *repository.EventRow, eventDateID string, keys *qrkeys.Keyring) (*checkin.Manifest, error) {
+	rows, err := repository.ManifestTickets(db, eventDateID)
+	if err != nil {
+		return nil, err
+	}
+	m := &checkin.Manifest{
+		EventID:     ev.ID,
+		EventDateID: eventDateID,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		DynamicOnly: ev.DynamicQR == 1 && ev.StaticQRFallback == 0,
+		QRKeys:      keys.PublicKeys(),
+		Tickets:     make([]checkin.Entry, 0, len(rows)),
+	}
+	gates, err := repository.EventGatesByEventDate(db, eventDateID)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range gates {
+		m.Gates = append(m.Gates, checkin.Gate{ID: g.ID, Name: g.Name, AreaIDs: g.AreaIDs})
+	}
+	for _, t := range rows {
+		var dynamicOfflineSecret string
+		if ev.DynamicQR == 1 {
+			// A payload no key can verify (e.g. its key was retired while the ticket was being
+			// reissued) leaves only this ticket out of the manifest, not the whole event.
+			secret, err := keys.DynamicSecret(t.QRCode)
+			if err != nil {
+				log.Printf("offline manifest %s: ticket %s left out: %v", eventDateID, t.ID, err)
+				continue
+			}
+			dynamicOfflineSecret = hex.EncodeToString(qrcode.DynamicOfflineSecret(secret))
+		}
+		m.Tickets = append(m.Tickets, checkin.Entry{
+			TicketID:             t.ID,
+			Code:                 t.Code,
+			QRHash:               checkin.QRHash(t.QRCode),
+			DynamicOfflineSecret: dynamicOfflineSecret,
+			TicketTypeID:         t.TicketTypeID,
+			TicketTypeName:       t.TicketTypeName,
+			AreaID:               t.AreaID.String,
+			HolderName:           t.HolderName.String,
+			HolderRequired:       t.Nominal == 1 && !t.HolderName.Valid,
+			Used:                 t.Used == 1,
+			EntryPolicy:          t.EntryPolicy,
+			MaxEntries:           int(t.MaxEntries.Int64),
+			EntryCount:           t.EntryCount,
+			LastEntryDate:        t.LastEntryDate.String,
+			Inside:               t.CheckinState == repository.CheckinIn,
+		})
+	}
+	return m, nil
+}
+
+// offlineScanFromInput validates an uploaded scan; the time is stored in UTC for ordering.
+func offlineScanFromInput(deviceID string, in *model.OfflineScanInput) (*repository.OfflineCheckinRow, error) {
+	c := &repository.OfflineCheckinRow{
+		DeviceID: deviceID,
+		ScanID:   strings.TrimSpace(in.ScanID),
+		TicketID: strings.TrimSpace(in.TicketID),
+	}
+	if c.ScanID == "" || c.TicketID == "" {
+		return nil, errors.New("leitura sem scanId ou ticketId")
+	}
+	if strings.TrimSpace(in.QRCode) == "" {
+		return nil, errors.New("leitura sem qrCode")
+	}
+	c.QRHash = checkin.QRHash(strings.TrimSpace(in.QRCode))
+	t, err := time.Parse(time.RFC3339, in.ScannedAt)
+	if err != nil {
+		return nil, errors.New("scannedAt inválido; use data e hora ISO 8601")
+	}
+	c.ScannedAt = t.UTC().Format("2006-01-02 15:04:05")
+	if in.Gate != nil {
+		gate := strings.TrimSpace(*in.Gate)
+		c.Gate = sql.NullString{String: gate, Valid: gate != ""}
+	}
+	if in.GateID != nil {
+		gateID := strings.TrimSpace(*in.GateID)
+		c.GateID = sql.NullString{String: gateID, Valid: gateID != ""}
+	}
+	return c, nil
+}
+
+// offlineQRRefusal checks the payload of an offline scan like validateTicket, at the scan time:
+// it must be the ticket's current static QR code (or a V1/V2 signature of the ticket, unless the
+// QR code was reissued) or its dynamic code for a window around the scan, in the formats the
+// event accepts. Returns the error code that rejects the scan, or "" when the payload admits the
+// ticket; unknown tickets are left to RecordOfflineCheckin (NOT_FOUND).
+func (r *Resolver) offlineQRRefusal(ev *repository.EventRow, c *repository.OfflineCheckinRow, payload string) (string, error) {
+	t, err := repository.TicketByID(r.DB, c.TicketID)
+	if err != nil || t == nil {
+		return "", err
+	}
+	if ticketID, ok := qrcode.PayloadTicketID(payload); !ok || ticketID != t.ID {
+		return "INVALID_QR", nil
+	}
+	if qrcode.IsDynamicPayload(payload) {
+		if ev.DynamicQR == 0 {
+			return "DYNAMIC_QR_NOT_ALLOWED", nil
+		}
+		secret, err := r.QRKeys.DynamicSecret(t.QRCode)
+		if err != nil {
+			return "INVALID_QR", nil
+		}
+		scannedAt, _ := time.Parse("2006-01-02 15:04:05", c.ScannedAt)
+		switch qrcode.VerifyDynamicPayload(payload, secret, scannedAt) {
+		case nil:
+			return "", nil
+		case qrcode.ErrDynamicExpired:
+			return "QR_EXPIRED", nil
+		}
+		return "INVALID_QR", nil
+	}
+	if ev.DynamicQR == 1 && ev.StaticQRFallback == 0 {
+		return "STATIC_QR_NOT_ALLOWED", nil
+	}
+	if payload == t.QRCode {
+		return "", nil
+	}
+	if ticketID, ok := r.QRKeys.Verify(payload); !ok || ticketID != t.ID {
+		return "INVALID_QR", nil
+	}
+	// As in validateTicket: V3 payloads are stored as issued, so a different one was replaced.
+	if _, isV3 := qrcode.ParseV3(payload); isV3 {
+		return "QR_REISSUED", nil
+	}
+	reissued, err := repository.TicketQRReissued(r.DB, t.ID)
+	if err != nil {
+		return "", err
+	}
+	if reissued {
+		return "QR_REISSUED", nil
+	}
+	return "", nil
+}
+
+func offlineCheckinResultToModel(res *repository.OfflineCheckinResult) *model.OfflineCheckinResult {
+	c := res.Checkin
+	out := &model.OfflineCheckinResult{
+		ScanID:   c.ScanID,
+		TicketID: c.TicketID,
+		Status:   model.OfflineCheckinStatus(c.Status),
+	}
+	if c.ErrorCode.Valid {
+		out.ErrorCode = &c.ErrorCode.String
+	}
+	if res.AcceptedAt.Valid {
+		t := parseDateTimeToRFC3339(res.AcceptedAt.String)
+		out.AcceptedAt = &t
+	}
+	if res.AcceptedGate.Valid {
+		out.AcceptedGate = &res.AcceptedGate.String
+	}
+	return out
+}
+
+func scannerDeviceRowToModel(db *sql.DB, d *repository.ScannerDeviceRow) *model.ScannerDevice {
+	nullTime := func(s sql.NullString) *string {
+		if !s.Valid {
+			return nil
+		}
+		t := parseDateTimeToRFC3339(s.String)
+		return &t
+	}
+	out := &model.ScannerDevice{
+		ID:         d.ID,
+		Name:       d.Name,
+		CreatedAt:  parseDateTimeToRFC3339(d.CreatedAt),
+		LastSyncAt: nullTime(d.LastSyncAt),
+		RevokedAt:  nullTime(d.RevokedAt),
+	}
+	if u, _ := repository.UserByID(db, d.UserID); u != nil {
+		out.RegisteredBy = u.Name
+	}
+	evRow, _ := repository.EventByID(db, d.EventID)
+	out.Event, _ = eventRowToModel(evRow, db)
+	return out
+}
@@ -0,0 +1,104 @@
+package graphql
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"afterzin/api/internal/graphql/model"
+	"afterzin/api/internal/repository"
+	"afterzin/api/internal/taxid"
+)
+
+// maxProducerDocumentSize bounds each document (URL or base64 data URI) sent with the application.
+const maxProducerDocumentSize = 2 * 1024 * 1024
+
+// validateProducerApplication normalizes and validates the application input.
+func validateProducerApplication(input model.ProducerApplicationInput) (repository.ProducerApplication, []repository.ProducerDocumentRow, error) {
+	app := repository.ProducerApplication{
+		CompanyName:  strings.TrimSpace(input.CompanyName),
+		CNPJ:         taxid.Digits(input.Cnpj),
+		ContactName:  strings.TrimSpace(input.ContactName),
+		ContactEmail: strings.TrimSpace(input.ContactEmail),
+		ContactPhone: strings.TrimSpace(input.ContactPhone),
+	}
+	if app.CompanyName == "" || app.ContactName == "" || app.ContactPhone == "" {
+		return app, nil, errors.New("razão social, contato e telefone são obrigatórios")
+	}
+	if !strings.Contains(app.ContactEmail, "@") {
+		return app, nil, errors.New("e-mail de contato inválido")
+	}
+	if !taxid.ValidCNPJ(app.CNPJ) {
+		return app, nil, errors.New("CNPJ inválido")
+	}
+	if len(input.Documents) == 0 {
+		return app, nil, errors.New("envie ao menos um documento")
+	}
+	docs := make([]repository.ProducerDocumentRow, 0, len(input.Documents))
+	for _, d := range input.Documents {
+		if strings.TrimSpace(d.Kind) == "" || d.URL == "" {
+			return app, nil, errors.New("documento inválido")
+		}
+		if len(d.URL) > maxProducerDocumentSize {
+			return app, nil, errors.New("documento muito grande; máximo 2 MB")
+		}
+		docs = append(docs, repository.ProducerDocumentRow{Kind: strings.TrimSpace(d.Kind), URL: d.URL})
+	}
+	return app, docs, nil
+}
+
+func producerApplicationToModel(db *sql.DB, p *repository.ProducerRow) *model.ProducerApplication {
+	if p == nil {
+		return nil
+	}
+	nullStr := func(s sql.NullString) *string {
+		if !s.Valid {
+			return nil
+		}
+		return &s.String
+	}
+	nullTime := func(s sql.NullString) *string {
+		if !s.Valid || s.String == "" {
+			return nil
+		}
+		t := parseDateTimeToRFC3339(s.String)
+		return &t
+	}
+	app := &model.ProducerApplication{
+		Producer:        producerRowToModel(db, p),
+		Status:          model.ProducerStatus(p.Status),
+		CompanyName:     nullStr(p.CompanyName),
+		Cnpj:            nullStr(p.CNPJ),
+		ContactName:     nullStr(p.ContactName),
+		ContactEmail:    nullStr(p.ContactEmail),
+		ContactPhone:    nullStr(p.ContactPhone),
+		RejectionReason: nullStr(p.RejectionReason),
+		SubmittedAt:     nullTime(p.SubmittedAt),
+		ReviewedAt:      nullTime(p.ReviewedAt),
+		Documents:       []*model.ProducerDocument{},
+	}
+	docs, _ := repository.ProducerDocuments(db, p.ID)
+	for _, d := range docs {
+		app.Documents = append(app.Documents, &model.ProducerDocument{ID: d.ID, Kind: d.Kind, URL: d.URL})
+	}
+	return app
+}
+
+// publishTargetStatus applies the publishing gate: the producer must be APPROVED, and while
+// the producer has fewer than EventModerationFirstEvents live events the event goes to the
+// moderation queue (IN_REVIEW) instead of being published directly.
+func (r *Resolver) publishTargetStatus(prod *repository.ProducerRow) (string, error) {
+	if prod.Status != repository.ProducerApproved {
+		return "", errors.New("seu cadastro de produtor precisa ser aprovado antes de publicar eventos")
+	}
+	if r.Config.EventModerationFirstEvents > 0 {
+		n, err := repository.CountPublishedEventsByProducer(r.DB, prod.ID)
+		if err != nil {
+			return "", err
+		}
+		if n < r.Config.EventModerationFirstEvents {
+			return string(model.EventStatusInReview), nil
+		}
+	}
+	return string(model.EventStatusPublished), nil
+}
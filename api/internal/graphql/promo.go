@@ -0,0 +1,172 @@
+package graphql
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"time"
+
+	"afterzin/api/internal/checkout"
+	"afterzin/api/internal/graphql/model"
+	"afterzin/api/internal/repository"
+)
+
+// promoCodePattern: 3 to 30 letters, digits, "-" or "_" (after uppercasing).
+var promoCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,30}$`)
+
+// producerEvent loads the event and checks that the user is its producer.
+func (r *Resolver) producerEvent(userID, eventID string) (*repository.EventRow, error) {
+	ev, _ := repository.EventByID(r.DB, eventID)
+	if ev == nil {
+		return nil, errors.New("evento não encontrado")
+	}
+	prod, _ := repository.ProducerByID(r.DB, ev.ProducerID)
+	if prod == nil || prod.UserID != userID {
+		return nil, errors.New("sem permissão")
+	}
+	return ev, nil
+}
+
+// promoCodeFromInput validates the producer's promo code rules for the event.
+func promoCodeFromInput(db *sql.DB, eventID string, in model.PromoCodeInput) (*repository.PromoCodeRow, error) {
+	p := &repository.PromoCodeRow{
+		EventID:       eventID,
+		Code:          checkout.NormalizePromoCode(in.Code),
+		DiscountType:  string(in.DiscountType),
+		DiscountValue: in.DiscountValue,
+		Active:        in.Active == nil || *in.Active,
+	}
+	if !promoCodePattern.MatchString(p.Code) {
+		return nil, errors.New("código do cupom deve ter de 3 a 30 letras, números, - ou _")
+	}
+	if p.DiscountValue <= 0 {
+		return nil, errors.New("desconto deve ser maior que zero")
+	}
+	if in.DiscountType == model.PromoDiscountTypePercent && p.DiscountValue > 100 {
+		return nil, errors.New("desconto percentual deve ser de no máximo 100%")
+	}
+	if in.TicketTypeID != nil && *in.TicketTypeID != "" {
+		if ev, _, err := checkout.TicketTypeEvent(db, *in.TicketTypeID); err != nil || ev.ID != eventID {
+			return nil, errors.New("tipo de ingresso não pertence ao evento")
+		}
+		p.TicketTypeID = sql.NullString{String: *in.TicketTypeID, Valid: true}
+	}
+	for _, f := range []struct {
+		in  *int
+		out *sql.NullInt64
+	}{
+		{in.MaxUses, &p.MaxUses},
+		{in.MaxUsesPerUser, &p.MaxUsesPerUser},
+	} {
+		if f.in == nil {
+			continue
+		}
+		if *f.in < 1 {
+			return nil, errors.New("limites de uso do cupom devem ser de pelo menos 1")
+		}
+		*f.out = sql.NullInt64{Int64: int64(*f.in), Valid: true}
+	}
+	var from, until time.Time
+	for _, f := range []struct {
+		in  *string
+		t   *time.Time
+		out *sql.NullString
+	}{
+		{in.ValidFrom, &from, &p.ValidFrom},
+		{in.ValidUntil, &until, &p.ValidUntil},
+	} {
+		if f.in == nil || *f.in == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, *f.in)
+		if err != nil {
+			return nil, errors.New("validade do cupom inválida; use data e hora ISO 8601")
+		}
+		*f.t = t.UTC()
+		*f.out = sql.NullString{String: f.t.Format(checkout.PromoTimeLayout), Valid: true}
+	}
+	if p.ValidFrom.Valid && p.ValidUntil.Valid && !until.After(from) {
+		return nil, errors.New("fim da validade do cupom deve ser depois do início")
+	}
+	return p, nil
+}
+
+func promoCodeRowToModel(db *sql.DB, p *repository.PromoCodeRow) *model.PromoCode {
+	nullInt := func(v sql.NullInt64) *int {
+		if !v.Valid {
+			return nil
+		}
+		n := int(v.Int64)
+		return &n
+	}
+	nullTime := func(s sql.NullString) *string {
+		if !s.Valid {
+			return nil
+		}
+		t := parseDateTimeToRFC3339(s.String)
+		return &t
+	}
+	out := &model.PromoCode{
+		ID:             p.ID,
+		Code:           p.Code,
+		DiscountType:   model.PromoDiscountType(p.DiscountType),
+		DiscountValue:  p.DiscountValue,
+		MaxUses:        nullInt(p.MaxUses),
+		MaxUsesPerUser: nullInt(p.MaxUsesPerUser),
+		ValidFrom:      nullTime(p.ValidFrom),
+		ValidUntil:     nullTime(p.ValidUntil),
+		Active:         p.Active,
+		CreatedAt:      parseDateTimeToRFC3339(p.CreatedAt),
+	}
+	out.UsedCount, out.DiscountTotal, _ = repository.PromoCodeUsage(db, p.ID)
+	evRow, _ := repository.EventByID(db, p.EventID)
+	out.Event, _ = eventRowToModel(evRow, db)
+	if p.TicketTypeID.Valid {
+		tt, _ := repository.TicketTypeByID(db, p.TicketTypeID.String)
+		out.TicketType = ticketTypeRowToModel(tt)
+	}
+	return out
+}
+
+// orderCheckoutPreview rebuilds the checkout summary of a pending order, with the promo code
+// discount already applied to the unit prices.
+func orderCheckoutPreview(db *sql.DB, orderID string) (*model.CheckoutPreviewResult, error) {
+	_, _, total, err := repository.OrderByID(db, orderID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := repository.OrderItemsByOrderID(db, orderID)
+	if err != nil {
+		return nil, err
+	}
+	discounts, err := repository.OrderItemDiscounts(db, orderID)
+	if err != nil {
+		return nil, err
+	}
+	out := &model.CheckoutPreviewResult{CheckoutID: orderID, Total: total, Items: []*model.CheckoutPreviewItem{}}
+	for _, it := range items {
+		item := &model.CheckoutPreviewItem{
+			Quantity:  it.Quantity,
+			UnitPrice: it.UnitPrice,
+			Subtotal:  float64(it.Quantity) * it.UnitPrice,
+			Discount:  float64(it.Quantity) * discounts[it.ID],
+		}
+		if ed, _ := repository.EventDateByID(db, it.EventDateID); ed != nil {
+			item.EventDate = ed.Date
+			if ev, _ := repository.EventByID(db, ed.EventID); ev != nil {
+				item.EventTitle = ev.Title
+			}
+		}
+		if tt, _ := repository.TicketTypeByID(db, it.TicketTypeID); tt != nil {
+			item.TicketTypeName = tt.Name
+		}
+		out.Discount += item.Discount
+		out.Items = append(out.Items, item)
+	}
+	if id, _ := repository.OrderPromoCodeID(db, orderID); id != "" {
+		if p, _ := repository.PromoCodeByID(db, id); p != nil {
+			out.PromoCode = &p.Code
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,104 @@
+package graphql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"afterzin/api/internal/graphql/model"
+	"afterzin/api/internal/repository"
+)
+
+const (
+	// resaleReservationTTL holds a listing for the buyer while paying (PIX expires in 15 minutes).
+	resaleReservationTTL = 15 * time.Minute
+	// Bounds of the producer's resale rules.
+	maxResaleMarkupPercent  = 100
+	maxResaleRoyaltyPercent = 50
+)
+
+// roundBRL rounds an amount to centavos.
+func roundBRL(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// ticketResellable checks that the ticket can be listed or bought on the resale marketplace.
+func ticketResellable(db *sql.DB, t *repository.TicketRow) (*repository.EventRow, error) {
+	ev, err := ticketOwnerChangeable(db, t, "revendido", "revendas")
+	if err != nil {
+		return nil, err
+	}
+	if c, _ := repository.CourtesyTicketByTicketID(db, t.ID); c != nil {
+		return nil, errors.New("cortesias não podem ser revendidas")
+	}
+	if ev.ResaleEnabled == 0 {
+		return nil, errors.New("o produtor não permite revenda para este evento")
+	}
+	return ev, nil
+}
+
+// resaleListing validates the price against the producer's cap over the price paid for the
+// ticket (not the ticket type's current price) and computes the split:
+// platform fee per ticket (same as primary sales), producer royalty (none while the producer
+// cannot receive payments), and the seller's net amount.
+func (r *Resolver) resaleListing(t *repository.TicketRow, ev *repository.EventRow, sellerID string, price float64) (repository.ResaleListingRow, error) {
+	l := repository.ResaleListingRow{TicketID: t.ID, SellerID: sellerID, EventID: ev.ID, Price: roundBRL(price)}
+	faceValue, err := repository.TicketFaceValue(r.DB, t.ID)
+	if err != nil {
+		return l, err
+	}
+	l.FaceValue = faceValue
+	maxPrice := roundBRL(faceValue * (1 + ev.ResaleMaxMarkupPercent/100))
+	if l.Price <= 0 {
+		return l, errors.New("preço inválido")
+	}
+	if l.Price > maxPrice {
+		return l, fmt.Errorf("preço acima do permitido pelo produtor: máximo R$ %.2f", maxPrice)
+	}
+	l.PlatformFee = roundBRL(float64(r.Config.PagarmeAppFee) / 100)
+	l.RoyaltyAmount = roundBRL(l.Price * ev.ResaleRoyaltyPercent / 100)
+	// The royalty is paid through the Pagar.me split: a producer without a recipient cannot
+	// receive it, so the seller keeps it instead of it going to the platform.
+	if l.RoyaltyAmount > 0 && r.Config.PagarmeAPIKey != "" {
+		if recipientID, _ := repository.GetProducerPagarmeRecipientID(r.DB, ev.ProducerID); recipientID == "" {
+			l.RoyaltyAmount = 0
+		}
+	}
+	l.SellerAmount = roundBRL(l.Price - l.PlatformFee - l.RoyaltyAmount)
+	if l.SellerAmount <= 0 {
+		return l, errors.New("preço não cobre a taxa da plataforma e o repasse ao produtor")
+	}
+	return l, nil
+}
+
+func resaleListingRowToModel(db *sql.DB, l *repository.ResaleListingRow) *model.ResaleListing {
+	out := &model.ResaleListing{
+		ID:            l.ID,
+		Price:         l.Price,
+		FaceValue:     l.FaceValue,
+		SellerAmount:  l.SellerAmount,
+		RoyaltyAmount: l.RoyaltyAmount,
+		PlatformFee:   l.PlatformFee,
+		Status:        model.ResaleListingStatus(l.Status),
+		CreatedAt:     parseDateTimeToRFC3339(l.CreatedAt),
+	}
+	if l.Status == repository.ResaleActive && l.ReservedUntil.Valid {
+		if until, err := time.Parse("2006-01-02 15:04:05", l.ReservedUntil.String); err == nil {
+			out.Reserved = time.Now().Before(until)
+		}
+	}
+	if l.SoldAt.Valid {
+		soldAt := parseDateTimeToRFC3339(l.SoldAt.String)
+		out.SoldAt = &soldAt
+	}
+	if t, _ := repository.TicketByID(db, l.TicketID); t != nil {
+		evRow, _ := repository.EventByID(db, t.EventID)
+		out.Event, _ = eventRowToModel(evRow, db)
+		out.EventDate, _ = eventDateToModel(db, t.EventDateID)
+		tt, _ := repository.TicketTypeByID(db, t.TicketTypeID)
+		out.TicketType = ticketTypeRowToModel(tt)
+	}
+	return out
+}
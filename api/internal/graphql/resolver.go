@@ -3,7 +3,11 @@ package graphql
 import (
 	"database/sql"
 
+	"afterzin/api/internal/checkin"
 	"afterzin/api/internal/config"
+	"afterzin/api/internal/mailer"
+	"afterzin/api/internal/qrkeys"
+	"afterzin/api/internal/wallet"
 )
 
 // This file will not be regenerated automatically.
@@ -13,4 +17,10 @@ import (
 type Resolver struct {
 	DB     *sql.DB
 	Config *config.Config
+	Outbox *mailer.Outbox // queued sends with retries
+	Wallet *wallet.Service
+	// Checkins publishes door validations to the check-in subscriptions.
+	Checkins *checkin.Hub
+	// QRKeys signs and verifies ticket QR codes.
+	QRKeys *qrkeys.Keyring
 }
@@ -0,0 +1,142 @@
+package graphql
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"afterzin/api/internal/graphql/model"
+	"afterzin/api/internal/repository"
+)
+
+// salesRange validates the optional period of the sales dashboard ("" for open bounds).
+func salesRange(in *model.DateRangeInput) (from, to string, err error) {
+	if in == nil {
+		return "", "", nil
+	}
+	if in.From != nil {
+		from = *in.From
+		if _, err := time.Parse("2006-01-02", from); err != nil {
+			return "", "", errors.New("data inicial inválida (use AAAA-MM-DD)")
+		}
+	}
+	if in.To != nil {
+		to = *in.To
+		if _, err := time.Parse("2006-01-02", to); err != nil {
+			return "", "", errors.New("data final inválida (use AAAA-MM-DD)")
+		}
+	}
+	if from != "" && to != "" && from > to {
+		return "", "", errors.New("data inicial depois da data final")
+	}
+	return from, to, nil
+}
+
+// ratio is n/d, or nil when there is nothing to divide by.
+func ratio(n, d float64) *float64 {
+	if d == 0 {
+		return nil
+	}
+	v := n / d
+	return &v
+}
+
+// salesSummary totals the event's rollup days and ticket types of the period, with the
+// check-in numbers of the whole event.
+func salesSummary(db *sql.DB, eventID string, days []*repository.SalesDayRow, types []*repository.TicketTypeSalesRow) (*model.SalesSummary, error) {
+	s := &model.SalesSummary{}
+	converted := 0
+	for _, d := range days {
+		s.Revenue += d.Revenue
+		s.TicketsSold += d.Tickets
+		s.PaidOrders += d.PaidOrders
+		s.Previews += d.Previews
+		converted += d.Converted
+	}
+	lots := map[string]bool{}
+	for _, t := range types {
+		if !lots[t.LotID] {
+			lots[t.LotID] = true
+			s.Capacity += t.LotTotalQuantity
+		}
+	}
+	s.ConversionRate = ratio(float64(converted), float64(s.Previews))
+	s.AverageOrderValue = ratio(s.Revenue, float64(s.PaidOrders))
+	s.SellThrough = ratio(float64(s.TicketsSold), float64(s.Capacity))
+	issued, checkedIn, err := repository.EventCheckinTotals(db, eventID)
+	if err != nil {
+		return nil, err
+	}
+	s.TicketsIssued, s.CheckedIn = issued, checkedIn
+	s.CheckinRate = ratio(float64(checkedIn), float64(issued))
+	return s, nil
+}
+
+// eventSales loads the event's rollup for the period and its summary.
+func eventSales(db *sql.DB, eventID, from, to string) (*model.SalesSummary, []*repository.SalesDayRow, []*repository.TicketTypeSalesRow, error) {
+	days, err := repository.SalesDays(db, eventID, from, to)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	types, err := repository.SalesByTicketType(db, eventID, from, to)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	summary, err := salesSummary(db, eventID, days, types)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return summary, days, types, nil
+}
+
+// salesAnalytics builds the sales dashboard of the event for the period.
+func salesAnalytics(db *sql.DB, ev *repository.EventRow, from, to string) (*model.SalesAnalytics, error) {
+	summary, days, types, err := eventSales(db, ev.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	event, err := eventRowToModel(ev, db)
+	if err != nil {
+		return nil, err
+	}
+	out := &model.SalesAnalytics{
+		Event:        event,
+		Summary:      summary,
+		Daily:        make([]*model.SalesDay, 0, len(days)),
+		ByTicketType: make([]*model.TicketTypeSales, 0, len(types)),
+		ByLot:        []*model.LotSales{},
+	}
+	for _, d := range days {
+		out.Daily = append(out.Daily, &model.SalesDay{Day: d.Day, Revenue: d.Revenue, TicketsSold: d.Tickets, PaidOrders: d.PaidOrders, Previews: d.Previews})
+	}
+	byLot := map[string]*model.LotSales{}
+	for _, t := range types {
+		tt, _ := repository.TicketTypeByID(db, t.TicketTypeID)
+		if tt == nil {
+			continue
+		}
+		out.ByTicketType = append(out.ByTicketType, &model.TicketTypeSales{
+			TicketType:  ticketTypeRowToModel(tt),
+			TicketsSold: t.Tickets,
+			Revenue:     t.Revenue,
+			Capacity:    t.MaxQuantity,
+			SellThrough: ratio(float64(t.Tickets), float64(t.MaxQuantity)),
+		})
+		lot := byLot[t.LotID]
+		if lot == nil {
+			l, err := lotToModel(db, t.LotID)
+			if err != nil || l == nil {
+				continue
+			}
+			lot = &model.LotSales{Lot: l, Capacity: t.LotTotalQuantity}
+			byLot[t.LotID] = lot
+			out.ByLot = append(out.ByLot, lot)
+		}
+		lot.TicketsSold += t.Tickets
+		lot.Revenue += t.Revenue
+	}
+	for _, lot := range out.ByLot {
+		lot.SellThrough = ratio(float64(lot.TicketsSold), float64(lot.Capacity))
+	}
+	return out, nil
+}
@@ -6,15 +6,18 @@ package graphql
 
 import (
 	"afterzin/api/internal/auth"
+	"afterzin/api/internal/checkin"
+	"afterzin/api/internal/checkout"
 	"afterzin/api/internal/graphql/model"
 	"afterzin/api/internal/middleware"
 	"afterzin/api/internal/qrcode"
 	"afterzin/api/internal/repository"
+	"afterzin/api/internal/taxid"
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
-
-	"github.com/google/uuid"
 )
 
 // Register is the resolver for the register field.
@@ -31,25 +34,26 @@ func (r *mutationResolver) Register(ctx context.Context, input model.RegisterInp
 	if err != nil {
 		return nil, err
 	}
-	user, err := repository.UserByID(r.DB, id)
-	var userModel *model.User
-	if user != nil {
-		userModel = userRowToModel(user)
-	} else {
+	if input.Gender != nil {
+		if err := repository.UpdateUserGender(r.DB, id, string(*input.Gender)); err != nil {
+			return nil, err
+		}
+	}
+	user, _ := repository.UserByID(r.DB, id)
+	if user == nil {
 		// Schema requires non-null user; build from input if fetch failed (e.g. SQLite datetime)
-		userModel = &model.User{
+		user = &repository.UserRow{
 			ID:        id,
 			Name:      input.Name,
 			Email:     input.Email,
-			Cpf:       input.Cpf,
+			CPF:       input.Cpf,
 			BirthDate: input.BirthDate,
-			PhotoURL:  nil,
-			Role:      model.UserRoleUser,
-			CreatedAt: time.Now().UTC().Format("2006-01-02T15:04:05Z07:00"),
+			Role:      string(model.UserRoleUser),
+			CreatedAt: time.Now().UTC(),
 		}
 	}
-	token, _ := auth.NewToken(id, "USER", r.Config.JWTSecret, 24*time.Hour)
-	return &model.AuthPayload{Token: token, User: userModel}, nil
+	logMailError("email verification", user.ID, r.sendEmailVerification(user))
+	return r.newSession(ctx, user)
 }
 
 // Login is the resolver for the login field.
@@ -61,8 +65,199 @@ func (r *mutationResolver) Login(ctx context.Context, input model.LoginInput) (*
 	if !auth.CheckPassword(user.PasswordHash, input.Password) {
 		return nil, errors.New("credenciais inválidas")
 	}
-	token, _ := auth.NewToken(user.ID, user.Role, r.Config.JWTSecret, 24*time.Hour)
-	return &model.AuthPayload{Token: token, User: userRowToModel(user)}, nil
+	if user.BlockedAt.Valid {
+		return nil, errors.New("conta bloqueada")
+	}
+	return r.newSession(ctx, user)
+}
+
+// RefreshToken is the resolver for the refreshToken field.
+// Rotates the refresh token: the presented token is invalidated and a new pair is returned.
+// Reusing an already-rotated token revokes the whole session (possible token theft).
+func (r *mutationResolver) RefreshToken(ctx context.Context, refreshToken string) (*model.AuthPayload, error) {
+	hash := auth.HashToken(refreshToken)
+	sess, err := repository.SessionByRefreshHash(r.DB, hash)
+	if err != nil {
+		return nil, err
+	}
+	if sess == nil {
+		if reusedID, _ := repository.SessionIDByPreviousRefreshHash(r.DB, hash); reusedID != "" {
+			_ = repository.RevokeSessionByID(r.DB, reusedID)
+		}
+		return nil, errors.New("sessão inválida")
+	}
+	expiresAt, _ := time.Parse("2006-01-02 15:04:05", sess.ExpiresAt)
+	if sess.RevokedAt.Valid || time.Now().UTC().After(expiresAt) {
+		return nil, errors.New("sessão expirada")
+	}
+	user, _ := repository.UserByID(r.DB, sess.UserID)
+	if user == nil || user.BlockedAt.Valid {
+		return nil, errors.New("sessão inválida")
+	}
+	next, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := repository.RotateSessionRefreshToken(r.DB, sess.ID, hash, auth.HashToken(next), r.Config.RefreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		return nil, errors.New("sessão inválida")
+	}
+	return r.authPayload(user, sess.ID, next)
+}
+
+// Logout is the resolver for the logout field.
+func (r *mutationResolver) Logout(ctx context.Context) (bool, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return false, errors.New("não autenticado")
+	}
+	if err := repository.RevokeSession(r.DB, middleware.SessionID(ctx), userID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// LogoutAllSessions is the resolver for the logoutAllSessions field.
+func (r *mutationResolver) LogoutAllSessions(ctx context.Context) (bool, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return false, errors.New("não autenticado")
+	}
+	if err := repository.RevokeUserSessions(r.DB, userID, ""); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ChangePassword is the resolver for the changePassword field.
+// All other sessions are revoked; the current one stays logged in.
+func (r *mutationResolver) ChangePassword(ctx context.Context, currentPassword string, newPassword string) (bool, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return false, errors.New("não autenticado")
+	}
+	user, _ := repository.UserByID(r.DB, userID)
+	if user == nil || !auth.CheckPassword(user.PasswordHash, currentPassword) {
+		return false, errors.New("senha atual incorreta")
+	}
+	if len(newPassword) < 6 {
+		return false, errors.New("a nova senha deve ter ao menos 6 caracteres")
+	}
+	hash, err := auth.HashPassword(newPassword)
+	if err != nil {
+		return false, err
+	}
+	if err := repository.UpdateUserPassword(r.DB, userID, hash); err != nil {
+		return false, err
+	}
+	if err := repository.RevokeUserSessions(r.DB, userID, middleware.SessionID(ctx)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RequestPasswordReset is the resolver for the requestPasswordReset field.
+func (r *mutationResolver) RequestPasswordReset(ctx context.Context, email string) (bool, error) {
+	user, _ := repository.UserByEmail(r.DB, email)
+	if user == nil || user.BlockedAt.Valid {
+		return true, nil
+	}
+	logMailError("password reset", user.ID, r.sendPasswordReset(user))
+	return true, nil
+}
+
+// ResetPassword is the resolver for the resetPassword field.
+// Consumes the single-use token and revokes every session of the account.
+func (r *mutationResolver) ResetPassword(ctx context.Context, token string, newPassword string) (bool, error) {
+	if len(newPassword) < 6 {
+		return false, errors.New("a nova senha deve ter ao menos 6 caracteres")
+	}
+	userID, err := repository.ConsumeUserToken(r.DB, repository.TokenPasswordReset, auth.HashToken(token))
+	if err != nil {
+		return false, err
+	}
+	if userID == "" {
+		return false, errors.New("link inválido ou expirado")
+	}
+	hash, err := auth.HashPassword(newPassword)
+	if err != nil {
+		return false, err
+	}
+	if err := repository.UpdateUserPassword(r.DB, userID, hash); err != nil {
+		return false, err
+	}
+	// The reset link was delivered to the inbox, so the address is confirmed as well.
+	_ = repository.MarkEmailVerified(r.DB, userID)
+	if err := repository.RevokeUserSessions(r.DB, userID, ""); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SendEmailVerification is the resolver for the sendEmailVerification field.
+func (r *mutationResolver) SendEmailVerification(ctx context.Context) (bool, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return false, errors.New("não autenticado")
+	}
+	user, _ := repository.UserByID(r.DB, userID)
+	if user == nil {
+		return false, errors.New("usuário não encontrado")
+	}
+	if user.EmailVerifiedAt.Valid {
+		return false, errors.New("e-mail já confirmado")
+	}
+	if err := r.sendEmailVerification(user); err != nil {
+		logMailError("email verification", user.ID, err)
+		return false, errors.New("não foi possível enviar o e-mail")
+	}
+	return true, nil
+}
+
+// VerifyEmail is the resolver for the verifyEmail field.
+func (r *mutationResolver) VerifyEmail(ctx context.Context, token string) (*model.User, error) {
+	userID, err := repository.ConsumeUserToken(r.DB, repository.TokenEmailVerification, auth.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if userID == "" {
+		return nil, errors.New("link inválido ou expirado")
+	}
+	if err := repository.MarkEmailVerified(r.DB, userID); err != nil {
+		return nil, err
+	}
+	user, _ := repository.UserByID(r.DB, userID)
+	return userRowToModel(user), nil
+}
+
+// SubmitProducerApplication is the resolver for the submitProducerApplication field.
+func (r *mutationResolver) SubmitProducerApplication(ctx context.Context, input model.ProducerApplicationInput) (*model.ProducerApplication, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	if prodID, _ := repository.ProducerIDByUser(r.DB, userID); prodID != "" {
+		prod, _ := repository.ProducerByID(r.DB, prodID)
+		if prod != nil && prod.Status == repository.ProducerApproved {
+			return nil, errors.New("cadastro de produtor já aprovado")
+		}
+	}
+	app, docs, err := validateProducerApplication(input)
+	if err != nil {
+		return nil, err
+	}
+	prodID, err := repository.SubmitProducerApplication(r.DB, userID, app)
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.ReplaceProducerDocuments(r.DB, prodID, docs); err != nil {
+		return nil, err
+	}
+	prod, _ := repository.ProducerByID(r.DB, prodID)
+	return producerApplicationToModel(r.DB, prod), nil
 }
 
 // CreateEvent is the resolver for the createEvent field.
@@ -73,15 +268,20 @@ func (r *mutationResolver) CreateEvent(ctx context.Context, input model.CreateEv
 	}
 	prodID, _ := repository.ProducerIDByUser(r.DB, userID)
 	if prodID == "" {
-		prodID, _ = repository.CreateProducer(r.DB, userID)
-		if prodID == "" {
-			return nil, errors.New("erro ao criar perfil de produtor")
-		}
+		return nil, errors.New("envie sua solicitação de produtor antes de criar eventos")
+	}
+	if input.MinAge != nil && (*input.MinAge < 0 || *input.MinAge > maxEventMinAge) {
+		return nil, fmt.Errorf("idade mínima deve ser entre 0 e %d anos", maxEventMinAge)
 	}
 	id, err := repository.CreateEvent(r.DB, prodID, input.Title, input.Description, input.Category, input.CoverImage, input.Location, input.Address)
 	if err != nil {
 		return nil, err
 	}
+	if input.MinAge != nil {
+		if err := repository.UpdateEventMinAge(r.DB, id, *input.MinAge); err != nil {
+			return nil, err
+		}
+	}
 	row, _ := repository.EventByID(r.DB, id)
 	return eventRowToModel(row, r.DB)
 }
@@ -100,9 +300,81 @@ func (r *mutationResolver) UpdateEvent(ctx context.Context, id string, input mod
 	if prod == nil || prod.UserID != userID {
 		return nil, errors.New("sem permissão")
 	}
+	if row.Status == string(model.EventStatusRemoved) {
+		return nil, errors.New("evento removido pela administração")
+	}
+	if input.TransferCutoffHours != nil && (*input.TransferCutoffHours < 0 || *input.TransferCutoffHours > maxTransferCutoffHours) {
+		return nil, fmt.Errorf("prazo de transferência deve ser entre 0 e %d horas", maxTransferCutoffHours)
+	}
+	if input.HolderCutoffHours != nil && (*input.HolderCutoffHours < 0 || *input.HolderCutoffHours > maxTransferCutoffHours) {
+		return nil, fmt.Errorf("prazo para titulares deve ser entre 0 e %d horas", maxTransferCutoffHours)
+	}
+	if input.MinAge != nil && (*input.MinAge < 0 || *input.MinAge > maxEventMinAge) {
+		return nil, fmt.Errorf("idade mínima deve ser entre 0 e %d anos", maxEventMinAge)
+	}
+	if input.MinAge != nil && *input.MinAge > checkout.ChildMaxAge {
+		child, err := repository.EventHasTicketAudience(r.DB, id, string(model.AudienceTypeChild))
+		if err != nil {
+			return nil, err
+		}
+		if child {
+			return nil, fmt.Errorf("evento com ingresso infantil não pode ter idade mínima acima de %d anos", checkout.ChildMaxAge)
+		}
+	}
+	limits, err := purchaseLimitsFromInput(input.PurchaseLimits)
+	if err != nil {
+		return nil, err
+	}
+	if input.ResaleMaxMarkupPercent != nil && (*input.ResaleMaxMarkupPercent < 0 || *input.ResaleMaxMarkupPercent > maxResaleMarkupPercent) {
+		return nil, fmt.Errorf("teto de revenda deve ser entre 0%% e %d%% acima do valor de face", maxResaleMarkupPercent)
+	}
+	if input.ResaleRoyaltyPercent != nil && (*input.ResaleRoyaltyPercent < 0 || *input.ResaleRoyaltyPercent > maxResaleRoyaltyPercent) {
+		return nil, fmt.Errorf("repasse da revenda deve ser entre 0%% e %d%%", maxResaleRoyaltyPercent)
+	}
+	if input.CourtesyQuota != nil {
+		issued, err := repository.CountCourtesyTickets(r.DB, id)
+		if err != nil {
+			return nil, err
+		}
+		if *input.CourtesyQuota < issued {
+			return nil, fmt.Errorf("cota de cortesias não pode ser menor que as %d já emitidas", issued)
+		}
+	}
 	if err := repository.UpdateEvent(r.DB, id, input.Title, input.Description, input.Category, input.CoverImage, input.Location, input.Address, nil); err != nil {
 		return nil, err
 	}
+	if err := repository.UpdateEventTransferPolicy(r.DB, id, input.TransfersEnabled, input.TransferCutoffHours); err != nil {
+		return nil, err
+	}
+	if err := repository.UpdateEventResalePolicy(r.DB, id, input.ResaleEnabled, input.ResaleMaxMarkupPercent, input.ResaleRoyaltyPercent); err != nil {
+		return nil, err
+	}
+	if err := repository.UpdateEventQRPolicy(r.DB, id, input.DynamicQREnabled, input.StaticQRFallback); err != nil {
+		return nil, err
+	}
+	if input.HolderCutoffHours != nil {
+		if err := repository.UpdateEventHolderCutoff(r.DB, id, *input.HolderCutoffHours); err != nil {
+			return nil, err
+		}
+	}
+	if input.MinAge != nil {
+		if err := repository.UpdateEventMinAge(r.DB, id, *input.MinAge); err != nil {
+			return nil, err
+		}
+	}
+	if input.PurchaseLimits != nil {
+		if err := repository.UpdateEventPurchaseLimits(r.DB, id, limits); err != nil {
+			return nil, err
+		}
+	}
+	if input.CourtesyQuota != nil {
+		if err := repository.UpdateEventCourtesyQuota(r.DB, id, *input.CourtesyQuota); err != nil {
+			return nil, err
+		}
+	}
+	if input.Title != nil || input.Location != nil || input.Address != nil {
+		r.Wallet.EventChanged(id)
+	}
 	row, _ = repository.EventByID(r.DB, id)
 	return eventRowToModel(row, r.DB)
 }
@@ -121,7 +393,17 @@ func (r *mutationResolver) PublishEvent(ctx context.Context, id string) (*model.
 	if prod == nil || prod.UserID != userID {
 		return nil, errors.New("sem permissão")
 	}
-	if err := repository.UpdateEventStatus(r.DB, id, "PUBLISHED"); err != nil {
+	if row.Status == string(model.EventStatusRemoved) {
+		return nil, errors.New("evento removido pela administração")
+	}
+	if row.Status == string(model.EventStatusInReview) {
+		return nil, errors.New("evento aguardando moderação")
+	}
+	target, err := r.publishTargetStatus(prod)
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.SetEventModeration(r.DB, id, target, nil); err != nil {
 		return nil, err
 	}
 	row, _ = repository.EventByID(r.DB, id)
@@ -142,7 +424,23 @@ func (r *mutationResolver) UpdateEventStatus(ctx context.Context, id string, sta
 	if prod == nil || prod.UserID != userID {
 		return nil, errors.New("sem permissão")
 	}
-	if err := repository.UpdateEventStatus(r.DB, id, string(status)); err != nil {
+	if row.Status == string(model.EventStatusRemoved) || status == model.EventStatusRemoved {
+		return nil, errors.New("evento removido pela administração")
+	}
+	if status == model.EventStatusInReview || (row.Status == string(model.EventStatusInReview) && status != model.EventStatusDraft) {
+		return nil, errors.New("evento aguardando moderação")
+	}
+	target := string(status)
+	if status == model.EventStatusPublished && row.Status == string(model.EventStatusDraft) {
+		t, err := r.publishTargetStatus(prod)
+		if err != nil {
+			return nil, err
+		}
+		target = t
+	} else if status == model.EventStatusPublished && prod.Status != repository.ProducerApproved {
+		return nil, errors.New("seu cadastro de produtor precisa ser aprovado antes de publicar eventos")
+	}
+	if err := repository.UpdateEventStatus(r.DB, id, target); err != nil {
 		return nil, err
 	}
 	row, _ = repository.EventByID(r.DB, id)
@@ -170,6 +468,32 @@ func (r *mutationResolver) CreateEventDate(ctx context.Context, eventID string,
 	return eventDateToModel(r.DB, id)
 }
 
+// UpdateEventDate is the resolver for the updateEventDate field.
+// Passes already saved in Apple/Google Wallet are refreshed with the new date/time.
+func (r *mutationResolver) UpdateEventDate(ctx context.Context, id string, input model.EventDateInput) (*model.EventDate, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	ed, _ := repository.EventDateByID(r.DB, id)
+	if ed == nil {
+		return nil, errors.New("data não encontrada")
+	}
+	ev, _ := repository.EventByID(r.DB, ed.EventID)
+	if ev == nil {
+		return nil, errors.New("evento não encontrado")
+	}
+	prod, _ := repository.ProducerByID(r.DB, ev.ProducerID)
+	if prod == nil || prod.UserID != userID {
+		return nil, errors.New("sem permissão")
+	}
+	if err := repository.UpdateEventDate(r.DB, id, input.Date, input.StartTime, input.EndTime); err != nil {
+		return nil, err
+	}
+	r.Wallet.EventDateChanged(id)
+	return eventDateToModel(r.DB, id)
+}
+
 // CreateLot is the resolver for the createLot field.
 func (r *mutationResolver) CreateLot(ctx context.Context, dateID string, input model.LotInput) (*model.Lot, error) {
 	userID := middleware.UserID(ctx)
@@ -217,274 +541,1955 @@ func (r *mutationResolver) CreateTicketType(ctx context.Context, lotID string, i
 	if prod == nil || prod.UserID != userID {
 		return nil, errors.New("sem permissão")
 	}
-	id, err := repository.CreateTicketType(r.DB, lotID, input.Name, input.Description, input.Price, string(input.Audience), input.MaxQuantity)
+	if input.Audience == model.AudienceTypeChild && ev.MinAge > checkout.ChildMaxAge {
+		return nil, fmt.Errorf("evento com idade mínima de %d anos não pode ter ingresso infantil", ev.MinAge)
+	}
+	limits, err := purchaseLimitsFromInput(input.PurchaseLimits)
+	if err != nil {
+		return nil, err
+	}
+	policy, maxEntries, err := entryPolicyFromInput(input.EntryPolicy, input.MaxEntries)
 	if err != nil {
 		return nil, err
 	}
+	if input.AreaID != nil && *input.AreaID != "" {
+		if a, _ := repository.EventAreaByID(r.DB, *input.AreaID); a == nil || a.EventID != ev.ID {
+			return nil, errors.New("setor não encontrado neste evento")
+		}
+	}
+	// CHILD tickets are checked against the child's birth date, so they always need a holder.
+	nominal := (input.Nominal != nil && *input.Nominal) || input.Audience == model.AudienceTypeChild
+	var halfPrice string
+	if input.HalfPriceEntitlement != nil {
+		halfPrice = string(*input.HalfPriceEntitlement)
+	}
+	id, err := repository.CreateTicketType(r.DB, lotID, input.Name, input.Description, input.Price, string(input.Audience), input.MaxQuantity, nominal, halfPrice)
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.UpdateTicketTypePurchaseLimits(r.DB, id, limits); err != nil {
+		return nil, err
+	}
+	if err := repository.UpdateTicketTypeEntryPolicy(r.DB, id, policy, maxEntries); err != nil {
+		return nil, err
+	}
+	if input.AreaID != nil && *input.AreaID != "" {
+		if err := repository.SetTicketTypeArea(r.DB, id, *input.AreaID); err != nil {
+			return nil, err
+		}
+	}
 	tt, _ := repository.TicketTypeByID(r.DB, id)
 	if tt == nil {
 		return nil, err
 	}
-	var desc *string
-	if tt.Description.Valid {
-		desc = &tt.Description.String
-	}
-	return &model.TicketType{
-		ID:           tt.ID,
-		Name:         tt.Name,
-		Description:  desc,
-		Price:        tt.Price,
-		Audience:     model.AudienceType(tt.Audience),
-		MaxQuantity:  tt.MaxQuantity,
-		SoldQuantity: tt.SoldQuantity,
-	}, nil
+	return ticketTypeRowToModel(tt), nil
 }
 
-// CheckoutPreview is the resolver for the checkoutPreview field.
-func (r *mutationResolver) CheckoutPreview(ctx context.Context, input model.CheckoutInput) (*model.CheckoutPreviewResult, error) {
+// UpdateTicketTypeLimits is the resolver for the updateTicketTypeLimits field.
+func (r *mutationResolver) UpdateTicketTypeLimits(ctx context.Context, id string, input model.PurchaseLimitsInput) (*model.TicketType, error) {
 	userID := middleware.UserID(ctx)
 	if userID == "" {
 		return nil, errors.New("não autenticado")
 	}
-	if len(input.Items) == 0 {
-		return nil, errors.New("nenhum item")
+	tt, _ := repository.TicketTypeByID(r.DB, id)
+	if tt == nil {
+		return nil, errors.New("tipo de ingresso não encontrado")
 	}
-	var total float64
-	var items []*model.CheckoutPreviewItem
-	for _, it := range input.Items {
-		tt, _ := repository.TicketTypeByID(r.DB, it.TicketTypeID)
-		if tt == nil {
-			return nil, errors.New("tipo de ingresso não encontrado")
-		}
-		if it.Quantity <= 0 || tt.SoldQuantity+it.Quantity > tt.MaxQuantity {
-			return nil, errors.New("quantidade indisponível")
-		}
-		ed, _ := repository.EventDateByID(r.DB, it.EventDateID)
-		if ed == nil {
-			return nil, errors.New("data não encontrada")
-		}
-		ev, _ := repository.EventByID(r.DB, ed.EventID)
-		if ev == nil {
-			return nil, errors.New("evento não encontrado")
-		}
-		sub := float64(it.Quantity) * tt.Price
-		total += sub
-		items = append(items, &model.CheckoutPreviewItem{
-			EventTitle:     ev.Title,
-			EventDate:      ed.Date,
-			TicketTypeName: tt.Name,
-			Quantity:       it.Quantity,
-			UnitPrice:      tt.Price,
-			Subtotal:       sub,
-		})
+	lot, _ := repository.LotByID(r.DB, tt.LotID)
+	if lot == nil {
+		return nil, errors.New("lote não encontrado")
 	}
-	orderID, err := repository.CreateOrder(r.DB, userID, total, 30*time.Minute)
+	ed, _ := repository.EventDateByID(r.DB, lot.EventDateID)
+	if ed == nil {
+		return nil, errors.New("data não encontrada")
+	}
+	ev, _ := repository.EventByID(r.DB, ed.EventID)
+	if ev == nil {
+		return nil, errors.New("evento não encontrado")
+	}
+	prod, _ := repository.ProducerByID(r.DB, ev.ProducerID)
+	if prod == nil || prod.UserID != userID {
+		return nil, errors.New("sem permissão")
+	}
+	limits, err := purchaseLimitsFromInput(&input)
 	if err != nil {
 		return nil, err
 	}
-	for _, it := range input.Items {
-		tt, _ := repository.TicketTypeByID(r.DB, it.TicketTypeID)
-		if tt == nil {
-			continue
-		}
-		_, _ = repository.CreateOrderItem(r.DB, orderID, it.EventDateID, it.TicketTypeID, it.Quantity, tt.Price)
+	if err := repository.UpdateTicketTypePurchaseLimits(r.DB, tt.ID, limits); err != nil {
+		return nil, err
 	}
-	return &model.CheckoutPreviewResult{
-		CheckoutID: orderID,
-		Total:      total,
-		Items:      items,
-	}, nil
+	tt, _ = repository.TicketTypeByID(r.DB, tt.ID)
+	return ticketTypeRowToModel(tt), nil
 }
 
-// CheckoutPay is the resolver for the checkoutPay field.
-func (r *mutationResolver) CheckoutPay(ctx context.Context, input model.CheckoutPayInput) (*model.CheckoutPayResult, error) {
+// CreateEventArea is the resolver for the createEventArea field.
+func (r *mutationResolver) CreateEventArea(ctx context.Context, eventID string, input model.EventAreaInput) (*model.EventArea, error) {
 	userID := middleware.UserID(ctx)
 	if userID == "" {
 		return nil, errors.New("não autenticado")
 	}
-	orderUserID, status, _, err := repository.OrderByID(r.DB, input.CheckoutID)
-	if err != nil || orderUserID == "" {
-		return nil, errors.New("pedido não encontrado")
-	}
-	if orderUserID != userID {
-		return nil, errors.New("pedido não pertence ao usuário")
-	}
-	if status == "PAID" {
-		msg := "Pedido já pago."
-		return &model.CheckoutPayResult{Success: true, Message: &msg}, nil
+	if _, err := r.producerEvent(userID, eventID); err != nil {
+		return nil, err
 	}
-	items, err := repository.OrderItemsByOrderID(r.DB, input.CheckoutID)
+	name, capacity, err := eventAreaFromInput(input)
 	if err != nil {
 		return nil, err
 	}
-	var ticketIDs []string
-	for _, it := range items {
-		evDate, _ := repository.EventDateByID(r.DB, it.EventDateID)
-		if evDate == nil {
-			continue
-		}
-		ev, _ := repository.EventByID(r.DB, evDate.EventID)
-		if ev == nil {
-			continue
+	id, err := repository.CreateEventArea(r.DB, eventID, name, capacity)
+	if err != nil {
+		return nil, errors.New("já existe um setor com este nome")
+	}
+	a, _ := repository.EventAreaByID(r.DB, id)
+	return eventAreaRowToModel(a), nil
+}
+
+// UpdateEventArea is the resolver for the updateEventArea field.
+func (r *mutationResolver) UpdateEventArea(ctx context.Context, id string, input model.EventAreaInput) (*model.EventArea, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	a, _ := repository.EventAreaByID(r.DB, id)
+	if a == nil {
+		return nil, errors.New("setor não encontrado")
+	}
+	if _, err := r.producerEvent(userID, a.EventID); err != nil {
+		return nil, err
+	}
+	name, capacity, err := eventAreaFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.UpdateEventArea(r.DB, id, name, capacity); err != nil {
+		return nil, errors.New("já existe um setor com este nome")
+	}
+	a, _ = repository.EventAreaByID(r.DB, id)
+	return eventAreaRowToModel(a), nil
+}
+
+// DeleteEventArea is the resolver for the deleteEventArea field.
+func (r *mutationResolver) DeleteEventArea(ctx context.Context, id string) (bool, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return false, errors.New("não autenticado")
+	}
+	a, _ := repository.EventAreaByID(r.DB, id)
+	if a == nil {
+		return false, errors.New("setor não encontrado")
+	}
+	if _, err := r.producerEvent(userID, a.EventID); err != nil {
+		return false, err
+	}
+	ok, err := repository.DeleteEventArea(r.DB, id)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return false, errors.New("setor em uso por tipos de ingresso; troque o setor deles antes")
+	}
+	return true, nil
+}
+
+// SetTicketTypeArea is the resolver for the setTicketTypeArea field.
+func (r *mutationResolver) SetTicketTypeArea(ctx context.Context, ticketTypeID string, areaID *string) (*model.TicketType, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	ev, tt, err := checkout.TicketTypeEvent(r.DB, ticketTypeID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.producerEvent(userID, ev.ID); err != nil {
+		return nil, err
+	}
+	var area string
+	if areaID != nil && *areaID != "" {
+		if a, _ := repository.EventAreaByID(r.DB, *areaID); a == nil || a.EventID != ev.ID {
+			return nil, errors.New("setor não encontrado neste evento")
 		}
-		for i := 0; i < it.Quantity; i++ {
-			id := uuid.New().String()
-			code := repository.GenerateTicketCode()
-			qrPayload := qrcode.GenerateSignedPayload(id, []byte(r.Config.JWTSecret))
-			err := repository.CreateTicketWithID(r.DB, id, code, qrPayload, input.CheckoutID, it.ID, userID, ev.ID, it.EventDateID, it.TicketTypeID)
+		area = *areaID
+	}
+	if err := repository.SetTicketTypeArea(r.DB, tt.ID, area); err != nil {
+		return nil, err
+	}
+	tt, _ = repository.TicketTypeByID(r.DB, tt.ID)
+	return ticketTypeRowToModel(tt), nil
+}
+
+// CreateEventGate is the resolver for the createEventGate field.
+func (r *mutationResolver) CreateEventGate(ctx context.Context, eventDateID string, input model.EventGateInput) (*model.EventGate, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	ed, _ := repository.EventDateByID(r.DB, eventDateID)
+	if ed == nil {
+		return nil, errors.New("data não encontrada")
+	}
+	if _, err := r.eventAccess(userID, ed.EventID, repository.ScopeManage); err != nil {
+		return nil, err
+	}
+	name, areaIDs, err := eventGateFromInput(r.DB, ed.EventID, input)
+	if err != nil {
+		return nil, err
+	}
+	id, err := repository.CreateEventGate(r.DB, ed.ID, name, areaIDs)
+	if err != nil {
+		return nil, errors.New("já existe um portão com este nome nesta data")
+	}
+	g, _ := repository.EventGateByID(r.DB, id)
+	return eventGateRowToModel(r.DB, g), nil
+}
+
+// UpdateEventGate is the resolver for the updateEventGate field.
+func (r *mutationResolver) UpdateEventGate(ctx context.Context, id string, input model.EventGateInput) (*model.EventGate, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	g, ev, err := r.eventDateGate(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	name, areaIDs, err := eventGateFromInput(r.DB, ev.ID, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.UpdateEventGate(r.DB, g.ID, name, areaIDs); err != nil {
+		return nil, errors.New("já existe um portão com este nome nesta data")
+	}
+	g, _ = repository.EventGateByID(r.DB, g.ID)
+	return eventGateRowToModel(r.DB, g), nil
+}
+
+// DeleteEventGate is the resolver for the deleteEventGate field.
+func (r *mutationResolver) DeleteEventGate(ctx context.Context, id string) (bool, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return false, errors.New("não autenticado")
+	}
+	g, _, err := r.eventDateGate(userID, id)
+	if err != nil {
+		return false, err
+	}
+	if err := repository.DeleteEventGate(r.DB, g.ID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CreatePromoCode is the resolver for the createPromoCode field.
+func (r *mutationResolver) CreatePromoCode(ctx context.Context, eventID string, input model.PromoCodeInput) (*model.PromoCode, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	if _, err := r.eventAccess(userID, eventID, repository.ScopeManage); err != nil {
+		return nil, err
+	}
+	p, err := promoCodeFromInput(r.DB, eventID, input)
+	if err != nil {
+		return nil, err
+	}
+	if existing, _ := repository.PromoCodeByEventAndCode(r.DB, eventID, p.Code); existing != nil {
+		return nil, errors.New("o evento já tem um cupom com este código")
+	}
+	id, err := repository.CreatePromoCode(r.DB, p)
+	if err != nil {
+		return nil, err
+	}
+	p, _ = repository.PromoCodeByID(r.DB, id)
+	return promoCodeRowToModel(r.DB, p), nil
+}
+
+// UpdatePromoCode is the resolver for the updatePromoCode field.
+func (r *mutationResolver) UpdatePromoCode(ctx context.Context, id string, input model.PromoCodeInput) (*model.PromoCode, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	current, _ := repository.PromoCodeByID(r.DB, id)
+	if current == nil {
+		return nil, errors.New("cupom não encontrado")
+	}
+	if _, err := r.eventAccess(userID, current.EventID, repository.ScopeManage); err != nil {
+		return nil, err
+	}
+	p, err := promoCodeFromInput(r.DB, current.EventID, input)
+	if err != nil {
+		return nil, err
+	}
+	if existing, _ := repository.PromoCodeByEventAndCode(r.DB, current.EventID, p.Code); existing != nil && existing.ID != id {
+		return nil, errors.New("o evento já tem um cupom com este código")
+	}
+	p.ID = id
+	if err := repository.UpdatePromoCode(r.DB, p); err != nil {
+		return nil, err
+	}
+	p, _ = repository.PromoCodeByID(r.DB, id)
+	return promoCodeRowToModel(r.DB, p), nil
+}
+
+// IssueCourtesyTickets is the resolver for the issueCourtesyTickets field.
+func (r *mutationResolver) IssueCourtesyTickets(ctx context.Context, eventDateID string, ticketTypeID string, recipients []*model.CourtesyRecipientInput, guestList *bool) ([]*model.CourtesyTicket, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	tt, _ := repository.TicketTypeByID(r.DB, ticketTypeID)
+	if tt == nil {
+		return nil, errors.New("tipo de ingresso não encontrado")
+	}
+	lot, _ := repository.LotByID(r.DB, tt.LotID)
+	if lot == nil || lot.EventDateID != eventDateID {
+		return nil, errors.New("tipo de ingresso não pertence a esta data")
+	}
+	ed, _ := repository.EventDateByID(r.DB, eventDateID)
+	if ed == nil {
+		return nil, errors.New("data não encontrada")
+	}
+	ev, err := r.eventAccess(userID, ed.EventID, repository.ScopeManage)
+	if err != nil {
+		return nil, err
+	}
+	if ev.Status == string(model.EventStatusRemoved) {
+		return nil, errors.New("evento removido pela administração")
+	}
+	list, err := validateCourtesyRecipients(recipients, guestList != nil && *guestList)
+	if err != nil {
+		return nil, err
+	}
+	issues := make([]checkout.CourtesyIssue, 0, len(list))
+	for _, c := range list {
+		c.IssuedBy = userID
+		issue := checkout.CourtesyIssue{Courtesy: c, OwnerID: userID}
+		// The ticket goes to the account with the recipient's email, if any, whose profile must
+		// meet the event's minimum age and the ticket type's audience as in a purchase; otherwise
+		// (and on the guest list) it stays with the issuing user and staff check at the door.
+		if !c.GuestList && c.RecipientEmail.Valid {
+			u, err := repository.UserByEmail(r.DB, c.RecipientEmail.String)
 			if err != nil {
-				continue
+				return nil, err
+			}
+			if u != nil {
+				for _, recipient := range checkout.TicketAttendees(u, tt, nil) {
+					recipient.Label = "convidado " + c.RecipientName + ": "
+					if err := checkout.CheckTicketAudience(ev, ed, tt, recipient); err != nil {
+						return nil, err
+					}
+				}
+				issue.OwnerID = u.ID
+			}
+		}
+		issues = append(issues, issue)
+	}
+	ticketIDs, err := checkout.IssueCourtesyTickets(r.DB, ev, tt, eventDateID, issues, func(ticketID, orderID string) (string, error) {
+		return r.QRKeys.Sign(ticketID, orderID, ev.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.CourtesyTicket, 0, len(ticketIDs))
+	for _, id := range ticketIDs {
+		r.Outbox.CourtesyIssued(id)
+		if row, _ := repository.CourtesyTicketByTicketID(r.DB, id); row != nil {
+			out = append(out, courtesyTicketRowToModel(r.DB, row))
+		}
+	}
+	return out, nil
+}
+
+// AddEventStaff is the resolver for the addEventStaff field.
+func (r *mutationResolver) AddEventStaff(ctx context.Context, eventID string, email string, role model.StaffRole) (*model.EventStaff, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	if _, err := r.producerEvent(userID, eventID); err != nil {
+		return nil, err
+	}
+	if !role.IsValid() {
+		return nil, errors.New("papel inválido")
+	}
+	member, _ := repository.UserByEmail(r.DB, repository.NormalizeEmail(email))
+	if member == nil {
+		return nil, errors.New("nenhuma conta com este e-mail; peça para a pessoa se cadastrar antes")
+	}
+	if member.ID == userID {
+		return nil, errors.New("o produtor já tem acesso total ao evento")
+	}
+	id, err := repository.AddEventStaff(r.DB, eventID, member.ID, string(role), userID)
+	if err != nil {
+		return nil, err
+	}
+	s, _ := repository.EventStaffByID(r.DB, id)
+	return eventStaffRowToModel(r.DB, s), nil
+}
+
+// RevokeEventStaff is the resolver for the revokeEventStaff field.
+func (r *mutationResolver) RevokeEventStaff(ctx context.Context, id string) (*model.EventStaff, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	s, _ := repository.EventStaffByID(r.DB, id)
+	if s == nil {
+		return nil, errors.New("membro da equipe não encontrado")
+	}
+	if _, err := r.producerEvent(userID, s.EventID); err != nil {
+		return nil, err
+	}
+	ok, err := repository.RevokeEventStaff(r.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("membro já removido da equipe")
+	}
+	s, _ = repository.EventStaffByID(r.DB, id)
+	return eventStaffRowToModel(r.DB, s), nil
+}
+
+// RegisterScannerDevice is the resolver for the registerScannerDevice field.
+func (r *mutationResolver) RegisterScannerDevice(ctx context.Context, eventID string, name string) (*model.ScannerDeviceRegistration, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	if _, err := r.eventAccess(userID, eventID, repository.ScopeCheckIn); err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("nome do aparelho é obrigatório")
+	}
+	key, err := checkin.NewDeviceKey()
+	if err != nil {
+		return nil, err
+	}
+	id, err := repository.CreateScannerDevice(r.DB, eventID, userID, name, key)
+	if err != nil {
+		return nil, err
+	}
+	d, _ := repository.ScannerDeviceByID(r.DB, id)
+	return &model.ScannerDeviceRegistration{Device: scannerDeviceRowToModel(r.DB, d), DeviceKey: key}, nil
+}
+
+// RevokeScannerDevice is the resolver for the revokeScannerDevice field.
+func (r *mutationResolver) RevokeScannerDevice(ctx context.Context, id string) (*model.ScannerDevice, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	d, _ := repository.ScannerDeviceByID(r.DB, id)
+	if d == nil {
+		return nil, errors.New("aparelho não encontrado")
+	}
+	// The person who registered it, or the producer and managers (lost or stolen devices)
+	if d.UserID != userID {
+		if _, err := r.eventAccess(userID, d.EventID, repository.ScopeManage); err != nil {
+			return nil, err
+		}
+	}
+	ok, err := repository.RevokeScannerDevice(r.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("aparelho já revogado")
+	}
+	d, _ = repository.ScannerDeviceByID(r.DB, id)
+	return scannerDeviceRowToModel(r.DB, d), nil
+}
+
+// SyncCheckins is the resolver for the syncCheckins field.
+func (r *mutationResolver) SyncCheckins(ctx context.Context, deviceID string, scans []*model.OfflineScanInput) ([]*model.OfflineCheckinResult, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	d, ev, err := r.scannerDevice(userID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(scans) > maxSyncBatch {
+		return nil, fmt.Errorf("máximo de %d leituras por sincronização", maxSyncBatch)
+	}
+	list := make([]*repository.OfflineCheckinRow, 0, len(scans))
+	for _, in := range scans {
+		c, err := offlineScanFromInput(d.ID, in)
+		if err != nil {
+			return nil, err
+		}
+		if c.QRRefusal, err = r.offlineQRRefusal(ev, c, strings.TrimSpace(in.QRCode)); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	out := make([]*model.OfflineCheckinResult, 0, len(list))
+	for _, c := range list {
+		res, err := repository.RecordOfflineCheckin(r.DB, c, ev.ID, ev.ProducerID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if res.Admitted {
+			_ = repository.CancelResaleListingsByTicket(r.DB, c.TicketID)
+			r.Wallet.TicketsChanged(c.TicketID)
+			if t, _ := repository.TicketByID(r.DB, c.TicketID); t != nil {
+				scannedAt, _ := time.Parse("2006-01-02 15:04:05", c.ScannedAt)
+				r.Checkins.Publish(checkin.Validation{
+					ID:           res.ValidationID,
+					TicketID:     t.ID,
+					EventID:      ev.ID,
+					EventDateID:  t.EventDateID,
+					TicketTypeID: t.TicketTypeID,
+					ValidatedBy:  userID,
+					Direction:    repository.CheckinIn,
+					Gate:         c.Gate.String,
+					Offline:      true,
+					ValidatedAt:  scannedAt,
+				})
 			}
-			ticketIDs = append(ticketIDs, id)
-			repository.IncrementTicketTypeSold(r.DB, it.TicketTypeID, 1)
-			lotID, _ := repository.LotIDByTicketTypeID(r.DB, it.TicketTypeID)
-			repository.DecrementLotAvailable(r.DB, lotID, 1)
 		}
+		out = append(out, offlineCheckinResultToModel(res))
+	}
+	_ = repository.TouchScannerDeviceSync(r.DB, d.ID)
+	return out, nil
+}
+
+// CheckoutPreview is the resolver for the checkoutPreview field.
+func (r *mutationResolver) CheckoutPreview(ctx context.Context, input model.CheckoutInput) (*model.CheckoutPreviewResult, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
 	}
-	if err := repository.ConfirmOrder(r.DB, input.CheckoutID); err != nil {
+	if err := r.requireVerifiedEmail(userID); err != nil {
+		return nil, err
+	}
+	if len(input.Items) == 0 {
+		return nil, errors.New("nenhum item")
+	}
+	buyer, _ := repository.UserByID(r.DB, userID)
+	if buyer == nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+	var total float64
+	var items []*model.CheckoutPreviewItem
+	holders := make([][]repository.TicketHolder, len(input.Items))
+	halfPriceDocs := make([][]string, len(input.Items))
+	halfPriceRequested := map[string]int{}
+	for i, it := range input.Items {
+		tt, _ := repository.TicketTypeByID(r.DB, it.TicketTypeID)
+		if tt == nil {
+			return nil, errors.New("tipo de ingresso não encontrado")
+		}
+		// The date drives the half-price quota, the age check and the tickets issued: it must be the ticket type's.
+		lot, _ := repository.LotByID(r.DB, tt.LotID)
+		if lot == nil || lot.EventDateID != it.EventDateID {
+			return nil, errors.New("tipo de ingresso não pertence a esta data")
+		}
+		if it.Quantity <= 0 || tt.SoldQuantity+it.Quantity > tt.MaxQuantity {
+			return nil, errors.New("quantidade indisponível")
+		}
+		itemHolders, err := validateTicketHolders(tt, it.Quantity, it.Holders)
+		if err != nil {
+			return nil, err
+		}
+		holders[i] = itemHolders
+		docs, err := validateHalfPriceDocuments(tt, it.Quantity, it.HalfPriceDocuments)
+		if err != nil {
+			return nil, err
+		}
+		halfPriceDocs[i] = docs
+		if tt.HalfPriceEntitlement.Valid {
+			halfPriceRequested[it.EventDateID] += it.Quantity
+		}
+		ed, _ := repository.EventDateByID(r.DB, it.EventDateID)
+		if ed == nil {
+			return nil, errors.New("data não encontrada")
+		}
+		ev, _ := repository.EventByID(r.DB, ed.EventID)
+		if ev == nil {
+			return nil, errors.New("evento não encontrado")
+		}
+		if err := checkout.CheckEventOnSale(ev); err != nil {
+			return nil, err
+		}
+		// Restrictions apply to each nominal holder, or to the buyer when no holders were given.
+		for _, a := range checkout.TicketAttendees(buyer, tt, itemHolders) {
+			if err := checkout.CheckTicketAudience(ev, ed, tt, a); err != nil {
+				return nil, err
+			}
+		}
+		sub := float64(it.Quantity) * tt.Price
+		total += sub
+		items = append(items, &model.CheckoutPreviewItem{
+			EventTitle:     ev.Title,
+			EventDate:      ed.Date,
+			TicketTypeName: tt.Name,
+			Quantity:       it.Quantity,
+			UnitPrice:      tt.Price,
+			Subtotal:       sub,
+		})
+	}
+	if err := checkout.CheckHalfPriceQuota(r.DB, halfPriceRequested); err != nil {
+		return nil, err
+	}
+	cart := make([]checkout.Item, 0, len(input.Items))
+	for i, it := range input.Items {
+		item := checkout.Item{EventDateID: it.EventDateID, TicketTypeID: it.TicketTypeID, Quantity: it.Quantity}
+		for _, h := range holders[i] {
+			item.HolderCPFs = append(item.HolderCPFs, h.CPF)
+		}
+		cart = append(cart, item)
+	}
+	if err := checkout.CheckPurchaseLimits(r.DB, buyer, cart); err != nil {
+		return nil, err
+	}
+	orderID, err := repository.CreateOrder(r.DB, userID, total, 30*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	for i, it := range input.Items {
+		tt, _ := repository.TicketTypeByID(r.DB, it.TicketTypeID)
+		if tt == nil {
+			continue
+		}
+		itemID, err := repository.CreateOrderItem(r.DB, orderID, it.EventDateID, it.TicketTypeID, it.Quantity, tt.Price)
+		if err != nil {
+			continue
+		}
+		if err := repository.CreateOrderItemHolders(r.DB, itemID, holders[i]); err != nil {
+			return nil, err
+		}
+		if err := repository.CreateOrderItemHalfPriceDocuments(r.DB, itemID, halfPriceDocs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return &model.CheckoutPreviewResult{
+		CheckoutID: orderID,
+		Total:      total,
+		Items:      items,
+	}, nil
+}
+
+// ApplyPromoCode is the resolver for the applyPromoCode field.
+func (r *mutationResolver) ApplyPromoCode(ctx context.Context, checkoutID string, code *string) (*model.CheckoutPreviewResult, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	orderUserID, status, _, err := repository.OrderByID(r.DB, checkoutID)
+	if err != nil || orderUserID == "" {
+		return nil, errors.New("pedido não encontrado")
+	}
+	if orderUserID != userID {
+		return nil, errors.New("pedido não pertence ao usuário")
+	}
+	if status != "PENDING" {
+		return nil, errors.New("pedido já processado")
+	}
+	if listing, _ := repository.ResaleListingByOrder(r.DB, checkoutID); listing != nil {
+		return nil, errors.New("cupons não valem para a revenda")
+	}
+	var c string
+	if code != nil {
+		c = *code
+	}
+	if err := checkout.ApplyPromoCode(r.DB, checkoutID, userID, c); err != nil {
+		return nil, err
+	}
+	return orderCheckoutPreview(r.DB, checkoutID)
+}
+
+// CheckoutPay is the resolver for the checkoutPay field.
+func (r *mutationResolver) CheckoutPay(ctx context.Context, input model.CheckoutPayInput) (*model.CheckoutPayResult, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	orderUserID, status, _, err := repository.OrderByID(r.DB, input.CheckoutID)
+	if err != nil || orderUserID == "" {
+		return nil, errors.New("pedido não encontrado")
+	}
+	if orderUserID != userID {
+		return nil, errors.New("pedido não pertence ao usuário")
+	}
+	if err := r.requireVerifiedEmail(userID); err != nil {
+		return nil, err
+	}
+	if status == "PAID" {
+		msg := "Pedido já pago."
+		return &model.CheckoutPayResult{Success: true, Message: &msg}, nil
+	}
+	// Resales are settled only when the PIX is paid (Pagar.me webhook), which also pays the seller.
+	if listing, _ := repository.ResaleListingByOrder(r.DB, input.CheckoutID); listing != nil {
+		return nil, errors.New("revendas são pagas apenas pelo PIX (POST /api/pagarme/payment/create)")
+	}
+	// Limits are checked again: other orders may have been paid since the preview.
+	cart, err := checkout.OrderItems(r.DB, input.CheckoutID)
+	if err != nil {
+		return nil, err
+	}
+	buyer, _ := repository.UserByID(r.DB, userID)
+	if buyer == nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+	if err := checkout.CheckOrderOnSale(r.DB, input.CheckoutID); err != nil {
+		return nil, err
+	}
+	if err := checkout.CheckPurchaseLimits(r.DB, buyer, cart); err != nil {
+		return nil, err
+	}
+	if err := checkout.CheckOrderAudience(r.DB, input.CheckoutID, buyer); err != nil {
+		return nil, err
+	}
+	if err := checkout.CheckOrderPromoCode(r.DB, input.CheckoutID, userID); err != nil {
+		return nil, err
+	}
+	ticketIDs, err := checkout.FulfillOrder(r.DB, input.CheckoutID, func(ticketID, eventID string) (string, error) {
+		return r.QRKeys.Sign(ticketID, input.CheckoutID, eventID)
+	})
+	if errors.Is(err, checkout.ErrOrderNotPending) {
+		msg := "Pedido já processado."
+		return &model.CheckoutPayResult{Success: false, Message: &msg}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.Outbox.OrderPaid(input.CheckoutID)
+	msg := "Após a confirmação do pagamento, o ingresso ficará disponível na sua Mochila de Tickets."
+	return &model.CheckoutPayResult{
+		Success:   true,
+		TicketIds: ticketIDs,
+		Message:   &msg,
+	}, nil
+}
+
+// UpdateProfilePhoto is the resolver for the updateProfilePhoto field.
+func (r *mutationResolver) UpdateProfilePhoto(ctx context.Context, photoBase64 string) (*model.User, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	payload := photoBase64
+	if len(payload) > 7 && (payload[:7] == "data:img" || payload[:10] == "data:image/") {
+		idx := 0
+		for i, c := range payload {
+			if c == ',' {
+				idx = i + 1
+				break
+			}
+		}
+		if idx > 0 {
+			payload = payload[idx:]
+		}
+	}
+	if len(payload) > 300*1024 { // ~300 KB in base64 is ~400k chars; allow up to 400k for safety
+		return nil, errors.New("imagem muito grande; máximo 300 KB")
+	}
+	// Accept only base64 that decodes to image (we store as data URI for display)
+	normalized := "data:image/jpeg;base64," + payload
+	if len(normalized) > 500*1024 {
+		return nil, errors.New("imagem muito grande; máximo 300 KB")
+	}
+	if err := repository.UpdateUserPhotoURL(r.DB, userID, normalized); err != nil {
+		return nil, err
+	}
+	user, _ := repository.UserByID(r.DB, userID)
+	return userRowToModel(user), nil
+}
+
+// UpdateProfileGender is the resolver for the updateProfileGender field.
+func (r *mutationResolver) UpdateProfileGender(ctx context.Context, gender *model.Gender) (*model.User, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	var g string
+	if gender != nil {
+		g = string(*gender)
+	}
+	if err := repository.UpdateUserGender(r.DB, userID, g); err != nil {
+		return nil, err
+	}
+	user, _ := repository.UserByID(r.DB, userID)
+	return userRowToModel(user), nil
+}
+
+// ValidateTicket is the resolver for the validateTicket field.
+// Uses signed QR payloads; validates then marks ticket as used in a single atomic update to prevent double validation.
+func (r *mutationResolver) ValidateTicket(ctx context.Context, eventID string, qrCode string, direction *model.CheckinDirection, gate *string, gateID *string) (*model.ValidateTicketResult, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("UNAUTHORIZED"), Message: strPtr("não autenticado")}, nil
+	}
+	eventProducerID, err := repository.EventProducerID(r.DB, eventID)
+	if err != nil || eventProducerID == "" {
+		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("NOT_FOUND"), Message: strPtr("evento não encontrado")}, nil
+	}
+	// The event's producer or its door staff (SCANNER/MANAGER)
+	if _, err := r.eventAccess(userID, eventID, repository.ScopeCheckIn); err != nil {
+		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("FORBIDDEN"), Message: strPtr("você não é o produtor nem da equipe de portaria deste evento")}, nil
+	}
+	// QR lookup: dynamic payloads are checked with the ticket's dynamic secret and time window;
+	// static ones by direct DB match first, then by signature (V3 keyring, V2/V1 legacy secret).
+	dynamic := qrcode.IsDynamicPayload(qrCode)
+	var t *repository.TicketRow
+	if dynamic {
+		if ticketID, ok := qrcode.DynamicPayloadTicketID(qrCode); ok {
+			t, err = repository.TicketByID(r.DB, ticketID)
+		}
+		if err == nil && t != nil {
+			// The secret is unavailable only when the key of the static payload is gone: unverifiable.
+			secret, serr := r.QRKeys.DynamicSecret(t.QRCode)
+			if serr != nil {
+				t = nil
+			} else {
+				switch qrcode.VerifyDynamicPayload(qrCode, secret, time.Now()) {
+				case nil:
+				case qrcode.ErrDynamicExpired:
+					return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("QR_EXPIRED"), Message: strPtr("QR Code expirado; peça para abrir o ingresso no app novamente")}, nil
+				default:
+					t = nil
+				}
+			}
+		}
+	} else {
+		t, err = repository.TicketByQRCode(r.DB, qrCode)
+		if err != nil || t == nil {
+			if ticketID, sigOK := r.QRKeys.Verify(qrCode); sigOK {
+				t, err = repository.TicketByID(r.DB, ticketID)
+			}
+		}
+	}
+	if err != nil || t == nil {
+		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("NOT_FOUND"), Message: strPtr("ingresso não encontrado")}, nil
+	}
+	// After a transfer only the reissued payload is valid (old owner's screenshot is rejected).
+	if !dynamic && t.QRCode != qrCode {
+		if reissued, _ := repository.TicketQRReissued(r.DB, t.ID); reissued {
+			return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("QR_REISSUED"), Message: strPtr("QR Code substituído após transferência do ingresso")}, nil
+		}
+		// V3 payloads are stored as issued, so a different one was replaced (e.g. re-signed when
+		// its key was retired).
+		if _, isV3 := qrcode.ParseV3(qrCode); isV3 {
+			return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("QR_REISSUED"), Message: strPtr("QR Code substituído; abra o ingresso no app novamente")}, nil
+		}
+	}
+	if t.EventID != eventID {
+		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("WRONG_EVENT"), Message: strPtr("ingresso não pertence a este evento")}, nil
+	}
+	ev, err := repository.EventByID(r.DB, eventID)
+	if err != nil || ev == nil {
+		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("NOT_FOUND"), Message: strPtr("evento não encontrado")}, nil
+	}
+	// Rotating QR codes only count while the event uses them; with them on, the producer can
+	// refuse the static payload, which can be forwarded.
+	switch {
+	case dynamic && ev.DynamicQR == 0:
+		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("DYNAMIC_QR_NOT_ALLOWED"), Message: strPtr("este evento não usa QR Code dinâmico; apresente o QR Code do ingresso")}, nil
+	case !dynamic && ev.DynamicQR == 1 && ev.StaticQRFallback == 0:
+		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("STATIC_QR_NOT_ALLOWED"), Message: strPtr("este evento só aceita o QR Code dinâmico do app")}, nil
+	}
+	dir := repository.CheckinIn
+	if direction != nil && *direction == model.CheckinDirectionOut {
+		dir = repository.CheckinOut
+	}
+	// Nominal tickets are only let in with a holder, whose data staff check against an ID.
+	tt, _ := repository.TicketTypeByID(r.DB, t.TicketTypeID)
+	holder, _ := repository.TicketHolderByTicketID(r.DB, t.ID)
+	if dir == repository.CheckinIn && holder == nil && tt != nil && tt.Nominal == 1 {
+		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("HOLDER_MISSING"), Message: strPtr("ingresso nominal sem titular informado")}, nil
+	}
+	v := &repository.TicketValidationRow{EventID: eventID, ProducerID: eventProducerID, ValidatedBy: userID, Direction: dir}
+	if gate != nil {
+		v.Gate = strings.TrimSpace(*gate)
+	}
+	if failed := checkGate(r.DB, t, tt, gateID, v); failed != nil {
+		return failed, nil
+	}
+	if failed := r.admitTicket(t, tt, v); failed != nil {
+		return failed, nil
+	}
+	return admittedResult(r.DB, t, tt, holder, v), nil
+}
+
+func strPtr(s string) *string { return &s }
+
+// ManualCheckin is the resolver for the manualCheckin field.
+func (r *mutationResolver) ManualCheckin(ctx context.Context, ticketCode string, reason string, gate *string, gateID *string) (*model.ValidateTicketResult, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("UNAUTHORIZED"), Message: strPtr("não autenticado")}, nil
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("REASON_REQUIRED"), Message: strPtr("informe o motivo da entrada manual")}, nil
+	}
+	t, err := repository.TicketByCode(r.DB, strings.TrimSpace(ticketCode))
+	if err != nil {
+		return &model.ValidateTicketResult{Success: false, Message: strPtr("erro ao validar")}, nil
+	}
+	// Only the producer or a MANAGER of the ticket's event; door staff scan the QR code. Codes of
+	// other events get the same answer as unknown ones, so the mutation does not reveal which exist.
+	var ev *repository.EventRow
+	if t != nil {
+		ev, err = r.eventAccess(userID, t.EventID, repository.ScopeManage)
+	}
+	if t == nil || err != nil {
+		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("NOT_FOUND"), Message: strPtr("ingresso não encontrado nos eventos que você gerencia")}, nil
+	}
+	tt, _ := repository.TicketTypeByID(r.DB, t.TicketTypeID)
+	holder, _ := repository.TicketHolderByTicketID(r.DB, t.ID)
+	if holder == nil && tt != nil && tt.Nominal == 1 {
+		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("HOLDER_MISSING"), Message: strPtr("ingresso nominal sem titular informado")}, nil
+	}
+	v := &repository.TicketValidationRow{EventID: ev.ID, ProducerID: ev.ProducerID, ValidatedBy: userID, Direction: repository.CheckinIn, ManualReason: reason}
+	if gate != nil {
+		v.Gate = strings.TrimSpace(*gate)
+	}
+	if failed := checkGate(r.DB, t, tt, gateID, v); failed != nil {
+		return failed, nil
+	}
+	if failed := r.admitTicket(t, tt, v); failed != nil {
+		return failed, nil
+	}
+	return admittedResult(r.DB, t, tt, holder, v), nil
+}
+
+// RevertTicketValidation is the resolver for the revertTicketValidation field.
+func (r *mutationResolver) RevertTicketValidation(ctx context.Context, validationID string, reason string) (*model.TicketValidation, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	v, _ := repository.TicketValidationByID(r.DB, validationID)
+	if v == nil {
+		return nil, errors.New("leitura não encontrada")
+	}
+	if _, err := r.eventAccess(userID, v.EventID, repository.ScopeManage); err != nil {
+		return nil, errors.New("apenas o produtor e os gerentes do evento desfazem leituras")
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("informe o motivo")
+	}
+	if v.RevertedAt.Valid {
+		return nil, errors.New("leitura já desfeita")
+	}
+	ok, err := repository.RevertTicketValidation(r.DB, v.ID, userID, reason)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("só a leitura mais recente do ingresso pode ser desfeita")
+	}
+	if t, _ := repository.TicketByID(r.DB, v.TicketID); t != nil {
+		if t.Used == 0 {
+			r.Wallet.TicketsChanged(t.ID)
+		}
+		r.publishValidation(t, v, true)
+	}
+	v, _ = repository.TicketValidationByID(r.DB, v.ID)
+	return ticketValidationRowToModel(r.DB, v), nil
+}
+
+// CheckInGuest is the resolver for the checkInGuest field.
+func (r *mutationResolver) CheckInGuest(ctx context.Context, eventID string, ticketID string, gateID *string) (*model.ValidateTicketResult, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("UNAUTHORIZED"), Message: strPtr("não autenticado")}, nil
+	}
+	ev, err := r.eventAccess(userID, eventID, repository.ScopeCheckIn)
+	if err != nil {
+		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("FORBIDDEN"), Message: strPtr(err.Error())}, nil
+	}
+	c, _ := repository.CourtesyTicketByTicketID(r.DB, ticketID)
+	t, _ := repository.TicketByID(r.DB, ticketID)
+	if c == nil || !c.GuestList || t == nil {
+		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("NOT_FOUND"), Message: strPtr("convidado não encontrado na lista")}, nil
+	}
+	if t.EventID != eventID {
+		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("WRONG_EVENT"), Message: strPtr("convidado não pertence a este evento")}, nil
+	}
+	tt, _ := repository.TicketTypeByID(r.DB, t.TicketTypeID)
+	v := &repository.TicketValidationRow{EventID: eventID, ProducerID: ev.ProducerID, ValidatedBy: userID, Direction: repository.CheckinIn}
+	if failed := checkGate(r.DB, t, tt, gateID, v); failed != nil {
+		return failed, nil
+	}
+	if failed := r.admitTicket(t, tt, v); failed != nil {
+		return failed, nil
+	}
+	ticket, _ := staffTicketRowToModel(r.DB, t)
+	result := &model.ValidateTicketResult{Success: true, Ticket: ticket, ValidationID: &v.ID, Message: strPtr("convidado: " + c.RecipientName)}
+	entryCounts(r.DB, result, tt, t.ID, repository.CheckinIn)
+	return result, nil
+}
+
+// TransferTicket is the resolver for the transferTicket field.
+func (r *mutationResolver) TransferTicket(ctx context.Context, ticketID string, recipientEmail string) (*model.TicketTransfer, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	t, _ := repository.TicketByID(r.DB, ticketID)
+	if t == nil || t.UserID != userID {
+		return nil, errors.New("ingresso não encontrado")
+	}
+	email := repository.NormalizeEmail(recipientEmail)
+	if !strings.Contains(email, "@") {
+		return nil, errors.New("e-mail do destinatário inválido")
+	}
+	if user, _ := repository.UserByID(r.DB, userID); user != nil && repository.NormalizeEmail(user.Email) == email {
+		return nil, errors.New("não é possível transferir um ingresso para você mesmo")
+	}
+	if err := ticketTransferable(r.DB, t); err != nil {
+		return nil, err
+	}
+	if pending, _ := repository.PendingTicketTransfer(r.DB, t.ID); pending != nil {
+		return nil, errors.New("este ingresso já tem uma transferência pendente; cancele-a antes de fazer outra")
+	}
+	if listing, _ := repository.ActiveResaleListingByTicket(r.DB, t.ID); listing != nil {
+		return nil, errors.New("este ingresso está anunciado na revenda; cancele o anúncio antes de transferir")
+	}
+	id, err := repository.CreateTicketTransfer(r.DB, t.ID, userID, email)
+	if err != nil {
+		return nil, err
+	}
+	r.Outbox.TicketTransferOffered(id)
+	tr, _ := repository.TicketTransferByID(r.DB, id)
+	return ticketTransferRowToModel(r.DB, tr, userID), nil
+}
+
+// AcceptTicketTransfer is the resolver for the acceptTicketTransfer field.
+func (r *mutationResolver) AcceptTicketTransfer(ctx context.Context, transferID string) (*model.Ticket, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	user, _ := repository.UserByID(r.DB, userID)
+	tr, _ := repository.TicketTransferByID(r.DB, transferID)
+	if user == nil || tr == nil || repository.NormalizeEmail(user.Email) != tr.RecipientEmail {
+		return nil, errors.New("transferência não encontrada")
+	}
+	if tr.Status != repository.TransferPending {
+		return nil, errors.New("esta transferência não está mais pendente")
+	}
+	// The offer is addressed to an email, so the recipient must prove they own it.
+	if !user.EmailVerifiedAt.Valid {
+		return nil, errors.New("confirme seu e-mail antes de aceitar a transferência")
+	}
+	t, _ := repository.TicketByID(r.DB, tr.TicketID)
+	if t == nil {
+		return nil, errors.New("ingresso não encontrado")
+	}
+	if err := ticketTransferable(r.DB, t); err != nil {
+		return nil, err
+	}
+	if err := checkTicketOwnerAudience(r.DB, t, user); err != nil {
+		return nil, err
+	}
+	// Reissued payload, with the transfer ID as the issue ID.
+	newQR, err := r.QRKeys.Sign(t.ID, tr.ID, t.EventID)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := repository.AcceptTicketTransfer(r.DB, tr.ID, userID, newQR)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("não foi possível concluir a transferência; o ingresso mudou de situação")
+	}
+	r.Wallet.TicketTransferred(t.ID, t.QRCode)
+	r.Outbox.TicketTransferAccepted(tr.ID)
+	t, _ = repository.TicketByID(r.DB, t.ID)
+	ticket, err := ticketRowToModel(r.DB, t)
+	if err != nil {
+		return nil, err
+	}
+	r.withOwnerLinks(ticket, t)
+	return ticket, nil
+}
+
+// DeclineTicketTransfer is the resolver for the declineTicketTransfer field.
+func (r *mutationResolver) DeclineTicketTransfer(ctx context.Context, transferID string) (*model.TicketTransfer, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	user, _ := repository.UserByID(r.DB, userID)
+	tr, _ := repository.TicketTransferByID(r.DB, transferID)
+	if user == nil || tr == nil || repository.NormalizeEmail(user.Email) != tr.RecipientEmail {
+		return nil, errors.New("transferência não encontrada")
+	}
+	return r.closeTicketTransfer(tr, userID, repository.TransferDeclined)
+}
+
+// CancelTicketTransfer is the resolver for the cancelTicketTransfer field.
+func (r *mutationResolver) CancelTicketTransfer(ctx context.Context, transferID string) (*model.TicketTransfer, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	tr, _ := repository.TicketTransferByID(r.DB, transferID)
+	if tr == nil || tr.FromUserID != userID {
+		return nil, errors.New("transferência não encontrada")
+	}
+	return r.closeTicketTransfer(tr, userID, repository.TransferCancelled)
+}
+
+// ListTicketForResale is the resolver for the listTicketForResale field.
+func (r *mutationResolver) ListTicketForResale(ctx context.Context, ticketID string, price float64) (*model.ResaleListing, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	t, _ := repository.TicketByID(r.DB, ticketID)
+	if t == nil || t.UserID != userID {
+		return nil, errors.New("ingresso não encontrado")
+	}
+	ev, err := ticketResellable(r.DB, t)
+	if err != nil {
+		return nil, err
+	}
+	if existing, _ := repository.ActiveResaleListingByTicket(r.DB, t.ID); existing != nil {
+		return nil, errors.New("este ingresso já está anunciado na revenda")
+	}
+	if pending, _ := repository.PendingTicketTransfer(r.DB, t.ID); pending != nil {
+		return nil, errors.New("este ingresso tem uma transferência pendente; cancele-a antes de revender")
+	}
+	// With Pagar.me the seller is paid through the split, so a recipient is required.
+	if r.Config.PagarmeAPIKey != "" {
+		if recipientID, _ := repository.GetUserPagarmeRecipientID(r.DB, userID); recipientID == "" {
+			return nil, errors.New("cadastre sua conta de recebimento antes de revender ingressos")
+		}
+	}
+	listing, err := r.resaleListing(t, ev, userID, price)
+	if err != nil {
+		return nil, err
+	}
+	id, err := repository.CreateResaleListing(r.DB, listing)
+	if err != nil {
+		return nil, err
+	}
+	l, _ := repository.ResaleListingByID(r.DB, id)
+	return resaleListingRowToModel(r.DB, l), nil
+}
+
+// CancelResaleListing is the resolver for the cancelResaleListing field.
+func (r *mutationResolver) CancelResaleListing(ctx context.Context, listingID string) (*model.ResaleListing, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	l, _ := repository.ResaleListingByID(r.DB, listingID)
+	if l == nil || l.SellerID != userID {
+		return nil, errors.New("anúncio não encontrado")
+	}
+	if l.Status != repository.ResaleActive {
+		return nil, errors.New("este anúncio não está mais ativo")
+	}
+	ok, err := repository.CancelResaleListing(r.DB, l.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("um comprador está pagando por este ingresso; tente novamente em alguns minutos")
+	}
+	l, _ = repository.ResaleListingByID(r.DB, l.ID)
+	return resaleListingRowToModel(r.DB, l), nil
+}
+
+// BuyResaleTicket is the resolver for the buyResaleTicket field.
+func (r *mutationResolver) BuyResaleTicket(ctx context.Context, listingID string) (*model.CheckoutPreviewResult, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	if err := r.requireVerifiedEmail(userID); err != nil {
+		return nil, err
+	}
+	l, _ := repository.ResaleListingByID(r.DB, listingID)
+	if l == nil || l.Status != repository.ResaleActive {
+		return nil, errors.New("anúncio não encontrado")
+	}
+	if l.SellerID == userID {
+		return nil, errors.New("você não pode comprar o seu próprio anúncio")
+	}
+	t, _ := repository.TicketByID(r.DB, l.TicketID)
+	if t == nil {
+		return nil, errors.New("ingresso não encontrado")
+	}
+	ev, err := ticketResellable(r.DB, t)
+	if err != nil {
+		return nil, err
+	}
+	buyer, _ := repository.UserByID(r.DB, userID)
+	if err := checkTicketOwnerAudience(r.DB, t, buyer); err != nil {
+		return nil, err
+	}
+	orderID, err := repository.CreateOrder(r.DB, userID, l.Price, resaleReservationTTL)
+	if err != nil {
+		return nil, err
+	}
+	reserved, err := repository.ReserveResaleListing(r.DB, l.ID, orderID, resaleReservationTTL)
+	if err != nil {
+		return nil, err
+	}
+	if !reserved {
+		return nil, errors.New("este ingresso acabou de ser reservado por outra pessoa")
+	}
+	if _, err := repository.CreateOrderItem(r.DB, orderID, t.EventDateID, t.TicketTypeID, 1, l.Price); err != nil {
+		return nil, err
+	}
+	item := &model.CheckoutPreviewItem{
+		EventTitle: ev.Title,
+		Quantity:   1,
+		UnitPrice:  l.Price,
+		Subtotal:   l.Price,
+	}
+	if d, _ := repository.EventDateByID(r.DB, t.EventDateID); d != nil {
+		item.EventDate = d.Date
+	}
+	if tt, _ := repository.TicketTypeByID(r.DB, t.TicketTypeID); tt != nil {
+		item.TicketTypeName = tt.Name
+	}
+	return &model.CheckoutPreviewResult{CheckoutID: orderID, Total: l.Price, Items: []*model.CheckoutPreviewItem{item}}, nil
+}
+
+// AssignTicketHolder is the resolver for the assignTicketHolder field.
+func (r *mutationResolver) AssignTicketHolder(ctx context.Context, ticketID string, holder model.TicketHolderInput) (*model.Ticket, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	t, _ := repository.TicketByID(r.DB, ticketID)
+	if t == nil || t.UserID != userID {
+		return nil, errors.New("ingresso não encontrado")
+	}
+	tt, _ := repository.TicketTypeByID(r.DB, t.TicketTypeID)
+	if tt == nil || tt.Nominal == 0 {
+		return nil, errors.New("este ingresso não é nominal")
+	}
+	h, err := validateTicketHolder(&holder)
+	if err != nil {
+		return nil, err
+	}
+	ev, _ := repository.EventByID(r.DB, t.EventID)
+	d, _ := repository.EventDateByID(r.DB, t.EventDateID)
+	if ev == nil || d == nil {
+		return nil, errors.New("evento não encontrado")
+	}
+	if err := checkout.CheckTicketAudience(ev, d, tt, checkout.HolderAttendee(h)); err != nil {
+		return nil, err
+	}
+	if err := ticketHolderAssignable(r.DB, t); err != nil {
+		return nil, err
+	}
+	if err := checkout.CheckHolderCPFLimit(r.DB, t, h.CPF); err != nil {
+		return nil, err
+	}
+	ok, err := repository.SetTicketHolder(r.DB, t.ID, h)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("ingresso já utilizado não pode ter o titular alterado")
+	}
+	r.Wallet.TicketsChanged(t.ID)
+	ticket, _ := ticketRowToModel(r.DB, t)
+	r.withOwnerLinks(ticket, t)
+	return ticket, nil
+}
+
+// AdminApproveProducer is the resolver for the adminApproveProducer field.
+func (r *mutationResolver) AdminApproveProducer(ctx context.Context, producerID string) (*model.Producer, error) {
+	adminID, err := r.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prod, _ := repository.ProducerByID(r.DB, producerID)
+	if prod == nil {
+		return nil, errors.New("produtor não encontrado")
+	}
+	if err := repository.ReviewProducer(r.DB, producerID, repository.ProducerApproved, "", adminID); err != nil {
+		return nil, err
+	}
+	r.auditAdmin(adminID, "APPROVE_PRODUCER", "producer", producerID, "")
+	prod, _ = repository.ProducerByID(r.DB, producerID)
+	return producerRowToModel(r.DB, prod), nil
+}
+
+// AdminRejectProducer is the resolver for the adminRejectProducer field.
+func (r *mutationResolver) AdminRejectProducer(ctx context.Context, producerID string, reason string) (*model.Producer, error) {
+	adminID, err := r.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prod, _ := repository.ProducerByID(r.DB, producerID)
+	if prod == nil {
+		return nil, errors.New("produtor não encontrado")
+	}
+	if err := repository.ReviewProducer(r.DB, producerID, repository.ProducerRejected, reason, adminID); err != nil {
+		return nil, err
+	}
+	r.auditAdmin(adminID, "REJECT_PRODUCER", "producer", producerID, reason)
+	prod, _ = repository.ProducerByID(r.DB, producerID)
+	return producerRowToModel(r.DB, prod), nil
+}
+
+// AdminApproveEvent is the resolver for the adminApproveEvent field.
+func (r *mutationResolver) AdminApproveEvent(ctx context.Context, eventID string) (*model.Event, error) {
+	adminID, err := r.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	row, _ := repository.EventByID(r.DB, eventID)
+	if row == nil {
+		return nil, errors.New("evento não encontrado")
+	}
+	if row.Status != string(model.EventStatusInReview) {
+		return nil, errors.New("evento não está em moderação")
+	}
+	if err := repository.SetEventModeration(r.DB, eventID, string(model.EventStatusPublished), nil); err != nil {
+		return nil, err
+	}
+	r.auditAdmin(adminID, "APPROVE_EVENT", "event", eventID, "")
+	row, _ = repository.EventByID(r.DB, eventID)
+	return eventRowToModel(row, r.DB)
+}
+
+// AdminRejectEvent is the resolver for the adminRejectEvent field.
+// The event goes back to DRAFT with the moderator note so the producer can fix and resubmit.
+func (r *mutationResolver) AdminRejectEvent(ctx context.Context, eventID string, reason string) (*model.Event, error) {
+	adminID, err := r.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	row, _ := repository.EventByID(r.DB, eventID)
+	if row == nil {
+		return nil, errors.New("evento não encontrado")
+	}
+	if row.Status != string(model.EventStatusInReview) {
+		return nil, errors.New("evento não está em moderação")
+	}
+	if err := repository.SetEventModeration(r.DB, eventID, string(model.EventStatusDraft), &reason); err != nil {
+		return nil, err
+	}
+	r.auditAdmin(adminID, "REJECT_EVENT", "event", eventID, reason)
+	row, _ = repository.EventByID(r.DB, eventID)
+	return eventRowToModel(row, r.DB)
+}
+
+// AdminSetEventFeatured is the resolver for the adminSetEventFeatured field.
+func (r *mutationResolver) AdminSetEventFeatured(ctx context.Context, eventID string, featured bool) (*model.Event, error) {
+	adminID, err := r.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	row, _ := repository.EventByID(r.DB, eventID)
+	if row == nil {
+		return nil, errors.New("evento não encontrado")
+	}
+	if err := repository.UpdateEvent(r.DB, eventID, nil, nil, nil, nil, nil, nil, &featured); err != nil {
+		return nil, err
+	}
+	action := "FEATURE_EVENT"
+	if !featured {
+		action = "UNFEATURE_EVENT"
+	}
+	r.auditAdmin(adminID, action, "event", eventID, "")
+	row, _ = repository.EventByID(r.DB, eventID)
+	return eventRowToModel(row, r.DB)
+}
+
+// AdminTakeDownEvent is the resolver for the adminTakeDownEvent field.
+func (r *mutationResolver) AdminTakeDownEvent(ctx context.Context, eventID string, reason string) (*model.Event, error) {
+	adminID, err := r.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	row, _ := repository.EventByID(r.DB, eventID)
+	if row == nil {
+		return nil, errors.New("evento não encontrado")
+	}
+	if err := repository.TakeDownEvent(r.DB, eventID, reason); err != nil {
+		return nil, err
+	}
+	r.auditAdmin(adminID, "TAKE_DOWN_EVENT", "event", eventID, reason)
+	row, _ = repository.EventByID(r.DB, eventID)
+	return eventRowToModel(row, r.DB)
+}
+
+// AdminBlockUser is the resolver for the adminBlockUser field.
+func (r *mutationResolver) AdminBlockUser(ctx context.Context, userID string, reason string) (*model.User, error) {
+	adminID, err := r.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if userID == adminID {
+		return nil, errors.New("não é possível bloquear a própria conta")
+	}
+	user, _ := repository.UserByID(r.DB, userID)
+	if user == nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+	if err := repository.BlockUser(r.DB, userID, reason); err != nil {
+		return nil, err
+	}
+	if err := repository.RevokeUserSessions(r.DB, userID, ""); err != nil {
+		return nil, err
+	}
+	r.auditAdmin(adminID, "BLOCK_USER", "user", userID, reason)
+	user, _ = repository.UserByID(r.DB, userID)
+	return userRowToModel(user), nil
+}
+
+// AdminUnblockUser is the resolver for the adminUnblockUser field.
+func (r *mutationResolver) AdminUnblockUser(ctx context.Context, userID string) (*model.User, error) {
+	adminID, err := r.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, _ := repository.UserByID(r.DB, userID)
+	if user == nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+	if err := repository.UnblockUser(r.DB, userID); err != nil {
+		return nil, err
+	}
+	r.auditAdmin(adminID, "UNBLOCK_USER", "user", userID, "")
+	user, _ = repository.UserByID(r.DB, userID)
+	return userRowToModel(user), nil
+}
+
+// Events is the resolver for the events field.
+func (r *queryResolver) Events(ctx context.Context, filter *model.EventFilter) ([]*model.Event, error) {
+	var cat, date, city *string
+	if filter != nil {
+		cat, date, city = filter.Category, filter.Date, filter.City
+	}
+	ids, err := repository.ListPublishedEvents(r.DB, cat, date, city)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.Event, 0, len(ids))
+	for _, id := range ids {
+		row, _ := repository.EventByID(r.DB, id)
+		if row == nil {
+			continue
+		}
+		ev, err := eventRowToModel(row, r.DB)
+		if err != nil {
+			continue
+		}
+		out = append(out, ev)
+	}
+	return out, nil
+}
+
+// Event is the resolver for the event field.
+func (r *queryResolver) Event(ctx context.Context, id string) (*model.Event, error) {
+	row, err := repository.EventByID(r.DB, id)
+	if err != nil || row == nil {
+		return nil, nil
+	}
+	return eventRowToModel(row, r.DB)
+}
+
+// ProducerEvents is the resolver for the producerEvents field.
+func (r *queryResolver) ProducerEvents(ctx context.Context) ([]*model.Event, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	prodID, _ := repository.ProducerIDByUser(r.DB, userID)
+	if prodID == "" {
+		return []*model.Event{}, nil
+	}
+	ids, err := repository.ListEventsByProducerID(r.DB, prodID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.Event, 0, len(ids))
+	for _, id := range ids {
+		row, _ := repository.EventByID(r.DB, id)
+		if row == nil {
+			continue
+		}
+		ev, err := eventRowToModel(row, r.DB)
+		if err != nil {
+			continue
+		}
+		out = append(out, ev)
+	}
+	return out, nil
+}
+
+// ProducerPublicProfile is the resolver for the producerPublicProfile field.
+func (r *queryResolver) ProducerPublicProfile(ctx context.Context, producerID string) (*model.ProducerPublicProfile, error) {
+	prod, err := repository.ProducerByID(r.DB, producerID)
+	if err != nil || prod == nil {
+		return nil, nil
+	}
+	producer := producerRowToModel(r.DB, prod)
+	if producer.User == nil {
+		return nil, nil
+	}
+	eventIDs, err := repository.ListEventsByProducerIDExcludingDraft(r.DB, producerID)
+	if err != nil {
+		return &model.ProducerPublicProfile{Producer: producer, Events: []*model.Event{}}, nil
+	}
+	events := make([]*model.Event, 0, len(eventIDs))
+	for _, id := range eventIDs {
+		row, _ := repository.EventByID(r.DB, id)
+		if row == nil {
+			continue
+		}
+		ev, err := eventRowToModel(row, r.DB)
+		if err != nil {
+			continue
+		}
+		events = append(events, ev)
+	}
+	return &model.ProducerPublicProfile{Producer: producer, Events: events}, nil
+}
+
+// MyTickets is the resolver for the myTickets field.
+func (r *queryResolver) MyTickets(ctx context.Context) ([]*model.Ticket, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	list, err := repository.TicketsByUserID(r.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.Ticket, 0, len(list))
+	for _, t := range list {
+		ticket, err := ticketRowToModel(r.DB, t)
+		if err != nil {
+			continue
+		}
+		r.withOwnerLinks(ticket, t)
+		out = append(out, ticket)
+	}
+	return out, nil
+}
+
+// MyTicket is the resolver for the myTicket field.
+func (r *queryResolver) MyTicket(ctx context.Context, id string) (*model.Ticket, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	t, err := repository.TicketByID(r.DB, id)
+	if err != nil || t == nil || t.UserID != userID {
+		return nil, nil
+	}
+	ticket, err := ticketRowToModel(r.DB, t)
+	if ticket != nil {
+		r.withOwnerLinks(ticket, t)
+	}
+	return ticket, err
+}
+
+// MyTicketTransfers is the resolver for the myTicketTransfers field.
+func (r *queryResolver) MyTicketTransfers(ctx context.Context) ([]*model.TicketTransfer, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	user, _ := repository.UserByID(r.DB, userID)
+	if user == nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+	rows, err := repository.TicketTransfersByUser(r.DB, userID, user.Email)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.TicketTransfer, 0, len(rows))
+	for _, tr := range rows {
+		out = append(out, ticketTransferRowToModel(r.DB, tr, userID))
+	}
+	return out, nil
+}
+
+// ResaleListings is the resolver for the resaleListings field.
+func (r *queryResolver) ResaleListings(ctx context.Context, eventID string) ([]*model.ResaleListing, error) {
+	rows, err := repository.AvailableResaleListings(r.DB, eventID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.ResaleListing, 0, len(rows))
+	for _, l := range rows {
+		out = append(out, resaleListingRowToModel(r.DB, l))
+	}
+	return out, nil
+}
+
+// MyResaleListings is the resolver for the myResaleListings field.
+func (r *queryResolver) MyResaleListings(ctx context.Context) ([]*model.ResaleListing, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	rows, err := repository.ResaleListingsBySeller(r.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.ResaleListing, 0, len(rows))
+	for _, l := range rows {
+		out = append(out, resaleListingRowToModel(r.DB, l))
+	}
+	return out, nil
+}
+
+// PromoCodes is the resolver for the promoCodes field.
+func (r *queryResolver) PromoCodes(ctx context.Context, eventID string) ([]*model.PromoCode, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	if _, err := r.eventAccess(userID, eventID, repository.ScopeFinance); err != nil {
+		return nil, err
+	}
+	rows, err := repository.PromoCodesByEvent(r.DB, eventID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.PromoCode, 0, len(rows))
+	for _, p := range rows {
+		out = append(out, promoCodeRowToModel(r.DB, p))
+	}
+	return out, nil
+}
+
+// PromoCodeRedemptions is the resolver for the promoCodeRedemptions field.
+func (r *queryResolver) PromoCodeRedemptions(ctx context.Context, promoCodeID string) ([]*model.PromoCodeRedemption, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	p, _ := repository.PromoCodeByID(r.DB, promoCodeID)
+	if p == nil {
+		return nil, errors.New("cupom não encontrado")
+	}
+	if _, err := r.eventAccess(userID, p.EventID, repository.ScopeFinance); err != nil {
+		return nil, err
+	}
+	rows, err := repository.PromoCodeRedemptions(r.DB, promoCodeID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.PromoCodeRedemption, 0, len(rows))
+	for _, rd := range rows {
+		u, _ := repository.UserByID(r.DB, rd.UserID)
+		out = append(out, &model.PromoCodeRedemption{
+			ID:          rd.ID,
+			OrderID:     rd.OrderID,
+			User:        userRowToModel(u),
+			OrderStatus: rd.OrderStatus,
+			Discount:    rd.DiscountTotal,
+			CreatedAt:   parseDateTimeToRFC3339(rd.CreatedAt),
+		})
+	}
+	return out, nil
+}
+
+// CourtesyTickets is the resolver for the courtesyTickets field.
+func (r *queryResolver) CourtesyTickets(ctx context.Context, eventID string, search *string, guestList *bool) ([]*model.CourtesyTicket, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	if _, err := r.eventAccess(userID, eventID, repository.ScopeCheckIn); err != nil {
+		return nil, err
+	}
+	var q string
+	if search != nil {
+		q = strings.TrimSpace(*search)
+		// CPFs are stored as digits; "123.456.789-09" finds them too
+		if d := taxid.Digits(q); len(d) == 11 {
+			q = d
+		}
+	}
+	rows, err := repository.CourtesyTicketsByEvent(r.DB, eventID, q, guestList != nil && *guestList)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.CourtesyTicket, 0, len(rows))
+	for _, c := range rows {
+		out = append(out, courtesyTicketRowToModel(r.DB, c))
+	}
+	return out, nil
+}
+
+// EventStaff is the resolver for the eventStaff field.
+func (r *queryResolver) EventStaff(ctx context.Context, eventID string, includeRevoked *bool) ([]*model.EventStaff, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	if _, err := r.producerEvent(userID, eventID); err != nil {
+		return nil, err
+	}
+	rows, err := repository.EventStaffByEvent(r.DB, eventID, includeRevoked != nil && *includeRevoked)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.EventStaff, 0, len(rows))
+	for _, s := range rows {
+		out = append(out, eventStaffRowToModel(r.DB, s))
+	}
+	return out, nil
+}
+
+// MyStaffEvents is the resolver for the myStaffEvents field.
+func (r *queryResolver) MyStaffEvents(ctx context.Context) ([]*model.EventStaff, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	rows, err := repository.ActiveEventStaffByUser(r.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.EventStaff, 0, len(rows))
+	for _, s := range rows {
+		out = append(out, eventStaffRowToModel(r.DB, s))
+	}
+	return out, nil
+}
+
+// ScannerDevices is the resolver for the scannerDevices field.
+func (r *queryResolver) ScannerDevices(ctx context.Context, eventID string) ([]*model.ScannerDevice, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	if _, err := r.eventAccess(userID, eventID, repository.ScopeCheckIn); err != nil {
+		return nil, err
+	}
+	rows, err := repository.ScannerDevicesByEvent(r.DB, eventID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.ScannerDevice, 0, len(rows))
+	for _, d := range rows {
+		out = append(out, scannerDeviceRowToModel(r.DB, d))
+	}
+	return out, nil
+}
+
+// OfflineCheckinManifest is the resolver for the offlineCheckinManifest field.
+func (r *queryResolver) OfflineCheckinManifest(ctx context.Context, deviceID string, eventDateID string) (*model.OfflineManifest, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
+	}
+	d, ev, err := r.scannerDevice(userID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	ed, _ := repository.EventDateByID(r.DB, eventDateID)
+	if ed == nil || ed.EventID != ev.ID {
+		return nil, errors.New("data não pertence ao evento do aparelho")
+	}
+	m, err := offlineManifest(r.DB, ev, ed.ID, r.QRKeys)
+	if err != nil {
+		return nil, err
+	}
+	env, err := checkin.Seal(d.DeviceKey, d.ID, m)
+	if err != nil {
 		return nil, err
 	}
-	msg := "Após a confirmação do pagamento, o ingresso ficará disponível na sua Mochila de Tickets."
-	return &model.CheckoutPayResult{
-		Success:   true,
-		TicketIds: ticketIDs,
-		Message:   &msg,
+	return &model.OfflineManifest{
+		Version:     env.Version,
+		DeviceID:    env.DeviceID,
+		EventDateID: env.EventDateID,
+		IssuedAt:    env.IssuedAt.Format(time.RFC3339),
+		Nonce:       env.Nonce,
+		Ciphertext:  env.Ciphertext,
+		Signature:   env.Signature,
+		TicketCount: len(m.Tickets),
 	}, nil
 }
 
-// UpdateProfilePhoto is the resolver for the updateProfilePhoto field.
-func (r *mutationResolver) UpdateProfilePhoto(ctx context.Context, photoBase64 string) (*model.User, error) {
+// EventAttendees is the resolver for the eventAttendees field.
+func (r *queryResolver) EventAttendees(ctx context.Context, eventDateID string, filter *model.AttendeeFilter) (*model.AttendeePage, error) {
 	userID := middleware.UserID(ctx)
 	if userID == "" {
 		return nil, errors.New("não autenticado")
 	}
-	payload := photoBase64
-	if len(payload) > 7 && (payload[:7] == "data:img" || payload[:10] == "data:image/") {
-		idx := 0
-		for i, c := range payload {
-			if c == ',' {
-				idx = i + 1
-				break
-			}
-		}
-		if idx > 0 {
-			payload = payload[idx:]
-		}
-	}
-	if len(payload) > 300*1024 { // ~300 KB in base64 is ~400k chars; allow up to 400k for safety
-		return nil, errors.New("imagem muito grande; máximo 300 KB")
+	ed, _ := repository.EventDateByID(r.DB, eventDateID)
+	if ed == nil {
+		return nil, errors.New("data não encontrada")
 	}
-	// Accept only base64 that decodes to image (we store as data URI for display)
-	normalized := "data:image/jpeg;base64," + payload
-	if len(normalized) > 500*1024 {
-		return nil, errors.New("imagem muito grande; máximo 300 KB")
+	if _, err := r.eventAccess(userID, ed.EventID, repository.ScopeManage); err != nil {
+		return nil, err
 	}
-	if err := repository.UpdateUserPhotoURL(r.DB, userID, normalized); err != nil {
+	f, limit, offset := attendeeFilter(filter)
+	list, total, err := repository.EventAttendees(r.DB, ed.ID, f, limit, offset)
+	if err != nil {
 		return nil, err
 	}
-	user, _ := repository.UserByID(r.DB, userID)
-	return userRowToModel(user), nil
+	return &model.AttendeePage{Items: attendeeRowsToModel(r.DB, list), Total: total}, nil
 }
 
-// ValidateTicket is the resolver for the validateTicket field.
-// Uses signed QR payloads; validates then marks ticket as used in a single atomic update to prevent double validation.
-func (r *mutationResolver) ValidateTicket(ctx context.Context, eventID string, qrCode string) (*model.ValidateTicketResult, error) {
+// SalesAnalytics is the resolver for the salesAnalytics field.
+func (r *queryResolver) SalesAnalytics(ctx context.Context, eventID string, rangeArg *model.DateRangeInput) (*model.SalesAnalytics, error) {
 	userID := middleware.UserID(ctx)
 	if userID == "" {
-		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("UNAUTHORIZED"), Message: strPtr("não autenticado")}, nil
+		return nil, errors.New("não autenticado")
 	}
-	prodID, _ := repository.ProducerIDByUser(r.DB, userID)
-	if prodID == "" {
-		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("FORBIDDEN"), Message: strPtr("apenas produtores podem validar ingressos")}, nil
+	ev, err := r.eventAccess(userID, eventID, repository.ScopeFinance)
+	if err != nil {
+		return nil, err
 	}
-	eventProducerID, err := repository.EventProducerID(r.DB, eventID)
-	if err != nil || eventProducerID == "" {
-		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("NOT_FOUND"), Message: strPtr("evento não encontrado")}, nil
+	from, to, err := salesRange(rangeArg)
+	if err != nil {
+		return nil, err
+	}
+	return salesAnalytics(r.DB, ev, from, to)
+}
+
+// SalesComparison is the resolver for the salesComparison field.
+func (r *queryResolver) SalesComparison(ctx context.Context, eventIds []string, rangeArg *model.DateRangeInput) ([]*model.EventSalesSummary, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
 	}
-	if eventProducerID != prodID {
-		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("WRONG_EVENT"), Message: strPtr("ingresso não pertence a este evento ou você não é o produtor")}, nil
+	from, to, err := salesRange(rangeArg)
+	if err != nil {
+		return nil, err
 	}
-	// QR lookup: try direct DB match first, then V2 signed payload, then V1 signed payload.
-	t, err := repository.TicketByQRCode(r.DB, qrCode)
-	if err != nil || t == nil {
-		// Try V2 QR payload: ticketID:paymentIntentID:eventID.signature
-		if ticketID, _, _, sigOK := qrcode.VerifySignedPayloadV2(qrCode, []byte(r.Config.JWTSecret)); sigOK {
-			t, err = repository.TicketByID(r.DB, ticketID)
+	if len(eventIds) == 0 {
+		prodID, _ := repository.ProducerIDByUser(r.DB, userID)
+		if prodID == "" {
+			return []*model.EventSalesSummary{}, nil
 		}
-		// Try V1 QR payload: ticketID.signature
-		if err != nil || t == nil {
-			if ticketID, sigOK := qrcode.VerifySignedPayload(qrCode, []byte(r.Config.JWTSecret)); sigOK {
-				t, err = repository.TicketByID(r.DB, ticketID)
-			}
+		if eventIds, err = repository.ListEventsByProducerID(r.DB, prodID); err != nil {
+			return nil, err
 		}
 	}
-	if err != nil || t == nil {
-		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("NOT_FOUND"), Message: strPtr("ingresso não encontrado")}, nil
-	}
-	if t.EventID != eventID {
-		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("WRONG_EVENT"), Message: strPtr("ingresso não pertence a este evento")}, nil
-	}
-	// Atomic update: only one validation can succeed (prevents concurrent double use)
-	updated, err := repository.MarkTicketUsedIfNotUsed(r.DB, t.ID)
-	if err != nil {
-		return &model.ValidateTicketResult{Success: false, Message: strPtr("erro ao validar")}, nil
+	events := make([]*repository.EventRow, 0, len(eventIds))
+	for _, id := range eventIds {
+		ev, err := r.eventAccess(userID, id, repository.ScopeFinance)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
 	}
-	if !updated {
-		return &model.ValidateTicketResult{Success: false, ErrorCode: strPtr("ALREADY_USED"), Message: strPtr("ingresso já utilizado")}, nil
+	out := make([]*model.EventSalesSummary, 0, len(events))
+	for _, ev := range events {
+		summary, _, _, err := eventSales(r.DB, ev.ID, from, to)
+		if err != nil {
+			return nil, err
+		}
+		event, err := eventRowToModel(ev, r.DB)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &model.EventSalesSummary{Event: event, Summary: summary})
 	}
-	_ = repository.InsertTicketValidation(r.DB, t.ID, eventID, prodID)
-	t.Used = 1
-	ticket, _ := ticketRowToModel(r.DB, t)
-	return &model.ValidateTicketResult{Success: true, Ticket: ticket}, nil
+	return out, nil
 }
 
-func strPtr(s string) *string { return &s }
+// Me is the resolver for the me field.
+func (r *queryResolver) Me(ctx context.Context) (*model.User, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, nil
+	}
+	user, _ := repository.UserByID(r.DB, userID)
+	return userRowToModel(user), nil
+}
 
-// Events is the resolver for the events field.
-func (r *queryResolver) Events(ctx context.Context, filter *model.EventFilter) ([]*model.Event, error) {
-	var cat, date, city *string
-	if filter != nil {
-		cat, date, city = filter.Category, filter.Date, filter.City
+// MySessions is the resolver for the mySessions field.
+func (r *queryResolver) MySessions(ctx context.Context) ([]*model.Session, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
 	}
-	ids, err := repository.ListPublishedEvents(r.DB, cat, date, city)
+	list, err := repository.ActiveSessionsByUser(r.DB, userID)
 	if err != nil {
 		return nil, err
 	}
-	out := make([]*model.Event, 0, len(ids))
-	for _, id := range ids {
-		row, _ := repository.EventByID(r.DB, id)
-		if row == nil {
-			continue
-		}
-		ev, err := eventRowToModel(row, r.DB)
-		if err != nil {
-			continue
-		}
-		out = append(out, ev)
+	current := middleware.SessionID(ctx)
+	out := make([]*model.Session, 0, len(list))
+	for _, s := range list {
+		out = append(out, sessionRowToModel(s, current))
 	}
 	return out, nil
 }
 
-// Event is the resolver for the event field.
-func (r *queryResolver) Event(ctx context.Context, id string) (*model.Event, error) {
-	row, err := repository.EventByID(r.DB, id)
-	if err != nil || row == nil {
+// ProducerMe is the resolver for the producerMe field.
+func (r *queryResolver) ProducerMe(ctx context.Context) (*model.Producer, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
 		return nil, nil
 	}
-	return eventRowToModel(row, r.DB)
+	prodID, _ := repository.ProducerIDByUser(r.DB, userID)
+	if prodID == "" {
+		return nil, nil
+	}
+	prod, _ := repository.ProducerByID(r.DB, prodID)
+	if prod == nil {
+		return nil, nil
+	}
+	return producerRowToModel(r.DB, prod), nil
 }
 
-// ProducerEvents is the resolver for the producerEvents field.
-func (r *queryResolver) ProducerEvents(ctx context.Context) ([]*model.Event, error) {
+// MyProducerApplication is the resolver for the myProducerApplication field.
+func (r *queryResolver) MyProducerApplication(ctx context.Context) (*model.ProducerApplication, error) {
 	userID := middleware.UserID(ctx)
 	if userID == "" {
 		return nil, errors.New("não autenticado")
 	}
 	prodID, _ := repository.ProducerIDByUser(r.DB, userID)
 	if prodID == "" {
-		return []*model.Event{}, nil
+		return nil, nil
 	}
-	ids, err := repository.ListEventsByProducerID(r.DB, prodID)
+	prod, _ := repository.ProducerByID(r.DB, prodID)
+	return producerApplicationToModel(r.DB, prod), nil
+}
+
+// AdminProducerApplications is the resolver for the adminProducerApplications field.
+func (r *queryResolver) AdminProducerApplications(ctx context.Context, status *model.ProducerStatus, filter *model.AdminSearchInput) ([]*model.ProducerApplication, error) {
+	if _, err := r.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	var st string
+	if status != nil {
+		st = string(*status)
+	}
+	search, _, limit, offset := adminFilter(filter)
+	ids, err := repository.ListProducerIDs(r.DB, st, search, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.ProducerApplication, 0, len(ids))
+	for _, id := range ids {
+		prod, _ := repository.ProducerByID(r.DB, id)
+		if prod == nil {
+			continue
+		}
+		out = append(out, producerApplicationToModel(r.DB, prod))
+	}
+	return out, nil
+}
+
+// AdminEvents is the resolver for the adminEvents field.
+func (r *queryResolver) AdminEvents(ctx context.Context, filter *model.AdminSearchInput) ([]*model.Event, error) {
+	if _, err := r.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	search, status, limit, offset := adminFilter(filter)
+	ids, err := repository.SearchEventIDs(r.DB, search, status, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -503,55 +2508,79 @@ func (r *queryResolver) ProducerEvents(ctx context.Context) ([]*model.Event, err
 	return out, nil
 }
 
-// ProducerPublicProfile is the resolver for the producerPublicProfile field.
-func (r *queryResolver) ProducerPublicProfile(ctx context.Context, producerID string) (*model.ProducerPublicProfile, error) {
-	prod, err := repository.ProducerByID(r.DB, producerID)
-	if err != nil || prod == nil {
-		return nil, nil
+// AdminUsers is the resolver for the adminUsers field.
+func (r *queryResolver) AdminUsers(ctx context.Context, filter *model.AdminSearchInput) ([]*model.User, error) {
+	adminID, err := r.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
 	}
-	user, _ := repository.UserByID(r.DB, prod.UserID)
-	if user == nil {
-		return nil, nil
+	search, _, limit, offset := adminFilter(filter)
+	list, err := repository.SearchUsers(r.DB, search, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	r.auditAdmin(adminID, "SEARCH_USERS", "user", "", search)
+	out := make([]*model.User, 0, len(list))
+	for _, u := range list {
+		out = append(out, userRowToModel(u))
+	}
+	return out, nil
+}
+
+// AdminOrders is the resolver for the adminOrders field.
+func (r *queryResolver) AdminOrders(ctx context.Context, filter *model.AdminSearchInput) ([]*model.Order, error) {
+	adminID, err := r.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
 	}
-	var company *string
-	if prod.CompanyName.Valid {
-		company = &prod.CompanyName.String
+	search, status, limit, offset := adminFilter(filter)
+	list, err := repository.SearchOrders(r.DB, search, status, limit, offset)
+	if err != nil {
+		return nil, err
 	}
-	producer := &model.Producer{
-		ID:          prod.ID,
-		User:        userRowToModel(user),
-		CompanyName: company,
-		Approved:    prod.Approved == 1,
+	r.auditAdmin(adminID, "SEARCH_ORDERS", "order", "", search)
+	out := make([]*model.Order, 0, len(list))
+	for _, o := range list {
+		out = append(out, orderRowToModel(r.DB, o))
 	}
-	eventIDs, err := repository.ListEventsByProducerIDExcludingDraft(r.DB, producerID)
+	return out, nil
+}
+
+// AdminTickets is the resolver for the adminTickets field.
+func (r *queryResolver) AdminTickets(ctx context.Context, filter *model.AdminSearchInput) ([]*model.Ticket, error) {
+	adminID, err := r.requireAdmin(ctx)
 	if err != nil {
-		return &model.ProducerPublicProfile{Producer: producer, Events: []*model.Event{}}, nil
+		return nil, err
 	}
-	events := make([]*model.Event, 0, len(eventIDs))
-	for _, id := range eventIDs {
-		row, _ := repository.EventByID(r.DB, id)
-		if row == nil {
-			continue
-		}
-		ev, err := eventRowToModel(row, r.DB)
+	search, _, limit, offset := adminFilter(filter)
+	list, err := repository.SearchTickets(r.DB, search, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	r.auditAdmin(adminID, "SEARCH_TICKETS", "ticket", "", search)
+	out := make([]*model.Ticket, 0, len(list))
+	for _, t := range list {
+		ticket, err := ticketRowToModel(r.DB, t)
 		if err != nil {
 			continue
 		}
-		events = append(events, ev)
+		out = append(out, ticket)
 	}
-	return &model.ProducerPublicProfile{Producer: producer, Events: events}, nil
+	return out, nil
 }
 
-// MyTickets is the resolver for the myTickets field.
-func (r *queryResolver) MyTickets(ctx context.Context) ([]*model.Ticket, error) {
-	userID := middleware.UserID(ctx)
-	if userID == "" {
-		return nil, errors.New("não autenticado")
+// AdminUserTickets is the resolver for the adminUserTickets field.
+// Read-only view of a user's ticket backpack for support; every access is audited.
+func (r *queryResolver) AdminUserTickets(ctx context.Context, userID string) ([]*model.Ticket, error) {
+	adminID, err := r.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
 	}
 	list, err := repository.TicketsByUserID(r.DB, userID)
 	if err != nil {
 		return nil, err
 	}
+	r.auditAdmin(adminID, "VIEW_USER_TICKETS", "user", userID, "")
 	out := make([]*model.Ticket, 0, len(list))
 	for _, t := range list {
 		ticket, err := ticketRowToModel(r.DB, t)
@@ -563,54 +2592,146 @@ func (r *queryResolver) MyTickets(ctx context.Context) ([]*model.Ticket, error)
 	return out, nil
 }
 
-// MyTicket is the resolver for the myTicket field.
-func (r *queryResolver) MyTicket(ctx context.Context, id string) (*model.Ticket, error) {
-	userID := middleware.UserID(ctx)
-	if userID == "" {
-		return nil, errors.New("não autenticado")
+// AdminWebhookEvents is the resolver for the adminWebhookEvents field.
+func (r *queryResolver) AdminWebhookEvents(ctx context.Context, filter *model.AdminSearchInput) ([]*model.WebhookEventLog, error) {
+	if _, err := r.requireAdmin(ctx); err != nil {
+		return nil, err
 	}
-	t, err := repository.TicketByID(r.DB, id)
-	if err != nil || t == nil || t.UserID != userID {
-		return nil, nil
+	search, _, limit, offset := adminFilter(filter)
+	list, err := repository.ListWebhookEvents(r.DB, search, limit, offset)
+	if err != nil {
+		return nil, err
 	}
-	return ticketRowToModel(r.DB, t)
+	out := make([]*model.WebhookEventLog, 0, len(list))
+	for _, w := range list {
+		out = append(out, webhookEventRowToModel(w))
+	}
+	return out, nil
 }
 
-// Me is the resolver for the me field.
-func (r *queryResolver) Me(ctx context.Context) (*model.User, error) {
-	userID := middleware.UserID(ctx)
-	if userID == "" {
-		return nil, nil
+// AdminAuditLog is the resolver for the adminAuditLog field.
+func (r *queryResolver) AdminAuditLog(ctx context.Context, filter *model.AdminSearchInput, targetID *string, adminID *string) ([]*model.AdminAuditLogEntry, error) {
+	if _, err := r.requireAdmin(ctx); err != nil {
+		return nil, err
 	}
-	user, _ := repository.UserByID(r.DB, userID)
-	return userRowToModel(user), nil
+	search, _, limit, offset := adminFilter(filter)
+	f := repository.AdminAuditLogFilter{Search: search}
+	if targetID != nil {
+		f.TargetID = *targetID
+	}
+	if adminID != nil {
+		f.AdminID = *adminID
+	}
+	list, err := repository.ListAdminAuditLog(r.DB, f, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.AdminAuditLogEntry, 0, len(list))
+	for _, a := range list {
+		out = append(out, adminAuditLogRowToModel(r.DB, a))
+	}
+	return out, nil
 }
 
-// ProducerMe is the resolver for the producerMe field.
-func (r *queryResolver) ProducerMe(ctx context.Context) (*model.Producer, error) {
+// CheckinStats is the resolver for the checkinStats field.
+func (r *subscriptionResolver) CheckinStats(ctx context.Context, eventDateID string) (<-chan *model.CheckinStats, error) {
 	userID := middleware.UserID(ctx)
 	if userID == "" {
-		return nil, nil
+		return nil, errors.New("não autenticado")
 	}
-	prodID, _ := repository.ProducerIDByUser(r.DB, userID)
-	if prodID == "" {
-		return nil, nil
+	ed, _ := repository.EventDateByID(r.DB, eventDateID)
+	if ed == nil {
+		return nil, errors.New("data não encontrada")
 	}
-	prod, _ := repository.ProducerByID(r.DB, prodID)
-	if prod == nil {
-		return nil, nil
+	if _, err := r.eventAccess(userID, ed.EventID, repository.ScopeStats); err != nil {
+		return nil, err
+	}
+	first, err := checkinStats(r.DB, eventDateID)
+	if err != nil {
+		return nil, err
+	}
+	validations, cancel := r.Checkins.Subscribe(ed.EventID)
+	out := make(chan *model.CheckinStats, 1)
+	out <- first
+	go func() {
+		defer close(out)
+		defer cancel()
+		// Bursts of validations are coalesced into one update per statsInterval
+		ticker := time.NewTicker(statsInterval)
+		defer ticker.Stop()
+		recheck := time.NewTicker(subscriptionRecheckInterval)
+		defer recheck.Stop()
+		dirty := false
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-recheck.C:
+				if !r.subscriptionAllowed(ctx, ed.EventID, repository.ScopeStats) {
+					return
+				}
+			case v := <-validations:
+				if v.EventDateID == eventDateID {
+					dirty = true
+				}
+			case <-ticker.C:
+				if !dirty {
+					continue
+				}
+				dirty = false
+				stats, err := checkinStats(r.DB, eventDateID)
+				if err != nil {
+					continue
+				}
+				select {
+				case out <- stats:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// TicketValidated is the resolver for the ticketValidated field.
+func (r *subscriptionResolver) TicketValidated(ctx context.Context, eventID string) (<-chan *model.TicketValidation, error) {
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		return nil, errors.New("não autenticado")
 	}
-	user, _ := repository.UserByID(r.DB, prod.UserID)
-	var company *string
-	if prod.CompanyName.Valid {
-		company = &prod.CompanyName.String
+	if _, err := r.eventAccess(userID, eventID, repository.ScopeCheckIn); err != nil {
+		return nil, err
 	}
-	return &model.Producer{
-		ID:          prod.ID,
-		User:        userRowToModel(user),
-		CompanyName: company,
-		Approved:    prod.Approved == 1,
-	}, nil
+	validations, cancel := r.Checkins.Subscribe(eventID)
+	out := make(chan *model.TicketValidation, 1)
+	go func() {
+		defer close(out)
+		defer cancel()
+		recheck := time.NewTicker(subscriptionRecheckInterval)
+		defer recheck.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-recheck.C:
+				if !r.subscriptionAllowed(ctx, eventID, repository.ScopeCheckIn) {
+					return
+				}
+			case v := <-validations:
+				row, _ := repository.TicketValidationByID(r.DB, v.ID)
+				if row == nil {
+					continue
+				}
+				select {
+				case out <- ticketValidationRowToModel(r.DB, row):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
 }
 
 // Mutation returns MutationResolver implementation.
@@ -619,5 +2740,9 @@ func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
 type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
@@ -1,13 +1,26 @@
 package graphql
 
 import (
+	"context"
 	"database/sql"
 	"embed"
+	"errors"
 	"io/fs"
 	"net/http"
+	"strings"
+	"time"
 
+	"afterzin/api/internal/checkin"
 	"afterzin/api/internal/config"
+	"afterzin/api/internal/mailer"
+	"afterzin/api/internal/middleware"
+	"afterzin/api/internal/qrkeys"
+	"afterzin/api/internal/wallet"
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gorilla/websocket"
 	gqlparser "github.com/vektah/gqlparser/v2"
 	"github.com/vektah/gqlparser/v2/ast"
 )
@@ -15,17 +28,62 @@ import (
 //go:embed schema/*.graphqls
 var schemaFS embed.FS
 
-func NewHandler(db *sql.DB, cfg *config.Config) http.Handler {
+func NewHandler(db *sql.DB, cfg *config.Config, outbox *mailer.Outbox, w *wallet.Service, keys *qrkeys.Keyring) http.Handler {
 	schema, err := loadSchema()
 	if err != nil {
 		panic("load schema: " + err.Error())
 	}
-	resolver := &Resolver{DB: db, Config: cfg}
+	resolver := &Resolver{DB: db, Config: cfg, Outbox: outbox, Wallet: w, Checkins: checkin.NewHub(), QRKeys: keys}
 	es := NewExecutableSchema(Config{
 		Schema:    schema,
 		Resolvers: resolver,
 	})
-	return handler.NewDefaultServer(es)
+	// Same setup as handler.NewDefaultServer, with authentication on the websocket init payload
+	srv := handler.New(es)
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		Upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				return origin == "" || middleware.OriginAllowed(cfg.CORSOrigins, origin)
+			},
+		},
+		InitFunc: websocketAuth(db, cfg.JWTSecret),
+	})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+	srv.SetQueryCache(lru.New(1000))
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.AutomaticPersistedQuery{
+		Cache: lru.New(100),
+	})
+	return srv
+}
+
+// websocketAuth authenticates subscriptions: browsers cannot send headers on the upgrade, so
+// the access token comes in the connection_init payload ({"Authorization": "Bearer <token>"};
+// the bare token is accepted too). Connections without a valid token are refused, and the
+// connection is closed when the token expires: the client reconnects with a fresh one.
+func websocketAuth(db *sql.DB, jwtSecret string) transport.WebsocketInitFunc {
+	return func(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+		auth := payload.Authorization()
+		if auth != "" && !strings.HasPrefix(auth, "Bearer ") {
+			auth = "Bearer " + auth
+		}
+		sub, role, sid, ok := middleware.Authenticate(jwtSecret, db, auth)
+		if !ok {
+			return nil, nil, errors.New("não autenticado")
+		}
+		ctx = middleware.WithUser(ctx, sub, role, sid)
+		if exp, ok := middleware.TokenExpiry(auth); ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, exp)
+			context.AfterFunc(ctx, cancel)
+		}
+		return ctx, nil, nil
+	}
 }
 
 func loadSchema() (*ast.Schema, error) {
@@ -0,0 +1,58 @@
+package graphql
+
+import (
+	"context"
+	"time"
+
+	"afterzin/api/internal/auth"
+	"afterzin/api/internal/graphql/model"
+	"afterzin/api/internal/middleware"
+	"afterzin/api/internal/repository"
+)
+
+// newSession opens a session for the user (recording the client device) and returns the
+// access + refresh token pair.
+func (r *Resolver) newSession(ctx context.Context, user *repository.UserRow) (*model.AuthPayload, error) {
+	refresh, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	userAgent, ip := middleware.ClientInfo(ctx)
+	sessionID, err := repository.CreateSession(r.DB, user.ID, auth.HashToken(refresh), userAgent, ip, r.Config.RefreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	return r.authPayload(user, sessionID, refresh)
+}
+
+// authPayload issues a fresh access token for the session.
+func (r *Resolver) authPayload(user *repository.UserRow, sessionID, refresh string) (*model.AuthPayload, error) {
+	expiresAt := time.Now().Add(r.Config.AccessTokenTTL)
+	token, err := auth.NewToken(user.ID, user.Role, sessionID, r.Config.JWTSecret, r.Config.AccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &model.AuthPayload{
+		Token:        token,
+		ExpiresAt:    expiresAt.UTC().Format(time.RFC3339),
+		RefreshToken: refresh,
+		User:         userRowToModel(user),
+	}, nil
+}
+
+func sessionRowToModel(s *repository.SessionRow, currentSessionID string) *model.Session {
+	sess := &model.Session{
+		ID:         s.ID,
+		CreatedAt:  parseDateTimeToRFC3339(s.CreatedAt),
+		LastUsedAt: parseDateTimeToRFC3339(s.LastUsedAt),
+		ExpiresAt:  parseDateTimeToRFC3339(s.ExpiresAt),
+		Current:    s.ID == currentSessionID,
+	}
+	if s.UserAgent.Valid && s.UserAgent.String != "" {
+		sess.UserAgent = &s.UserAgent.String
+	}
+	if s.IPAddress.Valid && s.IPAddress.String != "" {
+		sess.IPAddress = &s.IPAddress.String
+	}
+	return sess
+}
@@ -0,0 +1,47 @@
+package graphql
+
+import (
+	"database/sql"
+	"errors"
+
+	"afterzin/api/internal/graphql/model"
+	"afterzin/api/internal/repository"
+)
+
+// eventAccess loads the event and checks that the user is its producer or an active staff
+// member whose role covers the scope.
+func (r *Resolver) eventAccess(userID, eventID string, scope repository.StaffScope) (*repository.EventRow, error) {
+	ev, ok, err := repository.EventAccess(r.DB, eventID, userID, scope)
+	if ev == nil {
+		return nil, errors.New("evento não encontrado")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("sem permissão")
+	}
+	return ev, nil
+}
+
+func eventStaffRowToModel(db *sql.DB, s *repository.EventStaffRow) *model.EventStaff {
+	out := &model.EventStaff{
+		ID:        s.ID,
+		UserID:    s.UserID,
+		Role:      model.StaffRole(s.Role),
+		CreatedAt: parseDateTimeToRFC3339(s.CreatedAt),
+	}
+	if s.RevokedAt.Valid {
+		t := parseDateTimeToRFC3339(s.RevokedAt.String)
+		out.RevokedAt = &t
+	}
+	if u, _ := repository.UserByID(db, s.UserID); u != nil {
+		out.Name = u.Name
+		out.Email = u.Email
+	}
+	out.Active, _ = repository.EventStaffActive(db, s.ID)
+	out.ValidationsCount, _ = repository.CountTicketValidationsByUser(db, s.EventID, s.UserID)
+	evRow, _ := repository.EventByID(db, s.EventID)
+	out.Event, _ = eventRowToModel(evRow, db)
+	return out
+}
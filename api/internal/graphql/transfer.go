@@ -0,0 +1,106 @@
+package graphql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"afterzin/api/internal/graphql/model"
+	"afterzin/api/internal/repository"
+)
+
+// maxTransferCutoffHours bounds the producer-configured transfer and holder cutoffs (30 days).
+const maxTransferCutoffHours = 720
+
+// ticketOwnerChangeable checks what transfers and resale have in common: the ticket is not
+// used, its order is paid (not refunded) and the producer's cutoff before the date has not been
+// reached. verb and noun name the operation in the error messages. Returns the event.
+func ticketOwnerChangeable(db *sql.DB, t *repository.TicketRow, verb, noun string) (*repository.EventRow, error) {
+	if t.Used == 1 {
+		return nil, fmt.Errorf("ingresso já utilizado não pode ser %s", verb)
+	}
+	if _, status, _, _ := repository.OrderByID(db, t.OrderID); status != "PAID" {
+		return nil, fmt.Errorf("ingresso de pedido não pago ou reembolsado não pode ser %s", verb)
+	}
+	ev, _ := repository.EventByID(db, t.EventID)
+	if ev == nil {
+		return nil, errors.New("evento não encontrado")
+	}
+	if err := checkDateCutoff(db, t.EventDateID, ev.TransferCutoffHours, noun); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// checkDateCutoff fails once the ticket's date is less than cutoffHours from starting.
+func checkDateCutoff(db *sql.DB, eventDateID string, cutoffHours int, noun string) error {
+	d, _ := repository.EventDateByID(db, eventDateID)
+	if d == nil {
+		return errors.New("data não encontrada")
+	}
+	if start, ok := repository.EventDateStart(d); ok {
+		cutoff := start.Add(-time.Duration(cutoffHours) * time.Hour)
+		if !time.Now().Before(cutoff) {
+			if cutoffHours > 0 {
+				return fmt.Errorf("%s encerradas: permitidas até %d hora(s) antes do início", noun, cutoffHours)
+			}
+			return fmt.Errorf("%s encerradas: o evento já começou", noun)
+		}
+	}
+	return nil
+}
+
+// ticketTransferable checks that the ticket can be transferred (checked both when offering
+// and when accepting).
+func ticketTransferable(db *sql.DB, t *repository.TicketRow) error {
+	ev, err := ticketOwnerChangeable(db, t, "transferido", "transferências")
+	if err != nil {
+		return err
+	}
+	if ev.TransfersEnabled == 0 {
+		return errors.New("o produtor não permite transferências para este evento")
+	}
+	return nil
+}
+
+func ticketTransferRowToModel(db *sql.DB, tr *repository.TicketTransferRow, userID string) *model.TicketTransfer {
+	out := &model.TicketTransfer{
+		ID:             tr.ID,
+		TicketID:       tr.TicketID,
+		RecipientEmail: tr.RecipientEmail,
+		Status:         model.TicketTransferStatus(tr.Status),
+		Incoming:       tr.FromUserID != userID,
+		CreatedAt:      parseDateTimeToRFC3339(tr.CreatedAt),
+	}
+	if tr.RespondedAt.Valid {
+		respondedAt := parseDateTimeToRFC3339(tr.RespondedAt.String)
+		out.RespondedAt = &respondedAt
+	}
+	if from, _ := repository.UserByID(db, tr.FromUserID); from != nil {
+		out.FromName = from.Name
+	}
+	// Only event, date and type are exposed: the recipient must not see the QR payload or the
+	// code before accepting.
+	if t, _ := repository.TicketByID(db, tr.TicketID); t != nil {
+		evRow, _ := repository.EventByID(db, t.EventID)
+		out.Event, _ = eventRowToModel(evRow, db)
+		out.EventDate, _ = eventDateToModel(db, t.EventDateID)
+		tt, _ := repository.TicketTypeByID(db, t.TicketTypeID)
+		out.TicketType = ticketTypeRowToModel(tt)
+	}
+	return out
+}
+
+// closeTicketTransfer declines or cancels a pending transfer; the ticket stays with the sender.
+func (r *Resolver) closeTicketTransfer(tr *repository.TicketTransferRow, userID, status string) (*model.TicketTransfer, error) {
+	ok, err := repository.CloseTicketTransfer(r.DB, tr.ID, status)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("esta transferência não está mais pendente")
+	}
+	tr, _ = repository.TicketTransferByID(r.DB, tr.ID)
+	return ticketTransferRowToModel(r.DB, tr, userID), nil
+}
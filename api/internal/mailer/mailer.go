@@ -0,0 +1,84 @@
+// Package mailer sends transactional email through a pluggable backend.
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"afterzin/api/internal/config"
+
+	"github.com/google/uuid"
+)
+
+// Message is a single email with plain-text and (optional) HTML bodies.
+type Message struct {
+	To          string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Attachment is a file sent with the message. When ContentID is set the file is sent
+// inline and can be referenced from the HTML body as "cid:<ContentID>".
+type Attachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	ContentID   string `json:"contentId,omitempty"`
+	Data        []byte `json:"data"`
+}
+
+// Mailer delivers messages. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogMailer writes messages to the application log instead of sending them (development).
+type LogMailer struct{}
+
+func (LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("mailer: to=%s subject=%q attachments=%d\n%s", msg.To, msg.Subject, len(msg.Attachments), msg.Text)
+	return nil
+}
+
+// FileMailer writes each message as an .eml file in Dir, so it can be opened in any
+// mail client (development and staging).
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func (m FileMailer) Send(ctx context.Context, msg Message) error {
+	raw, err := buildMIME(m.From, msg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.Dir, 0755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), uuid.New().String()[:8])
+	path := filepath.Join(m.Dir, name)
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		return err
+	}
+	log.Printf("mailer: to=%s subject=%q written to %s", msg.To, msg.Subject, path)
+	return nil
+}
+
+// New returns the mailer selected by cfg.MailDriver ("smtp", "file" or "log").
+// Unknown drivers fall back to the log mailer.
+func New(cfg *config.Config) Mailer {
+	switch strings.ToLower(cfg.MailDriver) {
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	case "file":
+		return FileMailer{Dir: cfg.MailDir, From: cfg.MailFrom}
+	default:
+		return LogMailer{}
+	}
+}
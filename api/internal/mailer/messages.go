@@ -0,0 +1,193 @@
+package mailer
+
+// Message builders for every transactional email. Bodies live in templates/ (pt-BR).
+
+// PasswordResetMessage builds the password reset email with a single-use link.
+func PasswordResetMessage(to, name, link string) (Message, error) {
+	return build(to, "Afterzin - Redefinição de senha", "password_reset", map[string]string{"Name": name, "Link": link})
+}
+
+// EmailVerificationMessage builds the email address confirmation email.
+func EmailVerificationMessage(to, name, link string) (Message, error) {
+	return build(to, "Afterzin - Confirme seu e-mail", "email_verification", map[string]string{"Name": name, "Link": link})
+}
+
+// OrderTicket is one issued ticket listed in the order confirmation.
+type OrderTicket struct {
+	Code       string
+	EventTitle string
+	TicketType string
+	Date       string
+	StartTime  string
+	Location   string
+	QRPNG      []byte // QR code image, sent inline
+	PDF        []byte // printable ticket, sent as attachment
+	// QRContentID is filled by OrderConfirmationMessage.
+	QRContentID string
+}
+
+// OrderConfirmation is the data of the order confirmation email.
+type OrderConfirmation struct {
+	To         string
+	Name       string
+	OrderID    string
+	Total      float64
+	Tickets    []OrderTicket
+	TicketsURL string
+}
+
+// OrderConfirmationMessage builds the buyer's order confirmation, with each ticket's QR
+// code embedded inline and its PDF attached.
+func OrderConfirmationMessage(d OrderConfirmation) (Message, error) {
+	var attachments []Attachment
+	for i := range d.Tickets {
+		attachments = append(attachments, ticketAttachments(&d.Tickets[i])...)
+	}
+	msg, err := build(d.To, "Afterzin - Pedido confirmado: seus ingressos", "order_confirmation", d)
+	msg.Attachments = attachments
+	return msg, err
+}
+
+// ticketAttachments returns the ticket's PDF attachment and inline QR image, setting its QRContentID.
+func ticketAttachments(t *OrderTicket) []Attachment {
+	var attachments []Attachment
+	if len(t.PDF) > 0 {
+		attachments = append(attachments, Attachment{
+			Filename:    "ingresso-" + t.Code + ".pdf",
+			ContentType: "application/pdf",
+			Data:        t.PDF,
+		})
+	}
+	if len(t.QRPNG) > 0 {
+		t.QRContentID = "qr-" + t.Code
+		attachments = append(attachments, Attachment{
+			Filename:    "ingresso-" + t.Code + ".png",
+			ContentType: "image/png",
+			ContentID:   t.QRContentID,
+			Data:        t.QRPNG,
+		})
+	}
+	return attachments
+}
+
+// Refund is the data of the refund notice.
+type Refund struct {
+	To         string
+	Name       string
+	OrderID    string
+	EventTitle string
+	Amount     float64
+}
+
+// RefundMessage builds the notice sent when an order is refunded.
+func RefundMessage(d Refund) (Message, error) {
+	return build(d.To, "Afterzin - Reembolso do pedido "+d.OrderID, "refund", d)
+}
+
+// EventReminder is the data of the reminder sent before an event date.
+type EventReminder struct {
+	To          string
+	Name        string
+	EventTitle  string
+	Date        string
+	StartTime   string
+	Location    string
+	Address     string
+	TicketCount int
+	TicketsURL  string
+}
+
+// EventReminderMessage builds the reminder sent to ticket holders shortly before the event.
+func EventReminderMessage(d EventReminder) (Message, error) {
+	return build(d.To, "Afterzin - Lembrete: "+d.EventTitle, "event_reminder", d)
+}
+
+// SaleAlert is the data of the new-sale notice sent to producers.
+type SaleAlert struct {
+	To           string
+	Name         string
+	EventTitle   string
+	OrderID      string
+	Quantity     int
+	Total        float64
+	DashboardURL string
+}
+
+// SaleAlertMessage builds the notice sent to the producer when an order is paid.
+func SaleAlertMessage(d SaleAlert) (Message, error) {
+	return build(d.To, "Afterzin - Nova venda: "+d.EventTitle, "sale_alert", d)
+}
+
+// TicketTransferOffer is the data of the email sent to the recipient of a ticket transfer.
+type TicketTransferOffer struct {
+	To         string
+	FromName   string
+	EventTitle string
+	TicketType string
+	Date       string
+	StartTime  string
+	Location   string
+	TicketsURL string
+}
+
+// TicketTransferOfferMessage builds the invitation to accept a transferred ticket.
+func TicketTransferOfferMessage(d TicketTransferOffer) (Message, error) {
+	return build(d.To, "Afterzin - "+d.FromName+" transferiu um ingresso para você", "ticket_transfer_offer", d)
+}
+
+// TicketTransferAccepted is the data of the notice sent to the previous owner.
+type TicketTransferAccepted struct {
+	To             string
+	Name           string
+	RecipientEmail string
+	Code           string
+	EventTitle     string
+	Date           string
+}
+
+// TicketTransferAcceptedMessage builds the notice that a transferred ticket was accepted.
+func TicketTransferAcceptedMessage(d TicketTransferAccepted) (Message, error) {
+	return build(d.To, "Afterzin - Transferência de ingresso aceita", "ticket_transfer_accepted", d)
+}
+
+// ResaleSold is the data of the notice sent to the seller when a resale listing is bought.
+type ResaleSold struct {
+	To            string
+	Name          string
+	Code          string
+	EventTitle    string
+	Price         float64
+	PlatformFee   float64
+	RoyaltyAmount float64
+	SellerAmount  float64
+}
+
+// ResaleSoldMessage builds the seller's sale notice with the payout breakdown.
+func ResaleSoldMessage(d ResaleSold) (Message, error) {
+	return build(d.To, "Afterzin - Seu ingresso foi revendido", "resale_sold", d)
+}
+
+// Courtesy is the data of the email that delivers a courtesy ticket to its recipient.
+type Courtesy struct {
+	To         string
+	Name       string
+	Ticket     OrderTicket
+	InAccount  bool // the recipient has an account and the ticket is in their Mochila
+	TicketsURL string
+}
+
+// CourtesyMessage builds the courtesy ticket email, with the QR code inline and the PDF attached.
+func CourtesyMessage(d Courtesy) (Message, error) {
+	attachments := ticketAttachments(&d.Ticket)
+	msg, err := build(d.To, "Afterzin - Você recebeu uma cortesia para "+d.Ticket.EventTitle, "courtesy", d)
+	msg.Attachments = attachments
+	return msg, err
+}
+
+func build(to, subject, template string, data interface{}) (Message, error) {
+	text, html, err := render(template, data)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{To: to, Subject: subject, Text: text, HTML: html}, nil
+}
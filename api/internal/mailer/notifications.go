@@ -0,0 +1,355 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"afterzin/api/internal/qrcode"
+	"afterzin/api/internal/repository"
+	"afterzin/api/internal/ticketpdf"
+)
+
+// Email kinds stored in email_outbox.kind.
+const (
+	KindOrderConfirmation = "order_confirmation"
+	KindSaleAlert         = "sale_alert"
+	KindRefund            = "refund"
+	KindEventReminder     = "event_reminder"
+	KindTicketTransfer    = "ticket_transfer"
+	KindResaleSold        = "resale_sold"
+	KindCourtesy          = "courtesy"
+	KindPasswordReset     = "password_reset"
+	KindEmailVerification = "email_verification"
+)
+
+const emailQRSize = 300
+
+// formatDate converts an event date (YYYY-MM-DD) to the Brazilian DD/MM/YYYY format.
+func formatDate(date string) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return t.Format("02/01/2006")
+}
+
+// OrderPaid queues the buyer's confirmation (tickets with QR codes) and a sale alert for the
+// producer of each event in the order. Safe to call more than once for the same order.
+func (o *Outbox) OrderPaid(orderID string) {
+	if err := o.enqueueOrderConfirmation(orderID); err != nil {
+		log.Printf("mailer: order confirmation for %s error: %v", orderID, err)
+	}
+	// Resale orders notify the seller instead of the producer (no new ticket was sold).
+	if listing, _ := repository.ResaleListingByOrder(o.db, orderID); listing != nil {
+		if err := o.enqueueResaleSold(listing); err != nil {
+			log.Printf("mailer: resale notice for %s error: %v", orderID, err)
+		}
+		return
+	}
+	if err := o.enqueueSaleAlerts(orderID); err != nil {
+		log.Printf("mailer: sale alert for %s error: %v", orderID, err)
+	}
+}
+
+func (o *Outbox) enqueueOrderConfirmation(orderID string) error {
+	dedupeKey := KindOrderConfirmation + ":" + orderID
+	if repository.EmailDedupeKeyExists(o.db, dedupeKey) {
+		return nil
+	}
+	userID, _, total, err := repository.OrderByID(o.db, orderID)
+	if err != nil {
+		return err
+	}
+	user, _ := repository.UserByID(o.db, userID)
+	if user == nil {
+		return fmt.Errorf("buyer %s not found", userID)
+	}
+	tickets, err := repository.TicketsByOrderID(o.db, orderID)
+	if err != nil {
+		return err
+	}
+	data := OrderConfirmation{
+		To:         user.Email,
+		Name:       user.Name,
+		OrderID:    orderID,
+		Total:      total,
+		TicketsURL: o.baseURL + "/mochila",
+	}
+	for _, t := range tickets {
+		data.Tickets = append(data.Tickets, o.orderTicket(t))
+	}
+	msg, err := OrderConfirmationMessage(data)
+	if err != nil {
+		return err
+	}
+	return o.Enqueue(KindOrderConfirmation, dedupeKey, msg)
+}
+
+// orderTicket gathers the event details, QR image and PDF of a ticket for an email.
+func (o *Outbox) orderTicket(t *repository.TicketRow) OrderTicket {
+	item := OrderTicket{Code: t.Code}
+	if ev, _ := repository.EventByID(o.db, t.EventID); ev != nil {
+		item.EventTitle = ev.Title
+		item.Location = ev.Location
+	}
+	if d, _ := repository.EventDateByID(o.db, t.EventDateID); d != nil {
+		item.Date = formatDate(d.Date)
+		item.StartTime = d.StartTime.String
+	}
+	if tt, _ := repository.TicketTypeByID(o.db, t.TicketTypeID); tt != nil {
+		item.TicketType = tt.Name
+	}
+	png, err := qrcode.PNG(t.QRCode, emailQRSize)
+	if err != nil {
+		log.Printf("mailer: QR image for ticket %s error: %v", t.ID, err)
+	}
+	item.QRPNG = png
+	pdf, err := ticketpdf.Build(o.db, t)
+	if err != nil {
+		log.Printf("mailer: PDF for ticket %s error: %v", t.ID, err)
+	}
+	item.PDF = pdf
+	return item
+}
+
+func (o *Outbox) enqueueResaleSold(l *repository.ResaleListingRow) error {
+	seller, _ := repository.UserByID(o.db, l.SellerID)
+	t, _ := repository.TicketByID(o.db, l.TicketID)
+	if seller == nil || t == nil {
+		return fmt.Errorf("listing %s: seller or ticket not found", l.ID)
+	}
+	data := ResaleSold{
+		To:            seller.Email,
+		Name:          seller.Name,
+		Code:          t.Code,
+		Price:         l.Price,
+		PlatformFee:   l.PlatformFee,
+		RoyaltyAmount: l.RoyaltyAmount,
+		SellerAmount:  l.SellerAmount,
+	}
+	if ev, _ := repository.EventByID(o.db, l.EventID); ev != nil {
+		data.EventTitle = ev.Title
+	}
+	msg, err := ResaleSoldMessage(data)
+	if err != nil {
+		return err
+	}
+	return o.Enqueue(KindResaleSold, KindResaleSold+":"+l.ID, msg)
+}
+
+func (o *Outbox) enqueueSaleAlerts(orderID string) error {
+	items, err := repository.OrderItemsByOrderID(o.db, orderID)
+	if err != nil {
+		return err
+	}
+	type sale struct {
+		quantity int
+		total    float64
+	}
+	byEvent := map[string]*sale{}
+	var eventIDs []string
+	for _, it := range items {
+		d, _ := repository.EventDateByID(o.db, it.EventDateID)
+		if d == nil {
+			continue
+		}
+		s := byEvent[d.EventID]
+		if s == nil {
+			s = &sale{}
+			byEvent[d.EventID] = s
+			eventIDs = append(eventIDs, d.EventID)
+		}
+		s.quantity += it.Quantity
+		s.total += float64(it.Quantity) * it.UnitPrice
+	}
+	for _, eventID := range eventIDs {
+		ev, _ := repository.EventByID(o.db, eventID)
+		if ev == nil {
+			continue
+		}
+		prod, _ := repository.ProducerByID(o.db, ev.ProducerID)
+		if prod == nil {
+			continue
+		}
+		owner, _ := repository.UserByID(o.db, prod.UserID)
+		if owner == nil {
+			continue
+		}
+		msg, err := SaleAlertMessage(SaleAlert{
+			To:           owner.Email,
+			Name:         owner.Name,
+			EventTitle:   ev.Title,
+			OrderID:      orderID,
+			Quantity:     byEvent[eventID].quantity,
+			Total:        byEvent[eventID].total,
+			DashboardURL: o.baseURL + "/produtor",
+		})
+		if err != nil {
+			return err
+		}
+		if err := o.Enqueue(KindSaleAlert, KindSaleAlert+":"+orderID+":"+eventID, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OrderRefunded queues the refund notice for the buyer.
+func (o *Outbox) OrderRefunded(orderID string) {
+	userID, _, total, err := repository.OrderByID(o.db, orderID)
+	if err != nil || userID == "" {
+		log.Printf("mailer: refund notice for %s: order not found", orderID)
+		return
+	}
+	user, _ := repository.UserByID(o.db, userID)
+	if user == nil {
+		return
+	}
+	data := Refund{To: user.Email, Name: user.Name, OrderID: orderID, Amount: total}
+	if items, _ := repository.OrderItemsByOrderID(o.db, orderID); len(items) > 0 {
+		if d, _ := repository.EventDateByID(o.db, items[0].EventDateID); d != nil {
+			if ev, _ := repository.EventByID(o.db, d.EventID); ev != nil {
+				data.EventTitle = ev.Title
+			}
+		}
+	}
+	msg, err := RefundMessage(data)
+	if err == nil {
+		err = o.Enqueue(KindRefund, KindRefund+":"+orderID, msg)
+	}
+	if err != nil {
+		log.Printf("mailer: refund notice for %s error: %v", orderID, err)
+	}
+}
+
+// EnqueueEventReminders queues a reminder for every holder of tickets for event dates
+// happening tomorrow. Each holder gets at most one reminder per event date.
+func (o *Outbox) EnqueueEventReminders() {
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	recipients, err := repository.ReminderRecipients(o.db, tomorrow)
+	if err != nil {
+		log.Printf("mailer: reminder recipients error: %v", err)
+		return
+	}
+	for _, rcp := range recipients {
+		dedupeKey := reminderDedupeKeyPrefix + rcp.EventDateID + ":" + rcp.UserID
+		if repository.EmailDedupeKeyExists(o.db, dedupeKey) {
+			continue
+		}
+		user, _ := repository.UserByID(o.db, rcp.UserID)
+		d, _ := repository.EventDateByID(o.db, rcp.EventDateID)
+		if user == nil || d == nil {
+			continue
+		}
+		ev, _ := repository.EventByID(o.db, d.EventID)
+		if ev == nil {
+			continue
+		}
+		msg, err := EventReminderMessage(EventReminder{
+			To:          user.Email,
+			Name:        user.Name,
+			EventTitle:  ev.Title,
+			Date:        formatDate(d.Date),
+			StartTime:   d.StartTime.String,
+			Location:    ev.Location,
+			Address:     ev.Address.String,
+			TicketCount: rcp.TicketCount,
+			TicketsURL:  o.baseURL + "/mochila",
+		})
+		if err == nil {
+			err = o.Enqueue(KindEventReminder, dedupeKey, msg)
+		}
+		if err != nil {
+			log.Printf("mailer: reminder for user %s date %s error: %v", rcp.UserID, rcp.EventDateID, err)
+		}
+	}
+}
+
+// TicketTransferOffered queues the invitation for the recipient of a transfer (who may not
+// have an account yet).
+func (o *Outbox) TicketTransferOffered(transferID string) {
+	tr, _ := repository.TicketTransferByID(o.db, transferID)
+	if tr == nil {
+		return
+	}
+	t, _ := repository.TicketByID(o.db, tr.TicketID)
+	from, _ := repository.UserByID(o.db, tr.FromUserID)
+	if t == nil || from == nil {
+		return
+	}
+	data := TicketTransferOffer{To: tr.RecipientEmail, FromName: from.Name, TicketsURL: o.baseURL + "/mochila"}
+	if ev, _ := repository.EventByID(o.db, t.EventID); ev != nil {
+		data.EventTitle = ev.Title
+		data.Location = ev.Location
+	}
+	if d, _ := repository.EventDateByID(o.db, t.EventDateID); d != nil {
+		data.Date = formatDate(d.Date)
+		data.StartTime = d.StartTime.String
+	}
+	if tt, _ := repository.TicketTypeByID(o.db, t.TicketTypeID); tt != nil {
+		data.TicketType = tt.Name
+	}
+	msg, err := TicketTransferOfferMessage(data)
+	if err == nil {
+		err = o.Enqueue(KindTicketTransfer, KindTicketTransfer+":offer:"+transferID, msg)
+	}
+	if err != nil {
+		log.Printf("mailer: transfer offer %s error: %v", transferID, err)
+	}
+}
+
+// TicketTransferAccepted queues the notice for the previous owner of an accepted transfer.
+func (o *Outbox) TicketTransferAccepted(transferID string) {
+	tr, _ := repository.TicketTransferByID(o.db, transferID)
+	if tr == nil {
+		return
+	}
+	t, _ := repository.TicketByID(o.db, tr.TicketID)
+	from, _ := repository.UserByID(o.db, tr.FromUserID)
+	if t == nil || from == nil {
+		return
+	}
+	data := TicketTransferAccepted{To: from.Email, Name: from.Name, RecipientEmail: tr.RecipientEmail, Code: t.Code}
+	if ev, _ := repository.EventByID(o.db, t.EventID); ev != nil {
+		data.EventTitle = ev.Title
+	}
+	if d, _ := repository.EventDateByID(o.db, t.EventDateID); d != nil {
+		data.Date = formatDate(d.Date)
+	}
+	msg, err := TicketTransferAcceptedMessage(data)
+	if err == nil {
+		err = o.Enqueue(KindTicketTransfer, KindTicketTransfer+":accepted:"+transferID, msg)
+	}
+	if err != nil {
+		log.Printf("mailer: transfer accepted %s error: %v", transferID, err)
+	}
+}
+
+// CourtesyIssued queues the courtesy ticket (QR code and PDF) for the recipient's email. Guest
+// list entries and recipients without an email get nothing: they are checked in by name.
+func (o *Outbox) CourtesyIssued(ticketID string) {
+	c, _ := repository.CourtesyTicketByTicketID(o.db, ticketID)
+	if c == nil || c.GuestList || !c.RecipientEmail.Valid {
+		return
+	}
+	t, _ := repository.TicketByID(o.db, ticketID)
+	if t == nil {
+		return
+	}
+	data := Courtesy{
+		To:     c.RecipientEmail.String,
+		Name:   c.RecipientName,
+		Ticket: o.orderTicket(t),
+		// Recipients with an account own the ticket and also find it in the Mochila.
+		InAccount:  t.UserID != c.IssuedBy,
+		TicketsURL: o.baseURL + "/mochila",
+	}
+	msg, err := CourtesyMessage(data)
+	if err == nil {
+		err = o.Enqueue(KindCourtesy, KindCourtesy+":"+ticketID, msg)
+	}
+	if err != nil {
+		log.Printf("mailer: courtesy %s error: %v", ticketID, err)
+	}
+}
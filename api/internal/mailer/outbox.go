@@ -0,0 +1,153 @@
+package mailer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"afterzin/api/internal/repository"
+)
+
+const (
+	outboxPollInterval      = 10 * time.Second
+	outboxBatchSize         = 20
+	reminderScanInterval    = time.Hour
+	outboxSendTimeout       = 30 * time.Second
+	outboxMaxErrorLength    = 500
+	reminderDedupeKeyPrefix = "event_reminder:"
+)
+
+// outboxRetryBackoff is the wait before each retry of a failed delivery; the email is
+// marked FAILED after len(outboxRetryBackoff)+1 attempts.
+var outboxRetryBackoff = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour, 6 * time.Hour}
+
+// redactedKinds are the emails whose body carries a raw single-use token (the database only keeps
+// its hash): the body is redacted as soon as the email is sent or given up on.
+var redactedKinds = map[string]bool{KindPasswordReset: true, KindEmailVerification: true}
+
+// Outbox queues rendered emails in the email_outbox table and delivers them in the
+// background with retries, so callers such as the payment webhook never wait on SMTP.
+type Outbox struct {
+	db      *sql.DB
+	mailer  Mailer
+	baseURL string
+	wake    chan struct{} // signals the worker that new email was queued
+}
+
+func NewOutbox(db *sql.DB, m Mailer, baseURL string) *Outbox {
+	return &Outbox{db: db, mailer: m, baseURL: baseURL, wake: make(chan struct{}, 1)}
+}
+
+// Enqueue stores the message for delivery. dedupeKey (optional) guarantees the same
+// logical email (e.g. the confirmation of one order) is only queued once.
+func (o *Outbox) Enqueue(kind, dedupeKey string, msg Message) error {
+	var attachments string
+	if len(msg.Attachments) > 0 {
+		b, err := json.Marshal(msg.Attachments)
+		if err != nil {
+			return err
+		}
+		attachments = string(b)
+	}
+	if _, err := repository.EnqueueEmail(o.db, kind, dedupeKey, msg.To, msg.Subject, msg.Text, msg.HTML, attachments); err != nil {
+		return err
+	}
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run delivers due emails and schedules event reminders until ctx is cancelled.
+func (o *Outbox) Run(ctx context.Context) {
+	poll := time.NewTicker(outboxPollInterval)
+	defer poll.Stop()
+	reminders := time.NewTicker(reminderScanInterval)
+	defer reminders.Stop()
+	o.EnqueueEventReminders()
+	o.Flush(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+			o.Flush(ctx)
+		case <-o.wake:
+			o.Flush(ctx)
+		case <-reminders.C:
+			o.EnqueueEventReminders()
+		}
+	}
+}
+
+// Flush attempts delivery of every due email once.
+func (o *Outbox) Flush(ctx context.Context) {
+	for {
+		due, err := repository.DueEmails(o.db, outboxBatchSize)
+		if err != nil {
+			log.Printf("mailer: outbox query error: %v", err)
+			return
+		}
+		if len(due) == 0 {
+			return
+		}
+		for _, e := range due {
+			if ctx.Err() != nil {
+				return
+			}
+			o.deliver(ctx, e)
+		}
+		if len(due) < outboxBatchSize {
+			return
+		}
+	}
+}
+
+func (o *Outbox) deliver(ctx context.Context, e *repository.EmailOutboxRow) {
+	msg := Message{To: e.ToAddress, Subject: e.Subject, Text: e.TextBody, HTML: e.HTMLBody.String}
+	if e.Attachments.Valid {
+		if err := json.Unmarshal([]byte(e.Attachments.String), &msg.Attachments); err != nil {
+			log.Printf("mailer: outbox %s attachments error: %v", e.ID, err)
+		}
+	}
+	sendCtx, cancel := context.WithTimeout(ctx, outboxSendTimeout)
+	err := o.mailer.Send(sendCtx, msg)
+	cancel()
+	if err == nil {
+		if err := repository.MarkEmailSent(o.db, e.ID); err != nil {
+			log.Printf("mailer: outbox %s mark sent error: %v", e.ID, err)
+		}
+		o.redact(e)
+		return
+	}
+	attempt := e.Attempts + 1
+	giveUp := attempt > len(outboxRetryBackoff)
+	next := time.Now()
+	if !giveUp {
+		next = next.Add(outboxRetryBackoff[attempt-1])
+	}
+	errMsg := err.Error()
+	if len(errMsg) > outboxMaxErrorLength {
+		errMsg = errMsg[:outboxMaxErrorLength]
+	}
+	log.Printf("mailer: outbox %s (%s to %s) attempt %d failed: %v", e.ID, e.Kind, e.ToAddress, attempt, err)
+	if err := repository.MarkEmailAttemptFailed(o.db, e.ID, errMsg, next, giveUp); err != nil {
+		log.Printf("mailer: outbox %s mark failed error: %v", e.ID, err)
+	}
+	if giveUp {
+		o.redact(e)
+	}
+}
+
+// redact removes the body of an email with a single-use token once it leaves the queue.
+func (o *Outbox) redact(e *repository.EmailOutboxRow) {
+	if !redactedKinds[e.Kind] {
+		return
+	}
+	if err := repository.RedactEmail(o.db, e.ID); err != nil {
+		log.Printf("mailer: outbox %s redact error: %v", e.ID, err)
+	}
+}
@@ -0,0 +1,219 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SMTPMailer delivers messages through an SMTP relay. STARTTLS is used when the server
+// offers it; authentication is skipped when no username is configured (e.g. a local
+// SMTP catcher such as MailHog or Mailpit).
+type SMTPMailer struct {
+	host string
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	m := &SMTPMailer{host: host, addr: host + ":" + strconv.Itoa(port), from: from}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	raw, err := buildMIME(m.from, msg)
+	if err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(m.from)
+	if err != nil {
+		return fmt.Errorf("invalid MAIL_FROM: %w", err)
+	}
+	return m.send(ctx, from.Address, msg.To, raw)
+}
+
+// send runs the SMTP conversation (as smtp.SendMail does) on a connection bound to ctx: the
+// dial honours it, its deadline applies to every read and write, and cancelling it closes
+// the connection.
+func (m *SMTPMailer) send(ctx context.Context, from, to string, raw []byte) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+	if m.auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp: server doesn't support AUTH")
+		}
+		if err := c.Auth(m.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// buildMIME renders the message as RFC 5322 text:
+//
+//	multipart/mixed
+//	├── multipart/related
+//	│   ├── multipart/alternative (text/plain, text/html)
+//	│   └── inline attachments (ContentID set)
+//	└── regular attachments
+func buildMIME(from string, msg Message) ([]byte, error) {
+	var alt bytes.Buffer
+	altW := multipart.NewWriter(&alt)
+	if err := writeEncoded(altW, partHeader("text/plain; charset=utf-8"), []byte(msg.Text)); err != nil {
+		return nil, err
+	}
+	if msg.HTML != "" {
+		if err := writeEncoded(altW, partHeader("text/html; charset=utf-8"), []byte(msg.HTML)); err != nil {
+			return nil, err
+		}
+	}
+	if err := altW.Close(); err != nil {
+		return nil, err
+	}
+
+	var related bytes.Buffer
+	relatedW := multipart.NewWriter(&related)
+	if err := writeRaw(relatedW, partHeader("multipart/alternative; boundary="+altW.Boundary()), alt.Bytes()); err != nil {
+		return nil, err
+	}
+	for _, a := range msg.Attachments {
+		if a.ContentID == "" {
+			continue
+		}
+		h := attachmentHeader(a, "inline")
+		h.Set("Content-ID", "<"+a.ContentID+">")
+		if err := writeEncoded(relatedW, h, a.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := relatedW.Close(); err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	mixedW := multipart.NewWriter(&body)
+	if err := writeRaw(mixedW, partHeader("multipart/related; boundary="+relatedW.Boundary()), related.Bytes()); err != nil {
+		return nil, err
+	}
+	for _, a := range msg.Attachments {
+		if a.ContentID != "" {
+			continue
+		}
+		if err := writeEncoded(mixedW, attachmentHeader(a, "attachment"), a.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mixedW.Close(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	header := func(k, v string) { fmt.Fprintf(&buf, "%s: %s\r\n", k, v) }
+	header("From", from)
+	header("To", msg.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", "<"+uuid.New().String()+"@afterzin>")
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/mixed; boundary="+mixedW.Boundary())
+	buf.WriteString("\r\n")
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
+
+func partHeader(contentType string) textproto.MIMEHeader {
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Type", contentType)
+	return h
+}
+
+func attachmentHeader(a Attachment, disposition string) textproto.MIMEHeader {
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Type", a.ContentType)
+	h.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}))
+	return h
+}
+
+func writeRaw(w *multipart.Writer, h textproto.MIMEHeader, data []byte) error {
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(data)
+	return err
+}
+
+// writeEncoded writes data base64-encoded in 76-character lines (RFC 2045).
+func writeEncoded(w *multipart.Writer, h textproto.MIMEHeader, data []byte) error {
+	h.Set("Content-Transfer-Encoding", "base64")
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	enc := base64.StdEncoding.EncodeToString(data)
+	for len(enc) > 76 {
+		if _, err := fmt.Fprintf(part, "%s\r\n", enc[:76]); err != nil {
+			return err
+		}
+		enc = enc[76:]
+	}
+	_, err = fmt.Fprintf(part, "%s\r\n", enc)
+	return err
+}
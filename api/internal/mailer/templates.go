@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*.html templates/*.txt
+var templateFS embed.FS
+
+var templateFuncs = map[string]interface{}{
+	"brl": formatBRL,
+}
+
+// formatBRL formats a value in reais as "R$ 1.234,56".
+func formatBRL(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	intPart, frac := s[:len(s)-3], s[len(s)-2:]
+	neg := strings.HasPrefix(intPart, "-")
+	intPart = strings.TrimPrefix(intPart, "-")
+	var b strings.Builder
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	if neg {
+		return "-R$ " + b.String() + "," + frac
+	}
+	return "R$ " + b.String() + "," + frac
+}
+
+// render executes templates/<name>.txt and templates/<name>.html (wrapped in the layout).
+func render(name string, data interface{}) (text, html string, err error) {
+	tt, err := texttemplate.New(name+".txt").Funcs(templateFuncs).ParseFS(templateFS, "templates/"+name+".txt")
+	if err != nil {
+		return "", "", err
+	}
+	var tb bytes.Buffer
+	if err := tt.Execute(&tb, data); err != nil {
+		return "", "", fmt.Errorf("render %s.txt: %w", name, err)
+	}
+	ht, err := htmltemplate.New("layout").Funcs(templateFuncs).ParseFS(templateFS, "templates/layout.html", "templates/"+name+".html")
+	if err != nil {
+		return "", "", err
+	}
+	var hb bytes.Buffer
+	if err := ht.ExecuteTemplate(&hb, "layout", data); err != nil {
+		return "", "", fmt.Errorf("render %s.html: %w", name, err)
+	}
+	return tb.String(), hb.String(), nil
+}
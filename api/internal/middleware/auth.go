@@ -2,8 +2,13 @@ package middleware
 
 import (
 	"context"
+	"database/sql"
+	"net"
 	"net/http"
 	"strings"
+	"time"
+
+	"afterzin/api/internal/repository"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -12,41 +17,93 @@ type contextKey string
 
 const UserIDKey contextKey = "user_id"
 const UserRoleKey contextKey = "user_role"
+const SessionIDKey contextKey = "session_id"
+const UserAgentKey contextKey = "user_agent"
+const ClientIPKey contextKey = "client_ip"
 
-func Auth(jwtSecret string) func(http.Handler) http.Handler {
+// Auth parses the Bearer JWT and stores the user ID, role and session ID in the request context.
+// Tokens whose session was revoked or expired, or whose account is blocked, are ignored
+// (the request proceeds as anonymous). The client user agent and IP are always stored so
+// resolvers can record them on new sessions.
+func Auth(jwtSecret string, db *sql.DB) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			auth := r.Header.Get("Authorization")
-			if auth == "" {
-				next.ServeHTTP(w, r)
-				return
-			}
-			if !strings.HasPrefix(auth, "Bearer ") {
-				next.ServeHTTP(w, r)
-				return
-			}
-			tokenStr := strings.TrimPrefix(auth, "Bearer ")
-			token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-				return []byte(jwtSecret), nil
-			})
-			if err != nil || !token.Valid {
-				next.ServeHTTP(w, r)
-				return
-			}
-			claims, ok := token.Claims.(jwt.MapClaims)
+			ctx := context.WithValue(r.Context(), UserAgentKey, r.UserAgent())
+			ctx = context.WithValue(ctx, ClientIPKey, clientIP(r))
+			r = r.WithContext(ctx)
+			sub, role, sid, ok := Authenticate(jwtSecret, db, r.Header.Get("Authorization"))
 			if !ok {
 				next.ServeHTTP(w, r)
 				return
 			}
-			sub, _ := claims["sub"].(string)
-			role, _ := claims["role"].(string)
-			ctx := context.WithValue(r.Context(), UserIDKey, sub)
-			ctx = context.WithValue(ctx, UserRoleKey, role)
+			ctx = WithUser(ctx, sub, role, sid)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// Authenticate validates an "Authorization: Bearer <jwt>" value and returns the user ID, role
+// and session ID. ok is false for missing or invalid tokens and for revoked or expired sessions
+// and blocked accounts.
+func Authenticate(jwtSecret string, db *sql.DB, authorization string) (sub, role, sid string, ok bool) {
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return "", "", "", false
+	}
+	tokenStr := strings.TrimPrefix(authorization, "Bearer ")
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		return []byte(jwtSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return "", "", "", false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", "", "", false
+	}
+	sub, _ = claims["sub"].(string)
+	role, _ = claims["role"].(string)
+	sid, _ = claims["sid"].(string)
+	if sid == "" {
+		return "", "", "", false
+	}
+	if active, _ := repository.SessionActive(db, sid, sub); !active {
+		return "", "", "", false
+	}
+	return sub, role, sid, true
+}
+
+// TokenExpiry returns the expiry of an "Authorization: Bearer <jwt>" value already accepted by
+// Authenticate; ok is false when the token has no expiry.
+func TokenExpiry(authorization string) (exp time.Time, ok bool) {
+	token, _, err := jwt.NewParser().ParseUnverified(strings.TrimPrefix(authorization, "Bearer "), jwt.MapClaims{})
+	if err != nil {
+		return time.Time{}, false
+	}
+	date, err := token.Claims.GetExpirationTime()
+	if err != nil || date == nil {
+		return time.Time{}, false
+	}
+	return date.Time, true
+}
+
+// WithUser stores the authenticated user in the context, as Auth does for HTTP requests.
+func WithUser(ctx context.Context, userID, role, sessionID string) context.Context {
+	ctx = context.WithValue(ctx, UserIDKey, userID)
+	ctx = context.WithValue(ctx, UserRoleKey, role)
+	return context.WithValue(ctx, SessionIDKey, sessionID)
+}
+
+// clientIP returns the first X-Forwarded-For address when behind a proxy, else the remote address.
+func clientIP(r *http.Request) string {
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		return strings.TrimSpace(strings.Split(fwd, ",")[0])
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
 func UserID(ctx context.Context) string {
 	v, _ := ctx.Value(UserIDKey).(string)
 	return v
@@ -56,3 +113,15 @@ func UserRole(ctx context.Context) string {
 	v, _ := ctx.Value(UserRoleKey).(string)
 	return v
 }
+
+func SessionID(ctx context.Context) string {
+	v, _ := ctx.Value(SessionIDKey).(string)
+	return v
+}
+
+// ClientInfo returns the user agent and IP of the current request.
+func ClientInfo(ctx context.Context) (userAgent, ip string) {
+	userAgent, _ = ctx.Value(UserAgentKey).(string)
+	ip, _ = ctx.Value(ClientIPKey).(string)
+	return
+}
@@ -5,6 +5,25 @@ import (
 	"strings"
 )
 
+// OriginAllowed reports whether a browser origin may use the API (the websocket upgrade checks
+// it, since CORS headers do not apply there).
+func OriginAllowed(origins []string, origin string) bool {
+	allowed := make(map[string]bool)
+	for _, o := range origins {
+		allowed[strings.TrimSpace(o)] = true
+	}
+	return originAllowed(allowed, origin)
+}
+
+func originAllowed(allowed map[string]bool, origin string) bool {
+	// Allow exact match from config
+	if allowed[origin] {
+		return true
+	}
+	// Allow any localhost / 127.0.0.1 port in development
+	return origin != "" && (strings.HasPrefix(origin, "http://localhost:") || strings.HasPrefix(origin, "http://127.0.0.1:"))
+}
+
 func CORS(origins []string) func(http.Handler) http.Handler {
 	allowed := make(map[string]bool)
 	for _, o := range origins {
@@ -13,11 +32,7 @@ func CORS(origins []string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			origin := r.Header.Get("Origin")
-			// Allow exact match from config
-			if allowed[origin] {
-				w.Header().Set("Access-Control-Allow-Origin", origin)
-			} else if origin != "" && (strings.HasPrefix(origin, "http://localhost:") || strings.HasPrefix(origin, "http://127.0.0.1:")) {
-				// Allow any localhost / 127.0.0.1 port in development
+			if originAllowed(allowed, origin) {
 				w.Header().Set("Access-Control-Allow-Origin", origin)
 			} else if len(origins) > 0 {
 				w.Header().Set("Access-Control-Allow-Origin", origins[0])
@@ -3,17 +3,21 @@ package pagarme
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
+	"time"
 
+	"afterzin/api/internal/checkout"
 	"afterzin/api/internal/config"
+	"afterzin/api/internal/mailer"
 	"afterzin/api/internal/middleware"
-	"afterzin/api/internal/qrcode"
+	"afterzin/api/internal/qrkeys"
 	"afterzin/api/internal/repository"
-
-	"github.com/google/uuid"
+	"afterzin/api/internal/wallet"
 )
 
 // Handler provides HTTP handlers for Pagar.me REST endpoints.
@@ -23,11 +27,14 @@ type Handler struct {
 	client *Client
 	db     *sql.DB
 	cfg    *config.Config
+	outbox *mailer.Outbox
+	wallet *wallet.Service
+	qrKeys *qrkeys.Keyring
 }
 
 // NewHandler creates a new Pagar.me HTTP handler.
-func NewHandler(client *Client, db *sql.DB, cfg *config.Config) *Handler {
-	return &Handler{client: client, db: db, cfg: cfg}
+func NewHandler(client *Client, db *sql.DB, cfg *config.Config, outbox *mailer.Outbox, w *wallet.Service, keys *qrkeys.Keyring) *Handler {
+	return &Handler{client: client, db: db, cfg: cfg, outbox: outbox, wallet: w, qrKeys: keys}
 }
 
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -56,15 +63,11 @@ func (h *Handler) CreateRecipient(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get or create producer profile
+	// Producer profile is created by the producer application (submitProducerApplication)
 	prodID, _ := repository.ProducerIDByUser(h.db, userID)
 	if prodID == "" {
-		var err error
-		prodID, err = repository.CreateProducer(h.db, userID)
-		if err != nil {
-			respondError(w, http.StatusInternalServerError, "erro ao criar perfil de produtor")
-			return
-		}
+		respondError(w, http.StatusBadRequest, "envie sua solicitação de produtor antes de configurar recebimentos")
+		return
 	}
 
 	// Check if already has a recipient
@@ -77,28 +80,69 @@ func (h *Handler) CreateRecipient(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse request body
-	var req struct {
-		Document          string `json:"document"`
-		DocumentType      string `json:"documentType"` // CPF or CNPJ
-		Type              string `json:"type"`         // individual or company
-		BankCode          string `json:"bankCode"`
-		BranchNumber      string `json:"branchNumber"`
-		BranchCheckDigit  string `json:"branchCheckDigit"`
-		AccountNumber     string `json:"accountNumber"`
-		AccountCheckDigit string `json:"accountCheckDigit"`
-		AccountType       string `json:"accountType"` // checking or savings
+	req, err := decodeRecipientRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "corpo inválido")
+
+	// Get user info
+	user, _ := repository.UserByID(h.db, userID)
+	if user == nil {
+		respondError(w, http.StatusInternalServerError, "usuário não encontrado")
 		return
 	}
 
-	if req.Document == "" || req.BankCode == "" || req.BranchNumber == "" || req.AccountNumber == "" {
-		respondError(w, http.StatusBadRequest, "documento, banco, agência e conta são obrigatórios")
+	// Create recipient in Pagar.me
+	result, err := h.client.CreateRecipient(req.params(user))
+	if err != nil {
+		log.Printf("pagarme: create recipient error: %v", err)
+		respondError(w, http.StatusInternalServerError, "erro ao criar recebedor: "+err.Error())
 		return
 	}
 
+	// Persist recipient ID
+	if err := repository.SetProducerPagarmeRecipientID(h.db, prodID, result.RecipientID); err != nil {
+		log.Printf("pagarme: save recipient id error: %v", err)
+		respondError(w, http.StatusInternalServerError, "erro ao salvar recebedor")
+		return
+	}
+
+	// Mark onboarding as complete
+	repository.SetProducerOnboardingComplete(h.db, prodID, true)
+
+	log.Printf("pagarme: recipient created for producer %s (recipient: %s)", prodID, result.RecipientID)
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"recipientId": result.RecipientID,
+		"status":      result.Status,
+		"message":     "recebedor criado com sucesso",
+	})
+}
+
+// recipientRequest is the bank account body of the recipient creation endpoints.
+type recipientRequest struct {
+	Document          string `json:"document"`
+	DocumentType      string `json:"documentType"` // CPF or CNPJ
+	Type              string `json:"type"`         // individual or company
+	BankCode          string `json:"bankCode"`
+	BranchNumber      string `json:"branchNumber"`
+	BranchCheckDigit  string `json:"branchCheckDigit"`
+	AccountNumber     string `json:"accountNumber"`
+	AccountCheckDigit string `json:"accountCheckDigit"`
+	AccountType       string `json:"accountType"` // checking or savings
+}
+
+// decodeRecipientRequest parses and validates the body, applying defaults.
+func decodeRecipientRequest(r *http.Request) (recipientRequest, error) {
+	var req recipientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, errors.New("corpo inválido")
+	}
+	if req.Document == "" || req.BankCode == "" || req.BranchNumber == "" || req.AccountNumber == "" {
+		return req, errors.New("documento, banco, agência e conta são obrigatórios")
+	}
+
 	// Default values
 	if req.DocumentType == "" {
 		req.DocumentType = "CPF"
@@ -109,16 +153,11 @@ func (h *Handler) CreateRecipient(w http.ResponseWriter, r *http.Request) {
 	if req.AccountType == "" {
 		req.AccountType = "checking"
 	}
+	return req, nil
+}
 
-	// Get user info
-	user, _ := repository.UserByID(h.db, userID)
-	if user == nil {
-		respondError(w, http.StatusInternalServerError, "usuário não encontrado")
-		return
-	}
-
-	// Create recipient in Pagar.me
-	result, err := h.client.CreateRecipient(CreateRecipientParams{
+func (req recipientRequest) params(user *repository.UserRow) CreateRecipientParams {
+	return CreateRecipientParams{
 		Name:              user.Name,
 		Email:             user.Email,
 		Document:          req.Document,
@@ -130,24 +169,57 @@ func (h *Handler) CreateRecipient(w http.ResponseWriter, r *http.Request) {
 		AccountNumber:     req.AccountNumber,
 		AccountCheckDigit: req.AccountCheckDigit,
 		AccountType:       req.AccountType,
-	})
+	}
+}
+
+// CreateSellerRecipient handles POST /api/pagarme/seller/recipient/create
+// Creates the Pagar.me recipient that receives the user's resale payouts (any user, not only producers).
+func (h *Handler) CreateSellerRecipient(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID := middleware.UserID(r.Context())
+	if userID == "" {
+		respondError(w, http.StatusUnauthorized, "não autenticado")
+		return
+	}
+
+	existing, _ := repository.GetUserPagarmeRecipientID(h.db, userID)
+	if existing != "" {
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"recipientId": existing,
+			"message":     "recebedor Pagar.me já existe",
+		})
+		return
+	}
+
+	req, err := decodeRecipientRequest(r)
 	if err != nil {
-		log.Printf("pagarme: create recipient error: %v", err)
-		respondError(w, http.StatusInternalServerError, "erro ao criar recebedor: "+err.Error())
+		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// Persist recipient ID
-	if err := repository.SetProducerPagarmeRecipientID(h.db, prodID, result.RecipientID); err != nil {
-		log.Printf("pagarme: save recipient id error: %v", err)
-		respondError(w, http.StatusInternalServerError, "erro ao salvar recebedor")
+	user, _ := repository.UserByID(h.db, userID)
+	if user == nil {
+		respondError(w, http.StatusInternalServerError, "usuário não encontrado")
 		return
 	}
 
-	// Mark onboarding as complete
-	repository.SetProducerOnboardingComplete(h.db, prodID, true)
+	result, err := h.client.CreateRecipient(req.params(user))
+	if err != nil {
+		log.Printf("pagarme: create seller recipient error: %v", err)
+		respondError(w, http.StatusInternalServerError, "erro ao criar recebedor: "+err.Error())
+		return
+	}
+	if err := repository.SetUserPagarmeRecipientID(h.db, userID, result.RecipientID); err != nil {
+		log.Printf("pagarme: save seller recipient id error: %v", err)
+		respondError(w, http.StatusInternalServerError, "erro ao salvar recebedor")
+		return
+	}
 
-	log.Printf("pagarme: recipient created for producer %s (recipient: %s)", prodID, result.RecipientID)
+	log.Printf("pagarme: seller recipient created for user %s (recipient: %s)", userID, result.RecipientID)
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"recipientId": result.RecipientID,
@@ -258,6 +330,12 @@ func (h *Handler) CreatePayment(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "pedido já processado")
 		return
 	}
+	if h.cfg.RequireVerifiedEmail {
+		if u, _ := repository.UserByID(h.db, userID); u == nil || !u.EmailVerifiedAt.Valid {
+			respondError(w, http.StatusForbidden, "confirme seu e-mail antes de comprar ingressos")
+			return
+		}
+	}
 
 	// Check if order already has a Pagar.me order (avoid duplicate charges)
 	existingOrderID, _ := repository.GetOrderPagarmeOrderID(h.db, req.OrderID)
@@ -285,6 +363,39 @@ func (h *Handler) CreatePayment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Resale orders pay the seller (and the producer royalty) instead of the producer
+	if listing, _ := repository.ResaleListingByOrder(h.db, req.OrderID); listing != nil {
+		h.createResalePayment(w, req.OrderID, listing, buyer, items[0])
+		return
+	}
+
+	// Purchase limits are checked again before charging: other orders may have been paid since checkout
+	cart, err := checkout.OrderItems(h.db, req.OrderID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "erro ao carregar pedido")
+		return
+	}
+	if err := checkout.CheckOrderOnSale(h.db, req.OrderID); err != nil {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err := checkout.CheckPurchaseLimits(h.db, buyer, cart); err != nil {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err := checkout.CheckOrderAudience(h.db, req.OrderID, buyer); err != nil {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err := checkout.CheckOrderPromoCode(h.db, req.OrderID, userID); err != nil {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err := checkout.CheckOrderHalfPriceQuota(h.db, req.OrderID); err != nil {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+
 	// Calculate total amount, resolve producer recipient, build order items
 	var producerRecipientID string
 	var totalCentavos int64
@@ -300,7 +411,8 @@ func (h *Handler) CreatePayment(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusBadRequest, "tipo de ingresso não encontrado")
 			return
 		}
-		totalCentavos += int64(tt.Price*100) * int64(item.Quantity)
+		// Unit price of the order item: already discounted when a promo code was applied
+		totalCentavos += toCentavos(item.UnitPrice) * int64(item.Quantity)
 
 		// Resolve event → producer → recipient
 		ed, _ := repository.EventDateByID(h.db, item.EventDateID)
@@ -330,10 +442,17 @@ func (h *Handler) CreatePayment(w http.ResponseWriter, r *http.Request) {
 			Code:        item.TicketTypeID,
 			Description: fmt.Sprintf("%s - %s", tt.Name, eventTitle),
 			Quantity:    item.Quantity,
-			Amount:      int64(tt.Price * 100), // unit price in centavos
+			Amount:      toCentavos(item.UnitPrice), // unit price in centavos
 		})
 	}
 
+	// Fully discounted orders have nothing to charge: tickets are issued right away
+	if totalCentavos == 0 {
+		h.processOrderPayment(req.OrderID, "", "")
+		respondJSON(w, http.StatusOK, PixOrderResult{Status: "paid"})
+		return
+	}
+
 	// Create Pagar.me order with PIX + split
 	pixResult, err := h.client.CreatePixOrder(PixOrderParams{
 		OrderID:             req.OrderID,
@@ -363,6 +482,85 @@ func (h *Handler) CreatePayment(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, pixResult)
 }
 
+// createResalePayment creates the PIX order of a resale purchase, split between seller,
+// producer royalty and platform with the amounts fixed when the ticket was listed.
+func (h *Handler) createResalePayment(w http.ResponseWriter, orderID string, listing *repository.ResaleListingRow, buyer *repository.UserRow, item repository.OrderItemRow) {
+	sellerRecipientID, _ := repository.GetUserPagarmeRecipientID(h.db, listing.SellerID)
+	if sellerRecipientID == "" {
+		respondError(w, http.StatusBadRequest, "vendedor não configurou recebimento de pagamentos")
+		return
+	}
+	ev, _ := repository.EventByID(h.db, listing.EventID)
+	tt, _ := repository.TicketTypeByID(h.db, item.TicketTypeID)
+	if ev == nil || tt == nil {
+		respondError(w, http.StatusBadRequest, "evento não encontrado")
+		return
+	}
+	split := &ResaleSplit{
+		SellerRecipientID: sellerRecipientID,
+		SellerCentavos:    toCentavos(listing.SellerAmount),
+		RoyaltyCentavos:   toCentavos(listing.RoyaltyAmount),
+	}
+	if split.RoyaltyCentavos > 0 {
+		split.ProducerRecipientID, _ = repository.GetProducerPagarmeRecipientID(h.db, ev.ProducerID)
+		// Listings only carry a royalty the producer can receive; should the recipient be
+		// missing anyway, the seller gets it rather than the platform.
+		if split.ProducerRecipientID == "" {
+			log.Printf("pagarme: resale %s royalty paid to the seller: producer %s has no recipient", listing.ID, ev.ProducerID)
+			split.SellerCentavos += split.RoyaltyCentavos
+			split.RoyaltyCentavos = 0
+		}
+	}
+	amount := toCentavos(listing.Price)
+
+	// The hold started at reservation; stretch it over the whole PIX window so a late payment
+	// still finds the ticket reserved for this order.
+	held, err := repository.ExtendResaleReservation(h.db, listing.ID, orderID, PixExpiration+time.Minute)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "erro ao reservar ingresso")
+		return
+	}
+	if !held {
+		respondError(w, http.StatusConflict, "reserva do ingresso expirou; reserve novamente")
+		return
+	}
+
+	pixResult, err := h.client.CreatePixOrder(PixOrderParams{
+		OrderID:          orderID,
+		AmountCentavos:   amount,
+		TotalTickets:     1,
+		Description:      fmt.Sprintf("Afterzin - Revenda %s", ev.Title),
+		CustomerName:     buyer.Name,
+		CustomerEmail:    buyer.Email,
+		CustomerDocument: buyer.CPF,
+		Items: []OrderItem{{
+			Code:        item.TicketTypeID,
+			Description: fmt.Sprintf("Revenda: %s - %s", tt.Name, ev.Title),
+			Quantity:    1,
+			Amount:      amount,
+		}},
+		Resale: split,
+	})
+	if err != nil {
+		log.Printf("pagarme: create resale pix order error: %v", err)
+		respondError(w, http.StatusInternalServerError, "erro ao criar pagamento PIX: "+err.Error())
+		return
+	}
+
+	repository.SetOrderPagarmeOrderID(h.db, orderID, pixResult.PagarmeOrderID)
+	repository.SetOrderPagarmeChargeID(h.db, orderID, pixResult.PagarmeChargeID)
+
+	log.Printf("pagarme: resale PIX order created for order %s (listing: %s, amount: %d, seller: %d, royalty: %d)",
+		orderID, listing.ID, amount, split.SellerCentavos, split.RoyaltyCentavos)
+
+	respondJSON(w, http.StatusOK, pixResult)
+}
+
+// toCentavos converts a BRL amount to centavos.
+func toCentavos(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
 // GetPaymentStatus handles GET /api/pagarme/payment/status?orderId=xxx
 // Frontend polls this to check if PIX was paid.
 func (h *Handler) GetPaymentStatus(w http.ResponseWriter, r *http.Request) {
@@ -442,6 +640,7 @@ func (h *Handler) GetPaymentStatus(w http.ResponseWriter, r *http.Request) {
 // Handled events:
 //   - order.paid → confirms order, creates tickets, generates QR codes
 //   - charge.paid → fallback handler
+//   - charge.refunded → marks the order REFUNDED and notifies the buyer
 func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -482,6 +681,8 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		h.handleOrderPaid(event)
 	case "charge.paid":
 		h.handleChargePaid(event)
+	case "charge.refunded":
+		h.handleChargeRefunded(event)
 	default:
 		log.Printf("pagarme: unhandled webhook event type: %s", event.Type)
 	}
@@ -552,8 +753,47 @@ func (h *Handler) handleChargePaid(event *WebhookEvent) {
 	h.processOrderPayment(orderID, pagarmeOrderID, chargeID)
 }
 
+// handleChargeRefunded processes charge.refunded: the order is marked REFUNDED
+// (only once) and the buyer receives the refund notice by email.
+func (h *Handler) handleChargeRefunded(event *WebhookEvent) {
+	data := event.Data
+	if data == nil {
+		log.Printf("pagarme: charge.refunded - no data")
+		return
+	}
+
+	chargeID, _ := data["id"].(string)
+	orderID := ""
+	if orderData, ok := data["order"].(map[string]interface{}); ok {
+		orderID, _ = orderData["code"].(string)
+	}
+	if orderID == "" && chargeID != "" {
+		orderID, _ = repository.OrderIDByPagarmeChargeID(h.db, chargeID)
+	}
+	if orderID == "" {
+		log.Printf("pagarme: charge.refunded but order not found (charge: %s)", chargeID)
+		return
+	}
+
+	refunded, err := repository.RefundOrder(h.db, orderID)
+	if err != nil {
+		log.Printf("pagarme: refund order %s error: %v", orderID, err)
+		return
+	}
+	if !refunded {
+		log.Printf("pagarme: order %s not PAID, skipping refund", orderID)
+		return
+	}
+	h.outbox.OrderRefunded(orderID)
+	if ticketIDs, err := repository.TicketIDsByOrder(h.db, orderID); err == nil {
+		h.wallet.TicketsChanged(ticketIDs...)
+	}
+
+	log.Printf("pagarme: order %s REFUNDED via webhook (charge: %s)", orderID, chargeID)
+}
+
 // processOrderPayment handles the common logic for confirming an order:
-// verify pending, create tickets, confirm order.
+// verify pending, then create the tickets and confirm the order (or refund it).
 func (h *Handler) processOrderPayment(orderID, pagarmeOrderID, chargeID string) {
 	// Save Pagar.me IDs to order
 	if pagarmeOrderID != "" {
@@ -574,49 +814,80 @@ func (h *Handler) processOrderPayment(orderID, pagarmeOrderID, chargeID string)
 		return
 	}
 
-	// Create tickets for each order item
-	items, err := repository.OrderItemsByOrderID(h.db, orderID)
-	if err != nil {
-		log.Printf("pagarme: get order items for %s error: %v", orderID, err)
+	// Resale: the existing ticket changes owner instead of new tickets being issued
+	if listing, _ := repository.ResaleListingByOrder(h.db, orderID); listing != nil {
+		h.completeResale(orderID, orderUserID, chargeID, listing)
 		return
 	}
 
-	for _, item := range items {
-		evDate, _ := repository.EventDateByID(h.db, item.EventDateID)
-		if evDate == nil {
-			continue
-		}
-		ev, _ := repository.EventByID(h.db, evDate.EventID)
-		if ev == nil {
-			continue
-		}
+	// Tickets are issued and the order confirmed in one transaction, which checks again the
+	// rules that only count paid orders: the half-price quota may have run out while the PIX
+	// was pending. A ticket whose QR code cannot be signed fails the whole order.
+	_, err = checkout.FulfillOrder(h.db, orderID, func(ticketID, eventID string) (string, error) {
+		// QR payload with charge_id and event_id for traceability
+		return h.qrKeys.Sign(ticketID, chargeID, eventID)
+	})
+	var unfulfillable *checkout.UnfulfillableError
+	switch {
+	case errors.As(err, &unfulfillable):
+		h.refundUnfulfilled(orderID, chargeID, err.Error())
+		return
+	case errors.Is(err, checkout.ErrOrderNotPending):
+		log.Printf("pagarme: order %s no longer pending, skipping ticket creation", orderID)
+		return
+	case err != nil:
+		log.Printf("pagarme: confirm order %s error: %v", orderID, err)
+		return
+	}
 
-		for i := 0; i < item.Quantity; i++ {
-			ticketID := uuid.New().String()
-			code := repository.GenerateTicketCode()
-
-			// QR payload with charge_id and event_id for traceability
-			qrPayload := qrcode.GenerateSignedPayloadV2(ticketID, chargeID, ev.ID, []byte(h.cfg.JWTSecret))
-
-			err := repository.CreateTicketWithID(
-				h.db, ticketID, code, qrPayload,
-				orderID, item.ID, orderUserID,
-				ev.ID, item.EventDateID, item.TicketTypeID,
-			)
-			if err != nil {
-				log.Printf("pagarme: create ticket error: %v", err)
-				continue
-			}
-			repository.IncrementTicketTypeSold(h.db, item.TicketTypeID, 1)
-			lotID, _ := repository.LotIDByTicketTypeID(h.db, item.TicketTypeID)
-			repository.DecrementLotAvailable(h.db, lotID, 1)
+	// Queue buyer confirmation and producer sale alerts; delivery happens in the outbox worker
+	h.outbox.OrderPaid(orderID)
+
+	log.Printf("pagarme: order %s CONFIRMED via webhook (pagarme_order: %s, charge: %s)", orderID, pagarmeOrderID, chargeID)
+}
+
+// refundUnfulfilled returns the payment of an order that was paid but cannot be fulfilled and
+// tells the buyer; without a refund the order stays PENDING and is logged for manual handling.
+func (h *Handler) refundUnfulfilled(orderID, chargeID, reason string) {
+	// Fully discounted orders have no charge to return.
+	if chargeID == "" {
+		if refunded, _ := repository.RefundUnfulfilledOrder(h.db, orderID); refunded {
+			h.outbox.OrderRefunded(orderID)
 		}
+		log.Printf("pagarme: order %s not fulfilled (%s); nothing was charged", orderID, reason)
+		return
+	}
+	if err := h.client.CancelCharge(chargeID); err != nil {
+		log.Printf("pagarme: order %s paid but not fulfilled (%s); refund of charge %s failed: %v — refund required", orderID, reason, chargeID, err)
+		return
 	}
+	if refunded, _ := repository.RefundUnfulfilledOrder(h.db, orderID); refunded {
+		h.outbox.OrderRefunded(orderID)
+	}
+	log.Printf("pagarme: order %s paid but not fulfilled (%s); charge %s refunded", orderID, reason, chargeID)
+}
 
-	// Confirm the order
-	if err := repository.ConfirmOrder(h.db, orderID); err != nil {
-		log.Printf("pagarme: confirm order %s error: %v", orderID, err)
+// completeResale hands the resold ticket to the buyer with a new QR payload and confirms the order.
+// The PIX is already paid: when the ticket cannot be handed over, the payment is returned.
+func (h *Handler) completeResale(orderID, buyerID, chargeID string, listing *repository.ResaleListingRow) {
+	newQR, err := h.qrKeys.Sign(listing.TicketID, chargeID, listing.EventID)
+	if err != nil {
+		h.refundUnfulfilled(orderID, chargeID, fmt.Sprintf("resale QR code not signed: %v", err))
+		return
+	}
+	// CompleteResale changes nothing when it fails, so the listing stays with the seller.
+	listing, previousQR, ok, err := repository.CompleteResale(h.db, orderID, buyerID, newQR)
+	if err != nil {
+		h.refundUnfulfilled(orderID, chargeID, fmt.Sprintf("resale not completed: %v", err))
+		return
+	}
+	if !ok {
+		// Paid, but the ticket was used or the listing withdrawn: give the money back.
+		h.refundUnfulfilled(orderID, chargeID, "ticket no longer available")
+		return
 	}
+	h.wallet.TicketTransferred(listing.TicketID, previousQR)
+	h.outbox.OrderPaid(orderID)
 
-	log.Printf("pagarme: order %s CONFIRMED via webhook (pagarme_order: %s, charge: %s)", orderID, pagarmeOrderID, chargeID)
+	log.Printf("pagarme: resale order %s CONFIRMED via webhook (listing: %s, ticket: %s, charge: %s)", orderID, listing.ID, listing.TicketID, chargeID)
 }
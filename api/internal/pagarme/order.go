@@ -1,18 +1,34 @@
 package pagarme
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
+
+// PixExpiration is how long a PIX charge can be paid after it is created.
+const PixExpiration = 15 * time.Minute
 
 // PixOrderParams holds parameters for creating a Pagar.me order with PIX.
 type PixOrderParams struct {
-	OrderID             string      // Internal order ID (used as order "code" in Pagar.me)
-	ProducerRecipientID string      // Producer's Pagar.me recipient ID (for split)
-	AmountCentavos      int64       // Total amount in BRL centavos
-	TotalTickets        int         // Ticket count for fee calculation
-	Description         string      // Description for the payment
-	CustomerName        string      // Buyer's name
-	CustomerEmail       string      // Buyer's email
-	CustomerDocument    string      // Buyer's CPF
-	Items               []OrderItem // Line items
+	OrderID             string       // Internal order ID (used as order "code" in Pagar.me)
+	ProducerRecipientID string       // Producer's Pagar.me recipient ID (for split)
+	AmountCentavos      int64        // Total amount in BRL centavos
+	TotalTickets        int          // Ticket count for fee calculation
+	Description         string       // Description for the payment
+	CustomerName        string       // Buyer's name
+	CustomerEmail       string       // Buyer's email
+	CustomerDocument    string       // Buyer's CPF
+	Items               []OrderItem  // Line items
+	Resale              *ResaleSplit // Set for resale orders; replaces the producer split
+}
+
+// ResaleSplit holds the receivers of a secondary-market (resale) order. The platform keeps
+// the remainder (its fee) of the order amount.
+type ResaleSplit struct {
+	SellerRecipientID   string // Seller's Pagar.me recipient ID
+	SellerCentavos      int64  // Price minus platform fee and royalty
+	ProducerRecipientID string // Royalty receiver; empty when there is no royalty
+	RoyaltyCentavos     int64
 }
 
 // OrderItem represents a single line item in the order.
@@ -36,10 +52,14 @@ type PixOrderResult struct {
 // CreatePixOrder creates a Pagar.me order with PIX payment method and split.
 //
 // Split logic:
-//   - Platform (Afterzin) receives ApplicationFee × TotalTickets (R$5.00 per ticket default)
-//   - Producer receives the remainder
+//   - Platform (Afterzin) receives ApplicationFee × TotalTickets (R$5.00 per ticket default),
+//     capped at the amount when promo code discounts leave less than the fee
+//   - Producer receives the remainder (the discount comes out of the producer's share)
 //   - Processing fees are charged to the producer
 //
+// Resale orders (params.Resale) split between seller, producer royalty and platform instead;
+// processing fees are charged to the seller.
+//
 // PIX flow:
 //  1. Create order with PIX payment + split
 //  2. Pagar.me generates QR code + copia-e-cola
@@ -47,13 +67,6 @@ type PixOrderResult struct {
 //  4. Customer scans/pastes in banking app
 //  5. Webhook order.paid fires when payment is confirmed
 func (c *Client) CreatePixOrder(params PixOrderParams) (*PixOrderResult, error) {
-	// Calculate split amounts
-	platformFee := c.ApplicationFee * int64(params.TotalTickets)
-	producerAmount := params.AmountCentavos - platformFee
-	if producerAmount < 0 {
-		producerAmount = 0
-	}
-
 	// Build items array
 	items := make([]map[string]interface{}, len(params.Items))
 	for i, item := range params.Items {
@@ -65,30 +78,11 @@ func (c *Client) CreatePixOrder(params PixOrderParams) (*PixOrderResult, error)
 		}
 	}
 
-	// Build split array
-	split := []map[string]interface{}{
-		{
-			"recipient_id": params.ProducerRecipientID,
-			"amount":       producerAmount,
-			"type":         "flat",
-			"options": map[string]interface{}{
-				"charge_processing_fee": true,
-				"charge_remainder_fee":  true,
-			},
-		},
-	}
-
-	// Only add platform split if PlatformRecipientID is set and fee > 0
-	if c.PlatformRecipientID != "" && platformFee > 0 {
-		split = append(split, map[string]interface{}{
-			"recipient_id": c.PlatformRecipientID,
-			"amount":       platformFee,
-			"type":         "flat",
-			"options": map[string]interface{}{
-				"charge_processing_fee": false,
-				"charge_remainder_fee":  false,
-			},
-		})
+	var split []map[string]interface{}
+	if params.Resale != nil {
+		split = c.resaleSplit(params.AmountCentavos, params.Resale)
+	} else {
+		split = c.producerSplit(params.AmountCentavos, params.TotalTickets, params.ProducerRecipientID)
 	}
 
 	body := map[string]interface{}{
@@ -105,7 +99,7 @@ func (c *Client) CreatePixOrder(params PixOrderParams) (*PixOrderResult, error)
 			{
 				"payment_method": "pix",
 				"pix": map[string]interface{}{
-					"expires_in": 900, // 15 minutes
+					"expires_in": int(PixExpiration / time.Second),
 					"additional_information": []map[string]interface{}{
 						{
 							"name":  "Afterzin",
@@ -141,6 +135,89 @@ func (c *Client) CreatePixOrder(params PixOrderParams) (*PixOrderResult, error)
 	return pixResult, nil
 }
 
+// producerSplit builds the split of a regular order: platform fee per ticket, rest to the producer.
+func (c *Client) producerSplit(amount int64, totalTickets int, producerRecipientID string) []map[string]interface{} {
+	platformFee := c.ApplicationFee * int64(totalTickets)
+	if platformFee > amount {
+		platformFee = amount
+	}
+	producerAmount := amount - platformFee
+
+	split := []map[string]interface{}{
+		{
+			"recipient_id": producerRecipientID,
+			"amount":       producerAmount,
+			"type":         "flat",
+			"options": map[string]interface{}{
+				"charge_processing_fee": true,
+				"charge_remainder_fee":  true,
+			},
+		},
+	}
+
+	// Only add platform split if PlatformRecipientID is set and fee > 0
+	if c.PlatformRecipientID != "" && platformFee > 0 {
+		split = append(split, map[string]interface{}{
+			"recipient_id": c.PlatformRecipientID,
+			"amount":       platformFee,
+			"type":         "flat",
+			"options": map[string]interface{}{
+				"charge_processing_fee": false,
+				"charge_remainder_fee":  false,
+			},
+		})
+	}
+	return split
+}
+
+// resaleSplit builds the split of a resale order: seller payout, optional producer royalty
+// (ProducerRecipientID must be set when RoyaltyCentavos is), and the remaining platform fee.
+func (c *Client) resaleSplit(amount int64, r *ResaleSplit) []map[string]interface{} {
+	split := []map[string]interface{}{
+		{
+			"recipient_id": r.SellerRecipientID,
+			"amount":       r.SellerCentavos,
+			"type":         "flat",
+			"options": map[string]interface{}{
+				"charge_processing_fee": true,
+				"charge_remainder_fee":  true,
+			},
+		},
+	}
+	if r.ProducerRecipientID != "" && r.RoyaltyCentavos > 0 {
+		split = append(split, map[string]interface{}{
+			"recipient_id": r.ProducerRecipientID,
+			"amount":       r.RoyaltyCentavos,
+			"type":         "flat",
+			"options": map[string]interface{}{
+				"charge_processing_fee": false,
+				"charge_remainder_fee":  false,
+			},
+		})
+	}
+	platformFee := amount - r.SellerCentavos - r.RoyaltyCentavos
+	if c.PlatformRecipientID != "" && platformFee > 0 {
+		split = append(split, map[string]interface{}{
+			"recipient_id": c.PlatformRecipientID,
+			"amount":       platformFee,
+			"type":         "flat",
+			"options": map[string]interface{}{
+				"charge_processing_fee": false,
+				"charge_remainder_fee":  false,
+			},
+		})
+	}
+	return split
+}
+
+// CancelCharge cancels a pending charge or refunds a paid one in full.
+func (c *Client) CancelCharge(chargeID string) error {
+	if _, err := c.doRequest("DELETE", "/charges/"+chargeID, nil); err != nil {
+		return fmt.Errorf("cancel charge: %w", err)
+	}
+	return nil
+}
+
 // GetOrder retrieves a Pagar.me order by its ID.
 func (c *Client) GetOrder(pagarmeOrderID string) (map[string]interface{}, error) {
 	return c.doRequest("GET", "/orders/"+pagarmeOrderID, nil)
@@ -0,0 +1,136 @@
+package qrcode
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dynamic QR codes rotate every DynamicPeriod, like TOTP: the app signs the current time window
+// with the ticket's dynamic secret, so a screenshot stops working a few seconds after it is taken.
+// Format: "D2:" + ticketID + ":" + window + "." + hex(HMAC-SHA256(ticketID + ":" + window, ticketSecret)) +
+// "." + hex(HMAC-SHA256(ticketID + ":" + window, offlineSecret)), where window is the Unix time
+// divided by the period. Online validation checks the first signature; offline scanners only get
+// the offline secret (DynamicOfflineSecret) and check the second, so a scanner cannot produce
+// codes the server accepts.
+const (
+	dynamicPrefix = "D2:"
+	// DynamicPeriod is how long each dynamic payload is shown.
+	DynamicPeriod = 30 * time.Second
+	// DynamicSkew is how many windows before or after the current one are still accepted, for
+	// clock drift between the phone and the server and the time it takes to scan.
+	DynamicSkew = 1
+)
+
+var (
+	ErrDynamicInvalid = errors.New("qrcode: invalid dynamic payload")
+	ErrDynamicExpired = errors.New("qrcode: dynamic payload outside the time window")
+)
+
+// DynamicSecret derives the per-ticket secret of the dynamic QR code from the ticket's current
+// static payload and the server-side key that signed it, so reissuing the QR code (transfer,
+// resale) also replaces the dynamic secret.
+func DynamicSecret(staticPayload string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("dynamic-qr/v2\n" + staticPayload))
+	return mac.Sum(nil)
+}
+
+// DynamicOfflineSecret derives, from the ticket's dynamic secret, the secret of the offline
+// signature of its dynamic payloads: the only one the offline manifest carries.
+func DynamicOfflineSecret(ticketSecret []byte) []byte {
+	mac := hmac.New(sha256.New, ticketSecret)
+	mac.Write([]byte("dynamic-qr/offline"))
+	return mac.Sum(nil)
+}
+
+// DynamicWindow is the time window of t.
+func DynamicWindow(t time.Time) int64 {
+	return t.Unix() / int64(DynamicPeriod/time.Second)
+}
+
+// GenerateDynamicPayload produces the dynamic payload of the ticket for the window of t.
+func GenerateDynamicPayload(ticketID string, ticketSecret []byte, t time.Time) string {
+	window := DynamicWindow(t)
+	data := dynamicPrefix + ticketID + ":" + strconv.FormatInt(window, 10)
+	return data + separator + hex.EncodeToString(dynamicSignature(ticketID, window, ticketSecret)) +
+		separator + hex.EncodeToString(dynamicSignature(ticketID, window, DynamicOfflineSecret(ticketSecret)))
+}
+
+func dynamicSignature(ticketID string, window int64, ticketSecret []byte) []byte {
+	mac := hmac.New(sha256.New, ticketSecret)
+	mac.Write([]byte(ticketID + ":" + strconv.FormatInt(window, 10)))
+	return mac.Sum(nil)
+}
+
+// IsDynamicPayload tells dynamic payloads apart from the static V1/V2 ones.
+func IsDynamicPayload(payload string) bool {
+	return strings.HasPrefix(payload, dynamicPrefix)
+}
+
+// parseDynamicPayload splits a dynamic payload into its ticket ID, window and online and offline
+// signatures; ok is false when it is malformed.
+func parseDynamicPayload(payload string) (ticketID string, window int64, sig, offlineSig []byte, ok bool) {
+	if !IsDynamicPayload(payload) {
+		return "", 0, nil, nil, false
+	}
+	parts := strings.Split(strings.TrimPrefix(payload, dynamicPrefix), separator)
+	if len(parts) != 3 {
+		return "", 0, nil, nil, false
+	}
+	sig, err := hex.DecodeString(parts[1])
+	if err != nil || len(sig) != sha256.Size {
+		return "", 0, nil, nil, false
+	}
+	offlineSig, err = hex.DecodeString(parts[2])
+	if err != nil || len(offlineSig) != sha256.Size {
+		return "", 0, nil, nil, false
+	}
+	i := strings.LastIndex(parts[0], ":")
+	if i <= 0 {
+		return "", 0, nil, nil, false
+	}
+	window, err = strconv.ParseInt(parts[0][i+1:], 10, 64)
+	if err != nil {
+		return "", 0, nil, nil, false
+	}
+	return parts[0][:i], window, sig, offlineSig, true
+}
+
+// DynamicPayloadTicketID extracts the ticket ID of a dynamic payload WITHOUT verifying it; the
+// caller then verifies it with the ticket's dynamic secret.
+func DynamicPayloadTicketID(payload string) (ticketID string, ok bool) {
+	ticketID, _, _, _, ok = parseDynamicPayload(payload)
+	return ticketID, ok
+}
+
+// VerifyDynamicPayload checks the online signature with the ticket's dynamic secret and that the
+// window is within DynamicSkew of now. Returns ErrDynamicInvalid or ErrDynamicExpired otherwise.
+func VerifyDynamicPayload(payload string, ticketSecret []byte, now time.Time) error {
+	ticketID, window, sig, _, ok := parseDynamicPayload(payload)
+	if !ok || !hmac.Equal(sig, dynamicSignature(ticketID, window, ticketSecret)) {
+		return ErrDynamicInvalid
+	}
+	return checkDynamicWindow(window, now)
+}
+
+// VerifyDynamicPayloadOffline is VerifyDynamicPayload for offline scanners: it checks the offline
+// signature with the ticket's offline secret (DynamicOfflineSecret).
+func VerifyDynamicPayloadOffline(payload string, offlineSecret []byte, now time.Time) error {
+	ticketID, window, _, offlineSig, ok := parseDynamicPayload(payload)
+	if !ok || !hmac.Equal(offlineSig, dynamicSignature(ticketID, window, offlineSecret)) {
+		return ErrDynamicInvalid
+	}
+	return checkDynamicWindow(window, now)
+}
+
+func checkDynamicWindow(window int64, now time.Time) error {
+	if d := window - DynamicWindow(now); d < -DynamicSkew || d > DynamicSkew {
+		return ErrDynamicExpired
+	}
+	return nil
+}
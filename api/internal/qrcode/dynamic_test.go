@@ -0,0 +1,58 @@
+package qrcode
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDynamicPayload(t *testing.T) {
+	secret := DynamicSecret("V3:k1:ticket-1:order-1:event-1.sig", []byte("chave"))
+	now := time.Unix(1_760_000_000, 0)
+	payload := GenerateDynamicPayload("ticket-1", secret, now)
+
+	if !IsDynamicPayload(payload) {
+		t.Fatalf("IsDynamicPayload(%q) = false", payload)
+	}
+	if id, ok := DynamicPayloadTicketID(payload); !ok || id != "ticket-1" {
+		t.Errorf("DynamicPayloadTicketID = %q, %v", id, ok)
+	}
+	for _, d := range []time.Duration{0, DynamicPeriod, -DynamicPeriod} {
+		if err := VerifyDynamicPayload(payload, secret, now.Add(d)); err != nil {
+			t.Errorf("verify %v from the window: %v", d, err)
+		}
+		if err := VerifyDynamicPayloadOffline(payload, DynamicOfflineSecret(secret), now.Add(d)); err != nil {
+			t.Errorf("verify offline %v from the window: %v", d, err)
+		}
+	}
+	// A screenshot stops working once the skew is over.
+	if err := VerifyDynamicPayload(payload, secret, now.Add(2*DynamicPeriod)); err != ErrDynamicExpired {
+		t.Errorf("two windows later: got %v, want ErrDynamicExpired", err)
+	}
+}
+
+func TestDynamicPayloadInvalid(t *testing.T) {
+	secret := DynamicSecret("V3:k1:ticket-1:order-1:event-1.sig", []byte("chave"))
+	now := time.Unix(1_760_000_000, 0)
+	payload := GenerateDynamicPayload("ticket-1", secret, now)
+
+	// Reissuing the static QR code (transfer, resale) replaces the dynamic secret.
+	reissued := DynamicSecret("V3:k1:ticket-1:transfer-1:event-1.sig", []byte("chave"))
+	if err := VerifyDynamicPayload(payload, reissued, now); err != ErrDynamicInvalid {
+		t.Errorf("old secret: got %v, want ErrDynamicInvalid", err)
+	}
+	// The offline secret cannot produce payloads the server accepts.
+	forged := GenerateDynamicPayload("ticket-1", DynamicOfflineSecret(secret), now)
+	if err := VerifyDynamicPayload(forged, secret, now); err != ErrDynamicInvalid {
+		t.Errorf("signed with the offline secret: got %v, want ErrDynamicInvalid", err)
+	}
+	other := strings.Replace(payload, "ticket-1", "ticket-2", 1)
+	if err := VerifyDynamicPayload(other, secret, now); err != ErrDynamicInvalid {
+		t.Errorf("other ticket ID: got %v, want ErrDynamicInvalid", err)
+	}
+	for _, p := range []string{"", "D2:", "D2:ticket-1:abc.00.00", "V2:ticket-1.sig"} {
+		if err := VerifyDynamicPayload(p, secret, now); err != ErrDynamicInvalid {
+			t.Errorf("VerifyDynamicPayload(%q) = %v, want ErrDynamicInvalid", p, err)
+		}
+	}
+}
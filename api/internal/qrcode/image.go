@@ -0,0 +1,37 @@
+package qrcode
+
+import (
+	"fmt"
+	"strings"
+
+	goqrcode "github.com/skip2/go-qrcode"
+)
+
+// PNG renders the QR payload as a square PNG image of size×size pixels.
+// Medium error correction keeps the code readable on scratched phone screens and prints.
+func PNG(payload string, size int) ([]byte, error) {
+	return goqrcode.Encode(payload, goqrcode.Medium, size)
+}
+
+// SVG renders the QR payload as a scalable SVG document (one path, including the quiet zone).
+// Vector output stays sharp at any print size and can be read by screen-reader tooling via its title.
+func SVG(payload string) ([]byte, error) {
+	q, err := goqrcode.New(payload, goqrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	bitmap := q.Bitmap()
+	n := len(bitmap)
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	svg := fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges" role="img">`+
+		`<title>QR Code do ingresso</title><rect width="%d" height="%d" fill="#fff"/><path d="%s" fill="#000"/></svg>`,
+		n, n, n, n, path.String())
+	return []byte(svg), nil
+}
@@ -9,21 +9,9 @@ import (
 
 const separator = "."
 
-// GenerateSignedPayload produces a signed payload for a ticket QR code.
-// Format: ticketID + "." + hex(HMAC-SHA256(ticketID, secret)).
-// The payload is unique, non-guessable and verifiable; it does not expose sensitive data in plain text.
-func GenerateSignedPayload(ticketID string, secret []byte) string {
-	if len(secret) == 0 {
-		secret = []byte("default-secret")
-	}
-	mac := hmac.New(sha256.New, secret)
-	mac.Write([]byte(ticketID))
-	sig := mac.Sum(nil)
-	return ticketID + separator + hex.EncodeToString(sig)
-}
-
-// VerifySignedPayload verifies the HMAC and returns the ticket ID if valid.
-// Returns ("", false) if the payload is malformed or the signature is invalid.
+// VerifySignedPayload verifies a V1 payload, ticketID + "." + hex(HMAC-SHA256(ticketID, secret)),
+// and returns the ticket ID if valid. New tickets are signed with V3; V1 is only verified.
+// Returns ("", false) if the payload is malformed, the signature is invalid or secret is empty.
 func VerifySignedPayload(payload string, secret []byte) (ticketID string, ok bool) {
 	idx := strings.LastIndex(payload, separator)
 	if idx <= 0 || idx >= len(payload)-1 {
@@ -36,7 +24,7 @@ func VerifySignedPayload(payload string, secret []byte) (ticketID string, ok boo
 		return "", false
 	}
 	if len(secret) == 0 {
-		secret = []byte("default-secret")
+		return "", false
 	}
 	mac := hmac.New(sha256.New, secret)
 	mac.Write([]byte(ticketID))
@@ -44,21 +32,8 @@ func VerifySignedPayload(payload string, secret []byte) (ticketID string, ok boo
 	return ticketID, hmac.Equal(sig, expected)
 }
 
-// GenerateSignedPayloadV2 creates a QR code payload containing ticket ID,
-// charge ID, and event ID. This links the ticket to its payment transaction.
-// Format: ticketID:chargeID:eventID.hmac_signature
-func GenerateSignedPayloadV2(ticketID, chargeID, eventID string, secret []byte) string {
-	if len(secret) == 0 {
-		secret = []byte("default-secret")
-	}
-	data := ticketID + ":" + chargeID + ":" + eventID
-	mac := hmac.New(sha256.New, secret)
-	mac.Write([]byte(data))
-	sig := mac.Sum(nil)
-	return data + separator + hex.EncodeToString(sig)
-}
-
-// VerifySignedPayloadV2 verifies a V2 QR code payload and extracts all components.
+// VerifySignedPayloadV2 verifies a V2 QR code payload, ticketID:chargeID:eventID.hmac_signature,
+// and extracts all components. New tickets are signed with V3; V2 is only verified.
 func VerifySignedPayloadV2(payload string, secret []byte) (ticketID, chargeID, eventID string, ok bool) {
 	idx := strings.LastIndex(payload, separator)
 	if idx <= 0 || idx >= len(payload)-1 {
@@ -71,7 +46,7 @@ func VerifySignedPayloadV2(payload string, secret []byte) (ticketID, chargeID, e
 		return "", "", "", false
 	}
 	if len(secret) == 0 {
-		secret = []byte("default-secret")
+		return "", "", "", false
 	}
 	mac := hmac.New(sha256.New, secret)
 	mac.Write([]byte(data))
@@ -85,3 +60,33 @@ func VerifySignedPayloadV2(payload string, secret []byte) (ticketID, chargeID, e
 	}
 	return parts[0], parts[1], parts[2], true
 }
+
+// PayloadTicketID extracts the ticket ID of a V1, V2, V3 or dynamic payload WITHOUT verifying the
+// signature; only the shape is checked (ID, "." and the signature). Offline scanners use
+// it together with the payload hashes (or dynamic secrets) of the check-in manifest, which stand
+// in for the signature.
+func PayloadTicketID(payload string) (ticketID string, ok bool) {
+	if IsDynamicPayload(payload) {
+		return DynamicPayloadTicketID(payload)
+	}
+	if p, ok := ParseV3(payload); ok {
+		return p.TicketID, true
+	}
+	idx := strings.LastIndex(payload, separator)
+	if idx <= 0 || idx >= len(payload)-1 {
+		return "", false
+	}
+	sig, err := hex.DecodeString(payload[idx+1:])
+	if err != nil || len(sig) != sha256.Size {
+		return "", false
+	}
+	data := payload[:idx]
+	if i := strings.Index(data, ":"); i >= 0 {
+		// V2: ticketID:chargeID:eventID
+		if strings.Count(data, ":") != 2 {
+			return "", false
+		}
+		data = data[:i]
+	}
+	return data, data != ""
+}
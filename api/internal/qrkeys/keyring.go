@@ -0,0 +1,294 @@
+// Package qrkeys is the keyring that signs ticket QR codes: Ed25519 keys stored in
+// qr_signing_keys, named by a key ID embedded in the V3 payload. The newest active key signs new
+// payloads and every active key verifies, so keys can be rotated without invalidating tickets
+// already sold; scanners verify offline with the public keys alone. The private seeds are stored
+// encrypted with Config.QRKeysSecret. V1/V2 payloads, signed with an HMAC secret before the
+// keyring, keep being verified with Config.QRLegacySecret.
+package qrkeys
+
+import (
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"afterzin/api/internal/config"
+	"afterzin/api/internal/qrcode"
+	"afterzin/api/internal/repository"
+)
+
+// refreshInterval is how often the keys are reloaded, so rotations made with cmd/qrkeys reach
+// running servers; an unknown key ID reloads earlier, at most every missReloadInterval.
+const (
+	refreshInterval    = time.Minute
+	missReloadInterval = 5 * time.Second
+)
+
+type key struct {
+	id      string
+	public  ed25519.PublicKey
+	private ed25519.PrivateKey
+}
+
+// Keyring holds the active keys, newest first.
+type Keyring struct {
+	db           *sql.DB
+	legacySecret []byte
+	seeds        cipher.AEAD
+
+	mu       sync.Mutex
+	keys     []key
+	loadedAt time.Time
+}
+
+// New loads the keyring, creating the first key when there is none.
+func New(db *sql.DB, cfg *config.Config) (*Keyring, error) {
+	seeds, err := seedCipher(cfg.QRKeysSecret)
+	if err != nil {
+		return nil, err
+	}
+	k := &Keyring{db: db, legacySecret: []byte(cfg.QRLegacySecret), seeds: seeds}
+	if err := k.reload(); err != nil {
+		return nil, err
+	}
+	if len(k.keys) == 0 {
+		if _, err := Rotate(db, cfg); err != nil {
+			return nil, err
+		}
+		if err := k.reload(); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+func (k *Keyring) reload() error {
+	rows, err := repository.QRSigningKeys(k.db)
+	if err != nil {
+		return err
+	}
+	var keys []key
+	for _, r := range rows {
+		seed, sealed, err := openSeed(k.seeds, r.ID, r.PrivateKey)
+		if err != nil {
+			return fmt.Errorf("qrkeys: key %s: %v", r.ID, err)
+		}
+		// Keys stored before encryption (retired ones too) are sealed in place.
+		if !sealed {
+			stored, err := sealSeed(k.seeds, r.ID, seed)
+			if err != nil {
+				return err
+			}
+			if err := repository.SealQRSigningKey(k.db, r.ID, r.PrivateKey, stored); err != nil {
+				return err
+			}
+		}
+		if r.RetiredAt.Valid {
+			continue
+		}
+		pub, err := hex.DecodeString(r.PublicKey)
+		if err != nil || len(pub) != ed25519.PublicKeySize || len(seed) != ed25519.SeedSize {
+			return fmt.Errorf("qrkeys: invalid key %s", r.ID)
+		}
+		keys = append(keys, key{id: r.ID, public: pub, private: ed25519.NewKeyFromSeed(seed)})
+	}
+	k.mu.Lock()
+	k.keys, k.loadedAt = keys, time.Now()
+	k.mu.Unlock()
+	return nil
+}
+
+// active returns the keys, reloading them when stale (or, with miss, when a key ID was not
+// found). A failed reload keeps the previous keys.
+func (k *Keyring) active(miss bool) []key {
+	k.mu.Lock()
+	age := time.Since(k.loadedAt)
+	k.mu.Unlock()
+	if age > refreshInterval || (miss && age > missReloadInterval) {
+		_ = k.reload()
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.keys
+}
+
+func (k *Keyring) find(id string) *key {
+	for _, miss := range []bool{false, true} {
+		for _, c := range k.active(miss) {
+			if c.id == id {
+				return &c
+			}
+		}
+	}
+	return nil
+}
+
+// Sign returns the V3 payload of the ticket signed with the newest active key. issueID names
+// this issue of the QR code (order, transfer, resale), so each reissue differs.
+func (k *Keyring) Sign(ticketID, issueID, eventID string) (string, error) {
+	keys := k.active(false)
+	if len(keys) == 0 {
+		return "", errors.New("qrkeys: no active signing key")
+	}
+	return qrcode.GenerateSignedPayloadV3(keys[0].id, keys[0].private, ticketID, issueID, eventID), nil
+}
+
+// Verify checks a static payload: V3 with an active key, or V2/V1 with the legacy secret.
+// Returns the ticket ID when valid.
+func (k *Keyring) Verify(payload string) (ticketID string, ok bool) {
+	if _, isV3 := qrcode.ParseV3(payload); isV3 {
+		p, ok := qrcode.VerifySignedPayloadV3(payload, k.publicKey)
+		return p.TicketID, ok
+	}
+	if ticketID, _, _, ok := qrcode.VerifySignedPayloadV2(payload, k.legacySecret); ok {
+		return ticketID, true
+	}
+	return qrcode.VerifySignedPayload(payload, k.legacySecret)
+}
+
+func (k *Keyring) publicKey(id string) ed25519.PublicKey {
+	if c := k.find(id); c != nil {
+		return c.public
+	}
+	return nil
+}
+
+// PublicKeys returns the active verification keys, hex encoded by key ID, for offline scanners.
+func (k *Keyring) PublicKeys() map[string]string {
+	out := map[string]string{}
+	for _, c := range k.active(false) {
+		out[c.id] = hex.EncodeToString(c.public)
+	}
+	return out
+}
+
+// DynamicSecret is the per-ticket secret of the rotating QR code, derived from the ticket's static
+// payload with the key that signed it (the legacy secret for V1/V2 payloads).
+func (k *Keyring) DynamicSecret(staticPayload string) ([]byte, error) {
+	p, isV3 := qrcode.ParseV3(staticPayload)
+	if !isV3 {
+		return qrcode.DynamicSecret(staticPayload, k.legacySecret), nil
+	}
+	c := k.find(p.KeyID)
+	if c == nil {
+		return nil, fmt.Errorf("qrkeys: unknown or retired key %s", p.KeyID)
+	}
+	return qrcode.DynamicSecret(staticPayload, c.private.Seed()), nil
+}
+
+// Rotate creates a new key, which signs new payloads from then on; older keys keep verifying.
+func Rotate(db *sql.DB, cfg *config.Config) (string, error) {
+	seeds, err := seedCipher(cfg.QRKeysSecret)
+	if err != nil {
+		return "", err
+	}
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	id, err := qrcode.NewKeyID()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := sealSeed(seeds, id, priv.Seed())
+	if err != nil {
+		return "", err
+	}
+	if err := repository.CreateQRSigningKey(db, id, hex.EncodeToString(pub), sealed); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Reseal encrypts every stored seed, retired keys included, again with cfg.QRKeysSecret after
+// opening it with previousSecret, in one transaction; returns how many. Run it before starting the
+// servers with a new QR_KEYS_SECRET: a server still on the previous one cannot load the keys again.
+func Reseal(db *sql.DB, previousSecret string, cfg *config.Config) (int, error) {
+	previous, err := seedCipher(previousSecret)
+	if err != nil {
+		return 0, err
+	}
+	seeds, err := seedCipher(cfg.QRKeysSecret)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := repository.QRSigningKeys(db)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	for _, r := range rows {
+		seed, _, err := openSeed(previous, r.ID, r.PrivateKey)
+		if err != nil {
+			return 0, fmt.Errorf("qrkeys: key %s: %v", r.ID, err)
+		}
+		stored, err := sealSeed(seeds, r.ID, seed)
+		if err != nil {
+			return 0, err
+		}
+		if err := repository.SealQRSigningKey(tx, r.ID, r.PrivateKey, stored); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+// Retire stops accepting the key. Tickets signed with it are signed again with the newest other
+// active key first (same ticket, issue and event), so they stay valid with a new payload; returns
+// how many. Used tickets are signed again too: REENTRY, DAILY and MULTI tickets enter again, and a
+// reverted validation makes a ticket valid again. The last active key cannot be retired: rotate first.
+func (k *Keyring) Retire(id string) (resigned int, err error) {
+	if err := k.reload(); err != nil {
+		return 0, err
+	}
+	var signer *key
+	found := false
+	keys := k.active(false)
+	for i := range keys {
+		if keys[i].id == id {
+			found = true
+		} else if signer == nil {
+			signer = &keys[i]
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("qrkeys: key %s not found or already retired", id)
+	}
+	if signer == nil {
+		return 0, errors.New("qrkeys: cannot retire the only active key; rotate first")
+	}
+	tickets, err := repository.TicketsByQRKey(k.db, id)
+	if err != nil {
+		return 0, err
+	}
+	for _, t := range tickets {
+		p, ok := qrcode.ParseV3(t.QRCode)
+		if !ok {
+			continue
+		}
+		qr := qrcode.GenerateSignedPayloadV3(signer.id, signer.private, p.TicketID, p.IssueID, p.EventID)
+		ok, err := repository.ResignTicketQR(k.db, t.ID, t.QRCode, qr)
+		if err != nil {
+			return resigned, err
+		}
+		if ok {
+			resigned++
+		}
+	}
+	if _, err := repository.RetireQRSigningKey(k.db, id); err != nil {
+		return resigned, err
+	}
+	return resigned, k.reload()
+}
@@ -0,0 +1,151 @@
+package qrkeys
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"afterzin/api/internal/config"
+	"afterzin/api/internal/qrcode"
+	"afterzin/api/internal/repository"
+	"afterzin/api/internal/repository/repotest"
+)
+
+var testConfig = &config.Config{QRKeysSecret: "segredo-das-chaves", QRLegacySecret: "segredo-legado"}
+
+func newTestKeyring(t *testing.T) (*Keyring, *repotest.Fixture) {
+	t.Helper()
+	f := repotest.NewFixture(t)
+	k, err := New(f.DB, testConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k, f
+}
+
+// newTicket issues a ticket of the fixture's event with the QR payload qr(ticketID, orderID, eventID).
+func newTicket(t *testing.T, f *repotest.Fixture, qr func(ticketID, orderID, eventID string) string) (ticketID string) {
+	t.Helper()
+	userID := f.User(t)
+	orderID, itemID := f.PendingOrder(t, userID, 1)
+	ticketID = "ticket-1"
+	if err := repository.CreateTicketWithID(f.DB, ticketID, repository.GenerateTicketCode(), qr(ticketID, orderID, f.EventID), orderID, itemID, userID, f.EventID, f.DateID, f.TicketTypeID); err != nil {
+		t.Fatal(err)
+	}
+	return ticketID
+}
+
+func TestKeyringSignVerify(t *testing.T) {
+	k, _ := newTestKeyring(t)
+	payload, err := k.Sign("ticket-1", "order-1", "event-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, ok := k.Verify(payload); !ok || id != "ticket-1" {
+		t.Errorf("Verify = %q, %v", id, ok)
+	}
+	if _, ok := k.Verify(strings.Replace(payload, "ticket-1", "ticket-2", 1)); ok {
+		t.Error("tampered payload verified")
+	}
+	// Payloads signed before the keyring keep verifying with the legacy secret.
+	mac := hmac.New(sha256.New, []byte(testConfig.QRLegacySecret))
+	mac.Write([]byte("ticket-9:charge-1:event-1"))
+	legacy := "ticket-9:charge-1:event-1." + hex.EncodeToString(mac.Sum(nil))
+	if id, ok := k.Verify(legacy); !ok || id != "ticket-9" {
+		t.Errorf("Verify(V2) = %q, %v", id, ok)
+	}
+}
+
+func TestKeyringRotateAndRetire(t *testing.T) {
+	k, f := newTestKeyring(t)
+	oldKey := k.active(false)[0].id
+	var oldPayload string
+	ticketID := newTicket(t, f, func(ticketID, orderID, eventID string) string {
+		oldPayload, _ = k.Sign(ticketID, orderID, eventID)
+		return oldPayload
+	})
+
+	if _, err := k.Retire(oldKey); err == nil {
+		t.Fatal("retired the only active key")
+	}
+	newKey, err := Rotate(f.DB, testConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.reload(); err != nil {
+		t.Fatal(err)
+	}
+	// The newest key signs; payloads of the older key keep verifying.
+	if p, _ := k.Sign(ticketID, "order-2", "event-1"); !strings.HasPrefix(p, "V3:"+newKey+":") {
+		t.Errorf("new payload %q not signed with the new key %s", p, newKey)
+	}
+	if _, ok := k.Verify(oldPayload); !ok {
+		t.Error("payload of the older key no longer verifies after a rotation")
+	}
+
+	n, err := k.Retire(oldKey)
+	if err != nil || n != 1 {
+		t.Fatalf("Retire: %d tickets signed again, %v", n, err)
+	}
+	if _, ok := k.Verify(oldPayload); ok {
+		t.Error("payload of a retired key still verifies")
+	}
+	tk, err := repository.TicketByID(f.DB, ticketID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(tk.QRCode, "V3:"+newKey+":") {
+		t.Errorf("ticket QR %q not signed again with %s", tk.QRCode, newKey)
+	}
+	if id, ok := k.Verify(tk.QRCode); !ok || id != ticketID {
+		t.Errorf("Verify(signed again) = %q, %v", id, ok)
+	}
+	old, _ := qrcode.ParseV3(oldPayload)
+	if p, _ := qrcode.ParseV3(tk.QRCode); p.IssueID != old.IssueID || p.EventID != old.EventID {
+		t.Errorf("issue or event changed: %+v, was %+v", p, old)
+	}
+	if _, err := k.DynamicSecret(oldPayload); err == nil {
+		t.Error("dynamic secret derived from a retired key")
+	}
+}
+
+func TestKeyringSealsSeeds(t *testing.T) {
+	_, f := newTestKeyring(t)
+	keys, err := repository.QRSigningKeys(f.DB)
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("%d keys, %v", len(keys), err)
+	}
+	if !strings.HasPrefix(keys[0].PrivateKey, sealedPrefix) {
+		t.Errorf("private seed stored unencrypted: %q", keys[0].PrivateKey)
+	}
+	if _, err := New(f.DB, &config.Config{QRKeysSecret: "outro-segredo"}); err == nil {
+		t.Error("keyring loaded with a wrong QR_KEYS_SECRET")
+	}
+}
+
+func TestReseal(t *testing.T) {
+	k, f := newTestKeyring(t)
+	payload, err := k.Sign("ticket-1", "order-1", "event-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := &config.Config{QRKeysSecret: "novo-segredo", QRLegacySecret: testConfig.QRLegacySecret}
+	if _, err := Reseal(f.DB, "outro-segredo", next); err == nil {
+		t.Fatal("resealed with a wrong previous secret")
+	}
+	if n, err := Reseal(f.DB, testConfig.QRKeysSecret, next); err != nil || n != 1 {
+		t.Fatalf("Reseal: %d keys, %v", n, err)
+	}
+	if _, err := New(f.DB, testConfig); err == nil {
+		t.Error("keyring loaded with the previous secret after the reseal")
+	}
+	resealed, err := New(f.DB, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, ok := resealed.Verify(payload); !ok || id != "ticket-1" {
+		t.Errorf("Verify after the reseal = %q, %v", id, ok)
+	}
+}
@@ -0,0 +1,58 @@
+package qrkeys
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// sealedPrefix marks an encrypted private seed in qr_signing_keys.private_key; keys created before
+// encryption hold the plain hex seed and are sealed when the keyring loads them.
+const sealedPrefix = "gcm1:"
+
+// seedCipher is the AES-256-GCM cipher of the private seeds, keyed from Config.QRKeysSecret, so
+// a read of the database alone cannot sign QR codes.
+func seedCipher(secret string) (cipher.AEAD, error) {
+	if secret == "" {
+		return nil, errors.New("qrkeys: QR_KEYS_SECRET is required (keys sealed before it was required use the JWT_SECRET value)")
+	}
+	key := sha256.Sum256([]byte("afterzin qr signing keys\x00" + secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealSeed encrypts the seed of key id; the key ID is bound as additional data, so a sealed seed
+// cannot be moved to another key.
+func sealSeed(aead cipher.AEAD, id string, seed []byte) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return sealedPrefix + base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, seed, []byte(id))), nil
+}
+
+// openSeed decrypts the stored seed of key id. sealed is false for a plain hex seed stored before
+// encryption.
+func openSeed(aead cipher.AEAD, id, stored string) (seed []byte, sealed bool, err error) {
+	if !strings.HasPrefix(stored, sealedPrefix) {
+		seed, err = hex.DecodeString(stored)
+		return seed, false, err
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(stored, sealedPrefix))
+	if err != nil || len(raw) < aead.NonceSize() {
+		return nil, true, errors.New("malformed sealed seed")
+	}
+	seed, err = aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return nil, true, errors.New("cannot decrypt the seed (wrong QR_KEYS_SECRET?)")
+	}
+	return seed, true, nil
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// ---------- Admin audit log ----------
+
+type AdminAuditLogRow struct {
+	ID          string
+	AdminUserID string
+	Action      string
+	TargetType  string
+	TargetID    sql.NullString
+	Details     sql.NullString
+	CreatedAt   string
+}
+
+// InsertAdminAuditLog records an action performed by an ADMIN user.
+func InsertAdminAuditLog(db *sql.DB, adminUserID, action, targetType, targetID, details string) error {
+	id := uuid.New().String()
+	var tid, det sql.NullString
+	if targetID != "" {
+		tid = sql.NullString{String: targetID, Valid: true}
+	}
+	if details != "" {
+		det = sql.NullString{String: details, Valid: true}
+	}
+	_, err := db.Exec(`INSERT INTO admin_audit_log (id, admin_user_id, action, target_type, target_id, details) VALUES (?, ?, ?, ?, ?, ?)`,
+		id, adminUserID, action, targetType, tid, det,
+	)
+	return err
+}
+
+// AdminAuditLogFilter narrows the audit log. Search matches the action and the details;
+// TargetID and AdminID are exact.
+type AdminAuditLogFilter struct {
+	Search   string
+	TargetID string
+	AdminID  string
+}
+
+// ListAdminAuditLog returns audit entries matching the filter, most recent first.
+func ListAdminAuditLog(db *sql.DB, f AdminAuditLogFilter, limit, offset int) ([]*AdminAuditLogRow, error) {
+	q := `SELECT id, admin_user_id, action, target_type, target_id, details, created_at FROM admin_audit_log WHERE 1 = 1`
+	args := []interface{}{}
+	if f.Search != "" {
+		like := "%" + f.Search + "%"
+		q += ` AND (action LIKE ? OR details LIKE ?)`
+		args = append(args, like, like)
+	}
+	if f.TargetID != "" {
+		q += ` AND target_id = ?`
+		args = append(args, f.TargetID)
+	}
+	if f.AdminID != "" {
+		q += ` AND admin_user_id = ?`
+		args = append(args, f.AdminID)
+	}
+	q += ` ORDER BY created_at DESC LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*AdminAuditLogRow
+	for rows.Next() {
+		var a AdminAuditLogRow
+		if err := rows.Scan(&a.ID, &a.AdminUserID, &a.Action, &a.TargetType, &a.TargetID, &a.Details, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, &a)
+	}
+	return list, rows.Err()
+}
+
+// ---------- Events ----------
+
+// SearchEventIDs returns event IDs matching title/location and status, for the admin back office.
+func SearchEventIDs(db *sql.DB, search, status string, limit, offset int) ([]string, error) {
+	q := `SELECT id FROM events WHERE 1 = 1`
+	args := []interface{}{}
+	if search != "" {
+		like := "%" + search + "%"
+		q += ` AND (title LIKE ? OR location LIKE ? OR id = ?)`
+		args = append(args, like, like, search)
+	}
+	if status != "" {
+		q += ` AND status = ?`
+		args = append(args, status)
+	}
+	q += ` ORDER BY created_at DESC LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// TakeDownEvent sets the event status to REMOVED and records the reason.
+func TakeDownEvent(db *sql.DB, eventID, reason string) error {
+	_, err := db.Exec(`UPDATE events SET status = 'REMOVED', removed_reason = ?, updated_at = datetime('now') WHERE id = ?`, reason, eventID)
+	return err
+}
+
+// ---------- Orders ----------
+
+type OrderRow struct {
+	ID              string
+	UserID          string
+	Status          string
+	Total           float64
+	CreatedAt       string
+	PagarmeOrderID  sql.NullString
+	PagarmeChargeID sql.NullString
+}
+
+// SearchOrders returns orders matching ID, Pagar.me IDs or buyer email, optionally filtered by status.
+func SearchOrders(db *sql.DB, search, status string, limit, offset int) ([]*OrderRow, error) {
+	q := `SELECT o.id, o.user_id, o.status, o.total, o.created_at, o.pagarme_order_id, o.pagarme_charge_id
+		FROM orders o JOIN users u ON u.id = o.user_id WHERE 1 = 1`
+	args := []interface{}{}
+	if search != "" {
+		q += ` AND (o.id = ? OR o.pagarme_order_id = ? OR o.pagarme_charge_id = ? OR u.email LIKE ?)`
+		args = append(args, search, search, search, "%"+search+"%")
+	}
+	if status != "" {
+		q += ` AND o.status = ?`
+		args = append(args, status)
+	}
+	q += ` ORDER BY o.created_at DESC LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*OrderRow
+	for rows.Next() {
+		var o OrderRow
+		if err := rows.Scan(&o.ID, &o.UserID, &o.Status, &o.Total, &o.CreatedAt, &o.PagarmeOrderID, &o.PagarmeChargeID); err != nil {
+			return nil, err
+		}
+		list = append(list, &o)
+	}
+	return list, rows.Err()
+}
+
+// ---------- Tickets ----------
+
+// SearchTickets returns tickets matching the ticket code/ID, order ID or owner email.
+func SearchTickets(db *sql.DB, search string, limit, offset int) ([]*TicketRow, error) {
+	q := `SELECT t.id, t.code, t.qr_code, t.order_id, t.order_item_id, t.user_id, t.event_id, t.event_date_id, t.ticket_type_id, t.used, t.used_at, t.created_at, t.checkin_state, t.entry_count
+		FROM tickets t JOIN users u ON u.id = t.user_id`
+	args := []interface{}{}
+	if search != "" {
+		q += ` WHERE t.id = ? OR t.code = ? OR t.order_id = ? OR u.email LIKE ?`
+		args = append(args, search, search, search, "%"+search+"%")
+	}
+	q += ` ORDER BY t.created_at DESC LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*TicketRow
+	for rows.Next() {
+		t, err := scanTicketRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+// ---------- Webhook / payment logs ----------
+
+type WebhookEventRow struct {
+	ID           string
+	Provider     string
+	ExternalID   string
+	EventType    string
+	Processed    int
+	ErrorMessage sql.NullString
+	CreatedAt    string
+}
+
+// ListWebhookEvents returns received payment webhooks (Pagar.me and legacy Stripe), most recent first.
+func ListWebhookEvents(db *sql.DB, search string, limit, offset int) ([]*WebhookEventRow, error) {
+	q := `SELECT id, provider, external_id, event_type, processed, error_message, created_at FROM (
+		SELECT id, 'PAGARME' AS provider, pagarme_event_id AS external_id, event_type, processed, error_message, created_at FROM pagarme_webhook_events
+		UNION ALL
+		SELECT id, 'STRIPE' AS provider, stripe_event_id AS external_id, event_type, processed, error_message, created_at FROM stripe_webhook_events
+	)`
+	args := []interface{}{}
+	if search != "" {
+		q += ` WHERE external_id = ? OR event_type LIKE ?`
+		args = append(args, search, "%"+search+"%")
+	}
+	q += ` ORDER BY created_at DESC LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*WebhookEventRow
+	for rows.Next() {
+		var w WebhookEventRow
+		if err := rows.Scan(&w.ID, &w.Provider, &w.ExternalID, &w.EventType, &w.Processed, &w.ErrorMessage, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, &w)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// EventAreaRow is a sector of the event (pista, camarote, VIP...) that ticket types give access to.
+type EventAreaRow struct {
+	ID        string
+	EventID   string
+	Name      string
+	Capacity  sql.NullInt64 // safety limit of people inside; NULL = no limit
+	CreatedAt string
+}
+
+const eventAreaColumns = `id, event_id, name, capacity, created_at`
+
+func scanEventAreaRow(row interface {
+	Scan(dest ...interface{}) error
+}) (*EventAreaRow, error) {
+	var a EventAreaRow
+	if err := row.Scan(&a.ID, &a.EventID, &a.Name, &a.Capacity, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func CreateEventArea(db *sql.DB, eventID, name string, capacity sql.NullInt64) (string, error) {
+	id := uuid.New().String()
+	_, err := db.Exec(`INSERT INTO event_areas (id, event_id, name, capacity) VALUES (?, ?, ?, ?)`, id, eventID, name, capacity)
+	return id, err
+}
+
+func UpdateEventArea(db *sql.DB, id, name string, capacity sql.NullInt64) error {
+	_, err := db.Exec(`UPDATE event_areas SET name = ?, capacity = ? WHERE id = ?`, name, capacity, id)
+	return err
+}
+
+// DeleteEventArea removes the area and its links to gates. Returns false when a ticket type still
+// gives access to it.
+func DeleteEventArea(db *sql.DB, id string) (bool, error) {
+	res, err := db.Exec(`DELETE FROM event_areas WHERE id = ? AND NOT EXISTS (SELECT 1 FROM ticket_types WHERE area_id = ?)`, id, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+func EventAreaByID(db *sql.DB, id string) (*EventAreaRow, error) {
+	a, err := scanEventAreaRow(db.QueryRow(`SELECT `+eventAreaColumns+` FROM event_areas WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return a, err
+}
+
+// EventAreasByEvent lists the event's areas by name.
+func EventAreasByEvent(db *sql.DB, eventID string) ([]*EventAreaRow, error) {
+	rows, err := db.Query(`SELECT `+eventAreaColumns+` FROM event_areas WHERE event_id = ? ORDER BY name COLLATE NOCASE`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*EventAreaRow
+	for rows.Next() {
+		a, err := scanEventAreaRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// EventHasAreas tells whether the event defines areas, so that entries must name a gate.
+func EventHasAreas(db *sql.DB, eventID string) (bool, error) {
+	var ok bool
+	err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM event_areas WHERE event_id = ?)`, eventID).Scan(&ok)
+	return ok, err
+}
+
+// SetTicketTypeArea sets the area the ticket type gives access to ("" for none).
+func SetTicketTypeArea(db *sql.DB, ticketTypeID, areaID string) error {
+	_, err := db.Exec(`UPDATE ticket_types SET area_id = ? WHERE id = ?`, nullIfEmpty(areaID), ticketTypeID)
+	return err
+}
+
+// EventGateRow is an entrance of an event date. A gate without areas admits every ticket.
+type EventGateRow struct {
+	ID          string
+	EventDateID string
+	Name        string
+	CreatedAt   string
+	AreaIDs     []string
+}
+
+// CreateEventGate creates the gate admitting the given areas.
+func CreateEventGate(db *sql.DB, eventDateID, name string, areaIDs []string) (string, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+	id := uuid.New().String()
+	if _, err := tx.Exec(`INSERT INTO event_gates (id, event_date_id, name) VALUES (?, ?, ?)`, id, eventDateID, name); err != nil {
+		return "", err
+	}
+	if err := setGateAreas(tx, id, areaIDs); err != nil {
+		return "", err
+	}
+	return id, tx.Commit()
+}
+
+// UpdateEventGate renames the gate and replaces the areas it admits.
+func UpdateEventGate(db *sql.DB, id, name string, areaIDs []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`UPDATE event_gates SET name = ? WHERE id = ?`, name, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM event_gate_areas WHERE gate_id = ?`, id); err != nil {
+		return err
+	}
+	if err := setGateAreas(tx, id, areaIDs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func setGateAreas(tx *sql.Tx, gateID string, areaIDs []string) error {
+	for _, areaID := range areaIDs {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO event_gate_areas (gate_id, area_id) VALUES (?, ?)`, gateID, areaID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteEventGate removes the gate; validations made at it keep the gate's name.
+func DeleteEventGate(db *sql.DB, id string) error {
+	_, err := db.Exec(`DELETE FROM event_gates WHERE id = ?`, id)
+	return err
+}
+
+func EventGateByID(db *sql.DB, id string) (*EventGateRow, error) {
+	var g EventGateRow
+	err := db.QueryRow(`SELECT id, event_date_id, name, created_at FROM event_gates WHERE id = ?`, id).Scan(&g.ID, &g.EventDateID, &g.Name, &g.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if g.AreaIDs, err = gateAreaIDs(db, g.ID); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// EventGatesByEventDate lists the gates of the event date by name.
+func EventGatesByEventDate(db *sql.DB, eventDateID string) ([]*EventGateRow, error) {
+	rows, err := db.Query(`SELECT id, event_date_id, name, created_at FROM event_gates WHERE event_date_id = ? ORDER BY name COLLATE NOCASE`, eventDateID)
+	if err != nil {
+		return nil, err
+	}
+	var list []*EventGateRow
+	for rows.Next() {
+		var g EventGateRow
+		if err := rows.Scan(&g.ID, &g.EventDateID, &g.Name, &g.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		list = append(list, &g)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, g := range list {
+		if g.AreaIDs, err = gateAreaIDs(db, g.ID); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+func gateAreaIDs(db *sql.DB, gateID string) ([]string, error) {
+	rows, err := db.Query(`SELECT ga.area_id FROM event_gate_areas ga JOIN event_areas a ON a.id = ga.area_id
+		WHERE ga.gate_id = ? ORDER BY a.name COLLATE NOCASE`, gateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// AreaCheckins is the door count of one area of an event date ("" for ticket types without area).
+type AreaCheckins struct {
+	AreaID string
+	Inside int
+	Total  int
+}
+
+// CheckinsByArea counts, per area, the tickets of paid orders for the event date and how many
+// holders are inside now (last reading an entry), from the tickets' door state.
+func CheckinsByArea(db *sql.DB, eventDateID string) ([]*AreaCheckins, error) {
+	rows, err := db.Query(`SELECT COALESCE(tt.area_id, ''), COALESCE(SUM(t.checkin_state = 'IN'), 0), COUNT(*)
+		FROM tickets t JOIN orders o ON o.id = t.order_id JOIN ticket_types tt ON tt.id = t.ticket_type_id
+		WHERE t.event_date_id = ? AND o.status = 'PAID' GROUP BY 1 ORDER BY 1`, eventDateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*AreaCheckins
+	for rows.Next() {
+		var c AreaCheckins
+		if err := rows.Scan(&c.AreaID, &c.Inside, &c.Total); err != nil {
+			return nil, err
+		}
+		list = append(list, &c)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"afterzin/api/internal/taxid"
+)
+
+// AttendeeRow is a ticket of paid orders of an event date with who goes in with it.
+type AttendeeRow struct {
+	TicketID       string
+	Code           string
+	TicketTypeID   string
+	TicketTypeName string
+	// Name and CPF of who goes in: the holder of nominal tickets, else the courtesy guest, else
+	// the ticket's owner. CPF is unmasked, as stored.
+	Name      string
+	CPF       string
+	BuyerName sql.NullString // NULL for courtesies
+	Holder    sql.NullString
+	Courtesy  bool
+	// Ticket issue time (payment confirmed), UTC.
+	PurchasedAt  string
+	Used         int
+	UsedAt       sql.NullString
+	CheckinState string
+}
+
+// AttendeeFilter narrows the attendee list. Search matches the attendee, buyer or owner name,
+// the owner's email, the ticket code and, when it has 11 digits, the CPF.
+type AttendeeFilter struct {
+	Search       string
+	TicketTypeID string
+	CheckedIn    *bool // used (entered at least once) or not
+}
+
+const attendeeFrom = ` FROM tickets t
+	JOIN orders o ON o.id = t.order_id
+	JOIN ticket_types tt ON tt.id = t.ticket_type_id
+	JOIN users owner ON owner.id = t.user_id
+	JOIN users buyer ON buyer.id = o.user_id
+	LEFT JOIN courtesy_tickets ct ON ct.ticket_id = t.id`
+
+// EventAttendees lists the attendees of the event date by name, with the total matching the
+// filter; limit 0 lists them all (exports).
+func EventAttendees(db *sql.DB, eventDateID string, f AttendeeFilter, limit, offset int) ([]*AttendeeRow, int, error) {
+	where := ` WHERE t.event_date_id = ? AND o.status = 'PAID'`
+	args := []interface{}{eventDateID}
+	if f.TicketTypeID != "" {
+		where += ` AND t.ticket_type_id = ?`
+		args = append(args, f.TicketTypeID)
+	}
+	if f.CheckedIn != nil {
+		if *f.CheckedIn {
+			where += ` AND t.used = 1`
+		} else {
+			where += ` AND t.used = 0`
+		}
+	}
+	if search := strings.TrimSpace(f.Search); search != "" {
+		like := "%" + search + "%"
+		where += ` AND (COALESCE(t.holder_name, ct.recipient_name, owner.name) LIKE ? OR buyer.name LIKE ? OR owner.name LIKE ?
+			OR owner.email LIKE ? OR t.code = ? COLLATE NOCASE`
+		args = append(args, like, like, like, like, search)
+		if cpf := taxid.Digits(search); len(cpf) == 11 {
+			where += ` OR REPLACE(REPLACE(COALESCE(t.holder_cpf, ct.recipient_cpf, owner.cpf), '.', ''), '-', '') = ?`
+			args = append(args, cpf)
+		}
+		where += `)`
+	}
+	var total int
+	if err := db.QueryRow(`SELECT COUNT(*)`+attendeeFrom+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	q := `SELECT t.id, t.code, t.ticket_type_id, tt.name, COALESCE(t.holder_name, ct.recipient_name, owner.name),
+		COALESCE(t.holder_cpf, ct.recipient_cpf, owner.cpf, ''), CASE WHEN o.kind = 'COURTESY' THEN NULL ELSE buyer.name END,
+		t.holder_name, o.kind = 'COURTESY', t.created_at, t.used, t.used_at, t.checkin_state` + attendeeFrom + where +
+		` ORDER BY COALESCE(t.holder_name, ct.recipient_name, owner.name) COLLATE NOCASE, t.code`
+	if limit > 0 {
+		q += ` LIMIT ? OFFSET ?`
+		args = append(args, limit, offset)
+	}
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	var list []*AttendeeRow
+	for rows.Next() {
+		var a AttendeeRow
+		if err := rows.Scan(&a.TicketID, &a.Code, &a.TicketTypeID, &a.TicketTypeName, &a.Name, &a.CPF, &a.BuyerName,
+			&a.Holder, &a.Courtesy, &a.PurchasedAt, &a.Used, &a.UsedAt, &a.CheckinState); err != nil {
+			return nil, 0, err
+		}
+		list = append(list, &a)
+	}
+	return list, total, rows.Err()
+}
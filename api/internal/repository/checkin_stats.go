@@ -0,0 +1,76 @@
+package repository
+
+import "database/sql"
+
+// TicketTypeCheckins is the door count of one ticket type of an event date.
+type TicketTypeCheckins struct {
+	TicketTypeID string
+	Validated    int
+	Total        int
+}
+
+// CheckinsByTicketType counts, per ticket type, the tickets of paid orders for the event date
+// and how many were already validated.
+func CheckinsByTicketType(db *sql.DB, eventDateID string) ([]*TicketTypeCheckins, error) {
+	rows, err := db.Query(`SELECT t.ticket_type_id, COALESCE(SUM(t.used), 0), COUNT(*) FROM tickets t JOIN orders o ON o.id = t.order_id
+		WHERE t.event_date_id = ? AND o.status = 'PAID' GROUP BY t.ticket_type_id ORDER BY t.ticket_type_id`, eventDateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*TicketTypeCheckins
+	for rows.Next() {
+		var c TicketTypeCheckins
+		if err := rows.Scan(&c.TicketTypeID, &c.Validated, &c.Total); err != nil {
+			return nil, err
+		}
+		list = append(list, &c)
+	}
+	return list, rows.Err()
+}
+
+// CheckinsByGate counts the entries of the event date per gate ("" for entries without a gate).
+func CheckinsByGate(db *sql.DB, eventDateID string) (map[string]int, error) {
+	rows, err := db.Query(`SELECT COALESCE(tv.gate, ''), COUNT(*) FROM ticket_validations tv JOIN tickets t ON t.id = tv.ticket_id
+		WHERE t.event_date_id = ? AND tv.direction = 'IN' AND tv.reverted_at IS NULL GROUP BY 1`, eventDateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]int{}
+	for rows.Next() {
+		var gate string
+		var n int
+		if err := rows.Scan(&gate, &n); err != nil {
+			return nil, err
+		}
+		out[gate] = n
+	}
+	return out, rows.Err()
+}
+
+// MinuteCheckins is the number of entries in one minute ("2006-01-02 15:04", UTC).
+type MinuteCheckins struct {
+	Minute    string
+	Validated int
+}
+
+// CheckinsPerMinute counts the entries of the event date per minute since the given UTC
+// time ("2006-01-02 15:04:05"), oldest first; minutes without validations are omitted.
+func CheckinsPerMinute(db *sql.DB, eventDateID, since string) ([]*MinuteCheckins, error) {
+	rows, err := db.Query(`SELECT substr(tv.validated_at, 1, 16), COUNT(*) FROM ticket_validations tv JOIN tickets t ON t.id = tv.ticket_id
+		WHERE t.event_date_id = ? AND tv.direction = 'IN' AND tv.reverted_at IS NULL AND tv.validated_at >= ? GROUP BY 1 ORDER BY 1`, eventDateID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*MinuteCheckins
+	for rows.Next() {
+		var m MinuteCheckins
+		if err := rows.Scan(&m.Minute, &m.Validated); err != nil {
+			return nil, err
+		}
+		list = append(list, &m)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Order kinds.
+const (
+	OrderSale     = "SALE"
+	OrderCourtesy = "COURTESY"
+)
+
+type CourtesyTicketRow struct {
+	TicketID       string
+	EventID        string
+	IssuedBy       string
+	RecipientName  string
+	RecipientEmail sql.NullString
+	RecipientCPF   sql.NullString
+	Category       sql.NullString
+	GuestList      bool
+	CreatedAt      string
+}
+
+const courtesyTicketColumns = `ticket_id, event_id, issued_by, recipient_name, recipient_email, recipient_cpf, category, guest_list, created_at`
+
+func scanCourtesyTicketRow(row interface {
+	Scan(dest ...interface{}) error
+}) (*CourtesyTicketRow, error) {
+	var c CourtesyTicketRow
+	err := row.Scan(&c.TicketID, &c.EventID, &c.IssuedBy, &c.RecipientName, &c.RecipientEmail, &c.RecipientCPF, &c.Category, &c.GuestList, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// CreateCourtesyOrder creates the zero-value, already paid order of a courtesy ticket, with the
+// given ID (the QR code is signed for it before the order exists).
+func CreateCourtesyOrder(db Querier, id, userID string) error {
+	expAt := time.Now().UTC().Format(time.RFC3339)
+	_, err := db.Exec(`INSERT INTO orders (id, user_id, status, total, expires_at, kind, paid_at) VALUES (?, ?, 'PAID', 0, ?, 'COURTESY', datetime('now'))`, id, userID, expAt)
+	return err
+}
+
+// CreateCourtesyTicket records the recipient of an issued courtesy ticket.
+func CreateCourtesyTicket(db Querier, c CourtesyTicketRow) error {
+	_, err := db.Exec(`INSERT INTO courtesy_tickets (ticket_id, event_id, issued_by, recipient_name, recipient_email, recipient_cpf, category, guest_list) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.TicketID, c.EventID, c.IssuedBy, c.RecipientName, c.RecipientEmail, c.RecipientCPF, c.Category, c.GuestList,
+	)
+	return err
+}
+
+// CourtesyTicketByTicketID returns the courtesy record of a ticket, or nil for purchased tickets.
+func CourtesyTicketByTicketID(db *sql.DB, ticketID string) (*CourtesyTicketRow, error) {
+	c, err := scanCourtesyTicketRow(db.QueryRow(`SELECT `+courtesyTicketColumns+` FROM courtesy_tickets WHERE ticket_id = ?`, ticketID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+// CourtesyTicketsByEvent lists the event's courtesy tickets by recipient name. search matches
+// the recipient name or email (partial) or CPF (digits); guestListOnly keeps the guest list.
+func CourtesyTicketsByEvent(db *sql.DB, eventID, search string, guestListOnly bool) ([]*CourtesyTicketRow, error) {
+	q := `SELECT ` + courtesyTicketColumns + ` FROM courtesy_tickets WHERE event_id = ?`
+	args := []interface{}{eventID}
+	if search != "" {
+		like := "%" + search + "%"
+		q += ` AND (recipient_name LIKE ? OR recipient_email LIKE ? OR recipient_cpf = ?)`
+		args = append(args, like, like, search)
+	}
+	if guestListOnly {
+		q += ` AND guest_list = 1`
+	}
+	q += ` ORDER BY recipient_name`
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*CourtesyTicketRow
+	for rows.Next() {
+		c, err := scanCourtesyTicketRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+// CountCourtesyTickets counts the courtesy tickets issued for the event.
+func CountCourtesyTickets(db Querier, eventID string) (int, error) {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM courtesy_tickets WHERE event_id = ?`, eventID).Scan(&n)
+	return n, err
+}
+
+// UpdateEventCourtesyQuota changes how many courtesy tickets the producer can issue for the event.
+func UpdateEventCourtesyQuota(db *sql.DB, eventID string, quota int) error {
+	_, err := db.Exec(`UPDATE events SET courtesy_quota = ?, updated_at = datetime('now') WHERE id = ?`, quota, eventID)
+	return err
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Email outbox statuses.
+const (
+	EmailPending = "PENDING"
+	EmailSent    = "SENT"
+	EmailFailed  = "FAILED"
+)
+
+// RedactedEmailBody replaces the body of emails with single-use links once they leave the outbox.
+const RedactedEmailBody = "[conteúdo removido após o envio: link de uso único]"
+
+type EmailOutboxRow struct {
+	ID          string
+	Kind        string
+	DedupeKey   sql.NullString
+	ToAddress   string
+	Subject     string
+	TextBody    string
+	HTMLBody    sql.NullString
+	Attachments sql.NullString
+	Attempts    int
+}
+
+// EnqueueEmail stores a rendered email for delivery by the outbox worker. When dedupeKey is
+// set and an email with the same key already exists, nothing is inserted and false is returned.
+func EnqueueEmail(db *sql.DB, kind, dedupeKey, to, subject, textBody, htmlBody, attachments string) (bool, error) {
+	nullable := func(s string) sql.NullString { return sql.NullString{String: s, Valid: s != ""} }
+	res, err := db.Exec(`INSERT OR IGNORE INTO email_outbox (id, kind, dedupe_key, to_address, subject, text_body, html_body, attachments) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		uuid.New().String(), kind, nullable(dedupeKey), to, subject, textBody, nullable(htmlBody), nullable(attachments),
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// EmailDedupeKeyExists reports whether an email with the given dedupe key was already enqueued.
+func EmailDedupeKeyExists(db *sql.DB, dedupeKey string) bool {
+	var n int
+	db.QueryRow(`SELECT COUNT(*) FROM email_outbox WHERE dedupe_key = ?`, dedupeKey).Scan(&n)
+	return n > 0
+}
+
+// DueEmails returns pending emails whose next attempt is due, oldest first.
+func DueEmails(db *sql.DB, limit int) ([]*EmailOutboxRow, error) {
+	rows, err := db.Query(`SELECT id, kind, dedupe_key, to_address, subject, text_body, html_body, attachments, attempts FROM email_outbox
+		WHERE status = 'PENDING' AND next_attempt_at <= datetime('now') ORDER BY created_at LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*EmailOutboxRow
+	for rows.Next() {
+		var e EmailOutboxRow
+		if err := rows.Scan(&e.ID, &e.Kind, &e.DedupeKey, &e.ToAddress, &e.Subject, &e.TextBody, &e.HTMLBody, &e.Attachments, &e.Attempts); err != nil {
+			return nil, err
+		}
+		list = append(list, &e)
+	}
+	return list, rows.Err()
+}
+
+// MarkEmailSent records a successful delivery.
+func MarkEmailSent(db *sql.DB, id string) error {
+	_, err := db.Exec(`UPDATE email_outbox SET status = 'SENT', attempts = attempts + 1, last_error = NULL, sent_at = datetime('now') WHERE id = ?`, id)
+	return err
+}
+
+// MarkEmailAttemptFailed records a failed delivery. The email is retried at nextAttempt,
+// or marked FAILED when giveUp is set.
+func MarkEmailAttemptFailed(db *sql.DB, id, lastError string, nextAttempt time.Time, giveUp bool) error {
+	status := EmailPending
+	if giveUp {
+		status = EmailFailed
+	}
+	_, err := db.Exec(`UPDATE email_outbox SET status = ?, attempts = attempts + 1, last_error = ?, next_attempt_at = ? WHERE id = ?`,
+		status, lastError, nextAttempt.UTC().Format("2006-01-02 15:04:05"), id,
+	)
+	return err
+}
+
+// RedactEmail replaces the body of a delivered (or abandoned) email, for messages whose links carry
+// single-use tokens that must not stay readable in the database.
+func RedactEmail(db *sql.DB, id string) error {
+	_, err := db.Exec(`UPDATE email_outbox SET text_body = ?, html_body = NULL WHERE id = ?`, RedactedEmailBody, id)
+	return err
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Entry policies of ticket types.
+const (
+	EntrySingle  = "SINGLE"
+	EntryReentry = "REENTRY"
+	EntryDaily   = "DAILY"
+	EntryMulti   = "MULTI"
+)
+
+// EntryTerms tells the ticket holder how the QR code can be used under the entry policy (PDF
+// and wallet passes).
+func EntryTerms(policy string, maxEntries sql.NullInt64) string {
+	switch policy {
+	case EntryReentry:
+		return "O QR Code vale para sair e voltar durante o evento: registre a saída na portaria."
+	case EntryDaily:
+		return "O QR Code vale para uma entrada por dia do evento."
+	case EntryMulti:
+		return fmt.Sprintf("O QR Code vale para até %d entradas.", maxEntries.Int64)
+	}
+	return "Cada QR Code é válido para uma única entrada."
+}
+
+// EntriesExhausted tells whether a ticket that entered entryCount times can never enter again
+// under the policy (REENTRY and DAILY tickets stay valid after entering).
+func EntriesExhausted(policy string, maxEntries sql.NullInt64, entryCount int) bool {
+	switch policy {
+	case EntryReentry, EntryDaily:
+		return false
+	case EntryMulti:
+		return int64(entryCount) >= maxEntries.Int64
+	}
+	return entryCount > 0
+}
+
+// Check-in directions (ticket_validations.direction) and ticket states (tickets.checkin_state).
+const (
+	CheckinIn  = "IN"
+	CheckinOut = "OUT"
+)
+
+// TicketCheckin is the door state of a ticket.
+type TicketCheckin struct {
+	State         string
+	EntryCount    int
+	LastEntryDate sql.NullString // "2006-01-02", São Paulo time
+}
+
+func TicketCheckinByID(db *sql.DB, ticketID string) (*TicketCheckin, error) {
+	var c TicketCheckin
+	err := db.QueryRow(`SELECT checkin_state, entry_count, last_entry_date FROM tickets WHERE id = ?`, ticketID).Scan(&c.State, &c.EntryCount, &c.LastEntryDate)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// EntryDate is the São Paulo calendar day of t, which DAILY tickets are limited to one entry per.
+func EntryDate(t time.Time) string {
+	return t.In(EventLocation).Format("2006-01-02")
+}
+
+// storedTimeLayout is the format of the UTC timestamps stored by datetime('now').
+const storedTimeLayout = "2006-01-02 15:04:05"
+
+// storedEntryDate is the EntryDate of a stored UTC timestamp; "" when it cannot be parsed.
+func storedEntryDate(stored string) string {
+	t, err := time.Parse(storedTimeLayout, stored)
+	if err != nil {
+		return ""
+	}
+	return EntryDate(t)
+}
+
+// entryDayBounds returns the stored UTC timestamps the São Paulo day (YYYY-MM-DD) starts and ends
+// at, so queries select a day's validations as EntryDate does (start <= validated_at < end).
+// Both are "" for an empty or invalid day.
+func entryDayBounds(day string) (start, end string) {
+	d, err := time.ParseInLocation("2006-01-02", day, EventLocation)
+	if err != nil {
+		return "", ""
+	}
+	return d.UTC().Format(storedTimeLayout), d.AddDate(0, 0, 1).UTC().Format(storedTimeLayout)
+}
+
+// EnterTicket records an entry if the ticket type's policy allows it, atomically so that two
+// concurrent scans cannot both succeed:
+//   - SINGLE: only the first entry;
+//   - REENTRY: any number of entries, each after an exit (the attendee must be outside);
+//   - DAILY: one entry per day (today, in São Paulo time);
+//   - MULTI: up to maxEntries entries.
+//
+// The first entry also marks the ticket as used. Returns false when the entry is refused; the
+// caller reads the ticket's state to tell why.
+func EnterTicket(db Querier, ticketID, policy string, maxEntries sql.NullInt64, today string) (bool, error) {
+	cond := `entry_count = 0`
+	args := []interface{}{today, ticketID}
+	switch policy {
+	case EntryReentry:
+		cond = `checkin_state = 'OUT'`
+	case EntryDaily:
+		cond = `(last_entry_date IS NULL OR last_entry_date != ?)`
+		args = append(args, today)
+	case EntryMulti:
+		cond = `entry_count < ?`
+		args = append(args, maxEntries.Int64)
+	}
+	res, err := db.Exec(`UPDATE tickets SET checkin_state = 'IN', entry_count = entry_count + 1, last_entry_date = ?,
+		used = 1, used_at = COALESCE(used_at, datetime('now')) WHERE id = ? AND `+cond, args...)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// ExitTicket records the exit of an attendee who is inside. Returns false otherwise. The exit
+// of a SINGLE ticket is final: EnterTicket does not let it in again.
+func ExitTicket(db Querier, ticketID string) (bool, error) {
+	res, err := db.Exec(`UPDATE tickets SET checkin_state = 'OUT' WHERE id = ? AND checkin_state = 'IN'`, ticketID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// AdmitTicket records an entry (EnterTicket) or exit (ExitTicket) of the ticket as read at the
+// door and its validation v (InsertTicketValidation, v.ID is set) in one transaction, so every
+// change of the door state has its validation row, which RevertTicketValidation recomputes the
+// state from. Returns false, recording nothing, when the policy refuses the entry or the attendee
+// is not inside for an exit.
+func AdmitTicket(db *sql.DB, v *TicketValidationRow, policy string, maxEntries sql.NullInt64, today string) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	var ok bool
+	if v.Direction == CheckinOut {
+		ok, err = ExitTicket(tx, v.TicketID)
+	} else {
+		ok, err = EnterTicket(tx, v.TicketID, policy, maxEntries, today)
+	}
+	if err != nil || !ok {
+		return false, err
+	}
+	if _, err := InsertTicketValidation(tx, v); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// UpdateTicketTypeEntryPolicy sets how tickets of the type can enter; maxEntries only for MULTI.
+func UpdateTicketTypeEntryPolicy(db *sql.DB, ticketTypeID, policy string, maxEntries sql.NullInt64) error {
+	_, err := db.Exec(`UPDATE ticket_types SET entry_policy = ?, max_entries = ? WHERE id = ?`, policy, maxEntries, ticketTypeID)
+	return err
+}
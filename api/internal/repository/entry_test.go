@@ -0,0 +1,125 @@
+package repository_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"afterzin/api/internal/repository"
+)
+
+func TestEnterTicket(t *testing.T) {
+	const day1, day2 = "2026-01-10", "2026-01-11"
+	multi := sql.NullInt64{Int64: 2, Valid: true}
+	// Each step is an entry (in) or an exit (out) on a day, and whether it is accepted.
+	type step struct {
+		in   bool
+		day  string
+		want bool
+	}
+	cases := []struct {
+		policy string
+		max    sql.NullInt64
+		steps  []step
+	}{
+		{repository.EntrySingle, sql.NullInt64{}, []step{{true, day1, true}, {true, day1, false}, {false, day1, true}, {true, day1, false}}},
+		{repository.EntryReentry, sql.NullInt64{}, []step{{true, day1, true}, {true, day1, false}, {false, day1, true}, {false, day1, false}, {true, day1, true}}},
+		{repository.EntryDaily, sql.NullInt64{}, []step{{true, day1, true}, {false, day1, true}, {true, day1, false}, {true, day2, true}}},
+		{repository.EntryMulti, multi, []step{{true, day1, true}, {true, day1, true}, {true, day2, false}}},
+	}
+	for _, c := range cases {
+		t.Run(c.policy, func(t *testing.T) {
+			f := newFixture(t)
+			if err := repository.UpdateTicketTypeEntryPolicy(f.DB, f.TicketTypeID, c.policy, c.max); err != nil {
+				t.Fatal(err)
+			}
+			_, ticketID := f.PaidTicket(t, f.User(t))
+			for i, s := range c.steps {
+				var ok bool
+				var err error
+				if s.in {
+					ok, err = repository.EnterTicket(f.DB, ticketID, c.policy, c.max, s.day)
+				} else {
+					ok, err = repository.ExitTicket(f.DB, ticketID)
+				}
+				if err != nil || ok != s.want {
+					t.Fatalf("step %d (in=%v, %s): ok=%v err=%v, want %v", i, s.in, s.day, ok, err, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestEnterTicketState(t *testing.T) {
+	f := newFixture(t)
+	_, ticketID := f.PaidTicket(t, f.User(t))
+	if ok, err := repository.EnterTicket(f.DB, ticketID, repository.EntryReentry, sql.NullInt64{}, "2026-01-10"); err != nil || !ok {
+		t.Fatalf("EnterTicket: ok=%v err=%v", ok, err)
+	}
+	tk, err := repository.TicketByID(f.DB, ticketID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tk.Used != 1 || tk.CheckinState != repository.CheckinIn || tk.EntryCount != 1 {
+		t.Errorf("after entry: used=%d state=%s entries=%d", tk.Used, tk.CheckinState, tk.EntryCount)
+	}
+	c, err := repository.TicketCheckinByID(f.DB, ticketID)
+	if err != nil || c.LastEntryDate.String != "2026-01-10" {
+		t.Errorf("last entry date = %q, %v", c.LastEntryDate.String, err)
+	}
+}
+
+func TestEntriesExhausted(t *testing.T) {
+	multi := sql.NullInt64{Int64: 3, Valid: true}
+	cases := []struct {
+		policy  string
+		max     sql.NullInt64
+		entries int
+		want    bool
+	}{
+		{repository.EntrySingle, sql.NullInt64{}, 0, false},
+		{repository.EntrySingle, sql.NullInt64{}, 1, true},
+		{repository.EntryReentry, sql.NullInt64{}, 5, false},
+		{repository.EntryDaily, sql.NullInt64{}, 5, false},
+		{repository.EntryMulti, multi, 2, false},
+		{repository.EntryMulti, multi, 3, true},
+	}
+	for _, c := range cases {
+		if got := repository.EntriesExhausted(c.policy, c.max, c.entries); got != c.want {
+			t.Errorf("EntriesExhausted(%s, %d entries) = %v, want %v", c.policy, c.entries, got, c.want)
+		}
+	}
+}
+
+func TestAdmitTicketRecordsValidation(t *testing.T) {
+	f := newFixture(t)
+	staff := f.User(t)
+	_, ticketID := f.PaidTicket(t, f.User(t))
+	admit := func(validatedBy string) (*repository.TicketValidationRow, bool, error) {
+		v := &repository.TicketValidationRow{TicketID: ticketID, EventID: f.EventID, ProducerID: f.ProducerID, ValidatedBy: validatedBy, Direction: repository.CheckinIn}
+		ok, err := repository.AdmitTicket(f.DB, v, repository.EntrySingle, sql.NullInt64{}, "2026-01-10")
+		return v, ok, err
+	}
+	// A validation that cannot be stored (unknown staff user) leaves the door state unchanged.
+	if _, _, err := admit("desconhecido"); err == nil {
+		t.Fatal("validation by an unknown user stored")
+	}
+	if tk, _ := repository.TicketByID(f.DB, ticketID); tk.Used != 0 || tk.EntryCount != 0 {
+		t.Errorf("door state changed without a validation: used=%d entries=%d", tk.Used, tk.EntryCount)
+	}
+
+	v, ok, err := admit(staff)
+	if err != nil || !ok {
+		t.Fatalf("first entry: ok=%v err=%v", ok, err)
+	}
+	if stored, err := repository.TicketValidationByID(f.DB, v.ID); err != nil || stored == nil || stored.Direction != repository.CheckinIn {
+		t.Errorf("validation %s not stored: %+v, %v", v.ID, stored, err)
+	}
+	// A refused entry records no validation.
+	if _, ok, err := admit(staff); err != nil || ok {
+		t.Errorf("second entry: ok=%v err=%v, want refused", ok, err)
+	}
+	var n int
+	if err := f.DB.QueryRow(`SELECT COUNT(*) FROM ticket_validations WHERE ticket_id = ?`, ticketID).Scan(&n); err != nil || n != 1 {
+		t.Errorf("%d validations, %v; want 1", n, err)
+	}
+}
@@ -2,10 +2,15 @@ package repository
 
 import (
 	"database/sql"
+	"time"
+	_ "time/tzdata" // event times are local to São Paulo
 
 	"github.com/google/uuid"
 )
 
+// EventLocation is the time zone event dates and start times are entered in.
+var EventLocation, _ = time.LoadLocation("America/Sao_Paulo")
+
 func ListEventsByProducerID(db *sql.DB, producerID string) ([]string, error) {
 	rows, err := db.Query(`SELECT id FROM events WHERE producer_id = ? ORDER BY created_at DESC`, producerID)
 	if err != nil {
@@ -23,9 +28,9 @@ func ListEventsByProducerID(db *sql.DB, producerID string) ([]string, error) {
 	return ids, rows.Err()
 }
 
-// ListEventsByProducerIDExcludingDraft returns event IDs for a producer with status != 'DRAFT' (for public profile).
+// ListEventsByProducerIDExcludingDraft returns event IDs for a producer with status not in ('DRAFT', 'REMOVED') (for public profile).
 func ListEventsByProducerIDExcludingDraft(db *sql.DB, producerID string) ([]string, error) {
-	rows, err := db.Query(`SELECT id FROM events WHERE producer_id = ? AND status != 'DRAFT' ORDER BY created_at DESC`, producerID)
+	rows, err := db.Query(`SELECT id FROM events WHERE producer_id = ? AND status NOT IN ('DRAFT', 'REMOVED') ORDER BY created_at DESC`, producerID)
 	if err != nil {
 		return nil, err
 	}
@@ -79,10 +84,14 @@ func ListPublishedEvents(db *sql.DB, category, date, city *string) ([]string, er
 	return ids, rows.Err()
 }
 
-func EventByID(db *sql.DB, id string) (*EventRow, error) {
+func EventByID(db Querier, id string) (*EventRow, error) {
 	var e EventRow
-	err := db.QueryRow(`SELECT id, producer_id, title, description, category, cover_image, location, address, status, featured FROM events WHERE id = ?`, id).Scan(
-		&e.ID, &e.ProducerID, &e.Title, &e.Description, &e.Category, &e.CoverImage, &e.Location, &e.Address, &e.Status, &e.Featured,
+	err := db.QueryRow(`SELECT id, producer_id, title, description, category, cover_image, location, address, status, featured, removed_reason, moderation_note, transfers_enabled, transfer_cutoff_hours,
+		resale_enabled, resale_max_markup_percent, resale_royalty_percent, holder_cutoff_hours, min_age,
+		max_per_order, max_per_account, max_per_cpf, courtesy_quota, dynamic_qr, static_qr_fallback FROM events WHERE id = ?`, id).Scan(
+		&e.ID, &e.ProducerID, &e.Title, &e.Description, &e.Category, &e.CoverImage, &e.Location, &e.Address, &e.Status, &e.Featured, &e.RemovedReason, &e.ModerationNote,
+		&e.TransfersEnabled, &e.TransferCutoffHours, &e.ResaleEnabled, &e.ResaleMaxMarkupPercent, &e.ResaleRoyaltyPercent, &e.HolderCutoffHours, &e.MinAge,
+		&e.Limits.MaxPerOrder, &e.Limits.MaxPerAccount, &e.Limits.MaxPerCPF, &e.CourtesyQuota, &e.DynamicQR, &e.StaticQRFallback,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -94,16 +103,36 @@ func EventByID(db *sql.DB, id string) (*EventRow, error) {
 }
 
 type EventRow struct {
-	ID          string
-	ProducerID  string
-	Title       string
-	Description string
-	Category    string
-	CoverImage  string
-	Location    string
-	Address     sql.NullString
-	Status      string
-	Featured    int
+	ID             string
+	ProducerID     string
+	Title          string
+	Description    string
+	Category       string
+	CoverImage     string
+	Location       string
+	Address        sql.NullString
+	Status         string
+	Featured       int
+	RemovedReason  sql.NullString
+	ModerationNote sql.NullString
+	// Transfer policy: transfers close TransferCutoffHours before each date starts.
+	TransfersEnabled    int
+	TransferCutoffHours int
+	// Resale rules: price cap over face value and producer royalty, both in percent.
+	ResaleEnabled          int
+	ResaleMaxMarkupPercent float64
+	ResaleRoyaltyPercent   float64
+	// Nominal tickets: holders can be assigned or changed until HolderCutoffHours before each date.
+	HolderCutoffHours int
+	// Minimum attendee age (0 = no restriction), checked at the event date.
+	MinAge int
+	Limits PurchaseLimits
+	// Maximum number of courtesy tickets the producer can issue for the event.
+	CourtesyQuota int
+	// Rotating QR codes in the app; StaticQRFallback keeps the static payload (prints, e-mails,
+	// wallet passes) valid at the door.
+	DynamicQR        int
+	StaticQRFallback int
 }
 
 func EventDateIDsByEvent(db *sql.DB, eventID string) ([]string, error) {
@@ -131,7 +160,7 @@ type EventDateRow struct {
 	EndTime   sql.NullString
 }
 
-func EventDateByID(db *sql.DB, id string) (*EventDateRow, error) {
+func EventDateByID(db Querier, id string) (*EventDateRow, error) {
 	var d EventDateRow
 	err := db.QueryRow(`SELECT id, event_id, date, start_time, end_time FROM event_dates WHERE id = ?`, id).Scan(
 		&d.ID, &d.EventID, &d.Date, &d.StartTime, &d.EndTime,
@@ -145,6 +174,17 @@ func EventDateByID(db *sql.DB, id string) (*EventDateRow, error) {
 	return &d, nil
 }
 
+// EventDateStart returns when an event date starts (midnight when it has no start time).
+// ok is false when the stored date cannot be parsed.
+func EventDateStart(d *EventDateRow) (start time.Time, ok bool) {
+	layout, value := "2006-01-02", d.Date
+	if d.StartTime.Valid && d.StartTime.String != "" {
+		layout, value = "2006-01-02 15:04", d.Date+" "+d.StartTime.String
+	}
+	start, err := time.ParseInLocation(layout, value, EventLocation)
+	return start, err == nil
+}
+
 func LotIDsByEventDate(db *sql.DB, dateID string) ([]string, error) {
 	rows, err := db.Query(`SELECT id FROM lots WHERE event_date_id = ?`, dateID)
 	if err != nil {
@@ -173,7 +213,7 @@ type LotRow struct {
 	Active            int
 }
 
-func LotByID(db *sql.DB, id string) (*LotRow, error) {
+func LotByID(db Querier, id string) (*LotRow, error) {
 	var l LotRow
 	err := db.QueryRow(`SELECT id, event_date_id, name, starts_at, ends_at, total_quantity, available_quantity, active FROM lots WHERE id = ?`, id).Scan(
 		&l.ID, &l.EventDateID, &l.Name, &l.StartsAt, &l.EndsAt, &l.TotalQuantity, &l.AvailableQuantity, &l.Active,
@@ -213,12 +253,27 @@ type TicketTypeRow struct {
 	Audience     string
 	MaxQuantity  int
 	SoldQuantity int
+	Nominal      int
+	// Half-price (meia-entrada) category; NULL for full-price types.
+	HalfPriceEntitlement sql.NullString
+	Limits               PurchaseLimits
+	// Entry policy (SINGLE, REENTRY, DAILY or MULTI); MaxEntries is set for MULTI.
+	EntryPolicy string
+	MaxEntries  sql.NullInt64
+	// Area (sector) the ticket gives access to; nil when the type has none.
+	Area *EventAreaRow
 }
 
-func TicketTypeByID(db *sql.DB, id string) (*TicketTypeRow, error) {
+func TicketTypeByID(db Querier, id string) (*TicketTypeRow, error) {
 	var t TicketTypeRow
-	err := db.QueryRow(`SELECT id, lot_id, name, description, price, audience, max_quantity, sold_quantity FROM ticket_types WHERE id = ?`, id).Scan(
-		&t.ID, &t.LotID, &t.Name, &t.Description, &t.Price, &t.Audience, &t.MaxQuantity, &t.SoldQuantity,
+	var area EventAreaRow
+	var areaID, areaEventID, areaName, areaCreatedAt sql.NullString
+	err := db.QueryRow(`SELECT tt.id, tt.lot_id, tt.name, tt.description, tt.price, tt.audience, tt.max_quantity, tt.sold_quantity, tt.nominal, tt.half_price_entitlement,
+		tt.max_per_order, tt.max_per_account, tt.max_per_cpf, tt.entry_policy, tt.max_entries, a.id, a.event_id, a.name, a.capacity, a.created_at
+		FROM ticket_types tt LEFT JOIN event_areas a ON a.id = tt.area_id WHERE tt.id = ?`, id).Scan(
+		&t.ID, &t.LotID, &t.Name, &t.Description, &t.Price, &t.Audience, &t.MaxQuantity, &t.SoldQuantity, &t.Nominal, &t.HalfPriceEntitlement,
+		&t.Limits.MaxPerOrder, &t.Limits.MaxPerAccount, &t.Limits.MaxPerCPF, &t.EntryPolicy, &t.MaxEntries,
+		&areaID, &areaEventID, &areaName, &area.Capacity, &areaCreatedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -226,6 +281,10 @@ func TicketTypeByID(db *sql.DB, id string) (*TicketTypeRow, error) {
 	if err != nil {
 		return nil, err
 	}
+	if areaID.Valid {
+		area.ID, area.EventID, area.Name, area.CreatedAt = areaID.String, areaEventID.String, areaName.String, areaCreatedAt.String
+		t.Area = &area
+	}
 	return &t, nil
 }
 
@@ -239,16 +298,24 @@ func ProducerIDByUser(db *sql.DB, userID string) (string, error) {
 }
 
 type ProducerRow struct {
-	ID          string
-	UserID      string
-	CompanyName sql.NullString
-	Approved    int
+	ID              string
+	UserID          string
+	CompanyName     sql.NullString
+	Approved        int
+	RejectionReason sql.NullString
+	Status          string
+	CNPJ            sql.NullString
+	ContactName     sql.NullString
+	ContactEmail    sql.NullString
+	ContactPhone    sql.NullString
+	SubmittedAt     sql.NullString
+	ReviewedAt      sql.NullString
 }
 
 func ProducerByID(db *sql.DB, id string) (*ProducerRow, error) {
 	var p ProducerRow
-	err := db.QueryRow(`SELECT id, user_id, company_name, approved FROM producers WHERE id = ?`, id).Scan(
-		&p.ID, &p.UserID, &p.CompanyName, &p.Approved,
+	err := db.QueryRow(`SELECT id, user_id, company_name, approved, rejection_reason, status, cnpj, contact_name, contact_email, contact_phone, submitted_at, reviewed_at FROM producers WHERE id = ?`, id).Scan(
+		&p.ID, &p.UserID, &p.CompanyName, &p.Approved, &p.RejectionReason, &p.Status, &p.CNPJ, &p.ContactName, &p.ContactEmail, &p.ContactPhone, &p.SubmittedAt, &p.ReviewedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -259,12 +326,6 @@ func ProducerByID(db *sql.DB, id string) (*ProducerRow, error) {
 	return &p, nil
 }
 
-func CreateProducer(db *sql.DB, userID string) (string, error) {
-	id := uuid.New().String()
-	_, err := db.Exec(`INSERT INTO producers (id, user_id, approved) VALUES (?, ?, 1)`, id, userID)
-	return id, err
-}
-
 func CreateEvent(db *sql.DB, producerID, title, description, category, coverImage, location string, address *string) (string, error) {
 	id := uuid.New().String()
 	var addr sql.NullString
@@ -292,6 +353,108 @@ func CreateEventDate(db *sql.DB, eventID, date string, startTime, endTime *strin
 	return id, err
 }
 
+// UpdateEventTransferPolicy changes whether tickets of the event can be transferred and how
+// many hours before each date transfers close. Nil values are left unchanged.
+func UpdateEventTransferPolicy(db *sql.DB, eventID string, enabled *bool, cutoffHours *int) error {
+	if enabled != nil {
+		v := 0
+		if *enabled {
+			v = 1
+		}
+		if _, err := db.Exec(`UPDATE events SET transfers_enabled = ?, updated_at = datetime('now') WHERE id = ?`, v, eventID); err != nil {
+			return err
+		}
+	}
+	if cutoffHours != nil {
+		if _, err := db.Exec(`UPDATE events SET transfer_cutoff_hours = ?, updated_at = datetime('now') WHERE id = ?`, *cutoffHours, eventID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateEventQRPolicy changes whether the app shows rotating QR codes for the event and whether
+// the static payload is still accepted. Nil values are left unchanged.
+func UpdateEventQRPolicy(db *sql.DB, eventID string, dynamic, staticFallback *bool) error {
+	for _, f := range []struct {
+		column string
+		value  *bool
+	}{
+		{"dynamic_qr", dynamic},
+		{"static_qr_fallback", staticFallback},
+	} {
+		if f.value == nil {
+			continue
+		}
+		v := 0
+		if *f.value {
+			v = 1
+		}
+		if _, err := db.Exec(`UPDATE events SET `+f.column+` = ?, updated_at = datetime('now') WHERE id = ?`, v, eventID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EventHasTicketAudience tells whether any ticket type of the event has the audience (e.g. CHILD).
+func EventHasTicketAudience(db *sql.DB, eventID, audience string) (bool, error) {
+	var ok bool
+	err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM ticket_types tt JOIN lots l ON l.id = tt.lot_id
+		JOIN event_dates ed ON ed.id = l.event_date_id WHERE ed.event_id = ? AND tt.audience = ?)`, eventID, audience).Scan(&ok)
+	return ok, err
+}
+
+// UpdateEventMinAge changes the minimum attendee age of the event (0 removes the restriction).
+func UpdateEventMinAge(db *sql.DB, eventID string, minAge int) error {
+	_, err := db.Exec(`UPDATE events SET min_age = ?, updated_at = datetime('now') WHERE id = ?`, minAge, eventID)
+	return err
+}
+
+// UpdateEventHolderCutoff changes how many hours before each date nominal ticket holders can
+// no longer be assigned or changed.
+func UpdateEventHolderCutoff(db *sql.DB, eventID string, cutoffHours int) error {
+	_, err := db.Exec(`UPDATE events SET holder_cutoff_hours = ?, updated_at = datetime('now') WHERE id = ?`, cutoffHours, eventID)
+	return err
+}
+
+// UpdateEventResalePolicy changes the resale rules of the event. Nil values are left unchanged.
+func UpdateEventResalePolicy(db *sql.DB, eventID string, enabled *bool, maxMarkupPercent, royaltyPercent *float64) error {
+	if enabled != nil {
+		v := 0
+		if *enabled {
+			v = 1
+		}
+		if _, err := db.Exec(`UPDATE events SET resale_enabled = ?, updated_at = datetime('now') WHERE id = ?`, v, eventID); err != nil {
+			return err
+		}
+	}
+	if maxMarkupPercent != nil {
+		if _, err := db.Exec(`UPDATE events SET resale_max_markup_percent = ?, updated_at = datetime('now') WHERE id = ?`, *maxMarkupPercent, eventID); err != nil {
+			return err
+		}
+	}
+	if royaltyPercent != nil {
+		if _, err := db.Exec(`UPDATE events SET resale_royalty_percent = ?, updated_at = datetime('now') WHERE id = ?`, *royaltyPercent, eventID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateEventDate changes the date and times of an event date.
+func UpdateEventDate(db *sql.DB, id, date string, startTime, endTime *string) error {
+	var st, et sql.NullString
+	if startTime != nil {
+		st = sql.NullString{String: *startTime, Valid: true}
+	}
+	if endTime != nil {
+		et = sql.NullString{String: *endTime, Valid: true}
+	}
+	_, err := db.Exec(`UPDATE event_dates SET date = ?, start_time = ?, end_time = ? WHERE id = ?`, date, st, et, id)
+	return err
+}
+
 func CreateLot(db *sql.DB, eventDateID, name, startsAt, endsAt string, totalQuantity int) (string, error) {
 	id := uuid.New().String()
 	_, err := db.Exec(`INSERT INTO lots (id, event_date_id, name, starts_at, ends_at, total_quantity, available_quantity, active) VALUES (?, ?, ?, ?, ?, ?, ?, 1)`,
@@ -300,18 +463,44 @@ func CreateLot(db *sql.DB, eventDateID, name, startsAt, endsAt string, totalQuan
 	return id, err
 }
 
-func CreateTicketType(db *sql.DB, lotID, name string, description *string, price float64, audience string, maxQuantity int) (string, error) {
+func CreateTicketType(db *sql.DB, lotID, name string, description *string, price float64, audience string, maxQuantity int, nominal bool, halfPriceEntitlement string) (string, error) {
 	id := uuid.New().String()
 	var desc sql.NullString
 	if description != nil {
 		desc = sql.NullString{String: *description, Valid: true}
 	}
-	_, err := db.Exec(`INSERT INTO ticket_types (id, lot_id, name, description, price, audience, max_quantity, sold_quantity) VALUES (?, ?, ?, ?, ?, ?, ?, 0)`,
-		id, lotID, name, desc, price, audience, maxQuantity,
+	nom := 0
+	if nominal {
+		nom = 1
+	}
+	var half sql.NullString
+	if halfPriceEntitlement != "" {
+		half = sql.NullString{String: halfPriceEntitlement, Valid: true}
+	}
+	_, err := db.Exec(`INSERT INTO ticket_types (id, lot_id, name, description, price, audience, max_quantity, sold_quantity, nominal, half_price_entitlement) VALUES (?, ?, ?, ?, ?, ?, ?, 0, ?, ?)`,
+		id, lotID, name, desc, price, audience, maxQuantity, nom, half,
 	)
 	return id, err
 }
 
+// CountPublishedEventsByProducer counts events of the producer that already went live
+// (published at least once), used to decide whether a new event needs moderation.
+func CountPublishedEventsByProducer(db *sql.DB, producerID string) (int, error) {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM events WHERE producer_id = ? AND status IN ('PUBLISHED', 'PAUSED', 'ENDED')`, producerID).Scan(&n)
+	return n, err
+}
+
+// SetEventModeration moves an event in or out of the moderation queue, storing the moderator note.
+func SetEventModeration(db *sql.DB, eventID, status string, note *string) error {
+	var n sql.NullString
+	if note != nil {
+		n = sql.NullString{String: *note, Valid: true}
+	}
+	_, err := db.Exec(`UPDATE events SET status = ?, moderation_note = ?, updated_at = datetime('now') WHERE id = ?`, status, n, eventID)
+	return err
+}
+
 func UpdateEventStatus(db *sql.DB, eventID, status string) error {
 	_, err := db.Exec(`UPDATE events SET status = ?, updated_at = datetime('now') WHERE id = ?`, status, eventID)
 	return err
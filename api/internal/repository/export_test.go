@@ -0,0 +1,7 @@
+package repository
+
+// Unexported helpers exercised by the external tests.
+var (
+	StoredEntryDate = storedEntryDate
+	EntryDayBounds  = entryDayBounds
+)
@@ -0,0 +1,17 @@
+package repository_test
+
+import (
+	"testing"
+
+	"afterzin/api/internal/repository/repotest"
+)
+
+// fixture is the shared test fixture, extended with the helpers of these tests.
+type fixture struct {
+	*repotest.Fixture
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	return &fixture{repotest.NewFixture(t)}
+}
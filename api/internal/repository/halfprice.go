@@ -0,0 +1,46 @@
+package repository
+
+import "database/sql"
+
+// HalfPriceUsage returns the ticket capacity of an event date (sum of its lots) and how many
+// half-price tickets were already sold for it, for the legal quota check.
+func HalfPriceUsage(db Querier, eventDateID string) (capacity, sold int, err error) {
+	err = db.QueryRow(`SELECT COALESCE(SUM(total_quantity), 0) FROM lots WHERE event_date_id = ?`, eventDateID).Scan(&capacity)
+	if err != nil {
+		return 0, 0, err
+	}
+	err = db.QueryRow(`SELECT COALESCE(SUM(tt.sold_quantity), 0) FROM ticket_types tt JOIN lots l ON l.id = tt.lot_id
+		WHERE l.event_date_id = ? AND tt.half_price_entitlement IS NOT NULL`, eventDateID).Scan(&sold)
+	return capacity, sold, err
+}
+
+// CreateOrderItemHalfPriceDocuments stores the proof documents given at checkout, one per ticket of the item in order.
+func CreateOrderItemHalfPriceDocuments(db *sql.DB, orderItemID string, documents []string) error {
+	for i, d := range documents {
+		if _, err := db.Exec(`INSERT INTO order_item_half_price_documents (order_item_id, position, document) VALUES (?, ?, ?)`, orderItemID, i, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyOrderItemHalfPriceDocument copies the checkout proof document at position to the issued ticket.
+// Does nothing when the item is not half-price.
+func ApplyOrderItemHalfPriceDocument(db Querier, ticketID, orderItemID string, position int) error {
+	_, err := db.Exec(`UPDATE tickets SET half_price_document =
+		(SELECT document FROM order_item_half_price_documents WHERE order_item_id = ? AND position = ?)
+		WHERE id = ? AND EXISTS (SELECT 1 FROM order_item_half_price_documents WHERE order_item_id = ? AND position = ?)`,
+		orderItemID, position, ticketID, orderItemID, position,
+	)
+	return err
+}
+
+// TicketHalfPriceDocument returns the proof document number of a half-price ticket ("" when none).
+func TicketHalfPriceDocument(db *sql.DB, ticketID string) (string, error) {
+	var doc sql.NullString
+	err := db.QueryRow(`SELECT half_price_document FROM tickets WHERE id = ?`, ticketID).Scan(&doc)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return doc.String, err
+}
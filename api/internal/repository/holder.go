@@ -0,0 +1,88 @@
+package repository
+
+import "database/sql"
+
+// TicketHolder identifies the person a nominal ticket belongs to (checked against an ID at the door).
+type TicketHolder struct {
+	Name      string
+	CPF       string
+	BirthDate string // YYYY-MM-DD
+	Gender    string // MALE, FEMALE or "" when not given
+}
+
+// clearTicketHolder is the SET clause that removes the holder when a ticket changes owner.
+const clearTicketHolder = `holder_name = NULL, holder_cpf = NULL, holder_birthdate = NULL, holder_gender = NULL, holder_assigned_at = NULL`
+
+// nullIfEmpty stores optional text columns as NULL when empty.
+func nullIfEmpty(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// CreateOrderItemHolders stores the holders given at checkout, one per ticket of the item in order.
+func CreateOrderItemHolders(db *sql.DB, orderItemID string, holders []TicketHolder) error {
+	for i, h := range holders {
+		if _, err := db.Exec(`INSERT INTO order_item_holders (order_item_id, position, name, cpf, birthdate, gender) VALUES (?, ?, ?, ?, ?, ?)`,
+			orderItemID, i, h.Name, h.CPF, h.BirthDate, nullIfEmpty(h.Gender),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OrderItemHolders returns the holders given at checkout for an order item, by position.
+func OrderItemHolders(db Querier, orderItemID string) ([]TicketHolder, error) {
+	rows, err := db.Query(`SELECT name, cpf, birthdate, gender FROM order_item_holders WHERE order_item_id = ? ORDER BY position`, orderItemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []TicketHolder
+	for rows.Next() {
+		var h TicketHolder
+		var gender sql.NullString
+		if err := rows.Scan(&h.Name, &h.CPF, &h.BirthDate, &gender); err != nil {
+			return nil, err
+		}
+		h.Gender = gender.String
+		list = append(list, h)
+	}
+	return list, rows.Err()
+}
+
+// ApplyOrderItemHolder copies the checkout holder at position to the issued ticket.
+// Does nothing when no holder was given for that position.
+func ApplyOrderItemHolder(db Querier, ticketID, orderItemID string, position int) error {
+	_, err := db.Exec(`UPDATE tickets SET (holder_name, holder_cpf, holder_birthdate, holder_gender, holder_assigned_at) =
+		(SELECT name, cpf, birthdate, gender, datetime('now') FROM order_item_holders WHERE order_item_id = ? AND position = ?)
+		WHERE id = ? AND EXISTS (SELECT 1 FROM order_item_holders WHERE order_item_id = ? AND position = ?)`,
+		orderItemID, position, ticketID, orderItemID, position,
+	)
+	return err
+}
+
+// TicketHolderByTicketID returns the ticket's holder, or nil when none was assigned.
+func TicketHolderByTicketID(db *sql.DB, ticketID string) (*TicketHolder, error) {
+	var name, cpf, birthDate, gender sql.NullString
+	err := db.QueryRow(`SELECT holder_name, holder_cpf, holder_birthdate, holder_gender FROM tickets WHERE id = ?`, ticketID).Scan(&name, &cpf, &birthDate, &gender)
+	if err == sql.ErrNoRows || (err == nil && !name.Valid) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &TicketHolder{Name: name.String, CPF: cpf.String, BirthDate: birthDate.String, Gender: gender.String}, nil
+}
+
+// SetTicketHolder assigns (or replaces) the holder of an unused ticket. Returns false when the
+// ticket was used in the meantime.
+func SetTicketHolder(db *sql.DB, ticketID string, h TicketHolder) (bool, error) {
+	res, err := db.Exec(`UPDATE tickets SET holder_name = ?, holder_cpf = ?, holder_birthdate = ?, holder_gender = ?, holder_assigned_at = datetime('now') WHERE id = ? AND used = 0`,
+		h.Name, h.CPF, h.BirthDate, nullIfEmpty(h.Gender), ticketID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
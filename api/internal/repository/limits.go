@@ -0,0 +1,88 @@
+package repository
+
+import "database/sql"
+
+// PurchaseLimits are the producer's anti-scalping limits of an event or ticket type (NULL = no limit).
+type PurchaseLimits struct {
+	MaxPerOrder   sql.NullInt64
+	MaxPerAccount sql.NullInt64
+	MaxPerCPF     sql.NullInt64
+}
+
+// UpdateEventPurchaseLimits replaces the purchase limits of the event.
+func UpdateEventPurchaseLimits(db *sql.DB, eventID string, l PurchaseLimits) error {
+	_, err := db.Exec(`UPDATE events SET max_per_order = ?, max_per_account = ?, max_per_cpf = ?, updated_at = datetime('now') WHERE id = ?`,
+		l.MaxPerOrder, l.MaxPerAccount, l.MaxPerCPF, eventID,
+	)
+	return err
+}
+
+// UpdateTicketTypePurchaseLimits replaces the purchase limits of the ticket type.
+func UpdateTicketTypePurchaseLimits(db *sql.DB, ticketTypeID string, l PurchaseLimits) error {
+	_, err := db.Exec(`UPDATE ticket_types SET max_per_order = ?, max_per_account = ?, max_per_cpf = ? WHERE id = ?`,
+		l.MaxPerOrder, l.MaxPerAccount, l.MaxPerCPF, ticketTypeID,
+	)
+	return err
+}
+
+// purchasedTicketsScope restricts a ticket count to paid orders of an event (ticketTypeID empty)
+// or of a single ticket type.
+func purchasedTicketsScope(eventID, ticketTypeID string) (string, []interface{}) {
+	if ticketTypeID != "" {
+		return ` AND t.ticket_type_id = ?`, []interface{}{ticketTypeID}
+	}
+	return ` AND t.event_id = ?`, []interface{}{eventID}
+}
+
+// CountPurchasedTicketsByUser counts the purchased (not courtesy) paid tickets the user bought for the event or ticket
+// type, by the order's buyer: transferring a ticket away does not free the account's limit.
+func CountPurchasedTicketsByUser(db Querier, userID, eventID, ticketTypeID string) (int, error) {
+	scope, args := purchasedTicketsScope(eventID, ticketTypeID)
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM tickets t JOIN orders o ON o.id = t.order_id
+		WHERE o.user_id = ? AND o.status = 'PAID' AND o.kind = 'SALE'`+scope, append([]interface{}{userID}, args...)...,
+	).Scan(&n)
+	return n, err
+}
+
+// CountPurchasedTicketsByCPF counts the purchased (not courtesy) paid tickets of a CPF (digits only) for the event or ticket
+// type: nominal tickets by holder CPF, the others by the buyer's CPF (kept after a transfer).
+func CountPurchasedTicketsByCPF(db Querier, cpf, eventID, ticketTypeID string) (int, error) {
+	scope, args := purchasedTicketsScope(eventID, ticketTypeID)
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM tickets t JOIN orders o ON o.id = t.order_id JOIN users u ON u.id = o.user_id
+		WHERE o.status = 'PAID' AND o.kind = 'SALE' AND (t.holder_cpf = ? OR (t.holder_cpf IS NULL AND REPLACE(REPLACE(u.cpf, '.', ''), '-', '') = ?))`+scope,
+		append([]interface{}{cpf, cpf}, args...)...,
+	).Scan(&n)
+	return n, err
+}
+
+// TicketPurchaseCPF returns the CPF (digits only) the ticket counts for in the per-CPF limits —
+// its holder's, else its buyer's — and whether it counts at all (purchased, paid ticket).
+func TicketPurchaseCPF(db Querier, ticketID string) (cpf string, purchased bool, err error) {
+	err = db.QueryRow(`SELECT COALESCE(t.holder_cpf, REPLACE(REPLACE(u.cpf, '.', ''), '-', ''), ''), o.status = 'PAID' AND o.kind = 'SALE'
+		FROM tickets t JOIN orders o ON o.id = t.order_id JOIN users u ON u.id = o.user_id WHERE t.id = ?`, ticketID,
+	).Scan(&cpf, &purchased)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	return cpf, purchased, err
+}
+
+// OrderItemHolderCPFs returns the holder CPFs given at checkout for an order item, by position.
+func OrderItemHolderCPFs(db Querier, orderItemID string) ([]string, error) {
+	rows, err := db.Query(`SELECT cpf FROM order_item_holders WHERE order_item_id = ? ORDER BY position`, orderItemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []string
+	for rows.Next() {
+		var cpf string
+		if err := rows.Scan(&cpf); err != nil {
+			return nil, err
+		}
+		list = append(list, cpf)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,71 @@
+package repository_test
+
+import (
+	"testing"
+
+	"afterzin/api/internal/repository"
+	"github.com/google/uuid"
+)
+
+func TestCountPurchasedTicketsByUser(t *testing.T) {
+	f := newFixture(t)
+	buyer := f.User(t)
+	f.PaidTicket(t, buyer)
+	f.PaidTicket(t, buyer)
+	f.PendingOrder(t, buyer, 3) // not paid: does not count
+
+	// A courtesy ticket is not a purchase.
+	courtesyOrder := uuid.New().String()
+	if err := repository.CreateCourtesyOrder(f.DB, courtesyOrder, buyer); err != nil {
+		t.Fatal(err)
+	}
+	itemID, err := repository.CreateOrderItem(f.DB, courtesyOrder, f.DateID, f.TicketTypeID, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repository.CreateTicket(f.DB, repository.GenerateTicketCode(), repository.GenerateQRCode(), courtesyOrder, itemID, buyer, f.EventID, f.DateID, f.TicketTypeID); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, scope := range []struct{ name, ticketTypeID string }{{"event", ""}, {"ticket type", f.TicketTypeID}} {
+		n, err := repository.CountPurchasedTicketsByUser(f.DB, buyer, f.EventID, scope.ticketTypeID)
+		if err != nil || n != 2 {
+			t.Errorf("%s scope: got %d, %v; want 2", scope.name, n, err)
+		}
+	}
+}
+
+func TestCountPurchasedTicketsByUserAfterRefund(t *testing.T) {
+	f := newFixture(t)
+	buyer := f.User(t)
+	orderID, _ := f.PaidTicket(t, buyer)
+	if ok, err := repository.RefundOrder(f.DB, orderID); err != nil || !ok {
+		t.Fatalf("RefundOrder: ok=%v err=%v", ok, err)
+	}
+	if n, err := repository.CountPurchasedTicketsByUser(f.DB, buyer, f.EventID, ""); err != nil || n != 0 {
+		t.Errorf("got %d, %v; want 0", n, err)
+	}
+}
+
+func TestCountPurchasedTicketsByCPF(t *testing.T) {
+	f := newFixture(t)
+	buyer := f.User(t) // CPF 000.000.000-02: the producer is the first user
+	f.PaidTicket(t, buyer)
+	_, nominal := f.PaidTicket(t, buyer)
+	if ok, err := repository.SetTicketHolder(f.DB, nominal, repository.TicketHolder{Name: "Titular", CPF: "12345678909", BirthDate: "1990-01-01"}); err != nil || !ok {
+		t.Fatalf("SetTicketHolder: ok=%v err=%v", ok, err)
+	}
+
+	// Tickets without a holder count for the buyer's CPF, nominal ones for the holder's.
+	if n, err := repository.CountPurchasedTicketsByCPF(f.DB, "00000000002", f.EventID, ""); err != nil || n != 1 {
+		t.Errorf("buyer CPF: got %d, %v; want 1", n, err)
+	}
+	if n, err := repository.CountPurchasedTicketsByCPF(f.DB, "12345678909", f.EventID, f.TicketTypeID); err != nil || n != 1 {
+		t.Errorf("holder CPF: got %d, %v; want 1", n, err)
+	}
+
+	cpf, purchased, err := repository.TicketPurchaseCPF(f.DB, nominal)
+	if err != nil || !purchased || cpf != "12345678909" {
+		t.Errorf("TicketPurchaseCPF = %q, %v, %v; want holder CPF, purchased", cpf, purchased, err)
+	}
+}
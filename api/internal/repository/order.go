@@ -7,6 +7,14 @@ import (
 	"github.com/google/uuid"
 )
 
+// Querier runs statements on the database or inside a transaction (*sql.DB or *sql.Tx), for
+// queries shared by both, such as the checks and inserts of an order confirmation.
+type Querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
 func CreateOrder(db *sql.DB, userID string, total float64, exp time.Duration) (string, error) {
 	id := uuid.New().String()
 	expAt := time.Now().Add(exp).UTC().Format(time.RFC3339)
@@ -14,17 +22,81 @@ func CreateOrder(db *sql.DB, userID string, total float64, exp time.Duration) (s
 	return id, err
 }
 
-func OrderByID(db *sql.DB, id string) (userID string, status string, total float64, err error) {
+func OrderByID(db Querier, id string) (userID string, status string, total float64, err error) {
 	err = db.QueryRow(`SELECT user_id, status, total FROM orders WHERE id = ?`, id).Scan(&userID, &status, &total)
 	return
 }
 
-func ConfirmOrder(db *sql.DB, orderID string) error {
-	_, err := db.Exec(`UPDATE orders SET status = 'PAID' WHERE id = ? AND status = 'PENDING'`, orderID)
+func ConfirmOrder(db Querier, orderID string) error {
+	_, err := db.Exec(`UPDATE orders SET status = 'PAID', paid_at = datetime('now') WHERE id = ? AND status = 'PENDING'`, orderID)
 	return err
 }
 
-func CreateOrderItem(db *sql.DB, orderID, eventDateID, ticketTypeID string, quantity int, unitPrice float64) (string, error) {
+// RefundOrder marks a paid order as REFUNDED. Returns false if the order was not PAID
+// (already refunded or never confirmed).
+func RefundOrder(db *sql.DB, orderID string) (bool, error) {
+	res, err := db.Exec(`UPDATE orders SET status = 'REFUNDED', refunded_at = datetime('now') WHERE id = ? AND status = 'PAID'`, orderID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// RefundUnfulfilledOrder marks as REFUNDED a pending order whose payment was returned because
+// it could not be fulfilled. Returns false if the order was not PENDING.
+func RefundUnfulfilledOrder(db *sql.DB, orderID string) (bool, error) {
+	res, err := db.Exec(`UPDATE orders SET status = 'REFUNDED', refunded_at = datetime('now') WHERE id = ? AND status = 'PENDING'`, orderID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// OrderIDByPagarmeChargeID returns the internal order ID for a Pagar.me charge.
+func OrderIDByPagarmeChargeID(db *sql.DB, chargeID string) (string, error) {
+	var id string
+	err := db.QueryRow(`SELECT id FROM orders WHERE pagarme_charge_id = ?`, chargeID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return id, err
+}
+
+// ReminderRecipientRow is a ticket holder of an event date with the number of tickets held.
+type ReminderRecipientRow struct {
+	UserID      string
+	EventDateID string
+	TicketCount int
+}
+
+// ReminderRecipients lists holders of paid, unused tickets for published events on the given date (YYYY-MM-DD).
+// Courtesies still held by the producer who issued them are left out.
+func ReminderRecipients(db *sql.DB, date string) ([]ReminderRecipientRow, error) {
+	rows, err := db.Query(`SELECT t.user_id, t.event_date_id, COUNT(*) FROM tickets t
+		JOIN orders o ON o.id = t.order_id
+		JOIN event_dates ed ON ed.id = t.event_date_id
+		JOIN events e ON e.id = t.event_id
+		WHERE ed.date = ? AND o.status = 'PAID' AND e.status = 'PUBLISHED' AND t.used = 0
+			AND t.id NOT IN (SELECT ticket_id FROM courtesy_tickets WHERE issued_by = t.user_id)
+		GROUP BY t.user_id, t.event_date_id`, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []ReminderRecipientRow
+	for rows.Next() {
+		var r ReminderRecipientRow
+		if err := rows.Scan(&r.UserID, &r.EventDateID, &r.TicketCount); err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+func CreateOrderItem(db Querier, orderID, eventDateID, ticketTypeID string, quantity int, unitPrice float64) (string, error) {
 	id := uuid.New().String()
 	_, err := db.Exec(`INSERT INTO order_items (id, order_id, event_date_id, ticket_type_id, quantity, unit_price) VALUES (?, ?, ?, ?, ?, ?)`,
 		id, orderID, eventDateID, ticketTypeID, quantity, unitPrice,
@@ -32,7 +104,7 @@ func CreateOrderItem(db *sql.DB, orderID, eventDateID, ticketTypeID string, quan
 	return id, err
 }
 
-func OrderItemsByOrderID(db *sql.DB, orderID string) ([]OrderItemRow, error) {
+func OrderItemsByOrderID(db Querier, orderID string) ([]OrderItemRow, error) {
 	rows, err := db.Query(`SELECT id, order_id, event_date_id, ticket_type_id, quantity, unit_price FROM order_items WHERE order_id = ?`, orderID)
 	if err != nil {
 		return nil, err
@@ -67,19 +139,19 @@ func CreateTicket(db *sql.DB, code, qrCode, orderID, orderItemID, userID, eventI
 }
 
 // CreateTicketWithID inserts a ticket with the given id and qr_code (e.g. signed payload). Used when QR is generated from ticket id.
-func CreateTicketWithID(db *sql.DB, id, code, qrCode, orderID, orderItemID, userID, eventID, eventDateID, ticketTypeID string) error {
+func CreateTicketWithID(db Querier, id, code, qrCode, orderID, orderItemID, userID, eventID, eventDateID, ticketTypeID string) error {
 	_, err := db.Exec(`INSERT INTO tickets (id, code, qr_code, order_id, order_item_id, user_id, event_id, event_date_id, ticket_type_id, used) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 0)`,
 		id, code, qrCode, orderID, orderItemID, userID, eventID, eventDateID, ticketTypeID,
 	)
 	return err
 }
 
-func IncrementTicketTypeSold(db *sql.DB, ticketTypeID string, n int) error {
+func IncrementTicketTypeSold(db Querier, ticketTypeID string, n int) error {
 	_, err := db.Exec(`UPDATE ticket_types SET sold_quantity = sold_quantity + ? WHERE id = ?`, n, ticketTypeID)
 	return err
 }
 
-func DecrementLotAvailable(db *sql.DB, lotID string, n int) error {
+func DecrementLotAvailable(db Querier, lotID string, n int) error {
 	_, err := db.Exec(`UPDATE lots SET available_quantity = available_quantity - ? WHERE id = ? AND available_quantity >= ?`, n, lotID, n)
 	return err
 }
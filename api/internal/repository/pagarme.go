@@ -27,6 +27,25 @@ func SetProducerPagarmeRecipientID(db *sql.DB, producerID, recipientID string) e
 	return err
 }
 
+// GetUserPagarmeRecipientID returns the Pagar.me recipient that receives the user's resale payouts.
+func GetUserPagarmeRecipientID(db *sql.DB, userID string) (string, error) {
+	var recipientID sql.NullString
+	err := db.QueryRow(`SELECT pagarme_recipient_id FROM users WHERE id = ?`, userID).Scan(&recipientID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return recipientID.String, nil
+}
+
+// SetUserPagarmeRecipientID saves the Pagar.me recipient for the user's resale payouts.
+func SetUserPagarmeRecipientID(db *sql.DB, userID, recipientID string) error {
+	_, err := db.Exec(`UPDATE users SET pagarme_recipient_id = ? WHERE id = ?`, recipientID, userID)
+	return err
+}
+
 // GetProducerOnboardingComplete returns whether the producer has completed payment onboarding.
 // Reuses the stripe_onboarding_complete column (shared concept).
 func GetProducerOnboardingComplete(db *sql.DB, producerID string) (bool, error) {
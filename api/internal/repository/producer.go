@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// Producer application states.
+const (
+	ProducerPendingReview = "PENDING_REVIEW"
+	ProducerApproved      = "APPROVED"
+	ProducerRejected      = "REJECTED"
+)
+
+type ProducerApplication struct {
+	CompanyName  string
+	CNPJ         string
+	ContactName  string
+	ContactEmail string
+	ContactPhone string
+}
+
+type ProducerDocumentRow struct {
+	ID        string
+	Kind      string
+	URL       string
+	CreatedAt string
+}
+
+// SubmitProducerApplication creates the producer profile for the user, or updates it on
+// re-submission, and puts it (back) into PENDING_REVIEW. Returns the producer ID.
+func SubmitProducerApplication(db *sql.DB, userID string, app ProducerApplication) (string, error) {
+	id, err := ProducerIDByUser(db, userID)
+	if err != nil {
+		return "", err
+	}
+	if id == "" {
+		id = uuid.New().String()
+		_, err = db.Exec(`INSERT INTO producers (id, user_id, company_name, cnpj, contact_name, contact_email, contact_phone, status, approved, submitted_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, 'PENDING_REVIEW', 0, datetime('now'))`,
+			id, userID, app.CompanyName, app.CNPJ, app.ContactName, app.ContactEmail, app.ContactPhone,
+		)
+		return id, err
+	}
+	_, err = db.Exec(`UPDATE producers SET company_name = ?, cnpj = ?, contact_name = ?, contact_email = ?, contact_phone = ?,
+		status = 'PENDING_REVIEW', approved = 0, rejection_reason = NULL, submitted_at = datetime('now'), reviewed_at = NULL, reviewed_by = NULL
+		WHERE id = ?`,
+		app.CompanyName, app.CNPJ, app.ContactName, app.ContactEmail, app.ContactPhone, id,
+	)
+	return id, err
+}
+
+// ReplaceProducerDocuments replaces the documents attached to a producer application.
+func ReplaceProducerDocuments(db *sql.DB, producerID string, docs []ProducerDocumentRow) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM producer_documents WHERE producer_id = ?`, producerID); err != nil {
+		return err
+	}
+	for _, d := range docs {
+		if _, err := tx.Exec(`INSERT INTO producer_documents (id, producer_id, kind, url) VALUES (?, ?, ?, ?)`,
+			uuid.New().String(), producerID, d.Kind, d.URL,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ProducerDocuments returns the documents attached to a producer application.
+func ProducerDocuments(db *sql.DB, producerID string) ([]ProducerDocumentRow, error) {
+	rows, err := db.Query(`SELECT id, kind, url, created_at FROM producer_documents WHERE producer_id = ? ORDER BY created_at`, producerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []ProducerDocumentRow
+	for rows.Next() {
+		var d ProducerDocumentRow
+		if err := rows.Scan(&d.ID, &d.Kind, &d.URL, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+// ReviewProducer sets the application state (APPROVED or REJECTED) and keeps the legacy
+// approved flag in sync. reason is stored only on rejection. Rejecting (also an approved
+// producer, i.e. a suspension) takes the producer's events off sale in the same transaction:
+// published ones are PAUSED and the ones waiting for moderation go back to DRAFT.
+func ReviewProducer(db *sql.DB, producerID, status, reason, reviewerID string) error {
+	approved := 0
+	var rr sql.NullString
+	if status == ProducerApproved {
+		approved = 1
+	} else if reason != "" {
+		rr = sql.NullString{String: reason, Valid: true}
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`UPDATE producers SET status = ?, approved = ?, rejection_reason = ?, reviewed_at = datetime('now'), reviewed_by = ? WHERE id = ?`,
+		status, approved, rr, reviewerID, producerID,
+	); err != nil {
+		return err
+	}
+	if status == ProducerRejected {
+		if _, err := tx.Exec(`UPDATE events SET status = CASE status WHEN 'PUBLISHED' THEN 'PAUSED' ELSE 'DRAFT' END, updated_at = datetime('now')
+			WHERE producer_id = ? AND status IN ('PUBLISHED', 'IN_REVIEW')`, producerID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListProducerIDs returns producer IDs, optionally filtered by application state and by a
+// search on the company, CNPJ, contact or user name and email, for the admin back office.
+// Pending applications come oldest first (review queue), the others newest first.
+func ListProducerIDs(db *sql.DB, status, search string, limit, offset int) ([]string, error) {
+	q := `SELECT p.id FROM producers p JOIN users u ON u.id = p.user_id WHERE 1 = 1`
+	args := []interface{}{}
+	if status != "" {
+		q += ` AND p.status = ?`
+		args = append(args, status)
+	}
+	if search != "" {
+		like := "%" + search + "%"
+		q += ` AND (p.company_name LIKE ? OR p.cnpj LIKE ? OR p.contact_name LIKE ? OR p.contact_email LIKE ? OR u.name LIKE ? OR u.email LIKE ? OR p.id = ?)`
+		args = append(args, like, like, like, like, like, like, search)
+	}
+	if status == ProducerPendingReview {
+		q += ` ORDER BY p.submitted_at ASC`
+	} else {
+		q += ` ORDER BY p.created_at DESC`
+	}
+	q += ` LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,45 @@
+package repository_test
+
+import (
+	"testing"
+
+	"afterzin/api/internal/repository"
+)
+
+func TestReviewProducerRejectTakesEventsOffSale(t *testing.T) {
+	f := newFixture(t)
+	inReview, err := repository.CreateEvent(f.DB, f.ProducerID, "Em análise", "descrição", "MUSIC", "capa.jpg", "São Paulo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.UpdateEventStatus(f.DB, inReview, "IN_REVIEW"); err != nil {
+		t.Fatal(err)
+	}
+	ended, err := repository.CreateEvent(f.DB, f.ProducerID, "Encerrado", "descrição", "MUSIC", "capa.jpg", "São Paulo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.UpdateEventStatus(f.DB, ended, "ENDED"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repository.ReviewProducer(f.DB, f.ProducerID, repository.ProducerRejected, "documentos inválidos", f.User(t)); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]string{f.EventID: "PAUSED", inReview: "DRAFT", ended: "ENDED"} {
+		ev, err := repository.EventByID(f.DB, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev.Status != want {
+			t.Errorf("event %s: status %s, want %s", ev.Title, ev.Status, want)
+		}
+	}
+	p, err := repository.ProducerByID(f.DB, f.ProducerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != repository.ProducerRejected {
+		t.Errorf("producer status = %s, want REJECTED", p.Status)
+	}
+}
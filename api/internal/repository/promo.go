@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// Promo code discount types.
+const (
+	PromoPercent = "PERCENT"
+	PromoFixed   = "FIXED"
+)
+
+type PromoCodeRow struct {
+	ID             string
+	EventID        string
+	TicketTypeID   sql.NullString // NULL applies to every ticket type of the event
+	Code           string
+	DiscountType   string
+	DiscountValue  float64
+	MaxUses        sql.NullInt64
+	MaxUsesPerUser sql.NullInt64
+	ValidFrom      sql.NullString // UTC, "2006-01-02 15:04:05"
+	ValidUntil     sql.NullString
+	Active         bool
+	CreatedAt      string
+}
+
+const promoCodeColumns = `id, event_id, ticket_type_id, code, discount_type, discount_value, max_uses, max_uses_per_user, valid_from, valid_until, active, created_at`
+
+func scanPromoCodeRow(row interface {
+	Scan(dest ...interface{}) error
+}) (*PromoCodeRow, error) {
+	var p PromoCodeRow
+	err := row.Scan(&p.ID, &p.EventID, &p.TicketTypeID, &p.Code, &p.DiscountType, &p.DiscountValue, &p.MaxUses, &p.MaxUsesPerUser,
+		&p.ValidFrom, &p.ValidUntil, &p.Active, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreatePromoCode inserts the promo code. Fails on the unique index when the event already
+// has the code.
+func CreatePromoCode(db *sql.DB, p *PromoCodeRow) (string, error) {
+	id := uuid.New().String()
+	_, err := db.Exec(`INSERT INTO promo_codes (id, event_id, ticket_type_id, code, discount_type, discount_value, max_uses, max_uses_per_user, valid_from, valid_until, active)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, p.EventID, p.TicketTypeID, p.Code, p.DiscountType, p.DiscountValue, p.MaxUses, p.MaxUsesPerUser, p.ValidFrom, p.ValidUntil, p.Active,
+	)
+	return id, err
+}
+
+// UpdatePromoCode replaces the rules of the promo code (the event is kept).
+func UpdatePromoCode(db *sql.DB, p *PromoCodeRow) error {
+	_, err := db.Exec(`UPDATE promo_codes SET ticket_type_id = ?, code = ?, discount_type = ?, discount_value = ?, max_uses = ?, max_uses_per_user = ?,
+		valid_from = ?, valid_until = ?, active = ? WHERE id = ?`,
+		p.TicketTypeID, p.Code, p.DiscountType, p.DiscountValue, p.MaxUses, p.MaxUsesPerUser, p.ValidFrom, p.ValidUntil, p.Active, p.ID,
+	)
+	return err
+}
+
+func PromoCodeByID(db *sql.DB, id string) (*PromoCodeRow, error) {
+	p, err := scanPromoCodeRow(db.QueryRow(`SELECT `+promoCodeColumns+` FROM promo_codes WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// PromoCodeByEventAndCode finds the event's promo code; code must already be uppercased.
+func PromoCodeByEventAndCode(db *sql.DB, eventID, code string) (*PromoCodeRow, error) {
+	p, err := scanPromoCodeRow(db.QueryRow(`SELECT `+promoCodeColumns+` FROM promo_codes WHERE event_id = ? AND code = ?`, eventID, code))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// PromoCodesByEvent lists the event's promo codes, most recent first.
+func PromoCodesByEvent(db *sql.DB, eventID string) ([]*PromoCodeRow, error) {
+	rows, err := db.Query(`SELECT `+promoCodeColumns+` FROM promo_codes WHERE event_id = ? ORDER BY created_at DESC`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*PromoCodeRow
+	for rows.Next() {
+		p, err := scanPromoCodeRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+// CountPromoCodeRedemptions counts the paid orders that used the promo code, only those of
+// userID when non-empty. excludeOrderID leaves out the order being checked.
+func CountPromoCodeRedemptions(db *sql.DB, promoCodeID, userID, excludeOrderID string) (int, error) {
+	q := `SELECT COUNT(*) FROM promo_code_redemptions r JOIN orders o ON o.id = r.order_id
+		WHERE r.promo_code_id = ? AND o.status = 'PAID' AND r.order_id != ?`
+	args := []interface{}{promoCodeID, excludeOrderID}
+	if userID != "" {
+		q += ` AND r.user_id = ?`
+		args = append(args, userID)
+	}
+	var n int
+	err := db.QueryRow(q, args...).Scan(&n)
+	return n, err
+}
+
+// PromoCodeUsage returns the number of paid orders with the promo code and the discount they got.
+func PromoCodeUsage(db *sql.DB, promoCodeID string) (uses int, discountTotal float64, err error) {
+	err = db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(r.discount_total), 0) FROM promo_code_redemptions r JOIN orders o ON o.id = r.order_id
+		WHERE r.promo_code_id = ? AND o.status = 'PAID'`, promoCodeID,
+	).Scan(&uses, &discountTotal)
+	return
+}
+
+type PromoCodeRedemptionRow struct {
+	ID            string
+	PromoCodeID   string
+	OrderID       string
+	UserID        string
+	OrderStatus   string
+	DiscountTotal float64
+	CreatedAt     string
+}
+
+// PromoCodeRedemptions lists the paid (or since refunded) orders that used the promo code, most recent first.
+func PromoCodeRedemptions(db *sql.DB, promoCodeID string) ([]*PromoCodeRedemptionRow, error) {
+	rows, err := db.Query(`SELECT r.id, r.promo_code_id, r.order_id, r.user_id, o.status, r.discount_total, r.created_at
+		FROM promo_code_redemptions r JOIN orders o ON o.id = r.order_id WHERE r.promo_code_id = ? ORDER BY r.created_at DESC`, promoCodeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*PromoCodeRedemptionRow
+	for rows.Next() {
+		var r PromoCodeRedemptionRow
+		if err := rows.Scan(&r.ID, &r.PromoCodeID, &r.OrderID, &r.UserID, &r.OrderStatus, &r.DiscountTotal, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, &r)
+	}
+	return list, rows.Err()
+}
+
+// OrderPromoCodeID returns the promo code applied to the order ("" when none).
+func OrderPromoCodeID(db *sql.DB, orderID string) (string, error) {
+	var id sql.NullString
+	err := db.QueryRow(`SELECT promo_code_id FROM orders WHERE id = ?`, orderID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return id.String, err
+}
+
+// OrderItemDiscounts returns the per-ticket discount of each item of the order, by item ID.
+// The original price of an item is its unit price plus the discount.
+func OrderItemDiscounts(db *sql.DB, orderID string) (map[string]float64, error) {
+	rows, err := db.Query(`SELECT id, discount FROM order_items WHERE order_id = ?`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	discounts := map[string]float64{}
+	for rows.Next() {
+		var id string
+		var d float64
+		if err := rows.Scan(&id, &d); err != nil {
+			return nil, err
+		}
+		discounts[id] = d
+	}
+	return discounts, rows.Err()
+}
+
+// ApplyOrderDiscount sets the per-ticket discount of each order item (items missing from
+// discounts go back to the original price), recomputes the order total and sets promoCodeID
+// as the order's promo code; an empty promoCodeID removes it. The redemption is only recorded
+// when the order is paid (RedeemOrderPromoCode).
+// Returns false (and changes nothing) when the order is no longer pending or already has a
+// Pagar.me charge.
+func ApplyOrderDiscount(db *sql.DB, orderID, promoCodeID string, discounts map[string]float64) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	var n int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM orders WHERE id = ? AND status = 'PENDING' AND pagarme_order_id IS NULL`, orderID).Scan(&n)
+	if err != nil {
+		return false, err
+	}
+	if n != 1 {
+		return false, nil
+	}
+	if _, err := tx.Exec(`UPDATE order_items SET unit_price = unit_price + discount, discount = 0 WHERE order_id = ?`, orderID); err != nil {
+		return false, err
+	}
+	for itemID, d := range discounts {
+		if _, err := tx.Exec(`UPDATE order_items SET unit_price = unit_price - ?, discount = ? WHERE id = ? AND order_id = ?`, d, d, itemID, orderID); err != nil {
+			return false, err
+		}
+	}
+	if _, err := tx.Exec(`UPDATE orders SET total = (SELECT COALESCE(SUM(unit_price * quantity), 0) FROM order_items WHERE order_id = ?), promo_code_id = ? WHERE id = ?`,
+		orderID, nullIfEmpty(promoCodeID), orderID,
+	); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// RedeemOrderPromoCode records the redemption of the promo code applied to the order, with the
+// order's buyer and discount, as part of the payment confirmation. The insert itself enforces
+// the code's maxUses and maxUsesPerUser against the paid orders, so that concurrent
+// confirmations cannot exceed them: returns false (and records nothing) when a cap is reached.
+// Orders without a promo code return true.
+func RedeemOrderPromoCode(db Querier, orderID string) (bool, error) {
+	var promoCodeID sql.NullString
+	if err := db.QueryRow(`SELECT promo_code_id FROM orders WHERE id = ?`, orderID).Scan(&promoCodeID); err != nil {
+		return false, err
+	}
+	if !promoCodeID.Valid {
+		return true, nil
+	}
+	res, err := db.Exec(`INSERT INTO promo_code_redemptions (id, promo_code_id, order_id, user_id, discount_total)
+		SELECT ?1, p.id, o.id, o.user_id, (SELECT COALESCE(SUM(discount * quantity), 0) FROM order_items WHERE order_id = o.id)
+		FROM orders o JOIN promo_codes p ON p.id = o.promo_code_id
+		WHERE o.id = ?2
+			AND (p.max_uses IS NULL OR p.max_uses > (SELECT COUNT(*) FROM promo_code_redemptions r JOIN orders ro ON ro.id = r.order_id
+				WHERE r.promo_code_id = p.id AND ro.status = 'PAID'))
+			AND (p.max_uses_per_user IS NULL OR p.max_uses_per_user > (SELECT COUNT(*) FROM promo_code_redemptions r JOIN orders ro ON ro.id = r.order_id
+				WHERE r.promo_code_id = p.id AND ro.status = 'PAID' AND r.user_id = o.user_id))`,
+		uuid.New().String(), orderID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"database/sql"
+)
+
+// QRSigningKeyRow is a key of the ticket QR keyring (qr_signing_keys). PublicKey is hex encoded;
+// PrivateKey is the Ed25519 seed encrypted by the keyring (hex for keys not yet sealed).
+type QRSigningKeyRow struct {
+	ID         string
+	PublicKey  string
+	PrivateKey string
+	CreatedAt  string
+	RetiredAt  sql.NullString
+}
+
+func scanQRSigningKeyRow(row interface {
+	Scan(dest ...interface{}) error
+}) (*QRSigningKeyRow, error) {
+	var k QRSigningKeyRow
+	if err := row.Scan(&k.ID, &k.PublicKey, &k.PrivateKey, &k.CreatedAt, &k.RetiredAt); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// QRSigningKeys lists the keys, newest first, including the retired ones.
+func QRSigningKeys(db *sql.DB) ([]*QRSigningKeyRow, error) {
+	rows, err := db.Query(`SELECT id, public_key, private_key, created_at, retired_at FROM qr_signing_keys ORDER BY created_at DESC, rowid DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*QRSigningKeyRow
+	for rows.Next() {
+		k, err := scanQRSigningKeyRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, k)
+	}
+	return list, rows.Err()
+}
+
+func CreateQRSigningKey(db *sql.DB, id, publicKey, privateKey string) error {
+	_, err := db.Exec(`INSERT INTO qr_signing_keys (id, public_key, private_key) VALUES (?, ?, ?)`, id, publicKey, privateKey)
+	return err
+}
+
+// SealQRSigningKey replaces the stored private seed (plain, or sealed with a previous secret)
+// with its encrypted form, unless it changed in the meantime.
+func SealQRSigningKey(db Querier, id, plain, sealed string) error {
+	_, err := db.Exec(`UPDATE qr_signing_keys SET private_key = ? WHERE id = ? AND private_key = ?`, sealed, id, plain)
+	return err
+}
+
+// RetireQRSigningKey stops accepting the key. Returns false when it is unknown or already retired.
+func RetireQRSigningKey(db *sql.DB, id string) (bool, error) {
+	res, err := db.Exec(`UPDATE qr_signing_keys SET retired_at = datetime('now') WHERE id = ? AND retired_at IS NULL`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// QRKeyTicket is a ticket whose QR payload is signed with a given key.
+type QRKeyTicket struct {
+	ID      string
+	EventID string
+	QRCode  string
+}
+
+// TicketsByQRKey lists the tickets whose current V3 payload is signed with the key.
+func TicketsByQRKey(db *sql.DB, keyID string) ([]QRKeyTicket, error) {
+	rows, err := db.Query(`SELECT id, event_id, qr_code FROM tickets WHERE substr(qr_code, 1, ?) = ?`, len(keyID)+4, "V3:"+keyID+":")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []QRKeyTicket
+	for rows.Next() {
+		var t QRKeyTicket
+		if err := rows.Scan(&t.ID, &t.EventID, &t.QRCode); err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+// ResignTicketQR replaces the ticket's QR payload, signed again with another key, keeping it valid.
+// Unlike a transfer, the previous payload is not flagged as reissued: it just no longer verifies.
+func ResignTicketQR(db *sql.DB, ticketID, previousQR, qrCode string) (bool, error) {
+	res, err := db.Exec(`UPDATE tickets SET qr_code = ? WHERE id = ? AND qr_code = ?`, qrCode, ticketID, previousQR)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
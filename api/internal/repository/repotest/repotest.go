@@ -0,0 +1,110 @@
+// Package repotest builds test databases for the packages that test against the repository: a
+// migrated SQLite file in a temporary directory and a fixture with a published event.
+package repotest
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"afterzin/api/internal/db"
+	"afterzin/api/internal/repository"
+)
+
+// NewDB opens a migrated database in a temporary directory, closed when the test ends.
+func NewDB(t *testing.T) *sql.DB {
+	t.Helper()
+	sqlite, err := db.OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlite.Close() })
+	if err := db.Migrate(sqlite); err != nil {
+		t.Fatal(err)
+	}
+	return sqlite
+}
+
+// Fixture is a published event of an approved producer with one date (a month from now), lot
+// and ticket type. The producer's owner is the first user.
+type Fixture struct {
+	DB           *sql.DB
+	ProducerID   string
+	EventID      string
+	DateID       string
+	LotID        string
+	TicketTypeID string
+	users        int
+}
+
+// Price is the price of the fixture's ticket type.
+const Price = 100
+
+func NewFixture(t *testing.T) *Fixture {
+	t.Helper()
+	f := &Fixture{DB: NewDB(t)}
+	owner := f.User(t)
+	var err error
+	if f.ProducerID, err = repository.SubmitProducerApplication(f.DB, owner, repository.ProducerApplication{CompanyName: "Produtora"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.ReviewProducer(f.DB, f.ProducerID, repository.ProducerApproved, "", owner); err != nil {
+		t.Fatal(err)
+	}
+	if f.EventID, err = repository.CreateEvent(f.DB, f.ProducerID, "Festival", "descrição", "MUSIC", "capa.jpg", "São Paulo", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.UpdateEventStatus(f.DB, f.EventID, "PUBLISHED"); err != nil {
+		t.Fatal(err)
+	}
+	if f.DateID, err = repository.CreateEventDate(f.DB, f.EventID, time.Now().AddDate(0, 1, 0).Format("2006-01-02"), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if f.LotID, err = repository.CreateLot(f.DB, f.DateID, "Lote 1", "2000-01-01T00:00:00Z", "2100-01-01T00:00:00Z", 100); err != nil {
+		t.Fatal(err)
+	}
+	if f.TicketTypeID, err = repository.CreateTicketType(f.DB, f.LotID, "Pista", nil, Price, "GENERAL", 100, false, ""); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// User creates a user with a unique e-mail and CPF (000.000.000-NN, NN counting from 01).
+func (f *Fixture) User(t *testing.T) string {
+	t.Helper()
+	f.users++
+	id, err := repository.CreateUser(f.DB, "Usuário", fmt.Sprintf("u%d@email.com", f.users), "hash", fmt.Sprintf("000.000.000-%02d", f.users), "1990-01-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// PendingOrder creates a pending order of quantity tickets of the fixture's ticket type.
+func (f *Fixture) PendingOrder(t *testing.T, userID string, quantity int) (orderID, itemID string) {
+	t.Helper()
+	orderID, err := repository.CreateOrder(f.DB, userID, float64(quantity*Price), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if itemID, err = repository.CreateOrderItem(f.DB, orderID, f.DateID, f.TicketTypeID, quantity, Price); err != nil {
+		t.Fatal(err)
+	}
+	return orderID, itemID
+}
+
+// PaidTicket issues a ticket of a paid order to the user.
+func (f *Fixture) PaidTicket(t *testing.T, userID string) (orderID, ticketID string) {
+	t.Helper()
+	orderID, itemID := f.PendingOrder(t, userID, 1)
+	ticketID, err := repository.CreateTicket(f.DB, repository.GenerateTicketCode(), repository.GenerateQRCode(), orderID, itemID, userID, f.EventID, f.DateID, f.TicketTypeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.ConfirmOrder(f.DB, orderID); err != nil {
+		t.Fatal(err)
+	}
+	return orderID, ticketID
+}
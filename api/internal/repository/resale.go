@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Resale listing statuses.
+const (
+	ResaleActive    = "ACTIVE"
+	ResaleSold      = "SOLD"
+	ResaleCancelled = "CANCELLED"
+)
+
+type ResaleListingRow struct {
+	ID            string
+	TicketID      string
+	SellerID      string
+	EventID       string
+	Price         float64
+	FaceValue     float64
+	SellerAmount  float64
+	RoyaltyAmount float64
+	PlatformFee   float64
+	Status        string
+	OrderID       sql.NullString
+	ReservedUntil sql.NullString
+	CreatedAt     string
+	SoldAt        sql.NullString
+}
+
+const resaleListingColumns = `id, ticket_id, seller_id, event_id, price, face_value, seller_amount, royalty_amount, platform_fee, status, order_id, reserved_until, created_at, sold_at`
+
+// resaleAvailable matches active listings not held by an unexpired buyer reservation.
+const resaleAvailable = `status = 'ACTIVE' AND (reserved_until IS NULL OR reserved_until < datetime('now'))`
+
+func scanResaleListingRow(row interface {
+	Scan(dest ...interface{}) error
+}) (*ResaleListingRow, error) {
+	var l ResaleListingRow
+	err := row.Scan(&l.ID, &l.TicketID, &l.SellerID, &l.EventID, &l.Price, &l.FaceValue, &l.SellerAmount, &l.RoyaltyAmount, &l.PlatformFee,
+		&l.Status, &l.OrderID, &l.ReservedUntil, &l.CreatedAt, &l.SoldAt)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func queryResaleListings(db *sql.DB, q string, args ...interface{}) ([]*ResaleListingRow, error) {
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*ResaleListingRow
+	for rows.Next() {
+		l, err := scanResaleListingRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, l)
+	}
+	return list, rows.Err()
+}
+
+// CreateResaleListing lists a ticket for resale with the split amounts already computed.
+// Fails on the unique index when the ticket already has an active listing.
+func CreateResaleListing(db *sql.DB, l ResaleListingRow) (string, error) {
+	id := uuid.New().String()
+	_, err := db.Exec(`INSERT INTO resale_listings (id, ticket_id, seller_id, event_id, price, face_value, seller_amount, royalty_amount, platform_fee)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, l.TicketID, l.SellerID, l.EventID, l.Price, l.FaceValue, l.SellerAmount, l.RoyaltyAmount, l.PlatformFee,
+	)
+	return id, err
+}
+
+func ResaleListingByID(db *sql.DB, id string) (*ResaleListingRow, error) {
+	l, err := scanResaleListingRow(db.QueryRow(`SELECT `+resaleListingColumns+` FROM resale_listings WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return l, err
+}
+
+// ResaleListingByOrder returns the listing reserved or bought by the order; nil for regular orders.
+func ResaleListingByOrder(db *sql.DB, orderID string) (*ResaleListingRow, error) {
+	l, err := scanResaleListingRow(db.QueryRow(`SELECT `+resaleListingColumns+` FROM resale_listings WHERE order_id = ?`, orderID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return l, err
+}
+
+// TicketFaceValue returns what the ticket's purchase paid for it (order item unit price, after
+// promo discounts). A ticket bought on the resale keeps the face value of that listing, so the
+// markup cap does not compound across resales.
+func TicketFaceValue(db *sql.DB, ticketID string) (float64, error) {
+	var v float64
+	err := db.QueryRow(`SELECT COALESCE((SELECT face_value FROM resale_listings WHERE order_id = t.order_id AND status = 'SOLD'), oi.unit_price)
+		FROM tickets t JOIN order_items oi ON oi.id = t.order_item_id WHERE t.id = ?`, ticketID).Scan(&v)
+	return v, err
+}
+
+// ActiveResaleListingByTicket returns the ticket's active listing, if any.
+func ActiveResaleListingByTicket(db *sql.DB, ticketID string) (*ResaleListingRow, error) {
+	l, err := scanResaleListingRow(db.QueryRow(`SELECT `+resaleListingColumns+` FROM resale_listings WHERE ticket_id = ? AND status = 'ACTIVE'`, ticketID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return l, err
+}
+
+// AvailableResaleListings lists the event's listings open for purchase, cheapest first.
+func AvailableResaleListings(db *sql.DB, eventID string) ([]*ResaleListingRow, error) {
+	return queryResaleListings(db, `SELECT `+resaleListingColumns+` FROM resale_listings WHERE event_id = ? AND `+resaleAvailable+` ORDER BY price, created_at`, eventID)
+}
+
+// ResaleListingsBySeller lists all listings of the seller, most recent first.
+func ResaleListingsBySeller(db *sql.DB, sellerID string) ([]*ResaleListingRow, error) {
+	return queryResaleListings(db, `SELECT `+resaleListingColumns+` FROM resale_listings WHERE seller_id = ? ORDER BY created_at DESC`, sellerID)
+}
+
+// ReserveResaleListing holds an available listing for the buyer's order until ttl expires.
+// Returns false when the listing is sold, cancelled or held by another buyer.
+func ReserveResaleListing(db *sql.DB, listingID, orderID string, ttl time.Duration) (bool, error) {
+	until := time.Now().Add(ttl).UTC().Format("2006-01-02 15:04:05")
+	res, err := db.Exec(`UPDATE resale_listings SET order_id = ?, reserved_until = ? WHERE id = ? AND `+resaleAvailable, orderID, until, listingID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// ExtendResaleReservation pushes the order's hold on the listing to ttl from now, so it covers
+// the payment window. Returns false when the order no longer holds the listing (reservation
+// expired or taken over, listing sold or cancelled).
+func ExtendResaleReservation(db *sql.DB, listingID, orderID string, ttl time.Duration) (bool, error) {
+	until := time.Now().Add(ttl).UTC().Format("2006-01-02 15:04:05")
+	res, err := db.Exec(`UPDATE resale_listings SET reserved_until = ?
+		WHERE id = ? AND order_id = ? AND status = 'ACTIVE' AND reserved_until >= datetime('now')`, until, listingID, orderID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// CancelResaleListing withdraws an active listing that is not reserved by a buyer.
+func CancelResaleListing(db *sql.DB, listingID string) (bool, error) {
+	res, err := db.Exec(`UPDATE resale_listings SET status = 'CANCELLED' WHERE id = ? AND `+resaleAvailable, listingID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// CancelResaleListingsByTicket withdraws the ticket's active listing (ticket used at the gate).
+func CancelResaleListingsByTicket(db *sql.DB, ticketID string) error {
+	_, err := db.Exec(`UPDATE resale_listings SET status = 'CANCELLED' WHERE ticket_id = ? AND status = 'ACTIVE'`, ticketID)
+	return err
+}
+
+// CompleteResale settles a paid resale order in one transaction: the ticket moves to the
+// buyer (and to the resale order, so refunds and emails follow the new purchase) with a new
+// QR payload, the listing becomes SOLD and the order PAID. Returns ok=false and changes
+// nothing when the listing is no longer held by this order or the ticket was used or moved.
+func CompleteResale(db *sql.DB, orderID, buyerID, newQRCode string) (l *ResaleListingRow, previousQRCode string, ok bool, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, "", false, err
+	}
+	defer tx.Rollback()
+	l, err = scanResaleListingRow(tx.QueryRow(`SELECT `+resaleListingColumns+` FROM resale_listings WHERE order_id = ? AND status = 'ACTIVE'`, orderID))
+	if err == sql.ErrNoRows {
+		return nil, "", false, nil
+	}
+	if err != nil {
+		return nil, "", false, err
+	}
+	var orderItemID string
+	if err := tx.QueryRow(`SELECT id FROM order_items WHERE order_id = ? LIMIT 1`, orderID).Scan(&orderItemID); err != nil {
+		return nil, "", false, err
+	}
+	if err := tx.QueryRow(`SELECT qr_code FROM tickets WHERE id = ?`, l.TicketID).Scan(&previousQRCode); err != nil {
+		return nil, "", false, err
+	}
+	res, err := tx.Exec(`UPDATE tickets SET user_id = ?, order_id = ?, order_item_id = ?, qr_code = ?, qr_reissued_at = datetime('now'), `+clearTicketHolder+`
+		WHERE id = ? AND user_id = ? AND used = 0`,
+		buyerID, orderID, orderItemID, newQRCode, l.TicketID, l.SellerID,
+	)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		return nil, "", false, nil
+	}
+	if _, err := tx.Exec(`UPDATE resale_listings SET status = 'SOLD', sold_at = datetime('now'), reserved_until = NULL WHERE id = ?`, l.ID); err != nil {
+		return nil, "", false, err
+	}
+	if _, err := tx.Exec(`UPDATE orders SET status = 'PAID', paid_at = datetime('now') WHERE id = ? AND status = 'PENDING'`, orderID); err != nil {
+		return nil, "", false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, "", false, err
+	}
+	l.Status = ResaleSold
+	return l, previousQRCode, true, nil
+}
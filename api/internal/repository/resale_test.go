@@ -0,0 +1,118 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"afterzin/api/internal/repository"
+	"afterzin/api/internal/repository/repotest"
+)
+
+// listForResale lists the seller's ticket at price, with the fixture's price as face value.
+func (f *fixture) listForResale(t *testing.T, ticketID, sellerID string, price float64) string {
+	t.Helper()
+	id, err := repository.CreateResaleListing(f.DB, repository.ResaleListingRow{
+		TicketID: ticketID, SellerID: sellerID, EventID: f.EventID,
+		Price: price, FaceValue: repotest.Price, SellerAmount: price * 0.9, RoyaltyAmount: price * 0.05, PlatformFee: price * 0.05,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// resaleOrder creates the buyer's pending order for the listing and reserves it.
+func (f *fixture) resaleOrder(t *testing.T, listingID, buyerID string, price float64) string {
+	t.Helper()
+	orderID, err := repository.CreateOrder(f.DB, buyerID, price, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repository.CreateOrderItem(f.DB, orderID, f.DateID, f.TicketTypeID, 1, price); err != nil {
+		t.Fatal(err)
+	}
+	ok, err := repository.ReserveResaleListing(f.DB, listingID, orderID, 15*time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("reserve listing: ok=%v err=%v", ok, err)
+	}
+	return orderID
+}
+
+func TestCompleteResale(t *testing.T) {
+	f := newFixture(t)
+	seller, buyer := f.User(t), f.User(t)
+	_, ticketID := f.PaidTicket(t, seller)
+	listingID := f.listForResale(t, ticketID, seller, 120)
+	orderID := f.resaleOrder(t, listingID, buyer, 120)
+	before, _ := repository.TicketByID(f.DB, ticketID)
+
+	l, previousQR, ok, err := repository.CompleteResale(f.DB, orderID, buyer, "novo-qr")
+	if err != nil || !ok {
+		t.Fatalf("CompleteResale: ok=%v err=%v", ok, err)
+	}
+	if l.ID != listingID || l.Status != repository.ResaleSold || previousQR != before.QRCode {
+		t.Errorf("listing %s status %s previous QR %q", l.ID, l.Status, previousQR)
+	}
+	tk, _ := repository.TicketByID(f.DB, ticketID)
+	if tk.UserID != buyer || tk.OrderID != orderID || tk.QRCode != "novo-qr" {
+		t.Errorf("ticket not moved to the buyer: user %s order %s qr %s", tk.UserID, tk.OrderID, tk.QRCode)
+	}
+	if _, status, _, _ := repository.OrderByID(f.DB, orderID); status != "PAID" {
+		t.Errorf("order status = %s, want PAID", status)
+	}
+	// The face value stays the original purchase's, so the markup cap does not compound.
+	if v, err := repository.TicketFaceValue(f.DB, ticketID); err != nil || v != repotest.Price {
+		t.Errorf("TicketFaceValue = %v, %v; want %v", v, err, repotest.Price)
+	}
+
+	if _, _, ok, err := repository.CompleteResale(f.DB, orderID, buyer, "outro-qr"); err != nil || ok {
+		t.Errorf("second CompleteResale: ok=%v err=%v, want not ok", ok, err)
+	}
+}
+
+func TestCompleteResaleUsedTicket(t *testing.T) {
+	f := newFixture(t)
+	seller, buyer := f.User(t), f.User(t)
+	_, ticketID := f.PaidTicket(t, seller)
+	listingID := f.listForResale(t, ticketID, seller, 100)
+	orderID := f.resaleOrder(t, listingID, buyer, 100)
+	if err := repository.MarkTicketUsed(f.DB, ticketID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, ok, err := repository.CompleteResale(f.DB, orderID, buyer, "novo-qr"); err != nil || ok {
+		t.Fatalf("CompleteResale of a used ticket: ok=%v err=%v, want not ok", ok, err)
+	}
+	tk, _ := repository.TicketByID(f.DB, ticketID)
+	if tk.UserID != seller {
+		t.Errorf("ticket moved to %s", tk.UserID)
+	}
+	if l, _ := repository.ResaleListingByID(f.DB, listingID); l.Status != repository.ResaleActive {
+		t.Errorf("listing status = %s, want ACTIVE", l.Status)
+	}
+	if _, status, _, _ := repository.OrderByID(f.DB, orderID); status != "PENDING" {
+		t.Errorf("order status = %s, want PENDING", status)
+	}
+}
+
+func TestReserveResaleListingHeld(t *testing.T) {
+	f := newFixture(t)
+	seller := f.User(t)
+	_, ticketID := f.PaidTicket(t, seller)
+	listingID := f.listForResale(t, ticketID, seller, 100)
+	f.resaleOrder(t, listingID, f.User(t), 100)
+
+	other, _ := repository.CreateOrder(f.DB, f.User(t), 100, time.Hour)
+	if ok, err := repository.ReserveResaleListing(f.DB, listingID, other, 15*time.Minute); err != nil || ok {
+		t.Errorf("reserve a held listing: ok=%v err=%v, want not ok", ok, err)
+	}
+}
+
+func TestTicketFaceValue(t *testing.T) {
+	f := newFixture(t)
+	user := f.User(t)
+	_, ticketID := f.PaidTicket(t, user)
+	if v, err := repository.TicketFaceValue(f.DB, ticketID); err != nil || v != repotest.Price {
+		t.Errorf("TicketFaceValue = %v, %v; want %v", v, err, repotest.Price)
+	}
+}
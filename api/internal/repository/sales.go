@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"database/sql"
+)
+
+// saleOrder restricts orders o to primary sales: courtesies and official resale purchases
+// (orders of a resale listing) are not the producer's sales.
+const saleOrder = `o.kind = 'SALE' AND NOT EXISTS (SELECT 1 FROM resale_listings rl WHERE rl.order_id = o.id)`
+
+// RefreshSalesRollup brings the daily sales rollup (sales_daily and sales_daily_orders) up to
+// date: every (event, day) with an order created, paid or refunded since the last refresh is
+// recomputed from orders and order_items. The first refresh builds it from scratch. Days are
+// Brasília days, like the rest of the date handling.
+func RefreshSalesRollup(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var since sql.NullString
+	var now string
+	if err := tx.QueryRow(`SELECT refreshed_at, datetime('now') FROM sales_rollup_state WHERE id = 1`).Scan(&since, &now); err != nil {
+		return err
+	}
+	// A minute of overlap covers orders written while the last refresh ran; recomputing is idempotent.
+	rows, err := tx.Query(`WITH touched AS (
+			SELECT o.id, o.created_at, o.paid_at FROM orders o
+			WHERE `+saleOrder+` AND (?1 IS NULL OR o.created_at >= datetime(?1, '-1 minute')
+				OR o.paid_at >= datetime(?1, '-1 minute') OR o.refunded_at >= datetime(?1, '-1 minute'))
+		)
+		SELECT DISTINCT ed.event_id, date(x.at, '-3 hours') FROM (
+			SELECT id, created_at AS at FROM touched UNION ALL SELECT id, paid_at FROM touched WHERE paid_at IS NOT NULL
+		) x
+		JOIN order_items oi ON oi.order_id = x.id
+		JOIN event_dates ed ON ed.id = oi.event_date_id`, since)
+	if err != nil {
+		return err
+	}
+	type eventDay struct{ eventID, day string }
+	var touched []eventDay
+	for rows.Next() {
+		var d eventDay
+		if err := rows.Scan(&d.eventID, &d.day); err != nil {
+			rows.Close()
+			return err
+		}
+		touched = append(touched, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, d := range touched {
+		if err := rollupSalesDay(tx, d.eventID, d.day); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`UPDATE sales_rollup_state SET refreshed_at = ? WHERE id = 1`, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// rollupSalesDay recomputes the rollup rows of one event on one Brasília day.
+func rollupSalesDay(tx *sql.Tx, eventID, day string) error {
+	// Bounds of the Brasília day in UTC, so the order timestamps' indexes can be used.
+	const dayStart, dayEnd = `datetime(?2, '+3 hours')`, `datetime(?2, '+1 day', '+3 hours')`
+	if _, err := tx.Exec(`DELETE FROM sales_daily WHERE event_id = ? AND day = ?`, eventID, day); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO sales_daily (event_id, day, ticket_type_id, event_date_id, lot_id, tickets, revenue)
+		SELECT ?1, ?2, oi.ticket_type_id, oi.event_date_id, tt.lot_id, SUM(oi.quantity), SUM(oi.quantity * oi.unit_price)
+		FROM orders o
+		JOIN order_items oi ON oi.order_id = o.id
+		JOIN event_dates ed ON ed.id = oi.event_date_id
+		JOIN ticket_types tt ON tt.id = oi.ticket_type_id
+		WHERE ed.event_id = ?1 AND o.status = 'PAID' AND `+saleOrder+`
+			AND o.paid_at >= `+dayStart+` AND o.paid_at < `+dayEnd+`
+		GROUP BY oi.ticket_type_id`, eventID, day); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM sales_daily_orders WHERE event_id = ? AND day = ?`, eventID, day); err != nil {
+		return err
+	}
+	eventOrders := `SELECT DISTINCT o.id, o.created_at, o.paid_at, o.status FROM orders o
+		JOIN order_items oi ON oi.order_id = o.id
+		JOIN event_dates ed ON ed.id = oi.event_date_id
+		WHERE ed.event_id = ?1 AND ` + saleOrder
+	_, err := tx.Exec(`INSERT INTO sales_daily_orders (event_id, day, previews, converted, paid_orders)
+		SELECT ?1, ?2, previews, converted, paid_orders FROM (
+			SELECT COALESCE(SUM(created_at >= `+dayStart+` AND created_at < `+dayEnd+`), 0) AS previews,
+				COALESCE(SUM(created_at >= `+dayStart+` AND created_at < `+dayEnd+` AND paid_at IS NOT NULL), 0) AS converted,
+				COALESCE(SUM(status = 'PAID' AND paid_at >= `+dayStart+` AND paid_at < `+dayEnd+`), 0) AS paid_orders
+			FROM (`+eventOrders+`)
+		) WHERE previews > 0 OR paid_orders > 0`, eventID, day)
+	return err
+}
+
+// SalesDayRow is the sales of an event on one Brasília day.
+type SalesDayRow struct {
+	Day        string
+	Tickets    int
+	Revenue    float64
+	Previews   int
+	Converted  int
+	PaidOrders int
+}
+
+// salesRange filters rollup rows by day, inclusive; empty bounds are open.
+const salesRange = ` AND (?2 = '' OR day >= ?2) AND (?3 = '' OR day <= ?3)`
+
+// SalesDays lists the event's days with sales or checkouts between from and to (YYYY-MM-DD,
+// inclusive, "" for open), oldest first. Reads the rollup, refreshed in the background (package
+// sales).
+func SalesDays(db *sql.DB, eventID, from, to string) ([]*SalesDayRow, error) {
+	rows, err := db.Query(`SELECT d.day, COALESCE(s.tickets, 0), COALESCE(s.revenue, 0),
+			COALESCE(o.previews, 0), COALESCE(o.converted, 0), COALESCE(o.paid_orders, 0)
+		FROM (
+			SELECT day FROM sales_daily WHERE event_id = ?1`+salesRange+`
+			UNION SELECT day FROM sales_daily_orders WHERE event_id = ?1`+salesRange+`
+		) d
+		LEFT JOIN (SELECT day, SUM(tickets) AS tickets, SUM(revenue) AS revenue FROM sales_daily WHERE event_id = ?1 GROUP BY day) s ON s.day = d.day
+		LEFT JOIN sales_daily_orders o ON o.event_id = ?1 AND o.day = d.day
+		ORDER BY d.day`, eventID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*SalesDayRow
+	for rows.Next() {
+		var d SalesDayRow
+		if err := rows.Scan(&d.Day, &d.Tickets, &d.Revenue, &d.Previews, &d.Converted, &d.PaidOrders); err != nil {
+			return nil, err
+		}
+		list = append(list, &d)
+	}
+	return list, rows.Err()
+}
+
+// TicketTypeSalesRow is the sales of one ticket type of the event with the capacities of the
+// type and of its lot.
+type TicketTypeSalesRow struct {
+	TicketTypeID     string
+	LotID            string
+	MaxQuantity      int
+	LotTotalQuantity int
+	Tickets          int
+	Revenue          float64
+}
+
+// SalesByTicketType lists every ticket type of the event (by date, lot and creation), with the
+// sales between from and to (YYYY-MM-DD, inclusive, "" for open). Reads the rollup.
+func SalesByTicketType(db *sql.DB, eventID, from, to string) ([]*TicketTypeSalesRow, error) {
+	rows, err := db.Query(`SELECT tt.id, tt.lot_id, tt.max_quantity, l.total_quantity, COALESCE(s.tickets, 0), COALESCE(s.revenue, 0)
+		FROM ticket_types tt
+		JOIN lots l ON l.id = tt.lot_id
+		JOIN event_dates ed ON ed.id = l.event_date_id
+		LEFT JOIN (
+			SELECT ticket_type_id, SUM(tickets) AS tickets, SUM(revenue) AS revenue FROM sales_daily
+			WHERE event_id = ?1`+salesRange+` GROUP BY ticket_type_id
+		) s ON s.ticket_type_id = tt.id
+		WHERE ed.event_id = ?1
+		ORDER BY ed.date, l.starts_at, tt.created_at`, eventID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*TicketTypeSalesRow
+	for rows.Next() {
+		var s TicketTypeSalesRow
+		if err := rows.Scan(&s.TicketTypeID, &s.LotID, &s.MaxQuantity, &s.LotTotalQuantity, &s.Tickets, &s.Revenue); err != nil {
+			return nil, err
+		}
+		list = append(list, &s)
+	}
+	return list, rows.Err()
+}
+
+// EventCheckinTotals counts the event's tickets of paid orders (sales, courtesies and resales)
+// and how many of them have entered.
+func EventCheckinTotals(db *sql.DB, eventID string) (issued, checkedIn int, err error) {
+	err = db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(t.used), 0) FROM tickets t JOIN orders o ON o.id = t.order_id
+		WHERE t.event_id = ? AND o.status = 'PAID'`, eventID).Scan(&issued, &checkedIn)
+	return
+}
@@ -0,0 +1,89 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"afterzin/api/internal/repository"
+	"afterzin/api/internal/repository/repotest"
+	"github.com/google/uuid"
+)
+
+// salesToday returns the rollup of the fixture's event for today (Brasília day).
+func salesToday(t *testing.T, f *fixture) *repository.SalesDayRow {
+	t.Helper()
+	if err := repository.RefreshSalesRollup(f.DB); err != nil {
+		t.Fatal(err)
+	}
+	today := time.Now().UTC().Add(-3 * time.Hour).Format("2006-01-02")
+	days, err := repository.SalesDays(f.DB, f.EventID, today, today)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 1 {
+		t.Fatalf("%d days in the rollup, want 1", len(days))
+	}
+	return days[0]
+}
+
+func TestRefreshSalesRollup(t *testing.T) {
+	f := newFixture(t)
+	buyer := f.User(t)
+	f.PaidTicket(t, buyer)
+	refunded, _ := f.PaidTicket(t, buyer)
+	f.PendingOrder(t, buyer, 3)
+
+	// Courtesies and resale purchases are not the producer's sales.
+	courtesyOrder := uuid.New().String()
+	if err := repository.CreateCourtesyOrder(f.DB, courtesyOrder, buyer); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repository.CreateOrderItem(f.DB, courtesyOrder, f.DateID, f.TicketTypeID, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	_, resold := f.PaidTicket(t, buyer)
+	listingID := f.listForResale(t, resold, buyer, 150)
+	resaleBuyer := f.User(t)
+	if _, _, ok, err := repository.CompleteResale(f.DB, f.resaleOrder(t, listingID, resaleBuyer, 150), resaleBuyer, "novo-qr"); err != nil || !ok {
+		t.Fatalf("CompleteResale: ok=%v err=%v", ok, err)
+	}
+
+	got := salesToday(t, f)
+	if got.Tickets != 3 || got.Revenue != 3*repotest.Price || got.Previews != 4 || got.Converted != 3 || got.PaidOrders != 3 {
+		t.Errorf("rollup = %+v, want 3 tickets, 300, 4 previews, 3 converted, 3 paid", got)
+	}
+
+	// A refund is picked up by the next refresh.
+	if ok, err := repository.RefundOrder(f.DB, refunded); err != nil || !ok {
+		t.Fatalf("RefundOrder: ok=%v err=%v", ok, err)
+	}
+	got = salesToday(t, f)
+	if got.Tickets != 2 || got.Revenue != 2*repotest.Price || got.PaidOrders != 2 {
+		t.Errorf("after refund = %+v, want 2 tickets, 200, 2 paid", got)
+	}
+
+	types, err := repository.SalesByTicketType(f.DB, f.EventID, "", "")
+	if err != nil || len(types) != 1 {
+		t.Fatalf("SalesByTicketType: %d rows, %v", len(types), err)
+	}
+	if types[0].TicketTypeID != f.TicketTypeID || types[0].Tickets != 2 || types[0].Revenue != 2*repotest.Price {
+		t.Errorf("by ticket type = %+v, want 2 tickets, 200", types[0])
+	}
+}
+
+func TestRefreshSalesRollupIncremental(t *testing.T) {
+	f := newFixture(t)
+	buyer := f.User(t)
+	f.PaidTicket(t, buyer)
+	if got := salesToday(t, f); got.Tickets != 1 {
+		t.Fatalf("first refresh: %d tickets, want 1", got.Tickets)
+	}
+	// Orders paid after a refresh are added by the next one.
+	orderID, _ := f.PendingOrder(t, buyer, 2)
+	if err := repository.ConfirmOrder(f.DB, orderID); err != nil {
+		t.Fatal(err)
+	}
+	if got := salesToday(t, f); got.Tickets != 3 || got.PaidOrders != 2 {
+		t.Errorf("second refresh = %+v, want 3 tickets, 2 paid", got)
+	}
+}
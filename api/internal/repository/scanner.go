@@ -0,0 +1,373 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// Offline check-in statuses.
+const (
+	OfflineAccepted  = "ACCEPTED"
+	OfflineDuplicate = "DUPLICATE"
+	OfflineRejected  = "REJECTED"
+)
+
+type ScannerDeviceRow struct {
+	ID         string
+	EventID    string
+	UserID     string
+	Name       string
+	DeviceKey  string
+	CreatedAt  string
+	LastSyncAt sql.NullString
+	RevokedAt  sql.NullString
+}
+
+const scannerDeviceColumns = `id, event_id, user_id, name, device_key, created_at, last_sync_at, revoked_at`
+
+func scanScannerDeviceRow(row interface {
+	Scan(dest ...interface{}) error
+}) (*ScannerDeviceRow, error) {
+	var d ScannerDeviceRow
+	err := row.Scan(&d.ID, &d.EventID, &d.UserID, &d.Name, &d.DeviceKey, &d.CreatedAt, &d.LastSyncAt, &d.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func CreateScannerDevice(db *sql.DB, eventID, userID, name, deviceKey string) (string, error) {
+	id := uuid.New().String()
+	_, err := db.Exec(`INSERT INTO scanner_devices (id, event_id, user_id, name, device_key) VALUES (?, ?, ?, ?, ?)`,
+		id, eventID, userID, name, deviceKey,
+	)
+	return id, err
+}
+
+func ScannerDeviceByID(db *sql.DB, id string) (*ScannerDeviceRow, error) {
+	d, err := scanScannerDeviceRow(db.QueryRow(`SELECT `+scannerDeviceColumns+` FROM scanner_devices WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return d, err
+}
+
+// ScannerDevicesByEvent lists the event's devices, most recent first.
+func ScannerDevicesByEvent(db *sql.DB, eventID string) ([]*ScannerDeviceRow, error) {
+	rows, err := db.Query(`SELECT `+scannerDeviceColumns+` FROM scanner_devices WHERE event_id = ? ORDER BY created_at DESC`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*ScannerDeviceRow
+	for rows.Next() {
+		d, err := scanScannerDeviceRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+// RevokeScannerDevice blocks the device from downloading manifests and syncing. Returns false
+// when it was already revoked.
+func RevokeScannerDevice(db *sql.DB, id string) (bool, error) {
+	res, err := db.Exec(`UPDATE scanner_devices SET revoked_at = datetime('now') WHERE id = ? AND revoked_at IS NULL`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+func TouchScannerDeviceSync(db *sql.DB, id string) error {
+	_, err := db.Exec(`UPDATE scanner_devices SET last_sync_at = datetime('now') WHERE id = ?`, id)
+	return err
+}
+
+// ManifestTicketRow is a ticket of the offline manifest.
+type ManifestTicketRow struct {
+	ID             string
+	Code           string
+	QRCode         string
+	TicketTypeID   string
+	TicketTypeName string
+	AreaID         sql.NullString
+	Nominal        int
+	HolderName     sql.NullString
+	Used           int
+	EntryPolicy    string
+	MaxEntries     sql.NullInt64
+	EntryCount     int
+	LastEntryDate  sql.NullString
+	CheckinState   string
+}
+
+// ManifestTickets lists the tickets of paid orders for the event date.
+func ManifestTickets(db *sql.DB, eventDateID string) ([]*ManifestTicketRow, error) {
+	rows, err := db.Query(`SELECT t.id, t.code, t.qr_code, t.ticket_type_id, tt.name, tt.area_id, tt.nominal, t.holder_name, t.used,
+			tt.entry_policy, tt.max_entries, t.entry_count, t.last_entry_date, t.checkin_state
+		FROM tickets t JOIN orders o ON o.id = t.order_id JOIN ticket_types tt ON tt.id = t.ticket_type_id
+		WHERE t.event_date_id = ? AND o.status = 'PAID' ORDER BY t.id`, eventDateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*ManifestTicketRow
+	for rows.Next() {
+		var m ManifestTicketRow
+		if err := rows.Scan(&m.ID, &m.Code, &m.QRCode, &m.TicketTypeID, &m.TicketTypeName, &m.AreaID, &m.Nominal, &m.HolderName, &m.Used,
+			&m.EntryPolicy, &m.MaxEntries, &m.EntryCount, &m.LastEntryDate, &m.CheckinState); err != nil {
+			return nil, err
+		}
+		list = append(list, &m)
+	}
+	return list, rows.Err()
+}
+
+type OfflineCheckinRow struct {
+	ID        string
+	DeviceID  string
+	ScanID    string
+	TicketID  string
+	ScannedAt string // UTC, "2006-01-02 15:04:05"
+	Gate      sql.NullString
+	GateID    sql.NullString
+	QRHash    string // SHA-256 (hex) of the scanned QR payload
+	// QRRefusal is set by the caller when the scanned payload does not admit the ticket (e.g.
+	// INVALID_QR, QR_EXPIRED), which rejects the scan; it is checked before the transaction,
+	// since verifying dynamic payloads needs the QR keys.
+	QRRefusal string
+	Status    string
+	ErrorCode sql.NullString
+}
+
+// OfflineCheckinResult is the outcome of one synced scan; for duplicates, AcceptedAt and
+// AcceptedGate describe the reading that admitted the ticket.
+type OfflineCheckinResult struct {
+	Checkin      *OfflineCheckinRow
+	AcceptedAt   sql.NullString
+	AcceptedGate sql.NullString
+	// The scan recorded a new entry of the ticket (not a replacement of an earlier offline
+	// winner); ValidationID is then the new ticket_validations row.
+	Admitted     bool
+	ValidationID string
+}
+
+// offlineRefusals are the error codes of DUPLICATE scans refused by each entry policy, as in
+// validateTicket.
+var offlineRefusals = map[string]string{
+	EntrySingle:  "ALREADY_USED",
+	EntryReentry: "ALREADY_INSIDE",
+	EntryDaily:   "ALREADY_USED_TODAY",
+	EntryMulti:   "NO_ENTRIES_LEFT",
+}
+
+// RecordOfflineCheckin stores an offline scan of the device's event and resolves it against
+// the ticket's entry policy and current validations, in one transaction, with the rules of
+// EnterTicket on the day of the scan (São Paulo time), once the gate passed the checks of
+// validateTicket (GATE_REQUIRED, WRONG_GATE, WRONG_AREA; see offlineGateRefusal) and the scanned
+// payload was verified (c.QRRefusal empty):
+//   - the policy allows one more entry (unused ticket; REENTRY ticket outside; DAILY ticket
+//     without an entry that day; MULTI ticket with entries left): the scan is accepted as a new
+//     entry, at the scan time;
+//   - otherwise, for SINGLE and DAILY tickets (one entry, per day for DAILY) admitted by
+//     another offline scan, the earliest scan wins (ties by device ID, then scan ID), whatever
+//     the upload order, and the loser becomes a duplicate; online validations are final;
+//   - any other scan is a duplicate, with the policy's refusal as error code.
+//
+// Re-sending a scan (same device and scan ID) returns the stored result unchanged.
+func RecordOfflineCheckin(db *sql.DB, c *OfflineCheckinRow, eventID, producerID, validatedBy string) (*OfflineCheckinResult, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	out := &OfflineCheckinResult{Checkin: c}
+	var ticketEventID, eventDateID, orderStatus, policy, state string
+	var used, entryCount int
+	var maxEntries sql.NullInt64
+	var lastEntryDate, areaID sql.NullString
+	ticketErr := tx.QueryRow(`SELECT t.event_id, t.event_date_id, t.used, o.status, tt.entry_policy, tt.max_entries, t.entry_count, t.last_entry_date,
+			t.checkin_state, tt.area_id
+		FROM tickets t JOIN orders o ON o.id = t.order_id JOIN ticket_types tt ON tt.id = t.ticket_type_id WHERE t.id = ?`, c.TicketID).
+		Scan(&ticketEventID, &eventDateID, &used, &orderStatus, &policy, &maxEntries, &entryCount, &lastEntryDate, &state, &areaID)
+	if ticketErr != nil && ticketErr != sql.ErrNoRows {
+		return nil, ticketErr
+	}
+	var existing OfflineCheckinRow
+	err = tx.QueryRow(`SELECT id, scanned_at, status, error_code FROM offline_checkins WHERE device_id = ? AND scan_id = ?`, c.DeviceID, c.ScanID).
+		Scan(&existing.ID, &existing.ScannedAt, &existing.Status, &existing.ErrorCode)
+	if err == nil {
+		c.ID, c.Status, c.ErrorCode = existing.ID, existing.Status, existing.ErrorCode
+		if c.Status == OfflineDuplicate {
+			out.AcceptedAt, out.AcceptedGate, err = acceptedValidation(tx, c.TicketID, entryScope(policy, existing.ScannedAt))
+		}
+		return out, err
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+	c.ID = uuid.New().String()
+	day := storedEntryDate(c.ScannedAt)
+	// DAILY tickets: whether the ticket already entered on the scan's day.
+	enteredThatDay := lastEntryDate.String == day
+	if ticketErr == nil && policy == EntryDaily && !enteredThatDay {
+		start, end := entryDayBounds(day)
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM ticket_validations WHERE ticket_id = ? AND direction = 'IN' AND reverted_at IS NULL
+			AND validated_at >= ? AND validated_at < ?)`, c.TicketID, start, end).Scan(&enteredThatDay); err != nil {
+			return nil, err
+		}
+	}
+	insert := func() error {
+		_, err := tx.Exec(`INSERT INTO offline_checkins (id, device_id, scan_id, ticket_id, scanned_at, gate, gate_id, qr_hash, status, error_code)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			c.ID, c.DeviceID, c.ScanID, c.TicketID, c.ScannedAt, c.Gate, c.GateID, nullIfEmpty(c.QRHash), c.Status, c.ErrorCode,
+		)
+		return err
+	}
+	var gateRefusal string
+	if ticketErr == nil && ticketEventID == eventID {
+		if gateRefusal, err = offlineGateRefusal(tx, c, eventID, eventDateID, areaID.String); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case ticketErr == sql.ErrNoRows || orderStatus != "PAID":
+		c.Status, c.ErrorCode = OfflineRejected, sql.NullString{String: "NOT_FOUND", Valid: true}
+	case ticketEventID != eventID:
+		c.Status, c.ErrorCode = OfflineRejected, sql.NullString{String: "WRONG_EVENT", Valid: true}
+	case c.QRRefusal != "":
+		c.Status, c.ErrorCode = OfflineRejected, sql.NullString{String: c.QRRefusal, Valid: true}
+	case gateRefusal != "":
+		c.Status, c.ErrorCode = OfflineRejected, sql.NullString{String: gateRefusal, Valid: true}
+	case used == 0,
+		policy == EntryReentry && state == CheckinOut,
+		policy == EntryDaily && !enteredThatDay,
+		policy == EntryMulti && int64(entryCount) < maxEntries.Int64:
+		c.Status = OfflineAccepted
+		if err := insert(); err != nil {
+			return nil, err
+		}
+		// A late upload of an older day does not move a DAILY ticket's last entry back.
+		if _, err := tx.Exec(`UPDATE tickets SET used = 1, used_at = MIN(COALESCE(used_at, ?1), ?1), checkin_state = 'IN',
+			entry_count = entry_count + 1, last_entry_date = MAX(COALESCE(last_entry_date, ''), ?2) WHERE id = ?3`,
+			c.ScannedAt, day, c.TicketID,
+		); err != nil {
+			return nil, err
+		}
+		out.ValidationID = uuid.New().String()
+		if _, err := tx.Exec(`INSERT INTO ticket_validations (id, ticket_id, event_id, producer_id, validated_by, validated_at, offline_checkin_id, direction, gate, gate_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, 'IN', ?, ?)`,
+			out.ValidationID, c.TicketID, eventID, producerID, validatedBy, c.ScannedAt, c.ID, c.Gate, c.GateID,
+		); err != nil {
+			return nil, err
+		}
+		out.Admitted = true
+		return out, tx.Commit()
+	case policy == EntrySingle || policy == EntryDaily:
+		scope := entryScope(policy, c.ScannedAt)
+		start, end := entryDayBounds(scope)
+		var validationID string
+		var winner OfflineCheckinRow
+		err := tx.QueryRow(`SELECT tv.id, oc.id, oc.device_id, oc.scan_id, oc.scanned_at FROM ticket_validations tv
+			JOIN offline_checkins oc ON oc.id = tv.offline_checkin_id WHERE tv.ticket_id = ? AND tv.direction = 'IN' AND tv.reverted_at IS NULL
+				AND (?2 = '' OR (tv.validated_at >= ?3 AND tv.validated_at < ?4))
+			ORDER BY tv.validated_at LIMIT 1`, c.TicketID, scope, start, end,
+		).Scan(&validationID, &winner.ID, &winner.DeviceID, &winner.ScanID, &winner.ScannedAt)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		earlier := err == nil && (c.ScannedAt < winner.ScannedAt ||
+			(c.ScannedAt == winner.ScannedAt && (c.DeviceID < winner.DeviceID || (c.DeviceID == winner.DeviceID && c.ScanID < winner.ScanID))))
+		if !earlier {
+			c.Status, c.ErrorCode = OfflineDuplicate, sql.NullString{String: offlineRefusals[policy], Valid: true}
+			break
+		}
+		c.Status = OfflineAccepted
+		if err := insert(); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`UPDATE offline_checkins SET status = 'DUPLICATE', error_code = ? WHERE id = ?`, offlineRefusals[policy], winner.ID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`UPDATE ticket_validations SET offline_checkin_id = ?, validated_by = ?, validated_at = ?, gate = ?, gate_id = ? WHERE id = ?`,
+			c.ID, validatedBy, c.ScannedAt, c.Gate, c.GateID, validationID,
+		); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`UPDATE tickets SET used_at = MIN(COALESCE(used_at, ?1), ?1) WHERE id = ?2`, c.ScannedAt, c.TicketID); err != nil {
+			return nil, err
+		}
+		return out, tx.Commit()
+	default:
+		c.Status, c.ErrorCode = OfflineDuplicate, sql.NullString{String: offlineRefusals[policy], Valid: true}
+	}
+	if err := insert(); err != nil {
+		return nil, err
+	}
+	if c.Status == OfflineDuplicate {
+		if out.AcceptedAt, out.AcceptedGate, err = acceptedValidation(tx, c.TicketID, entryScope(policy, c.ScannedAt)); err != nil {
+			return nil, err
+		}
+	}
+	return out, tx.Commit()
+}
+
+// offlineGateRefusal checks the gate of an offline scan like validateTicket: on events with
+// areas the scan must name a registered gate (GATE_REQUIRED), of the ticket's date (WRONG_GATE),
+// serving the ticket's area unless the gate has no areas (WRONG_AREA). A registered gate's name
+// replaces the free-text one in c. Returns "" when the gate lets the ticket in.
+func offlineGateRefusal(tx *sql.Tx, c *OfflineCheckinRow, eventID, eventDateID, areaID string) (string, error) {
+	if !c.GateID.Valid {
+		var hasAreas bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM event_areas WHERE event_id = ?)`, eventID).Scan(&hasAreas); err != nil {
+			return "", err
+		}
+		if hasAreas {
+			return "GATE_REQUIRED", nil
+		}
+		return "", nil
+	}
+	var gateDateID, name string
+	err := tx.QueryRow(`SELECT event_date_id, name FROM event_gates WHERE id = ?`, c.GateID.String).Scan(&gateDateID, &name)
+	if err == sql.ErrNoRows || (err == nil && gateDateID != eventDateID) {
+		return "WRONG_GATE", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	c.Gate = sql.NullString{String: name, Valid: true}
+	var restricted, admits bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM event_gate_areas WHERE gate_id = ?1),
+			EXISTS (SELECT 1 FROM event_gate_areas WHERE gate_id = ?1 AND area_id = ?2)`, c.GateID.String, areaID).Scan(&restricted, &admits); err != nil {
+		return "", err
+	}
+	if restricted && !admits {
+		return "WRONG_AREA", nil
+	}
+	return "", nil
+}
+
+// entryScope is the day (São Paulo time) a scan competes for with the ticket's other entries:
+// the scan's day for DAILY tickets, "" (the whole event) otherwise.
+func entryScope(policy, scannedAt string) string {
+	if policy == EntryDaily {
+		return storedEntryDate(scannedAt)
+	}
+	return ""
+}
+
+// acceptedValidation returns when (and, for offline scans, at which gate) the ticket was
+// admitted: its first entry, or its first entry on day (YYYY-MM-DD, São Paulo time) if set.
+func acceptedValidation(tx *sql.Tx, ticketID, day string) (at, gate sql.NullString, err error) {
+	start, end := entryDayBounds(day)
+	err = tx.QueryRow(`SELECT validated_at, gate FROM ticket_validations WHERE ticket_id = ?1 AND direction = 'IN' AND reverted_at IS NULL
+		AND (?2 = '' OR (validated_at >= ?3 AND validated_at < ?4)) ORDER BY validated_at LIMIT 1`, ticketID, day, start, end).Scan(&at, &gate)
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	return
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SessionRow struct {
+	ID               string
+	UserID           string
+	RefreshTokenHash string
+	UserAgent        sql.NullString
+	IPAddress        sql.NullString
+	CreatedAt        string
+	LastUsedAt       string
+	ExpiresAt        string
+	RevokedAt        sql.NullString
+}
+
+const sessionColumns = `id, user_id, refresh_token_hash, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at`
+
+func scanSessionRow(row interface {
+	Scan(dest ...interface{}) error
+}) (*SessionRow, error) {
+	var s SessionRow
+	err := row.Scan(&s.ID, &s.UserID, &s.RefreshTokenHash, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &s.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// CreateSession stores a new session with the hash of its first refresh token.
+func CreateSession(db *sql.DB, userID, refreshTokenHash, userAgent, ip string, ttl time.Duration) (string, error) {
+	id := uuid.New().String()
+	expAt := time.Now().Add(ttl).UTC().Format("2006-01-02 15:04:05")
+	_, err := db.Exec(`INSERT INTO sessions (id, user_id, refresh_token_hash, user_agent, ip_address, expires_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		id, userID, refreshTokenHash, userAgent, ip, expAt,
+	)
+	return id, err
+}
+
+// SessionByRefreshHash returns the session whose current refresh token has the given hash.
+func SessionByRefreshHash(db *sql.DB, hash string) (*SessionRow, error) {
+	s, err := scanSessionRow(db.QueryRow(`SELECT `+sessionColumns+` FROM sessions WHERE refresh_token_hash = ?`, hash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return s, err
+}
+
+// SessionIDByPreviousRefreshHash finds a session by an already-rotated refresh token.
+// A hit means the old token is being reused (likely stolen).
+func SessionIDByPreviousRefreshHash(db *sql.DB, hash string) (string, error) {
+	var id string
+	err := db.QueryRow(`SELECT id FROM sessions WHERE previous_refresh_token_hash = ?`, hash).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return id, err
+}
+
+// RotateSessionRefreshToken swaps the refresh token hash only if the old hash is still current,
+// so two concurrent refreshes with the same token cannot both succeed. Extends the session expiry.
+func RotateSessionRefreshToken(db *sql.DB, sessionID, oldHash, newHash string, ttl time.Duration) (bool, error) {
+	expAt := time.Now().Add(ttl).UTC().Format("2006-01-02 15:04:05")
+	res, err := db.Exec(`UPDATE sessions SET refresh_token_hash = ?, previous_refresh_token_hash = ?, last_used_at = datetime('now'), expires_at = ?
+		WHERE id = ? AND refresh_token_hash = ? AND revoked_at IS NULL`,
+		newHash, oldHash, expAt, sessionID, oldHash,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// SessionActive reports whether the session exists for the user, is neither revoked nor
+// expired, and the user is not blocked. Checked on every authenticated request.
+func SessionActive(db *sql.DB, sessionID, userID string) (bool, error) {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sessions s JOIN users u ON u.id = s.user_id
+		WHERE s.id = ? AND s.user_id = ? AND s.revoked_at IS NULL AND s.expires_at > datetime('now') AND u.blocked_at IS NULL`,
+		sessionID, userID,
+	).Scan(&n)
+	return n == 1, err
+}
+
+// ActiveSessionsByUser lists the user's non-revoked, non-expired sessions, most recently used first.
+func ActiveSessionsByUser(db *sql.DB, userID string) ([]*SessionRow, error) {
+	rows, err := db.Query(`SELECT `+sessionColumns+` FROM sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > datetime('now') ORDER BY last_used_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*SessionRow
+	for rows.Next() {
+		s, err := scanSessionRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// RevokeSession revokes a single session of the user.
+func RevokeSession(db *sql.DB, sessionID, userID string) error {
+	_, err := db.Exec(`UPDATE sessions SET revoked_at = datetime('now') WHERE id = ? AND user_id = ? AND revoked_at IS NULL`, sessionID, userID)
+	return err
+}
+
+// RevokeSessionByID revokes a session regardless of owner (refresh token reuse detection).
+func RevokeSessionByID(db *sql.DB, sessionID string) error {
+	_, err := db.Exec(`UPDATE sessions SET revoked_at = datetime('now') WHERE id = ? AND revoked_at IS NULL`, sessionID)
+	return err
+}
+
+// RevokeUserSessions revokes all sessions of the user except keepSessionID (empty revokes all).
+func RevokeUserSessions(db *sql.DB, userID, keepSessionID string) error {
+	_, err := db.Exec(`UPDATE sessions SET revoked_at = datetime('now') WHERE user_id = ? AND id != ? AND revoked_at IS NULL`, userID, keepSessionID)
+	return err
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// Event staff roles.
+const (
+	StaffScanner = "SCANNER"
+	StaffManager = "MANAGER"
+	StaffFinance = "FINANCE"
+)
+
+// StaffScope groups the event operations a staff role can perform; the event's producer has all.
+type StaffScope int
+
+const (
+	// ScopeCheckIn: validating tickets and searching the guest list at the door.
+	ScopeCheckIn StaffScope = iota
+	// ScopeManage: issuing courtesies, managing promo codes, door overrides (manual check-ins,
+	// reverted validations) and exporting the attendee list.
+	ScopeManage
+	// ScopeFinance: promo codes and their redemptions.
+	ScopeFinance
+	// ScopeStats: the live check-in dashboard, for the whole team.
+	ScopeStats
+)
+
+var staffScopeRoles = map[StaffScope][]string{
+	ScopeCheckIn: {StaffScanner, StaffManager},
+	ScopeManage:  {StaffManager},
+	ScopeFinance: {StaffManager, StaffFinance},
+	ScopeStats:   {StaffScanner, StaffManager, StaffFinance},
+}
+
+// EventAccess loads the event and reports whether the user is its producer or an active staff
+// member whose role covers the scope. The event is nil when it does not exist.
+func EventAccess(db *sql.DB, eventID, userID string, scope StaffScope) (*EventRow, bool, error) {
+	ev, err := EventByID(db, eventID)
+	if err != nil || ev == nil {
+		return nil, false, err
+	}
+	if prod, _ := ProducerByID(db, ev.ProducerID); prod != nil && prod.UserID == userID {
+		return ev, true, nil
+	}
+	role, err := ActiveEventStaffRole(db, eventID, userID)
+	if err != nil {
+		return ev, false, err
+	}
+	for _, allowed := range staffScopeRoles[scope] {
+		if role == allowed {
+			return ev, true, nil
+		}
+	}
+	return ev, false, nil
+}
+
+type EventStaffRow struct {
+	ID        string
+	EventID   string
+	UserID    string
+	Role      string
+	InvitedBy string
+	CreatedAt string
+	RevokedAt sql.NullString
+}
+
+const eventStaffColumns = `id, event_id, user_id, role, invited_by, created_at, revoked_at`
+
+// staffActive matches the memberships that still grant access: not revoked and the event's last
+// date (if any) not older than yesterday, so the door team keeps access through the night.
+const staffActive = `revoked_at IS NULL
+	AND COALESCE((SELECT MAX(date) FROM event_dates WHERE event_id = event_staff.event_id), date('now')) >= date('now', '-1 day')`
+
+func scanEventStaffRow(row interface {
+	Scan(dest ...interface{}) error
+}) (*EventStaffRow, error) {
+	var s EventStaffRow
+	err := row.Scan(&s.ID, &s.EventID, &s.UserID, &s.Role, &s.InvitedBy, &s.CreatedAt, &s.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func queryEventStaff(db *sql.DB, q string, args ...interface{}) ([]*EventStaffRow, error) {
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*EventStaffRow
+	for rows.Next() {
+		s, err := scanEventStaffRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// AddEventStaff gives the user the role on the event; an existing unrevoked membership just
+// changes role. Returns the membership ID.
+func AddEventStaff(db *sql.DB, eventID, userID, role, invitedBy string) (string, error) {
+	var id string
+	err := db.QueryRow(`SELECT id FROM event_staff WHERE event_id = ? AND user_id = ? AND revoked_at IS NULL`, eventID, userID).Scan(&id)
+	if err == nil {
+		_, err = db.Exec(`UPDATE event_staff SET role = ?, invited_by = ? WHERE id = ?`, role, invitedBy, id)
+		return id, err
+	}
+	if err != sql.ErrNoRows {
+		return "", err
+	}
+	id = uuid.New().String()
+	_, err = db.Exec(`INSERT INTO event_staff (id, event_id, user_id, role, invited_by) VALUES (?, ?, ?, ?, ?)`,
+		id, eventID, userID, role, invitedBy,
+	)
+	return id, err
+}
+
+func EventStaffByID(db *sql.DB, id string) (*EventStaffRow, error) {
+	s, err := scanEventStaffRow(db.QueryRow(`SELECT `+eventStaffColumns+` FROM event_staff WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return s, err
+}
+
+// EventStaffByEvent lists the event's team, oldest first; revoked memberships only with includeRevoked.
+func EventStaffByEvent(db *sql.DB, eventID string, includeRevoked bool) ([]*EventStaffRow, error) {
+	q := `SELECT ` + eventStaffColumns + ` FROM event_staff WHERE event_id = ?`
+	if !includeRevoked {
+		q += ` AND revoked_at IS NULL`
+	}
+	return queryEventStaff(db, q+` ORDER BY created_at`, eventID)
+}
+
+// ActiveEventStaffByUser lists the memberships of the user that still grant access.
+func ActiveEventStaffByUser(db *sql.DB, userID string) ([]*EventStaffRow, error) {
+	return queryEventStaff(db, `SELECT `+eventStaffColumns+` FROM event_staff WHERE user_id = ? AND `+staffActive+` ORDER BY created_at`, userID)
+}
+
+// ActiveEventStaffRole returns the role of the user on the event ("" when not on the team, revoked
+// or after the event).
+func ActiveEventStaffRole(db *sql.DB, eventID, userID string) (string, error) {
+	var role string
+	err := db.QueryRow(`SELECT role FROM event_staff WHERE event_id = ? AND user_id = ? AND `+staffActive, eventID, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return role, err
+}
+
+// EventStaffActive reports whether the membership still grants access.
+func EventStaffActive(db *sql.DB, id string) (bool, error) {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM event_staff WHERE id = ? AND `+staffActive, id).Scan(&n)
+	return n == 1, err
+}
+
+// RevokeEventStaff ends the membership. Returns false when it was already revoked.
+func RevokeEventStaff(db *sql.DB, id string) (bool, error) {
+	res, err := db.Exec(`UPDATE event_staff SET revoked_at = datetime('now') WHERE id = ? AND revoked_at IS NULL`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// CountTicketValidationsByUser counts the entries of the event validated by the user, excluding
+// reverted ones.
+func CountTicketValidationsByUser(db *sql.DB, eventID, userID string) (int, error) {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM ticket_validations WHERE event_id = ? AND validated_by = ? AND direction = 'IN' AND reverted_at IS NULL`, eventID, userID).Scan(&n)
+	return n, err
+}
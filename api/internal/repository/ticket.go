@@ -20,6 +20,9 @@ type TicketRow struct {
 	Used          int
 	UsedAt        sql.NullString
 	CreatedAt     time.Time
+	// Door state: IN/OUT and the entries made so far (see TicketCheckin).
+	CheckinState string
+	EntryCount   int
 }
 
 func parseDateTime(s string) time.Time {
@@ -33,8 +36,29 @@ func parseDateTime(s string) time.Time {
 	return t
 }
 
+// TicketsByUserID lists the user's tickets, newest first. Courtesies the user issued as producer
+// and still holds are listed in the event's courtesies instead.
 func TicketsByUserID(db *sql.DB, userID string) ([]*TicketRow, error) {
-	rows, err := db.Query(`SELECT id, code, qr_code, order_id, order_item_id, user_id, event_id, event_date_id, ticket_type_id, used, used_at, created_at FROM tickets WHERE user_id = ? ORDER BY created_at DESC`, userID)
+	rows, err := db.Query(`SELECT id, code, qr_code, order_id, order_item_id, user_id, event_id, event_date_id, ticket_type_id, used, used_at, created_at, checkin_state, entry_count FROM tickets
+		WHERE user_id = ? AND id NOT IN (SELECT ticket_id FROM courtesy_tickets WHERE issued_by = ?) ORDER BY created_at DESC`, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*TicketRow
+	for rows.Next() {
+		t, err := scanTicketRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+// TicketsByOrderID returns the tickets issued for an order, in issue order.
+func TicketsByOrderID(db *sql.DB, orderID string) ([]*TicketRow, error) {
+	rows, err := db.Query(`SELECT id, code, qr_code, order_id, order_item_id, user_id, event_id, event_date_id, ticket_type_id, used, used_at, created_at, checkin_state, entry_count FROM tickets WHERE order_id = ? ORDER BY created_at, code`, orderID)
 	if err != nil {
 		return nil, err
 	}
@@ -55,7 +79,7 @@ func scanTicketRow(rows interface {
 }) (*TicketRow, error) {
 	var t TicketRow
 	var usedAt, createdAt sql.NullString
-	err := rows.Scan(&t.ID, &t.Code, &t.QRCode, &t.OrderID, &t.OrderItemID, &t.UserID, &t.EventID, &t.EventDateID, &t.TicketTypeID, &t.Used, &usedAt, &createdAt)
+	err := rows.Scan(&t.ID, &t.Code, &t.QRCode, &t.OrderID, &t.OrderItemID, &t.UserID, &t.EventID, &t.EventDateID, &t.TicketTypeID, &t.Used, &usedAt, &createdAt, &t.CheckinState, &t.EntryCount)
 	if err != nil {
 		return nil, err
 	}
@@ -71,8 +95,8 @@ func scanTicketRow(rows interface {
 func TicketByID(db *sql.DB, id string) (*TicketRow, error) {
 	var t TicketRow
 	var usedAt, createdAt sql.NullString
-	err := db.QueryRow(`SELECT id, code, qr_code, order_id, order_item_id, user_id, event_id, event_date_id, ticket_type_id, used, COALESCE(used_at,'') as used_at, created_at FROM tickets WHERE id = ?`, id).Scan(
-		&t.ID, &t.Code, &t.QRCode, &t.OrderID, &t.OrderItemID, &t.UserID, &t.EventID, &t.EventDateID, &t.TicketTypeID, &t.Used, &usedAt, &createdAt,
+	err := db.QueryRow(`SELECT id, code, qr_code, order_id, order_item_id, user_id, event_id, event_date_id, ticket_type_id, used, COALESCE(used_at,'') as used_at, created_at, checkin_state, entry_count FROM tickets WHERE id = ?`, id).Scan(
+		&t.ID, &t.Code, &t.QRCode, &t.OrderID, &t.OrderItemID, &t.UserID, &t.EventID, &t.EventDateID, &t.TicketTypeID, &t.Used, &usedAt, &createdAt, &t.CheckinState, &t.EntryCount,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -92,8 +116,8 @@ func TicketByID(db *sql.DB, id string) (*TicketRow, error) {
 func TicketByQRCode(db *sql.DB, qrCode string) (*TicketRow, error) {
 	var t TicketRow
 	var usedAt, createdAt sql.NullString
-	err := db.QueryRow(`SELECT id, code, qr_code, order_id, order_item_id, user_id, event_id, event_date_id, ticket_type_id, used, used_at, created_at FROM tickets WHERE qr_code = ?`, qrCode).Scan(
-		&t.ID, &t.Code, &t.QRCode, &t.OrderID, &t.OrderItemID, &t.UserID, &t.EventID, &t.EventDateID, &t.TicketTypeID, &t.Used, &usedAt, &createdAt,
+	err := db.QueryRow(`SELECT id, code, qr_code, order_id, order_item_id, user_id, event_id, event_date_id, ticket_type_id, used, used_at, created_at, checkin_state, entry_count FROM tickets WHERE qr_code = ?`, qrCode).Scan(
+		&t.ID, &t.Code, &t.QRCode, &t.OrderID, &t.OrderItemID, &t.UserID, &t.EventID, &t.EventDateID, &t.TicketTypeID, &t.Used, &usedAt, &createdAt, &t.CheckinState, &t.EntryCount,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -115,24 +139,15 @@ func MarkTicketUsed(db *sql.DB, id string) error {
 	return err
 }
 
-// MarkTicketUsedIfNotUsed marks the ticket as used only if it is not already used.
-// Returns true if the row was updated (exactly one row), false if already used or not found.
-// Used for concurrent-safe validation: only one request can succeed.
-func MarkTicketUsedIfNotUsed(db *sql.DB, id string) (updated bool, err error) {
-	res, err := db.Exec(`UPDATE tickets SET used = 1, used_at = datetime('now') WHERE id = ? AND used = 0`, id)
-	if err != nil {
-		return false, err
-	}
-	n, _ := res.RowsAffected()
-	return n == 1, nil
-}
-
-func InsertTicketValidation(db *sql.DB, ticketID, eventID, producerID string) error {
-	id := uuid.New().String()
-	_, err := db.Exec(`INSERT INTO ticket_validations (id, ticket_id, event_id, producer_id) VALUES (?, ?, ?, ?)`,
-		id, ticketID, eventID, producerID,
+// InsertTicketValidation records a door reading of the ticket (direction IN or OUT, gate optional)
+// by v.ValidatedBy, the producer's user or a member of the event staff; ManualReason marks a manual
+// check-in. Sets and returns v.ID.
+func InsertTicketValidation(db Querier, v *TicketValidationRow) (string, error) {
+	v.ID = uuid.New().String()
+	_, err := db.Exec(`INSERT INTO ticket_validations (id, ticket_id, event_id, producer_id, validated_by, direction, gate, gate_id, manual_reason) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		v.ID, v.TicketID, v.EventID, v.ProducerID, v.ValidatedBy, v.Direction, nullIfEmpty(v.Gate), nullIfEmpty(v.GateID), nullIfEmpty(v.ManualReason),
 	)
-	return err
+	return v.ID, err
 }
 
 func GenerateTicketCode() string {
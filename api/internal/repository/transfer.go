@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Ticket transfer statuses.
+const (
+	TransferPending   = "PENDING"
+	TransferAccepted  = "ACCEPTED"
+	TransferDeclined  = "DECLINED"
+	TransferCancelled = "CANCELLED"
+)
+
+type TicketTransferRow struct {
+	ID             string
+	TicketID       string
+	FromUserID     string
+	ToUserID       sql.NullString
+	RecipientEmail string
+	Status         string
+	CreatedAt      string
+	RespondedAt    sql.NullString
+}
+
+const ticketTransferColumns = `id, ticket_id, from_user_id, to_user_id, recipient_email, status, created_at, responded_at`
+
+func scanTicketTransferRow(row interface {
+	Scan(dest ...interface{}) error
+}) (*TicketTransferRow, error) {
+	var t TicketTransferRow
+	err := row.Scan(&t.ID, &t.TicketID, &t.FromUserID, &t.ToUserID, &t.RecipientEmail, &t.Status, &t.CreatedAt, &t.RespondedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// NormalizeEmail lowercases and trims an email so transfers match the recipient's account.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// CreateTicketTransfer offers a ticket to the recipient email. Fails on the unique index when
+// the ticket already has a pending offer.
+func CreateTicketTransfer(db *sql.DB, ticketID, fromUserID, recipientEmail string) (string, error) {
+	id := uuid.New().String()
+	_, err := db.Exec(`INSERT INTO ticket_transfers (id, ticket_id, from_user_id, recipient_email) VALUES (?, ?, ?, ?)`,
+		id, ticketID, fromUserID, NormalizeEmail(recipientEmail),
+	)
+	return id, err
+}
+
+func TicketTransferByID(db *sql.DB, id string) (*TicketTransferRow, error) {
+	t, err := scanTicketTransferRow(db.QueryRow(`SELECT `+ticketTransferColumns+` FROM ticket_transfers WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
+}
+
+// PendingTicketTransfer returns the open offer for a ticket, if any.
+func PendingTicketTransfer(db *sql.DB, ticketID string) (*TicketTransferRow, error) {
+	t, err := scanTicketTransferRow(db.QueryRow(`SELECT `+ticketTransferColumns+` FROM ticket_transfers WHERE ticket_id = ? AND status = 'PENDING'`, ticketID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
+}
+
+// TicketTransfersByUser lists transfers sent by the user or addressed to their email, most recent first.
+func TicketTransfersByUser(db *sql.DB, userID, email string) ([]*TicketTransferRow, error) {
+	rows, err := db.Query(`SELECT `+ticketTransferColumns+` FROM ticket_transfers
+		WHERE from_user_id = ? OR to_user_id = ? OR recipient_email = ? ORDER BY created_at DESC`,
+		userID, userID, NormalizeEmail(email),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*TicketTransferRow
+	for rows.Next() {
+		t, err := scanTicketTransferRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+// CloseTicketTransfer moves a pending transfer to DECLINED or CANCELLED.
+// Returns false when the transfer was no longer pending.
+func CloseTicketTransfer(db *sql.DB, id, status string) (bool, error) {
+	res, err := db.Exec(`UPDATE ticket_transfers SET status = ?, responded_at = datetime('now') WHERE id = ? AND status = 'PENDING'`, status, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// AcceptTicketTransfer hands the ticket to the recipient and replaces its QR payload in one
+// transaction; a nominal holder is cleared for the new owner to assign. Returns false (and
+// changes nothing) when the transfer is no longer pending or the ticket changed owner or was
+// used in the meantime.
+func AcceptTicketTransfer(db *sql.DB, transferID, toUserID, newQRCode string) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	var ticketID, fromUserID string
+	err = tx.QueryRow(`SELECT ticket_id, from_user_id FROM ticket_transfers WHERE id = ? AND status = 'PENDING'`, transferID).Scan(&ticketID, &fromUserID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	res, err := tx.Exec(`UPDATE tickets SET user_id = ?, qr_code = ?, qr_reissued_at = datetime('now'), `+clearTicketHolder+` WHERE id = ? AND user_id = ? AND used = 0`,
+		toUserID, newQRCode, ticketID, fromUserID,
+	)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		return false, nil
+	}
+	if _, err := tx.Exec(`UPDATE ticket_transfers SET status = 'ACCEPTED', to_user_id = ?, responded_at = datetime('now') WHERE id = ?`, toUserID, transferID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// TicketQRReissued reports whether the ticket's QR payload was replaced by a transfer.
+// Reissued tickets are only valid with the current stored payload.
+func TicketQRReissued(db *sql.DB, ticketID string) (bool, error) {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM tickets WHERE id = ? AND qr_reissued_at IS NOT NULL`, ticketID).Scan(&n)
+	return n == 1, err
+}
@@ -8,15 +8,19 @@ import (
 )
 
 type UserRow struct {
-	ID           string
-	Name         string
-	Email        string
-	PasswordHash string
-	CPF          string
-	BirthDate    string
-	PhotoURL     sql.NullString
-	Role         string
-	CreatedAt    time.Time
+	ID              string
+	Name            string
+	Email           string
+	PasswordHash    string
+	CPF             string
+	BirthDate       string
+	PhotoURL        sql.NullString
+	Role            string
+	CreatedAt       time.Time
+	BlockedAt       sql.NullString
+	BlockedReason   sql.NullString
+	EmailVerifiedAt sql.NullString
+	Gender          sql.NullString
 }
 
 func parseCreatedAt(s string) time.Time {
@@ -30,15 +34,16 @@ func parseCreatedAt(s string) time.Time {
 	return t
 }
 
-func UserByEmail(db *sql.DB, email string) (*UserRow, error) {
+const userColumns = `id, name, email, password_hash, cpf, birth_date, photo_url, role, created_at, blocked_at, blocked_reason, email_verified_at, gender`
+
+func scanUserRow(row interface {
+	Scan(dest ...interface{}) error
+}) (*UserRow, error) {
 	var u UserRow
 	var createdAt sql.NullString
-	err := db.QueryRow(`SELECT id, name, email, password_hash, cpf, birth_date, photo_url, role, created_at FROM users WHERE email = ?`, email).Scan(
-		&u.ID, &u.Name, &u.Email, &u.PasswordHash, &u.CPF, &u.BirthDate, &u.PhotoURL, &u.Role, &createdAt,
+	err := row.Scan(
+		&u.ID, &u.Name, &u.Email, &u.PasswordHash, &u.CPF, &u.BirthDate, &u.PhotoURL, &u.Role, &createdAt, &u.BlockedAt, &u.BlockedReason, &u.EmailVerifiedAt, &u.Gender,
 	)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
 	if err != nil {
 		return nil, err
 	}
@@ -48,22 +53,20 @@ func UserByEmail(db *sql.DB, email string) (*UserRow, error) {
 	return &u, nil
 }
 
-func UserByID(db *sql.DB, id string) (*UserRow, error) {
-	var u UserRow
-	var createdAt sql.NullString
-	err := db.QueryRow(`SELECT id, name, email, password_hash, cpf, birth_date, photo_url, role, created_at FROM users WHERE id = ?`, id).Scan(
-		&u.ID, &u.Name, &u.Email, &u.PasswordHash, &u.CPF, &u.BirthDate, &u.PhotoURL, &u.Role, &createdAt,
-	)
+func UserByEmail(db *sql.DB, email string) (*UserRow, error) {
+	u, err := scanUserRow(db.QueryRow(`SELECT `+userColumns+` FROM users WHERE email = ?`, email))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	if err != nil {
-		return nil, err
-	}
-	if createdAt.Valid {
-		u.CreatedAt = parseCreatedAt(createdAt.String)
+	return u, err
+}
+
+func UserByID(db Querier, id string) (*UserRow, error) {
+	u, err := scanUserRow(db.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-	return &u, nil
+	return u, err
 }
 
 func CreateUser(db *sql.DB, name, email, passwordHash, cpf, birthDate string) (string, error) {
@@ -74,7 +77,64 @@ func CreateUser(db *sql.DB, name, email, passwordHash, cpf, birthDate string) (s
 	return id, err
 }
 
+// UpdateUserGender sets the gender of the user's profile ("" clears it).
+func UpdateUserGender(db *sql.DB, userID, gender string) error {
+	_, err := db.Exec(`UPDATE users SET gender = ? WHERE id = ?`, nullIfEmpty(gender), userID)
+	return err
+}
+
+// MarkEmailVerified records that the user confirmed their email address.
+func MarkEmailVerified(db *sql.DB, userID string) error {
+	_, err := db.Exec(`UPDATE users SET email_verified_at = datetime('now') WHERE id = ? AND email_verified_at IS NULL`, userID)
+	return err
+}
+
+// UpdateUserPassword replaces the password hash.
+func UpdateUserPassword(db *sql.DB, userID, passwordHash string) error {
+	_, err := db.Exec(`UPDATE users SET password_hash = ? WHERE id = ?`, passwordHash, userID)
+	return err
+}
+
 func UpdateUserPhotoURL(db *sql.DB, userID, photoURL string) error {
 	_, err := db.Exec(`UPDATE users SET photo_url = ? WHERE id = ?`, photoURL, userID)
 	return err
 }
+
+// SearchUsers returns users whose name, email or CPF contains the search term (admin back office).
+func SearchUsers(db *sql.DB, search string, limit, offset int) ([]*UserRow, error) {
+	q := `SELECT ` + userColumns + ` FROM users`
+	args := []interface{}{}
+	if search != "" {
+		like := "%" + search + "%"
+		q += ` WHERE name LIKE ? OR email LIKE ? OR cpf LIKE ? OR id = ?`
+		args = append(args, like, like, like, search)
+	}
+	q += ` ORDER BY created_at DESC LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*UserRow
+	for rows.Next() {
+		u, err := scanUserRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, u)
+	}
+	return list, rows.Err()
+}
+
+// BlockUser blocks the account; blocked users cannot log in.
+func BlockUser(db *sql.DB, userID, reason string) error {
+	_, err := db.Exec(`UPDATE users SET blocked_at = datetime('now'), blocked_reason = ? WHERE id = ?`, reason, userID)
+	return err
+}
+
+// UnblockUser clears the account block.
+func UnblockUser(db *sql.DB, userID string) error {
+	_, err := db.Exec(`UPDATE users SET blocked_at = NULL, blocked_reason = NULL WHERE id = ?`, userID)
+	return err
+}
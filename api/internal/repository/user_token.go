@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Single-use token purposes.
+const (
+	TokenPasswordReset     = "PASSWORD_RESET"
+	TokenEmailVerification = "EMAIL_VERIFICATION"
+)
+
+// CreateUserToken stores the hash of a single-use token, invalidating any earlier unused
+// token of the same purpose for the user (only the latest link works).
+func CreateUserToken(db *sql.DB, userID, purpose, tokenHash string, ttl time.Duration) error {
+	if _, err := db.Exec(`UPDATE user_tokens SET used_at = datetime('now') WHERE user_id = ? AND purpose = ? AND used_at IS NULL`, userID, purpose); err != nil {
+		return err
+	}
+	expAt := time.Now().Add(ttl).UTC().Format("2006-01-02 15:04:05")
+	_, err := db.Exec(`INSERT INTO user_tokens (id, user_id, purpose, token_hash, expires_at) VALUES (?, ?, ?, ?, ?)`,
+		uuid.New().String(), userID, purpose, tokenHash, expAt,
+	)
+	return err
+}
+
+// ConsumeUserToken marks a valid (unused, unexpired) token as used and returns its user ID.
+// Returns "" if the token is unknown, expired, already used or for another purpose.
+func ConsumeUserToken(db *sql.DB, purpose, tokenHash string) (string, error) {
+	var id, userID string
+	err := db.QueryRow(`SELECT id, user_id FROM user_tokens WHERE token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > datetime('now')`,
+		tokenHash, purpose,
+	).Scan(&id, &userID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	res, err := db.Exec(`UPDATE user_tokens SET used_at = datetime('now') WHERE id = ? AND used_at IS NULL`, id)
+	if err != nil {
+		return "", err
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		return "", nil
+	}
+	return userID, nil
+}
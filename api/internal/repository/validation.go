@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"database/sql"
+)
+
+// TicketValidationRow is a door reading of a ticket (ticket_validations), kept for auditing even
+// after it is reverted.
+type TicketValidationRow struct {
+	ID               string
+	TicketID         string
+	EventID          string
+	ProducerID       string
+	ValidatedBy      string
+	Direction        string
+	Gate             string
+	GateID           string // registered gate (event_gates), when the reading named one
+	ManualReason     string // set on manual check-ins, made without reading the QR code
+	OfflineCheckinID sql.NullString
+	ValidatedAt      string
+	RevertedAt       sql.NullString
+	RevertedBy       sql.NullString
+	RevertReason     sql.NullString
+}
+
+const ticketValidationColumns = `id, ticket_id, event_id, producer_id, COALESCE(validated_by, ''), direction, COALESCE(gate, ''), COALESCE(gate_id, ''),
+	COALESCE(manual_reason, ''), offline_checkin_id, validated_at, reverted_at, reverted_by, revert_reason`
+
+func scanTicketValidationRow(row interface {
+	Scan(dest ...interface{}) error
+}) (*TicketValidationRow, error) {
+	var v TicketValidationRow
+	err := row.Scan(&v.ID, &v.TicketID, &v.EventID, &v.ProducerID, &v.ValidatedBy, &v.Direction, &v.Gate, &v.GateID,
+		&v.ManualReason, &v.OfflineCheckinID, &v.ValidatedAt, &v.RevertedAt, &v.RevertedBy, &v.RevertReason)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func TicketValidationByID(db *sql.DB, id string) (*TicketValidationRow, error) {
+	v, err := scanTicketValidationRow(db.QueryRow(`SELECT `+ticketValidationColumns+` FROM ticket_validations WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return v, err
+}
+
+// TicketByCode finds a ticket by its printed code (tickets.code), case-insensitively.
+func TicketByCode(db *sql.DB, code string) (*TicketRow, error) {
+	t, err := scanTicketRow(db.QueryRow(`SELECT id, code, qr_code, order_id, order_item_id, user_id, event_id, event_date_id, ticket_type_id, used, used_at, created_at, checkin_state, entry_count
+		FROM tickets WHERE code = ? COLLATE NOCASE`, code))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
+}
+
+// RevertTicketValidation undoes the validation, which must be the ticket's latest one not yet
+// reverted, and recomputes the ticket's door state from the remaining validations, in one
+// transaction: reverting the only entry makes the ticket unused (valid again). The validation is
+// kept, marked as reverted by revertedBy with the reason; the offline scan it came from, if any,
+// becomes REJECTED (REVERTED). Returns false when it was already reverted or is not the latest.
+func RevertTicketValidation(db *sql.DB, id, revertedBy, reason string) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(`UPDATE ticket_validations SET reverted_at = datetime('now'), reverted_by = ?, revert_reason = ?
+		WHERE id = ? AND reverted_at IS NULL AND id = (
+			SELECT l.id FROM ticket_validations l WHERE l.ticket_id = ticket_validations.ticket_id AND l.reverted_at IS NULL
+			ORDER BY l.validated_at DESC, l.rowid DESC LIMIT 1)`, revertedBy, reason, id)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		return false, nil
+	}
+	var ticketID string
+	var lastEntry sql.NullString
+	if err := tx.QueryRow(`SELECT v.ticket_id, (SELECT MAX(l.validated_at) FROM ticket_validations l
+		WHERE l.ticket_id = v.ticket_id AND l.direction = 'IN' AND l.reverted_at IS NULL) FROM ticket_validations v WHERE v.id = ?`, id,
+	).Scan(&ticketID, &lastEntry); err != nil {
+		return false, err
+	}
+	// last_entry_date is the EntryDate of the latest remaining entry.
+	lastEntryDate := sql.NullString{}
+	if lastEntry.Valid {
+		lastEntryDate = nullIfEmpty(storedEntryDate(lastEntry.String))
+	}
+	if _, err := tx.Exec(`UPDATE tickets SET
+		entry_count = (SELECT COUNT(*) FROM ticket_validations v WHERE v.ticket_id = tickets.id AND v.direction = 'IN' AND v.reverted_at IS NULL),
+		checkin_state = COALESCE((SELECT v.direction FROM ticket_validations v WHERE v.ticket_id = tickets.id AND v.reverted_at IS NULL
+			ORDER BY v.validated_at DESC, v.rowid DESC LIMIT 1), 'OUT'),
+		used_at = (SELECT MIN(v.validated_at) FROM ticket_validations v WHERE v.ticket_id = tickets.id AND v.direction = 'IN' AND v.reverted_at IS NULL),
+		last_entry_date = ?
+		WHERE id = ?`, lastEntryDate, ticketID); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`UPDATE tickets SET used = CASE WHEN entry_count > 0 THEN 1 ELSE 0 END WHERE id = ?`, ticketID); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`UPDATE offline_checkins SET status = 'REJECTED', error_code = 'REVERTED'
+		WHERE id = (SELECT offline_checkin_id FROM ticket_validations WHERE id = ?)`, id); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
@@ -0,0 +1,63 @@
+package repository_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"afterzin/api/internal/repository"
+)
+
+func TestEntryDay(t *testing.T) {
+	// 02:30 UTC is still the previous day in São Paulo.
+	if got := repository.StoredEntryDate("2026-01-10 02:30:00"); got != "2026-01-09" {
+		t.Errorf("StoredEntryDate = %s, want 2026-01-09", got)
+	}
+	start, end := repository.EntryDayBounds("2026-01-09")
+	if start != "2026-01-09 03:00:00" || end != "2026-01-10 03:00:00" {
+		t.Errorf("EntryDayBounds = %s, %s", start, end)
+	}
+	if start, end := repository.EntryDayBounds(""); start != "" || end != "" {
+		t.Errorf("EntryDayBounds(\"\") = %q, %q", start, end)
+	}
+}
+
+func TestRevertTicketValidation(t *testing.T) {
+	f := newFixture(t)
+	staff := f.User(t)
+	_, ticketID := f.PaidTicket(t, f.User(t))
+	daily := func(validatedAt string) string {
+		t.Helper()
+		v := &repository.TicketValidationRow{TicketID: ticketID, EventID: f.EventID, ProducerID: f.ProducerID, ValidatedBy: staff, Direction: repository.CheckinIn}
+		if ok, err := repository.AdmitTicket(f.DB, v, repository.EntryDaily, sql.NullInt64{}, repository.StoredEntryDate(validatedAt)); err != nil || !ok {
+			t.Fatalf("entry at %s: ok=%v err=%v", validatedAt, ok, err)
+		}
+		if _, err := f.DB.Exec(`UPDATE ticket_validations SET validated_at = ? WHERE id = ?`, validatedAt, v.ID); err != nil {
+			t.Fatal(err)
+		}
+		return v.ID
+	}
+	first := daily("2026-01-10 02:30:00") // São Paulo day 2026-01-09
+	second := daily("2026-01-10 15:00:00")
+
+	if ok, err := repository.RevertTicketValidation(f.DB, first, staff, "engano"); err != nil || ok {
+		t.Errorf("revert of an older validation: ok=%v err=%v, want refused", ok, err)
+	}
+	if ok, err := repository.RevertTicketValidation(f.DB, second, staff, "engano"); err != nil || !ok {
+		t.Fatalf("revert: ok=%v err=%v", ok, err)
+	}
+	c, _ := repository.TicketCheckinByID(f.DB, ticketID)
+	if c.EntryCount != 1 || c.State != repository.CheckinIn || c.LastEntryDate.String != "2026-01-09" {
+		t.Errorf("after revert: %+v, want 1 entry, IN, last entry 2026-01-09", c)
+	}
+	if ok, err := repository.RevertTicketValidation(f.DB, first, staff, "engano"); err != nil || !ok {
+		t.Fatalf("revert of the only entry: ok=%v err=%v", ok, err)
+	}
+	tk, _ := repository.TicketByID(f.DB, ticketID)
+	c, _ = repository.TicketCheckinByID(f.DB, ticketID)
+	if tk.Used != 0 || c.EntryCount != 0 || c.State != repository.CheckinOut || c.LastEntryDate.Valid {
+		t.Errorf("after reverting every entry: used=%d %+v, want an unused ticket", tk.Used, c)
+	}
+	if v, _ := repository.TicketValidationByID(f.DB, first); !v.RevertedAt.Valid || v.RevertReason.String != "engano" {
+		t.Errorf("reverted validation not kept for auditing: %+v", v)
+	}
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type WalletPassRow struct {
+	TicketID            string
+	AuthenticationToken string
+	UpdatedAt           string
+}
+
+// EnsureWalletPass returns the wallet pass record of the ticket, creating it with the given
+// authentication token on first use.
+func EnsureWalletPass(db *sql.DB, ticketID, newToken string) (*WalletPassRow, error) {
+	if _, err := db.Exec(`INSERT OR IGNORE INTO wallet_passes (ticket_id, authentication_token) VALUES (?, ?)`, ticketID, newToken); err != nil {
+		return nil, err
+	}
+	return WalletPassByTicket(db, ticketID)
+}
+
+func WalletPassByTicket(db *sql.DB, ticketID string) (*WalletPassRow, error) {
+	var p WalletPassRow
+	err := db.QueryRow(`SELECT ticket_id, authentication_token, updated_at FROM wallet_passes WHERE ticket_id = ?`, ticketID).Scan(
+		&p.TicketID, &p.AuthenticationToken, &p.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// DeleteWalletPass removes the pass record and device registrations of a ticket, revoking the
+// authentication token embedded in passes already downloaded.
+func DeleteWalletPass(db *sql.DB, ticketID string) error {
+	if _, err := db.Exec(`DELETE FROM wallet_device_registrations WHERE ticket_id = ?`, ticketID); err != nil {
+		return err
+	}
+	_, err := db.Exec(`DELETE FROM wallet_passes WHERE ticket_id = ?`, ticketID)
+	return err
+}
+
+// TouchWalletPasses bumps updated_at of the tickets' passes so registered devices fetch them again.
+func TouchWalletPasses(db *sql.DB, ticketIDs []string) error {
+	if len(ticketIDs) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(ticketIDs))
+	for i, id := range ticketIDs {
+		args[i] = id
+	}
+	_, err := db.Exec(`UPDATE wallet_passes SET updated_at = datetime('now') WHERE ticket_id IN (?`+strings.Repeat(", ?", len(ticketIDs)-1)+`)`, args...)
+	return err
+}
+
+// RegisterWalletDevice registers a device for pass update pushes. Returns false if the
+// registration already existed (the push token is refreshed).
+func RegisterWalletDevice(db *sql.DB, deviceLibraryID, pushToken, passTypeID, ticketID string) (bool, error) {
+	res, err := db.Exec(`INSERT OR IGNORE INTO wallet_device_registrations (id, device_library_id, push_token, pass_type_id, ticket_id) VALUES (?, ?, ?, ?, ?)`,
+		uuid.New().String(), deviceLibraryID, pushToken, passTypeID, ticketID,
+	)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 1 {
+		return true, nil
+	}
+	_, err = db.Exec(`UPDATE wallet_device_registrations SET push_token = ? WHERE device_library_id = ? AND pass_type_id = ? AND ticket_id = ?`,
+		pushToken, deviceLibraryID, passTypeID, ticketID,
+	)
+	return false, err
+}
+
+func UnregisterWalletDevice(db *sql.DB, deviceLibraryID, passTypeID, ticketID string) error {
+	_, err := db.Exec(`DELETE FROM wallet_device_registrations WHERE device_library_id = ? AND pass_type_id = ? AND ticket_id = ?`,
+		deviceLibraryID, passTypeID, ticketID,
+	)
+	return err
+}
+
+// WalletSerialsForDevice lists the tickets registered on a device whose pass changed after
+// since (empty returns all), plus the latest update time among them.
+func WalletSerialsForDevice(db *sql.DB, deviceLibraryID, passTypeID, since string) ([]string, string, error) {
+	q := `SELECT p.ticket_id, p.updated_at FROM wallet_device_registrations r JOIN wallet_passes p ON p.ticket_id = r.ticket_id
+		WHERE r.device_library_id = ? AND r.pass_type_id = ?`
+	args := []interface{}{deviceLibraryID, passTypeID}
+	if since != "" {
+		q += ` AND p.updated_at > ?`
+		args = append(args, since)
+	}
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+	var serials []string
+	var last string
+	for rows.Next() {
+		var id, updatedAt string
+		if err := rows.Scan(&id, &updatedAt); err != nil {
+			return nil, "", err
+		}
+		serials = append(serials, id)
+		if updatedAt > last {
+			last = updatedAt
+		}
+	}
+	return serials, last, rows.Err()
+}
+
+// WalletPushTokens returns the distinct push tokens of devices holding any of the tickets' passes.
+func WalletPushTokens(db *sql.DB, ticketIDs []string) ([]string, error) {
+	if len(ticketIDs) == 0 {
+		return nil, nil
+	}
+	args := make([]interface{}, len(ticketIDs))
+	for i, id := range ticketIDs {
+		args[i] = id
+	}
+	rows, err := db.Query(`SELECT DISTINCT push_token FROM wallet_device_registrations WHERE ticket_id IN (?`+strings.Repeat(", ?", len(ticketIDs)-1)+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tokens []string
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// TicketIDsByEventDate returns the IDs of all tickets issued for an event date.
+func TicketIDsByEventDate(db *sql.DB, eventDateID string) ([]string, error) {
+	return ticketIDs(db, `SELECT id FROM tickets WHERE event_date_id = ?`, eventDateID)
+}
+
+// TicketIDsByEvent returns the IDs of all tickets issued for an event.
+func TicketIDsByEvent(db *sql.DB, eventID string) ([]string, error) {
+	return ticketIDs(db, `SELECT id FROM tickets WHERE event_id = ?`, eventID)
+}
+
+// TicketIDsByOrder returns the IDs of all tickets issued for an order.
+func TicketIDsByOrder(db *sql.DB, orderID string) ([]string, error) {
+	return ticketIDs(db, `SELECT id FROM tickets WHERE order_id = ?`, orderID)
+}
+
+func ticketIDs(db *sql.DB, q string, arg string) ([]string, error) {
+	rows, err := db.Query(q, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
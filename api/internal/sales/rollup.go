@@ -0,0 +1,31 @@
+// Package sales keeps the daily sales rollup behind the producers' sales analytics up to date,
+// so the analytics queries only read it.
+package sales
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"afterzin/api/internal/repository"
+)
+
+// refreshInterval is how often orders created, paid or refunded reach the rollup.
+const refreshInterval = time.Minute
+
+// Run refreshes the rollup at start and then every refreshInterval until ctx is cancelled.
+func Run(ctx context.Context, db *sql.DB) {
+	tick := time.NewTicker(refreshInterval)
+	defer tick.Stop()
+	for {
+		if err := repository.RefreshSalesRollup(db); err != nil {
+			log.Printf("sales: rollup refresh error: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+	}
+}
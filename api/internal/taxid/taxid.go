@@ -0,0 +1,64 @@
+// Package taxid normalizes, validates and masks Brazilian tax documents (CPF and CNPJ).
+package taxid
+
+import "strings"
+
+// Digits strips the formatting (dots, slashes, dashes) of a document.
+func Digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// ValidCPF checks the length and both check digits of a CPF (formatting is ignored).
+func ValidCPF(cpf string) bool {
+	d := Digits(cpf)
+	if len(d) != 11 || strings.Count(d, d[:1]) == 11 {
+		return false
+	}
+	checkDigit := func(n int) byte {
+		sum := 0
+		for i := 0; i < n; i++ {
+			sum += int(d[i]-'0') * (n + 1 - i)
+		}
+		r := sum * 10 % 11
+		if r == 10 {
+			r = 0
+		}
+		return byte('0' + r)
+	}
+	return d[9] == checkDigit(9) && d[10] == checkDigit(10)
+}
+
+// ValidCNPJ checks the length and both check digits of a CNPJ (formatting is ignored).
+func ValidCNPJ(cnpj string) bool {
+	d := Digits(cnpj)
+	if len(d) != 14 || strings.Count(d, d[:1]) == 14 {
+		return false
+	}
+	checkDigit := func(n int) byte {
+		weights := []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}[13-n:]
+		sum := 0
+		for i := 0; i < n; i++ {
+			sum += int(d[i]-'0') * weights[i]
+		}
+		r := sum % 11
+		if r < 2 {
+			return '0'
+		}
+		return byte('0' + 11 - r)
+	}
+	return d[12] == checkDigit(12) && d[13] == checkDigit(13)
+}
+
+// MaskCPF shows only the middle digits of a CPF (***.456.789-**), formatted or not (LGPD).
+func MaskCPF(cpf string) string {
+	d := Digits(cpf)
+	if len(d) != 11 {
+		return "informado"
+	}
+	return "***." + d[3:6] + "." + d[6:9] + "-**"
+}
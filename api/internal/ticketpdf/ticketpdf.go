@@ -0,0 +1,211 @@
+// Package ticketpdf renders printable PDF tickets with the QR code embedded (pure Go, no
+// external binaries).
+package ticketpdf
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"afterzin/api/internal/qrcode"
+	"afterzin/api/internal/repository"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Ticket is everything printed on the PDF.
+type Ticket struct {
+	Code          string
+	QRPayload     string
+	EventTitle    string
+	Date          string // YYYY-MM-DD
+	StartTime     string
+	EndTime       string
+	Location      string
+	Address       string
+	TicketType    string
+	HolderName    string
+	ProducerName  string
+	ProducerEmail string
+	EntryTerms    string // how the QR code can be used, from the ticket type's entry policy
+}
+
+// Load gathers the printable data of a ticket (event, date, type, holder and producer).
+func Load(db *sql.DB, t *repository.TicketRow) (Ticket, error) {
+	data := Ticket{Code: t.Code, QRPayload: t.QRCode, EntryTerms: repository.EntryTerms(repository.EntrySingle, sql.NullInt64{})}
+	ev, err := repository.EventByID(db, t.EventID)
+	if err != nil {
+		return data, err
+	}
+	if ev != nil {
+		data.EventTitle = ev.Title
+		data.Location = ev.Location
+		data.Address = ev.Address.String
+		if prod, _ := repository.ProducerByID(db, ev.ProducerID); prod != nil {
+			data.ProducerName = prod.CompanyName.String
+			data.ProducerEmail = prod.ContactEmail.String
+			if data.ProducerName == "" || data.ProducerEmail == "" {
+				if owner, _ := repository.UserByID(db, prod.UserID); owner != nil {
+					if data.ProducerName == "" {
+						data.ProducerName = owner.Name
+					}
+					if data.ProducerEmail == "" {
+						data.ProducerEmail = owner.Email
+					}
+				}
+			}
+		}
+	}
+	if d, _ := repository.EventDateByID(db, t.EventDateID); d != nil {
+		data.Date = d.Date
+		data.StartTime = d.StartTime.String
+		data.EndTime = d.EndTime.String
+	}
+	if tt, _ := repository.TicketTypeByID(db, t.TicketTypeID); tt != nil {
+		data.TicketType = tt.Name
+		data.EntryTerms = repository.EntryTerms(tt.EntryPolicy, tt.MaxEntries)
+	}
+	// Nominal tickets show the assigned holder, courtesies still with the producer their
+	// recipient; otherwise the account owner.
+	if holder, _ := repository.TicketHolderByTicketID(db, t.ID); holder != nil {
+		data.HolderName = holder.Name
+	} else if c, _ := repository.CourtesyTicketByTicketID(db, t.ID); c != nil && c.IssuedBy == t.UserID {
+		data.HolderName = c.RecipientName
+	} else if owner, _ := repository.UserByID(db, t.UserID); owner != nil {
+		data.HolderName = owner.Name
+	}
+	return data, nil
+}
+
+// Build loads the ticket data and renders the PDF.
+func Build(db *sql.DB, t *repository.TicketRow) ([]byte, error) {
+	data, err := Load(db, t)
+	if err != nil {
+		return nil, err
+	}
+	return Render(data)
+}
+
+// formatDate converts YYYY-MM-DD to the Brazilian DD/MM/YYYY format.
+func formatDate(date string) string {
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return d.Format("02/01/2006")
+}
+
+// Render draws a single A4 page with the ticket card.
+func Render(t Ticket) ([]byte, error) {
+	png, err := qrcode.PNG(t.QRPayload, 600)
+	if err != nil {
+		return nil, fmt.Errorf("qr image: %w", err)
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle("Ingresso "+t.Code, true)
+	pdf.SetAuthor("Afterzin", true)
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(false, 15)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("") // UTF-8 → cp1252 for the core fonts
+
+	const (
+		left   = 15.0
+		width  = 180.0
+		top    = 15.0
+		height = 130.0
+	)
+
+	// Card border and header band
+	pdf.SetDrawColor(220, 220, 228)
+	pdf.SetLineWidth(0.3)
+	pdf.RoundedRect(left, top, width, height, 3, "1234", "D")
+	pdf.SetFillColor(31, 31, 41)
+	pdf.RoundedRect(left, top, width, 16, 3, "12", "F")
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.SetXY(left+6, top+4)
+	pdf.CellFormat(100, 8, "Afterzin", "", 0, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetXY(left+width-66, top+4)
+	pdf.CellFormat(60, 8, "INGRESSO", "", 0, "R", false, 0, "")
+
+	// Event details (left column)
+	textW := 108.0
+	x := left + 6
+	pdf.SetTextColor(31, 31, 41)
+	pdf.SetXY(x, top+22)
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.MultiCell(textW, 8, tr(t.EventTitle), "", "L", false)
+
+	when := formatDate(t.Date)
+	if t.StartTime != "" {
+		when += " às " + t.StartTime
+		if t.EndTime != "" {
+			when += " - " + t.EndTime
+		}
+	}
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetTextColor(92, 92, 110)
+	pdf.SetX(x)
+	pdf.MultiCell(textW, 6, tr(when), "", "L", false)
+	pdf.SetX(x)
+	pdf.MultiCell(textW, 6, tr(t.Location), "", "L", false)
+	if t.Address != "" {
+		pdf.SetX(x)
+		pdf.MultiCell(textW, 6, tr(t.Address), "", "L", false)
+	}
+
+	field := func(label, value string, font string, size float64) {
+		pdf.Ln(3)
+		pdf.SetX(x)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(138, 138, 153)
+		pdf.CellFormat(textW, 4, tr(label), "", 1, "L", false, 0, "")
+		pdf.SetX(x)
+		pdf.SetFont(font, "B", size)
+		pdf.SetTextColor(31, 31, 41)
+		pdf.MultiCell(textW, 6, tr(value), "", "L", false)
+	}
+	field("TIPO DE INGRESSO", t.TicketType, "Helvetica", 12)
+	field("TITULAR", t.HolderName, "Helvetica", 12)
+	field("CÓDIGO", t.Code, "Courier", 16)
+
+	// QR code (right column)
+	qrSize := 58.0
+	qrX := left + width - qrSize - 6
+	qrY := top + 24
+	opts := fpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader("qr", opts, bytes.NewReader(png))
+	pdf.ImageOptions("qr", qrX, qrY, qrSize, qrSize, false, opts, 0, "")
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.SetTextColor(138, 138, 153)
+	pdf.SetXY(qrX, qrY+qrSize+1)
+	pdf.CellFormat(qrSize, 4, tr("Apresente este QR Code na entrada"), "", 0, "C", false, 0, "")
+
+	// Producer info and notes (footer of the card)
+	footerY := top + height - 22
+	pdf.SetDrawColor(200, 200, 210)
+	pdf.SetDashPattern([]float64{1.5, 1.5}, 0)
+	pdf.Line(left+6, footerY, left+width-6, footerY)
+	pdf.SetDashPattern([]float64{}, 0)
+	pdf.SetXY(x, footerY+3)
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.SetTextColor(92, 92, 110)
+	producer := "Organização: " + t.ProducerName
+	if t.ProducerEmail != "" {
+		producer += " · " + t.ProducerEmail
+	}
+	pdf.CellFormat(width-12, 5, tr(producer), "", 1, "L", false, 0, "")
+	pdf.SetX(x)
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.MultiCell(width-12, 4, tr(t.EntryTerms+" Não compartilhe este ingresso: cópias podem impedir o seu acesso."), "", "L", false)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
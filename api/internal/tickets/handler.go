@@ -0,0 +1,173 @@
+// Package tickets serves ticket files (QR images and PDF) over plain HTTP.
+package tickets
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"afterzin/api/internal/config"
+	"afterzin/api/internal/middleware"
+	"afterzin/api/internal/qrcode"
+	"afterzin/api/internal/repository"
+	"afterzin/api/internal/ticketpdf"
+	"afterzin/api/internal/wallet"
+)
+
+const qrImageDefaultSize = 512
+
+// Handler provides the ticket file endpoints.
+type Handler struct {
+	db     *sql.DB
+	cfg    *config.Config
+	wallet *wallet.Service
+}
+
+// NewHandler creates the ticket file HTTP handler.
+func NewHandler(db *sql.DB, cfg *config.Config, w *wallet.Service) *Handler {
+	return &Handler{db: db, cfg: cfg, wallet: w}
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// ownedTicket loads the ticket from the {id} path value and checks that the authenticated
+// user owns it. Writes the error response and returns nil otherwise.
+func (h *Handler) ownedTicket(w http.ResponseWriter, r *http.Request) *repository.TicketRow {
+	userID := middleware.UserID(r.Context())
+	if userID == "" {
+		respondError(w, http.StatusUnauthorized, "não autenticado")
+		return nil
+	}
+	t, err := repository.TicketByID(h.db, r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "erro ao buscar ingresso")
+		return nil
+	}
+	// Same response for missing and foreign tickets so IDs cannot be probed
+	if t == nil || t.UserID != userID {
+		respondError(w, http.StatusNotFound, "ingresso não encontrado")
+		return nil
+	}
+	return t
+}
+
+// signedTicket loads the ticket and verifies the signed link (exp, sig) for the file against it:
+// links made before a transfer or resale no longer match the ticket's owner and QR code.
+// Writes the error response and returns nil otherwise.
+func (h *Handler) signedTicket(w http.ResponseWriter, r *http.Request, file string) *repository.TicketRow {
+	q := r.URL.Query()
+	t, err := repository.TicketByID(h.db, r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "erro ao buscar ingresso")
+		return nil
+	}
+	// Same response for missing tickets and bad links so IDs cannot be probed
+	if t == nil || !VerifyFileLink(t, file, q.Get("exp"), q.Get("sig"), []byte(h.cfg.TicketLinkSecret)) {
+		respondError(w, http.StatusForbidden, "link inválido ou expirado")
+		return nil
+	}
+	return t
+}
+
+// QRPNG handles GET /tickets/{id}/qr.png (owner only).
+// Optional ?size= in pixels (128–1024, default 512).
+func (h *Handler) QRPNG(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	t := h.ownedTicket(w, r)
+	if t == nil {
+		return
+	}
+	size := qrImageDefaultSize
+	if s, err := strconv.Atoi(r.URL.Query().Get("size")); err == nil && s >= 128 && s <= 1024 {
+		size = s
+	}
+	png, err := qrcode.PNG(t.QRCode, size)
+	if err != nil {
+		log.Printf("tickets: QR PNG for %s error: %v", t.ID, err)
+		respondError(w, http.StatusInternalServerError, "erro ao gerar QR Code")
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Write(png)
+}
+
+// QRSVG handles GET /tickets/{id}/qr.svg (owner only).
+func (h *Handler) QRSVG(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	t := h.ownedTicket(w, r)
+	if t == nil {
+		return
+	}
+	svg, err := qrcode.SVG(t.QRCode)
+	if err != nil {
+		log.Printf("tickets: QR SVG for %s error: %v", t.ID, err)
+		respondError(w, http.StatusInternalServerError, "erro ao gerar QR Code")
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Write(svg)
+}
+
+// PDF handles GET /tickets/{id}/ticket.pdf?exp=&sig=
+// Authorized by the signed, expiring link from Ticket.pdfUrl instead of a bearer token.
+func (h *Handler) PDF(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	t := h.signedTicket(w, r, FilePDF)
+	if t == nil {
+		return
+	}
+	pdf, err := ticketpdf.Build(h.db, t)
+	if err != nil {
+		log.Printf("tickets: PDF for %s error: %v", t.ID, err)
+		respondError(w, http.StatusInternalServerError, "erro ao gerar PDF")
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `inline; filename="ingresso-`+t.Code+`.pdf"`)
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Write(pdf)
+}
+
+// ApplePass handles GET /tickets/{id}/wallet.pkpass?exp=&sig=
+// Authorized by the signed link from Ticket.applePassUrl; opens directly in Apple Wallet.
+func (h *Handler) ApplePass(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !h.wallet.AppleEnabled() {
+		respondError(w, http.StatusNotFound, "apple wallet não configurado")
+		return
+	}
+	t := h.signedTicket(w, r, FileApplePass)
+	if t == nil {
+		return
+	}
+	pass, err := h.wallet.ApplePass(t)
+	if err != nil {
+		log.Printf("tickets: Apple pass for %s error: %v", t.ID, err)
+		respondError(w, http.StatusInternalServerError, "erro ao gerar passe")
+		return
+	}
+	w.Header().Set("Content-Type", "application/vnd.apple.pkpass")
+	w.Header().Set("Content-Disposition", `attachment; filename="ingresso-`+t.Code+`.pkpass"`)
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Write(pass)
+}
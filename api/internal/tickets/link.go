@@ -0,0 +1,56 @@
+package tickets
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"time"
+
+	"afterzin/api/internal/repository"
+)
+
+// Ticket files served through signed links.
+const (
+	FilePDF             = "ticket.pdf"
+	FileApplePass       = "wallet.pkpass"
+	linkSignaturePrefix = "ticket-file:"
+)
+
+// fileSignature signs file + ticket ID + owner + current QR code + expiry with HMAC-SHA256. The
+// owner and the QR code hash bind the link to the ticket as it was when the link was made, so
+// links handed to a previous owner stop working once the ticket is transferred or resold. The
+// secret is dedicated to these links (config TicketLinkSecret), and the prefix separates the
+// files from any other use of it.
+func fileSignature(t *repository.TicketRow, file string, expires int64, secret []byte) []byte {
+	qr := sha256.Sum256([]byte(t.QRCode))
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(linkSignaturePrefix + file + ":" + t.ID + ":" + t.UserID + ":" + hex.EncodeToString(qr[:]) + ":" + strconv.FormatInt(expires, 10)))
+	return mac.Sum(nil)
+}
+
+// SignedFileURL returns a download link for a ticket file that works without an Authorization
+// header (e-mail clients, browser downloads, Wallet) until it expires after ttl, or until the
+// ticket changes owner or QR code.
+func SignedFileURL(apiBaseURL string, t *repository.TicketRow, file string, secret []byte, ttl time.Duration) string {
+	exp := time.Now().Add(ttl).Unix()
+	q := url.Values{}
+	q.Set("exp", strconv.FormatInt(exp, 10))
+	q.Set("sig", hex.EncodeToString(fileSignature(t, file, exp, secret)))
+	return apiBaseURL + "/tickets/" + url.PathEscape(t.ID) + "/" + file + "?" + q.Encode()
+}
+
+// VerifyFileLink checks the signature and expiry of a link built by SignedFileURL against the
+// ticket's current row.
+func VerifyFileLink(t *repository.TicketRow, file, exp, sig string, secret []byte) bool {
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(got, fileSignature(t, file, expires, secret))
+}
@@ -0,0 +1,298 @@
+package wallet
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"afterzin/api/internal/auth"
+	"afterzin/api/internal/config"
+	"afterzin/api/internal/repository"
+
+	"github.com/digitorus/pkcs7"
+)
+
+const apnsURL = "https://api.push.apple.com/3/device/"
+
+// appleSigner holds the Pass Type ID certificate used to sign .pkpass bundles and to
+// authenticate APNs update pushes.
+type appleSigner struct {
+	passTypeID string
+	teamID     string
+	cert       tls.Certificate
+	leaf       *x509.Certificate
+	wwdr       *x509.Certificate
+	apns       *http.Client
+}
+
+func newAppleSigner(cfg *config.Config) (*appleSigner, error) {
+	if cfg.WalletAppleTeamID == "" || cfg.WalletAppleCertFile == "" || cfg.WalletAppleKeyFile == "" || cfg.WalletAppleWWDRFile == "" {
+		return nil, errors.New("WALLET_APPLE_TEAM_ID, WALLET_APPLE_CERT, WALLET_APPLE_KEY and WALLET_APPLE_WWDR are required")
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.WalletAppleCertFile, cfg.WalletAppleKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	wwdr, err := loadCertificate(cfg.WalletAppleWWDRFile)
+	if err != nil {
+		return nil, fmt.Errorf("WWDR certificate: %w", err)
+	}
+	return &appleSigner{
+		passTypeID: cfg.WalletApplePassTypeID,
+		teamID:     cfg.WalletAppleTeamID,
+		cert:       cert,
+		leaf:       leaf,
+		wwdr:       wwdr,
+		apns: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig:   &tls.Config{Certificates: []tls.Certificate{cert}},
+				ForceAttemptHTTP2: true,
+			},
+		},
+	}, nil
+}
+
+// loadCertificate reads a PEM or DER encoded certificate (Apple ships the WWDR as .cer/DER).
+func loadCertificate(path string) (*x509.Certificate, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(raw); block != nil {
+		raw = block.Bytes
+	}
+	return x509.ParseCertificate(raw)
+}
+
+type passField struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+type passBarcode struct {
+	Format          string `json:"format"`
+	Message         string `json:"message"`
+	MessageEncoding string `json:"messageEncoding"`
+	AltText         string `json:"altText,omitempty"`
+}
+
+type passJSON struct {
+	FormatVersion       int           `json:"formatVersion"`
+	PassTypeIdentifier  string        `json:"passTypeIdentifier"`
+	SerialNumber        string        `json:"serialNumber"`
+	TeamIdentifier      string        `json:"teamIdentifier"`
+	OrganizationName    string        `json:"organizationName"`
+	Description         string        `json:"description"`
+	LogoText            string        `json:"logoText"`
+	ForegroundColor     string        `json:"foregroundColor"`
+	BackgroundColor     string        `json:"backgroundColor"`
+	LabelColor          string        `json:"labelColor"`
+	WebServiceURL       string        `json:"webServiceURL"`
+	AuthenticationToken string        `json:"authenticationToken"`
+	RelevantDate        string        `json:"relevantDate,omitempty"`
+	Voided              bool          `json:"voided,omitempty"`
+	Barcodes            []passBarcode `json:"barcodes"`
+	EventTicket         struct {
+		PrimaryFields   []passField `json:"primaryFields"`
+		SecondaryFields []passField `json:"secondaryFields"`
+		AuxiliaryFields []passField `json:"auxiliaryFields"`
+		BackFields      []passField `json:"backFields"`
+	} `json:"eventTicket"`
+}
+
+func (a *appleSigner) passJSON(p *Pass, webServiceURL, authToken string) ([]byte, error) {
+	pj := passJSON{
+		FormatVersion:       1,
+		PassTypeIdentifier:  a.passTypeID,
+		SerialNumber:        p.TicketID,
+		TeamIdentifier:      a.teamID,
+		OrganizationName:    "Afterzin",
+		Description:         "Ingresso - " + p.EventTitle,
+		LogoText:            "Afterzin",
+		ForegroundColor:     "rgb(255, 255, 255)",
+		BackgroundColor:     "rgb(31, 31, 41)",
+		LabelColor:          "rgb(190, 190, 205)",
+		WebServiceURL:       webServiceURL,
+		AuthenticationToken: authToken,
+		Voided:              p.Voided,
+		Barcodes: []passBarcode{{
+			Format:          "PKBarcodeFormatQR",
+			Message:         p.Barcode,
+			MessageEncoding: "iso-8859-1",
+			AltText:         p.Code,
+		}},
+	}
+	if !p.Start.IsZero() {
+		pj.RelevantDate = p.Start.Format(time.RFC3339)
+	}
+	when := p.Date
+	if p.StartTime != "" {
+		when += " " + p.StartTime
+	}
+	pj.EventTicket.PrimaryFields = []passField{{Key: "event", Label: "EVENTO", Value: p.EventTitle}}
+	pj.EventTicket.SecondaryFields = []passField{
+		{Key: "date", Label: "DATA", Value: when},
+		{Key: "location", Label: "LOCAL", Value: p.Location},
+	}
+	pj.EventTicket.AuxiliaryFields = []passField{
+		{Key: "type", Label: "INGRESSO", Value: p.TicketType},
+		{Key: "holder", Label: "TITULAR", Value: p.HolderName},
+	}
+	back := []passField{{Key: "code", Label: "Código", Value: p.Code}}
+	if p.Address != "" {
+		back = append(back, passField{Key: "address", Label: "Endereço", Value: p.Address})
+	}
+	if p.OrganizerName != "" {
+		back = append(back, passField{Key: "organizer", Label: "Organização", Value: p.OrganizerName})
+	}
+	back = append(back, passField{Key: "terms", Label: "Importante", Value: p.EntryTerms})
+	pj.EventTicket.BackFields = back
+	return json.Marshal(pj)
+}
+
+// build assembles the .pkpass zip: pass.json, images, manifest.json (SHA-1 of every file)
+// and signature (detached PKCS#7 of the manifest, signed with the pass certificate + WWDR).
+func (a *appleSigner) build(p *Pass, webServiceURL, authToken string) ([]byte, error) {
+	passData, err := a.passJSON(p, webServiceURL, authToken)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{"pass.json": passData}
+	for name, img := range passImages() {
+		files[name] = img
+	}
+	manifest := map[string]string{}
+	for name, data := range files {
+		sum := sha1.Sum(data)
+		manifest[name] = hex.EncodeToString(sum[:])
+	}
+	manifestData, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := a.sign(manifestData)
+	if err != nil {
+		return nil, fmt.Errorf("sign manifest: %w", err)
+	}
+	files["manifest.json"] = manifestData
+	files["signature"] = signature
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (a *appleSigner) sign(manifest []byte) ([]byte, error) {
+	sd, err := pkcs7.NewSignedData(manifest)
+	if err != nil {
+		return nil, err
+	}
+	sd.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+	if err := sd.AddSignerChain(a.leaf, a.cert.PrivateKey, []*x509.Certificate{a.wwdr}, pkcs7.SignerInfoConfig{}); err != nil {
+		return nil, err
+	}
+	sd.Detach()
+	return sd.Finish()
+}
+
+// push sends the empty APNs notification that makes the device fetch the updated pass.
+func (a *appleSigner) push(ctx context.Context, pushToken string) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apnsURL+pushToken, strings.NewReader("{}"))
+	if err != nil {
+		return
+	}
+	req.Header.Set("apns-topic", a.passTypeID)
+	resp, err := a.apns.Do(req)
+	if err != nil {
+		log.Printf("wallet: APNs push error: %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("wallet: APNs push status %d", resp.StatusCode)
+	}
+}
+
+var (
+	passImagesOnce sync.Once
+	passImagesData map[string][]byte
+)
+
+// passImages renders the icon and logo images required in every pass (brand colors).
+func passImages() map[string][]byte {
+	passImagesOnce.Do(func() {
+		brand := color.RGBA{R: 31, G: 31, B: 41, A: 255}
+		accent := color.RGBA{R: 108, G: 43, B: 217, A: 255}
+		sizes := map[string][2]int{
+			"icon.png": {29, 29}, "icon@2x.png": {58, 58}, "icon@3x.png": {87, 87},
+			"logo.png": {50, 50}, "logo@2x.png": {100, 100}, "logo@3x.png": {150, 150},
+		}
+		passImagesData = map[string][]byte{}
+		for name, size := range sizes {
+			img := image.NewRGBA(image.Rect(0, 0, size[0], size[1]))
+			draw.Draw(img, img.Bounds(), &image.Uniform{C: brand}, image.Point{}, draw.Src)
+			inset := size[0] / 4
+			draw.Draw(img, image.Rect(inset, inset, size[0]-inset, size[1]-inset), &image.Uniform{C: accent}, image.Point{}, draw.Src)
+			var buf bytes.Buffer
+			png.Encode(&buf, img)
+			passImagesData[name] = buf.Bytes()
+		}
+	})
+	return passImagesData
+}
+
+// ApplePass returns the signed .pkpass bundle of the ticket.
+func (s *Service) ApplePass(t *repository.TicketRow) ([]byte, error) {
+	if !s.AppleEnabled() {
+		return nil, errors.New("apple wallet não configurado")
+	}
+	p, err := Load(s.db, t)
+	if err != nil {
+		return nil, err
+	}
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	rec, err := repository.EnsureWalletPass(s.db, t.ID, token)
+	if err != nil {
+		return nil, err
+	}
+	return s.apple.build(p, s.cfg.APIBaseURL+"/wallet", rec.AuthenticationToken)
+}
@@ -0,0 +1,256 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"afterzin/api/internal/config"
+	"afterzin/api/internal/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	googleSaveURL     = "https://pay.google.com/gp/v/save/"
+	googleTokenURL    = "https://oauth2.googleapis.com/token"
+	googleWalletAPI   = "https://walletobjects.googleapis.com/walletobjects/v1/"
+	googleWalletScope = "https://www.googleapis.com/auth/wallet_object.issuer"
+)
+
+// googleIssuer signs "Save to Google Wallet" JWTs and updates saved objects through the
+// Wallet REST API with a service account.
+type googleIssuer struct {
+	issuerID    string
+	clientEmail string
+	key         *rsa.PrivateKey
+	origins     []string
+
+	mu          sync.Mutex
+	accessToken string
+	tokenExpiry time.Time
+}
+
+func newGoogleIssuer(cfg *config.Config) (*googleIssuer, error) {
+	if cfg.WalletGoogleServiceAccountFile == "" {
+		return nil, errors.New("WALLET_GOOGLE_SERVICE_ACCOUNT is required")
+	}
+	raw, err := os.ReadFile(cfg.WalletGoogleServiceAccountFile)
+	if err != nil {
+		return nil, err
+	}
+	var sa struct {
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+	}
+	if err := json.Unmarshal(raw, &sa); err != nil {
+		return nil, fmt.Errorf("service account: %w", err)
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(sa.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("service account key: %w", err)
+	}
+	return &googleIssuer{
+		issuerID:    cfg.WalletGoogleIssuerID,
+		clientEmail: sa.ClientEmail,
+		key:         key,
+		origins:     []string{cfg.BaseURL},
+	}, nil
+}
+
+type localized struct {
+	DefaultValue struct {
+		Language string `json:"language"`
+		Value    string `json:"value"`
+	} `json:"defaultValue"`
+}
+
+func ptBR(value string) *localized {
+	if value == "" {
+		return nil
+	}
+	l := &localized{}
+	l.DefaultValue.Language = "pt-BR"
+	l.DefaultValue.Value = value
+	return l
+}
+
+// Classes are per event date (date/time lives on the class); objects are per ticket and QR
+// payload, so a ticket reissued on transfer gets a new object instead of updating the one
+// saved by the previous owner.
+func (g *googleIssuer) classID(p *Pass) string { return g.issuerID + "." + p.EventDateID }
+func (g *googleIssuer) objectID(p *Pass) string {
+	return g.objectIDFor(p.TicketID, p.Barcode)
+}
+
+func (g *googleIssuer) objectIDFor(ticketID, barcode string) string {
+	sum := sha256.Sum256([]byte(barcode))
+	return g.issuerID + "." + ticketID + "-" + hex.EncodeToString(sum[:4])
+}
+
+func (g *googleIssuer) class(p *Pass) map[string]interface{} {
+	c := map[string]interface{}{
+		"id":           g.classID(p),
+		"issuerName":   "Afterzin",
+		"reviewStatus": "UNDER_REVIEW",
+		"eventName":    ptBR(p.EventTitle),
+		"venue": map[string]interface{}{
+			"name":    ptBR(p.Location),
+			"address": ptBR(p.Address),
+		},
+	}
+	if !p.Start.IsZero() {
+		c["dateTime"] = map[string]string{"start": p.Start.Format(time.RFC3339)}
+	}
+	return c
+}
+
+func (g *googleIssuer) object(p *Pass) map[string]interface{} {
+	state := "ACTIVE"
+	if p.Voided {
+		state = "INACTIVE"
+	}
+	return map[string]interface{}{
+		"id":               g.objectID(p),
+		"classId":          g.classID(p),
+		"state":            state,
+		"ticketHolderName": p.HolderName,
+		"ticketNumber":     p.Code,
+		"ticketType":       ptBR(p.TicketType),
+		"barcode": map[string]string{
+			"type":          "QR_CODE",
+			"value":         p.Barcode,
+			"alternateText": p.Code,
+		},
+	}
+}
+
+// saveURL signs the JWT that creates the class and object when the user taps the link.
+func (g *googleIssuer) saveURL(p *Pass) (string, error) {
+	claims := jwt.MapClaims{
+		"iss":     g.clientEmail,
+		"aud":     "google",
+		"typ":     "savetowallet",
+		"iat":     time.Now().Unix(),
+		"origins": g.origins,
+		"payload": map[string]interface{}{
+			"eventTicketClasses": []interface{}{g.class(p)},
+			"eventTicketObjects": []interface{}{g.object(p)},
+		},
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(g.key)
+	if err != nil {
+		return "", err
+	}
+	return googleSaveURL + signed, nil
+}
+
+// token returns a cached OAuth access token obtained with the service account (JWT bearer grant).
+func (g *googleIssuer) token(ctx context.Context, client *http.Client) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.accessToken != "" && time.Now().Before(g.tokenExpiry) {
+		return g.accessToken, nil
+	}
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   g.clientEmail,
+		"scope": googleWalletScope,
+		"aud":   googleTokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(g.key)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, googleTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var out struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil || out.AccessToken == "" {
+		return "", fmt.Errorf("token request failed with status %d", resp.StatusCode)
+	}
+	g.accessToken = out.AccessToken
+	g.tokenExpiry = now.Add(time.Duration(out.ExpiresIn)*time.Second - time.Minute)
+	return g.accessToken, nil
+}
+
+// patch sends a PATCH to the Wallet API. A 404 means the user never saved the pass and is ignored.
+func (g *googleIssuer) patch(ctx context.Context, client *http.Client, resource, id string, body interface{}) {
+	token, err := g.token(ctx, client)
+	if err != nil {
+		log.Printf("wallet: google token error: %v", err)
+		return
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, googleWalletAPI+resource+"/"+url.PathEscape(id), bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("wallet: google patch %s %s error: %v", resource, id, err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		log.Printf("wallet: google patch %s %s status %d: %s", resource, id, resp.StatusCode, msg)
+	}
+}
+
+func (g *googleIssuer) patchClass(ctx context.Context, client *http.Client, p *Pass) {
+	g.patch(ctx, client, "eventTicketClass", g.classID(p), g.class(p))
+}
+
+func (g *googleIssuer) patchObject(ctx context.Context, client *http.Client, p *Pass) {
+	g.patch(ctx, client, "eventTicketObject", g.objectID(p), g.object(p))
+}
+
+// deactivateObject marks the object saved with a replaced QR payload as no longer valid.
+func (g *googleIssuer) deactivateObject(ctx context.Context, client *http.Client, ticketID, barcode string) {
+	g.patch(ctx, client, "eventTicketObject", g.objectIDFor(ticketID, barcode), map[string]string{"state": "INACTIVE"})
+}
+
+// GoogleSaveURL returns the "Save to Google Wallet" link of the ticket.
+func (s *Service) GoogleSaveURL(t *repository.TicketRow) (string, error) {
+	if !s.GoogleEnabled() {
+		return "", errors.New("google wallet não configurado")
+	}
+	p, err := Load(s.db, t)
+	if err != nil {
+		return "", err
+	}
+	return s.google.saveURL(p)
+}
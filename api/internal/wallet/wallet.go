@@ -0,0 +1,238 @@
+// Package wallet builds Apple Wallet (.pkpass) and Google Wallet passes for tickets and
+// pushes updates to passes already saved on phones when the ticket or its event changes.
+package wallet
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"afterzin/api/internal/config"
+	"afterzin/api/internal/repository"
+)
+
+const hookTimeout = time.Minute
+
+// Pass holds the ticket data shown on both wallet passes.
+type Pass struct {
+	TicketID      string
+	Code          string
+	Barcode       string // ticket QR payload, same as the PDF and the QR endpoints
+	EventID       string
+	EventDateID   string
+	EventTitle    string
+	Location      string
+	Address       string
+	Date          string // DD/MM/YYYY
+	StartTime     string
+	Start         time.Time // zero when the date cannot be parsed
+	TicketType    string
+	HolderName    string
+	OrganizerName string
+	EntryTerms    string // how the QR code can be used, from the ticket type's entry policy
+	// Voided passes (no entries left or order refunded) are shown as no longer valid.
+	Voided bool
+}
+
+// Load builds the pass data from a ticket row plus its event, date, type, holder and order.
+func Load(db *sql.DB, t *repository.TicketRow) (*Pass, error) {
+	p := &Pass{
+		TicketID:    t.ID,
+		Code:        t.Code,
+		Barcode:     t.QRCode,
+		EventID:     t.EventID,
+		EventDateID: t.EventDateID,
+		Voided:      t.Used == 1,
+		EntryTerms:  repository.EntryTerms(repository.EntrySingle, sql.NullInt64{}),
+	}
+	ev, err := repository.EventByID(db, t.EventID)
+	if err != nil {
+		return nil, err
+	}
+	if ev == nil {
+		return nil, fmt.Errorf("event %s not found", t.EventID)
+	}
+	p.EventTitle = ev.Title
+	p.Location = ev.Location
+	p.Address = ev.Address.String
+	if prod, _ := repository.ProducerByID(db, ev.ProducerID); prod != nil {
+		p.OrganizerName = prod.CompanyName.String
+	}
+	if d, _ := repository.EventDateByID(db, t.EventDateID); d != nil {
+		p.Date = d.Date
+		p.StartTime = d.StartTime.String
+		if start, ok := repository.EventDateStart(d); ok {
+			p.Start = start
+			p.Date = start.Format("02/01/2006")
+		}
+	}
+	if tt, _ := repository.TicketTypeByID(db, t.TicketTypeID); tt != nil {
+		p.TicketType = tt.Name
+		p.EntryTerms = repository.EntryTerms(tt.EntryPolicy, tt.MaxEntries)
+		// Used tickets are voided only when the entry policy lets them in no more.
+		p.Voided = repository.EntriesExhausted(tt.EntryPolicy, tt.MaxEntries, t.EntryCount)
+	}
+	// Nominal tickets show the assigned holder, courtesies still with the producer their
+	// recipient; otherwise the account owner.
+	if holder, _ := repository.TicketHolderByTicketID(db, t.ID); holder != nil {
+		p.HolderName = holder.Name
+	} else if c, _ := repository.CourtesyTicketByTicketID(db, t.ID); c != nil && c.IssuedBy == t.UserID {
+		p.HolderName = c.RecipientName
+	} else if owner, _ := repository.UserByID(db, t.UserID); owner != nil {
+		p.HolderName = owner.Name
+	}
+	if _, status, _, _ := repository.OrderByID(db, t.OrderID); status == "REFUNDED" {
+		p.Voided = true
+	}
+	return p, nil
+}
+
+// Service generates passes and runs the update hooks. Each wallet is enabled only when its
+// credentials are configured.
+type Service struct {
+	db     *sql.DB
+	cfg    *config.Config
+	apple  *appleSigner
+	google *googleIssuer
+	client *http.Client
+}
+
+// New loads the configured Apple certificate and Google service account.
+func New(db *sql.DB, cfg *config.Config) (*Service, error) {
+	s := &Service{db: db, cfg: cfg, client: &http.Client{Timeout: 15 * time.Second}}
+	if cfg.WalletApplePassTypeID != "" {
+		apple, err := newAppleSigner(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("apple wallet: %w", err)
+		}
+		s.apple = apple
+	}
+	if cfg.WalletGoogleIssuerID != "" {
+		google, err := newGoogleIssuer(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("google wallet: %w", err)
+		}
+		s.google = google
+	}
+	return s, nil
+}
+
+func (s *Service) AppleEnabled() bool  { return s != nil && s.apple != nil }
+func (s *Service) GoogleEnabled() bool { return s != nil && s.google != nil }
+
+// ---------- Update hooks ----------
+// Hooks run in the background: callers (validation, webhooks, event edits) never wait on
+// Apple or Google.
+
+// TicketsChanged refreshes the passes of tickets that were used or refunded.
+func (s *Service) TicketsChanged(ticketIDs ...string) {
+	if len(ticketIDs) == 0 || (!s.AppleEnabled() && !s.GoogleEnabled()) {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+		defer cancel()
+		s.refreshTickets(ctx, ticketIDs)
+	}()
+}
+
+// TicketTransferred detaches the passes saved by the previous owner of a transferred ticket:
+// the Apple pass loses its authentication token (devices stop receiving updates with the new
+// QR code) and the Google object with the old QR payload is deactivated.
+func (s *Service) TicketTransferred(ticketID, previousQRCode string) {
+	if s.AppleEnabled() {
+		if err := repository.DeleteWalletPass(s.db, ticketID); err != nil {
+			log.Printf("wallet: reset pass of %s error: %v", ticketID, err)
+		}
+	}
+	if !s.GoogleEnabled() {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+		defer cancel()
+		s.google.deactivateObject(ctx, s.client, ticketID, previousQRCode)
+	}()
+}
+
+// EventDateChanged refreshes every pass of an event date whose date or time changed.
+func (s *Service) EventDateChanged(eventDateID string) {
+	if !s.AppleEnabled() && !s.GoogleEnabled() {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+		defer cancel()
+		s.refreshEventDate(ctx, eventDateID)
+	}()
+}
+
+// EventChanged refreshes every pass of an event whose title or location changed.
+func (s *Service) EventChanged(eventID string) {
+	if !s.AppleEnabled() && !s.GoogleEnabled() {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+		defer cancel()
+		dateIDs, err := repository.EventDateIDsByEvent(s.db, eventID)
+		if err != nil {
+			log.Printf("wallet: dates of event %s error: %v", eventID, err)
+			return
+		}
+		for _, dateID := range dateIDs {
+			s.refreshEventDate(ctx, dateID)
+		}
+	}()
+}
+
+func (s *Service) refreshEventDate(ctx context.Context, eventDateID string) {
+	ids, err := repository.TicketIDsByEventDate(s.db, eventDateID)
+	if err != nil {
+		log.Printf("wallet: tickets of date %s error: %v", eventDateID, err)
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+	if s.GoogleEnabled() {
+		if t, _ := repository.TicketByID(s.db, ids[0]); t != nil {
+			if p, err := Load(s.db, t); err == nil {
+				s.google.patchClass(ctx, s.client, p)
+			}
+		}
+	}
+	s.refreshTickets(ctx, ids)
+}
+
+func (s *Service) refreshTickets(ctx context.Context, ticketIDs []string) {
+	if s.AppleEnabled() {
+		if err := repository.TouchWalletPasses(s.db, ticketIDs); err != nil {
+			log.Printf("wallet: touch passes error: %v", err)
+		}
+		tokens, err := repository.WalletPushTokens(s.db, ticketIDs)
+		if err != nil {
+			log.Printf("wallet: push tokens error: %v", err)
+		}
+		for _, token := range tokens {
+			s.apple.push(ctx, token)
+		}
+	}
+	if s.GoogleEnabled() {
+		for _, id := range ticketIDs {
+			t, _ := repository.TicketByID(s.db, id)
+			if t == nil {
+				continue
+			}
+			p, err := Load(s.db, t)
+			if err != nil {
+				log.Printf("wallet: load ticket %s error: %v", id, err)
+				continue
+			}
+			s.google.patchObject(ctx, s.client, p)
+		}
+	}
+}
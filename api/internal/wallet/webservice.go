@@ -0,0 +1,138 @@
+package wallet
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"afterzin/api/internal/repository"
+)
+
+// WebService implements the Apple Wallet web service (webServiceURL in pass.json), which
+// devices use to register for update pushes and to download updated passes.
+// Mount under /wallet/.
+func (s *Service) WebService() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /wallet/v1/devices/{device}/registrations/{passType}/{serial}", s.registerDevice)
+	mux.HandleFunc("DELETE /wallet/v1/devices/{device}/registrations/{passType}/{serial}", s.unregisterDevice)
+	mux.HandleFunc("GET /wallet/v1/devices/{device}/registrations/{passType}", s.updatedSerials)
+	mux.HandleFunc("GET /wallet/v1/passes/{passType}/{serial}", s.latestPass)
+	mux.HandleFunc("POST /wallet/v1/log", s.deviceLog)
+	return mux
+}
+
+// authorizedPass checks the "ApplePass <token>" header against the pass record of the serial
+// (ticket ID). Writes 401 and returns nil when it does not match.
+func (s *Service) authorizedPass(w http.ResponseWriter, r *http.Request) *repository.WalletPassRow {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "ApplePass ")
+	if r.PathValue("passType") != s.apple.passTypeID || token == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return nil
+	}
+	rec, err := repository.WalletPassByTicket(s.db, r.PathValue("serial"))
+	if err != nil || rec == nil || subtle.ConstantTimeCompare([]byte(rec.AuthenticationToken), []byte(token)) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+		return nil
+	}
+	return rec
+}
+
+func (s *Service) registerDevice(w http.ResponseWriter, r *http.Request) {
+	if s.authorizedPass(w, r) == nil {
+		return
+	}
+	var body struct {
+		PushToken string `json:"pushToken"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&body); err != nil || body.PushToken == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	created, err := repository.RegisterWalletDevice(s.db, r.PathValue("device"), body.PushToken, r.PathValue("passType"), r.PathValue("serial"))
+	if err != nil {
+		log.Printf("wallet: register device error: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if created {
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Service) unregisterDevice(w http.ResponseWriter, r *http.Request) {
+	if s.authorizedPass(w, r) == nil {
+		return
+	}
+	if err := repository.UnregisterWalletDevice(s.db, r.PathValue("device"), r.PathValue("passType"), r.PathValue("serial")); err != nil {
+		log.Printf("wallet: unregister device error: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// updatedSerials lists the passes on the device that changed since passesUpdatedSince
+// (the lastUpdated tag returned by the previous call).
+func (s *Service) updatedSerials(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("passType") != s.apple.passTypeID {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	serials, last, err := repository.WalletSerialsForDevice(s.db, r.PathValue("device"), r.PathValue("passType"), r.URL.Query().Get("passesUpdatedSince"))
+	if err != nil {
+		log.Printf("wallet: updated serials error: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if len(serials) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"serialNumbers": serials, "lastUpdated": last})
+}
+
+func (s *Service) latestPass(w http.ResponseWriter, r *http.Request) {
+	rec := s.authorizedPass(w, r)
+	if rec == nil {
+		return
+	}
+	modified, _ := time.Parse("2006-01-02 15:04:05", rec.UpdatedAt)
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !modified.Truncate(time.Second).After(since) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	t, err := repository.TicketByID(s.db, rec.TicketID)
+	if err != nil || t == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	pass, err := s.ApplePass(t)
+	if err != nil {
+		log.Printf("wallet: build pass %s error: %v", t.ID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/vnd.apple.pkpass")
+	w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	w.Write(pass)
+}
+
+// deviceLog receives error messages reported by devices about our passes.
+func (s *Service) deviceLog(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Logs []string `json:"logs"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 65536)).Decode(&body); err == nil {
+		for _, l := range body.Logs {
+			log.Printf("wallet: device log: %s", l)
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}